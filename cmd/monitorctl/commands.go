@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/migrate"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// pollTimeout bounds a one-off poll, like the engine's fetch timeout.
+const pollTimeout = 30 * time.Second
+
+// parseFlags parses a command's flags, reporting problems as usage errors.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %s: %v", errUsage, fs.Name(), err)
+	}
+	return nil
+}
+
+func (a *app) users(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("users", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	db, err := a.store(ctx)
+	if err != nil {
+		return err
+	}
+	users, err := db.ListTelegramUsers(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHAT\tUSERNAME\tLINKED\tLANGUAGE\tCREATED")
+	for _, u := range users {
+		fmt.Fprintf(tw, "%d\t%s\t%t\t%s\t%s\n", u.TgChatID, u.TgUsername, u.Linked, u.Language, u.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+func (a *app) subscriptions(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("subscriptions", flag.ContinueOnError)
+	chat := fs.Int64("chat", 0, "only this chat's subscriptions")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	subs, err := a.eventSubscriptions(ctx, *chat)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCHAT\tEVENT\tLINKED\tDIRECTION\tTHRESHOLD_PCT\tTHRESHOLD_VALUE\tWINDOW_MIN\tCOIN\tREPORT_HOUR")
+	for _, s := range subs {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%t\t%s\t%g\t%g\t%d\t%s\t%d\n", s.SubscriptionID, s.ChatID, s.EventName, s.Linked,
+			s.Direction, s.ThresholdPct, s.ThresholdValue, s.WindowMinutes, s.Coin, s.ReportHour)
+	}
+	return tw.Flush()
+}
+
+// eventSubscriptions lists every subscription, or only chat's if non-zero.
+func (a *app) eventSubscriptions(ctx context.Context, chat int64) ([]store.EventSubscription, error) {
+	db, err := a.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := db.ListEventSubscriptions(ctx)
+	if err != nil || chat == 0 {
+		return all, err
+	}
+	var subs []store.EventSubscription
+	for _, s := range all {
+		if s.ChatID == chat {
+			subs = append(subs, s)
+		}
+	}
+	return subs, nil
+}
+
+func (a *app) dedup(ctx context.Context, args []string) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "clear") {
+		return fmt.Errorf("%w: dedup needs list or clear", errUsage)
+	}
+	clearing := args[0] == "clear"
+	fs := flag.NewFlagSet("dedup "+args[0], flag.ContinueOnError)
+	chat := fs.Int64("chat", 0, "keys of every subscription of this chat, plus its legacy keys")
+	sub := fs.Int64("sub", 0, "keys of this subscription")
+	dryRun := fs.Bool("dry-run", false, "list the keys clear would delete")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if (*chat == 0) == (*sub == 0) {
+		return fmt.Errorf("%w: dedup %s needs exactly one of -chat and -sub", errUsage, args[0])
+	}
+
+	backend, err := a.dedupBackend(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := a.dedupKeys(ctx, backend, *chat, *sub)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		fmt.Fprintln(a.out, key)
+	}
+	if !clearing || *dryRun || len(keys) == 0 {
+		fmt.Fprintf(a.out, "%d key(s)\n", len(keys))
+		return nil
+	}
+	for _, key := range keys {
+		if err := backend.Delete(ctx, key); err != nil {
+			return fmt.Errorf("delete %s: %w", key, err)
+		}
+	}
+	fmt.Fprintf(a.out, "cleared %d key(s)\n", len(keys))
+	return nil
+}
+
+// dedupKeys finds a subscription's keys, or all keys of a chat: those of
+// its subscriptions and the legacy chat-scoped ones ("<kind>:<chat>:..."
+// or "<chat>:...") written before keys were namespaced by subscription.
+func (a *app) dedupKeys(ctx context.Context, backend dedup.Backend, chat, sub int64) ([]string, error) {
+	var subIDs []int64
+	var legacy []string
+	if sub != 0 {
+		subIDs = []int64{sub}
+	} else {
+		subs, err := a.eventSubscriptions(ctx, chat)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range subs {
+			subIDs = append(subIDs, s.SubscriptionID)
+		}
+		c := strconv.FormatInt(chat, 10)
+		legacy = []string{c + ":*", "*:" + c + ":*"}
+	}
+
+	seen := make(map[string]bool)
+	add := func(pattern string, skipNamespaced bool) error {
+		keys, err := backend.Keys(ctx, pattern)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !(skipNamespaced && strings.HasPrefix(key, "sub:")) {
+				seen[key] = true
+			}
+		}
+		return nil
+	}
+	for _, id := range subIDs {
+		if err := add(dedup.SubscriptionKey(id, "*"), false); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range legacy {
+		if err := add(pattern, true); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (a *app) migrate(ctx context.Context, args []string) error {
+	return migrate.Run(ctx, a.cfg.DatabaseURL, args, a.out)
+}
+
+func (a *app) listSources(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("sources", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	srcs, err := a.allSources()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCHAIN\tURL")
+	for _, src := range srcs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", src.Name(), src.Chain(), src.URL())
+	}
+	return tw.Flush()
+}
+
+// sourceArg parses a command's flags followed by exactly one source name.
+func (a *app) sourceArg(ctx context.Context, fs *flag.FlagSet, args []string) (monitor.Source, error) {
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 1 {
+		return nil, fmt.Errorf("%w: %s needs one source name", errUsage, fs.Name())
+	}
+	return a.source(ctx, fs.Arg(0))
+}
+
+func (a *app) poll(ctx context.Context, args []string) error {
+	src, err := a.sourceArg(ctx, flag.NewFlagSet("poll", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	type result struct {
+		snap *monitor.Snapshot
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		snap, err := src.FetchSnapshot()
+		ch <- result{snap, err}
+	}()
+	var r result
+	select {
+	case r = <-ch:
+	case <-time.After(pollTimeout):
+		return fmt.Errorf("poll %s: timed out after %v", src.Name(), pollTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if r.err != nil {
+		return fmt.Errorf("poll %s: %w", src.Name(), r.err)
+	}
+	enc := json.NewEncoder(a.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.snap)
+}
+
+func (a *app) report(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	lang := fs.String("lang", "en", "report language (en, zh)")
+	src, err := a.sourceArg(ctx, fs, args)
+	if err != nil {
+		return err
+	}
+	text, err := monitor.DailyReport(src, *lang)
+	if err != nil {
+		return fmt.Errorf("report %s: %w", src.Name(), err)
+	}
+	_, err = fmt.Fprintln(a.out, text)
+	return err
+}
+
+func (a *app) sendAlert(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("send-alert", flag.ContinueOnError)
+	chat := fs.Int64("chat", 0, "Telegram chat ID")
+	text := fs.String("text", "🔔 Test alert from monitorctl: alerts reach this chat.", "message (Telegram HTML)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *chat == 0 {
+		return fmt.Errorf("%w: send-alert needs -chat", errUsage)
+	}
+	send, err := a.sender()
+	if err != nil {
+		return err
+	}
+	if err := send(*chat, *text); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "sent to chat %d\n", *chat)
+	return nil
+}
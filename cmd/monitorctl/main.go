@@ -0,0 +1,232 @@
+// Command monitorctl operates an onchain-monitor deployment. It reads the
+// server's environment (DATABASE_URL, REDIS_URL, DEDUP_BACKEND,
+// TELEGRAM_BOT_TOKEN, ...) and works on the same store, dedup backend and
+// sources directly, instead of through kubectl and redis-cli.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/web3-frozen/onchain-monitor/internal/config"
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor/sources"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+	"github.com/web3-frozen/onchain-monitor/internal/telegram"
+)
+
+const usage = `usage: monitorctl <command> [flags] [args]
+
+Commands:
+  users                                 list Telegram chats
+  subscriptions [-chat ID]              list subscriptions, of one chat or all
+  dedup list  -chat ID | -sub ID        list dedup keys of a chat or subscription
+  dedup clear -chat ID | -sub ID [-dry-run]
+                                        delete them so the alerts can fire again
+  migrate status|up|down                show or change the Postgres schema version
+  sources                               list source names
+  poll SOURCE                           fetch one snapshot and print it as JSON
+  report [-lang en|zh] SOURCE           print a source's daily report
+  send-alert -chat ID [-text TEXT]      send a test alert through the Telegram bot
+
+Configuration comes from the same environment variables as the server.
+`
+
+// errUsage makes main print the usage text.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	a := &app{
+		cfg:    config.Load(),
+		out:    os.Stdout,
+		logger: slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	}
+	err := a.run(ctx, os.Args[1:])
+	a.close()
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(os.Stderr, "monitorctl: %v\n\n%s", err, usage)
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "monitorctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// app holds the configuration and the dependencies commands need. They are
+// opened on first use, so a command only needs the services it touches;
+// tests set them up front.
+type app struct {
+	cfg    config.Config
+	out    io.Writer
+	logger *slog.Logger
+
+	db       store.Store
+	backend  dedup.Backend
+	sources  []monitor.Source
+	settings config.Settings // CONFIG_FILE, read with the sources
+	send     func(chatID int64, text string) error
+
+	closers []func()
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: no command", errUsage)
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "users":
+		return a.users(ctx, args)
+	case "subscriptions":
+		return a.subscriptions(ctx, args)
+	case "dedup":
+		return a.dedup(ctx, args)
+	case "migrate":
+		return a.migrate(ctx, args)
+	case "sources":
+		return a.listSources(ctx, args)
+	case "poll":
+		return a.poll(ctx, args)
+	case "report":
+		return a.report(ctx, args)
+	case "send-alert":
+		return a.sendAlert(ctx, args)
+	case "help", "-h", "--help":
+		fmt.Fprint(a.out, usage)
+		return nil
+	}
+	return fmt.Errorf("%w: unknown command %q", errUsage, cmd)
+}
+
+func (a *app) close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+}
+
+// store opens DATABASE_URL.
+func (a *app) store(ctx context.Context) (store.Store, error) {
+	if a.db != nil {
+		return a.db, nil
+	}
+	if a.cfg.DatabaseURL == "" {
+		return nil, errors.New("DATABASE_URL is required")
+	}
+	db, err := store.Open(ctx, a.cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	a.db = db
+	a.closers = append(a.closers, db.Close)
+	return db, nil
+}
+
+// dedupBackend connects to the backend named by DEDUP_BACKEND.
+func (a *app) dedupBackend(ctx context.Context) (dedup.Backend, error) {
+	if a.backend != nil {
+		return a.backend, nil
+	}
+	switch a.cfg.DedupBackend {
+	case "redis":
+		opts, err := redis.ParseURL(a.cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		if a.cfg.RedisPassword != "" {
+			opts.Password = a.cfg.RedisPassword
+		}
+		rdb := redis.NewClient(opts)
+		a.closers = append(a.closers, func() { _ = rdb.Close() })
+		if err := rdb.Ping(ctx).Err(); err != nil {
+			return nil, fmt.Errorf("connect to redis: %w", err)
+		}
+		a.backend = dedup.NewRedis(rdb)
+	case "postgres":
+		db, err := a.store(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pg, ok := db.(*store.Postgres)
+		if !ok {
+			return nil, errors.New("DEDUP_BACKEND=postgres needs a postgres DATABASE_URL")
+		}
+		a.backend = dedup.NewPostgres(pg.Pool())
+	case "memory":
+		return nil, errors.New("DEDUP_BACKEND=memory keeps keys inside the server process, out of monitorctl's reach")
+	default:
+		return nil, fmt.Errorf("unknown DEDUP_BACKEND %q (want redis, postgres or memory)", a.cfg.DedupBackend)
+	}
+	return a.backend, nil
+}
+
+// source returns the built-in source called name. Max pain reads
+// liquidations, so it needs DATABASE_URL.
+func (a *app) source(ctx context.Context, name string) (monitor.Source, error) {
+	srcs, err := a.allSources()
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range srcs {
+		if src.Name() != name {
+			continue
+		}
+		if _, ok := src.(*sources.MaxPain); ok {
+			db, err := a.store(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("source %s reads liquidations: %w", name, err)
+			}
+			mp := sources.NewMaxPain(a.logger, db)
+			sources.ApplyFilters([]monitor.Source{mp}, a.settings.Sources)
+			return mp, nil
+		}
+		return src, nil
+	}
+	return nil, fmt.Errorf("unknown source %q (see monitorctl sources)", name)
+}
+
+// allSources lists the built-in sources, with the upstreams and filters
+// of CONFIG_FILE when it is set. Their max pain source has no store;
+// source builds one with the database when it is asked for.
+func (a *app) allSources() ([]monitor.Source, error) {
+	if a.sources != nil {
+		return a.sources, nil
+	}
+	if a.cfg.ConfigFile != "" {
+		st, err := config.LoadSettings(a.cfg.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		a.settings = st
+	}
+	srcs := sources.All(a.logger, nil, sources.OptionsFrom(a.settings.Sources))
+	if err := sources.Validate(srcs, a.settings.Sources); err != nil {
+		return nil, fmt.Errorf("%s: %w", a.cfg.ConfigFile, err)
+	}
+	sources.ApplyFilters(srcs, a.settings.Sources)
+	a.sources = srcs
+	return a.sources, nil
+}
+
+// sender returns the Telegram bot's SendMessage.
+func (a *app) sender() (func(chatID int64, text string) error, error) {
+	if a.send != nil {
+		return a.send, nil
+	}
+	if a.cfg.TelegramToken == "" {
+		return nil, errors.New("TELEGRAM_BOT_TOKEN is required")
+	}
+	a.send = telegram.NewBot(a.cfg.TelegramToken, nil, a.logger).SendMessage
+	return a.send, nil
+}
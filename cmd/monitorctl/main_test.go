@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+type fakeSource struct{}
+
+func (fakeSource) Name() string  { return "fake" }
+func (fakeSource) Chain() string { return "Testnet" }
+func (fakeSource) URL() string   { return "https://example.com" }
+func (fakeSource) FetchSnapshot() (*monitor.Snapshot, error) {
+	return &monitor.Snapshot{Source: "fake", Metrics: map[string]float64{"tvl": 42}, FetchedAt: time.Unix(0, 0)}, nil
+}
+func (fakeSource) FetchDailyReport() (string, error) { return "fake report", nil }
+func (fakeSource) FetchDailyReportLang(lang string) (string, error) {
+	return "fake report in " + lang, nil
+}
+
+// newTestApp returns an app on an in-memory store with chat 1 (two
+// subscriptions) and chat 2 (one), an in-memory dedup backend and a fake
+// source.
+func newTestApp(t *testing.T) (*app, *bytes.Buffer, []int64) {
+	t.Helper()
+	ctx := context.Background()
+	db := store.NewMemory()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	events, _ := db.ListEvents(ctx)
+	var subIDs []int64
+	for _, chat := range []int64{1, 1, 2} {
+		if _, err := db.UpsertLoginUser(ctx, chat, "user"); err != nil {
+			t.Fatalf("UpsertLoginUser: %v", err)
+		}
+		sub, err := db.Subscribe(ctx, chat, events[0].ID, 10, 5, "drop", 8, 0, "")
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+		subIDs = append(subIDs, sub.ID)
+	}
+	out := &bytes.Buffer{}
+	return &app{
+		out:     out,
+		logger:  slog.New(slog.DiscardHandler),
+		db:      db,
+		backend: dedup.NewMemory(),
+		sources: []monitor.Source{fakeSource{}},
+	}, out, subIDs
+}
+
+func TestListCommands(t *testing.T) {
+	ctx := context.Background()
+	a, out, subIDs := newTestApp(t)
+
+	if err := a.run(ctx, []string{"users"}); err != nil {
+		t.Fatalf("users: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 3 {
+		t.Errorf("users printed %d lines, want a header and 2 chats:\n%s", len(lines), out)
+	}
+
+	out.Reset()
+	if err := a.run(ctx, []string{"subscriptions", "-chat", "2"}); err != nil {
+		t.Fatalf("subscriptions: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || strings.Fields(lines[1])[0] != itoa(subIDs[2]) {
+		t.Errorf("subscriptions -chat 2:\n%s\nwant only subscription %d", out, subIDs[2])
+	}
+}
+
+func TestDedupCommands(t *testing.T) {
+	ctx := context.Background()
+	a, out, subIDs := newTestApp(t)
+	for _, key := range []string{
+		dedup.SubscriptionKey(subIDs[0], "altura:tvl"),
+		dedup.SubscriptionKey(subIDs[1], "altura:apr"),
+		dedup.SubscriptionKey(subIDs[2], "altura:tvl"), // chat 2
+		"merkl:1:opp-7", // legacy key of chat 1
+		"1:altura:tvl",  // older legacy key of chat 1
+		"merkl:12:opp-7",
+	} {
+		if err := a.backend.Set(ctx, key, 0); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	if err := a.run(ctx, []string{"dedup", "list", "-sub", itoa(subIDs[2])}); err != nil {
+		t.Fatalf("dedup list -sub: %v", err)
+	}
+	if !strings.Contains(out.String(), "1 key(s)") {
+		t.Errorf("dedup list -sub:\n%s\nwant 1 key", out)
+	}
+
+	out.Reset()
+	if err := a.run(ctx, []string{"dedup", "clear", "-chat", "1", "-dry-run"}); err != nil {
+		t.Fatalf("dedup clear -dry-run: %v", err)
+	}
+	if !strings.Contains(out.String(), "4 key(s)") {
+		t.Errorf("dedup clear -chat 1 -dry-run:\n%s\nwant 4 keys", out)
+	}
+
+	out.Reset()
+	if err := a.run(ctx, []string{"dedup", "clear", "-chat", "1"}); err != nil {
+		t.Fatalf("dedup clear: %v", err)
+	}
+	left, _ := a.backend.Keys(ctx, "*")
+	if len(left) != 2 {
+		t.Errorf("keys left after clearing chat 1 = %v, want chat 2's and merkl:12:opp-7", left)
+	}
+
+	if err := a.run(ctx, []string{"dedup", "clear"}); !errors.Is(err, errUsage) {
+		t.Errorf("dedup clear without a target: error = %v, want a usage error", err)
+	}
+}
+
+func TestSourceCommands(t *testing.T) {
+	ctx := context.Background()
+	a, out, _ := newTestApp(t)
+
+	if err := a.run(ctx, []string{"poll", "fake"}); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if !strings.Contains(out.String(), `"tvl": 42`) {
+		t.Errorf("poll printed:\n%s\nwant the snapshot as JSON", out)
+	}
+
+	out.Reset()
+	if err := a.run(ctx, []string{"report", "-lang", "zh", "fake"}); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if out.String() != "fake report in zh\n" {
+		t.Errorf("report printed %q", out)
+	}
+
+	if err := a.run(ctx, []string{"poll", "nope"}); err == nil || !strings.Contains(err.Error(), "unknown source") {
+		t.Errorf("poll nope: error = %v, want unknown source", err)
+	}
+}
+
+func TestSendAlert(t *testing.T) {
+	ctx := context.Background()
+	a, _, _ := newTestApp(t)
+	var gotChat int64
+	var gotText string
+	a.send = func(chatID int64, text string) error {
+		gotChat, gotText = chatID, text
+		return nil
+	}
+
+	if err := a.run(ctx, []string{"send-alert", "-chat", "7", "-text", "hello"}); err != nil {
+		t.Fatalf("send-alert: %v", err)
+	}
+	if gotChat != 7 || gotText != "hello" {
+		t.Errorf("sent %q to %d, want hello to 7", gotText, gotChat)
+	}
+	if err := a.run(ctx, []string{"send-alert"}); !errors.Is(err, errUsage) {
+		t.Errorf("send-alert without -chat: error = %v, want a usage error", err)
+	}
+	if err := a.run(ctx, []string{"frobnicate"}); !errors.Is(err, errUsage) {
+		t.Errorf("unknown command: error = %v, want a usage error", err)
+	}
+}
+
+func itoa(n int64) string { return strconv.FormatInt(n, 10) }
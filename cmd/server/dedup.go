@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// newDedupBackend returns the alert dedup backend named by DEDUP_BACKEND.
+// The Postgres backend shares the store's pool, so it needs a Postgres
+// DATABASE_URL.
+func newDedupBackend(name string, db store.Store, rdb *redis.Client) (dedup.Backend, error) {
+	switch name {
+	case "redis":
+		return dedup.NewRedis(rdb), nil
+	case "memory":
+		return dedup.NewMemory(), nil
+	case "postgres":
+		pg, ok := db.(*store.Postgres)
+		if !ok {
+			return nil, errors.New("DEDUP_BACKEND=postgres needs a postgres DATABASE_URL")
+		}
+		return dedup.NewPostgres(pg.Pool()), nil
+	}
+	return nil, fmt.Errorf("unknown DEDUP_BACKEND %q (want redis, postgres or memory)", name)
+}
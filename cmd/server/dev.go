@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// devChatID is the demo chat linked in --dev mode.
+const devChatID = 1
+
+// seedDevUser links the demo chat in a fresh dev store and returns a
+// session token for it, so the per-user API can be tried without a bot.
+func seedDevUser(ctx context.Context, db store.Store, ttl time.Duration) (string, error) {
+	if _, err := db.UpsertLoginUser(ctx, devChatID, "dev"); err != nil {
+		return "", err
+	}
+	token, _, err := db.CreateSession(ctx, devChatID, ttl)
+	return token, err
+}
+
+// logAlerts stands in for the Telegram bot when no token is configured:
+// alerts are written to the log instead of being sent.
+func logAlerts(logger *slog.Logger) monitor.AlertFunc {
+	return func(chatID int64, message string) error {
+		logger.Info("alert (not sent, no telegram token)", "chat_id", chatID, "message", message)
+		return nil
+	}
+}
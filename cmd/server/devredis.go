@@ -0,0 +1,16 @@
+//go:build dev
+
+package main
+
+import "github.com/alicebob/miniredis/v2"
+
+// startDevRedis starts the embedded Redis of --dev mode and returns its
+// address. It is only built with -tags dev, so release binaries do not
+// carry miniredis.
+func startDevRedis() (addr string, stop func(), err error) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		return "", nil, err
+	}
+	return mr.Addr(), mr.Close, nil
+}
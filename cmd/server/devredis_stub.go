@@ -0,0 +1,10 @@
+//go:build !dev
+
+package main
+
+import "errors"
+
+// startDevRedis fails in binaries built without -tags dev; see devredis.go.
+func startDevRedis() (string, func(), error) {
+	return "", nil, errors.New("--dev needs a binary built with -tags dev (go run -tags dev ./cmd/server --dev)")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/web3-frozen/onchain-monitor/internal/leader"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// leaderLeaseKey is the Redis key of the leader lease.
+const leaderLeaseKey = "leader:onchain-monitor"
+
+// newLeaderLock returns the lock named by LEADER_ELECTION. "none" makes
+// this replica the leader unconditionally, for single-replica deployments.
+func newLeaderLock(name string, db store.Store, rdb *redis.Client, ttl time.Duration) (leader.Lock, error) {
+	switch name {
+	case "none":
+		return leader.Always(), nil
+	case "redis":
+		return leader.NewRedis(rdb, leaderLeaseKey, leader.InstanceID(), ttl), nil
+	case "postgres":
+		pg, ok := db.(*store.Postgres)
+		if !ok {
+			return nil, errors.New("LEADER_ELECTION=postgres needs a postgres DATABASE_URL")
+		}
+		return leader.NewPostgres(pg.Pool(), leader.DefaultLockID), nil
+	}
+	return nil, fmt.Errorf("unknown LEADER_ELECTION %q (want none, redis or postgres)", name)
+}
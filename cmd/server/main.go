@@ -2,47 +2,90 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
-	"github.com/go-chi/chi/v5"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"github.com/web3-frozen/onchain-monitor/internal/collector"
 	"github.com/web3-frozen/onchain-monitor/internal/config"
 	"github.com/web3-frozen/onchain-monitor/internal/dedup"
 	"github.com/web3-frozen/onchain-monitor/internal/handler"
+	"github.com/web3-frozen/onchain-monitor/internal/leader"
+	"github.com/web3-frozen/onchain-monitor/internal/linkguard"
+	"github.com/web3-frozen/onchain-monitor/internal/livefeed"
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/middleware"
+	"github.com/web3-frozen/onchain-monitor/internal/migrate"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor/sources"
+	"github.com/web3-frozen/onchain-monitor/internal/openapi"
 	"github.com/web3-frozen/onchain-monitor/internal/store"
+	"github.com/web3-frozen/onchain-monitor/internal/subcache"
 	"github.com/web3-frozen/onchain-monitor/internal/telegram"
+	"github.com/web3-frozen/onchain-monitor/internal/upstream"
 	"golang.org/x/sync/errgroup"
 )
 
 func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	dev := flag.Bool("dev", false, "run without Postgres or Redis: in-memory storage and an embedded Redis (data is lost on exit); needs -tags dev")
+	flag.Parse()
 	cfg := config.Load()
 
-	if cfg.DatabaseURL == "" {
+	if flag.Arg(0) == "migrate" {
+		if err := migrate.Run(context.Background(), cfg.DatabaseURL, flag.Args()[1:], os.Stdout); err != nil {
+			logger.Error("migrate failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.DatabaseURL == "" && !*dev {
 		logger.Error("DATABASE_URL is required")
 		os.Exit(1)
 	}
-	if cfg.TelegramToken == "" {
+	if cfg.TelegramToken == "" && !*dev {
 		logger.Error("TELEGRAM_BOT_TOKEN is required")
 		os.Exit(1)
 	}
 
+	// Message templates (embedded defaults, optionally overridden per deployment)
+	if cfg.TemplatesDir != "" {
+		renderer, err := messages.New(cfg.TemplatesDir)
+		if err != nil {
+			logger.Error("failed to load message templates", "dir", cfg.TemplatesDir, "error", err)
+			os.Exit(1)
+		}
+		messages.SetDefault(renderer)
+		logger.Info("message template overrides loaded", "dir", cfg.TemplatesDir)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Database
-	db, err := store.New(ctx, cfg.DatabaseURL)
-	if err != nil {
-		logger.Error("failed to connect to database", "error", err)
-		os.Exit(1)
+	// Database (backend chosen by DATABASE_URL scheme); dev mode swaps it and Redis for in-process stand-ins
+	var db store.Store
+	if *dev {
+		db = store.NewMemory()
+		addr, stop, err := startDevRedis()
+		if err != nil {
+			logger.Error("failed to start embedded redis", "error", err)
+			os.Exit(1)
+		}
+		defer stop()
+		cfg.RedisURL, cfg.RedisPassword = "redis://"+addr, ""
+		logger.Warn("dev mode: in-memory storage and embedded redis, data is lost on exit")
+	} else {
+		var err error
+		db, err = store.Open(ctx, cfg.DatabaseURL)
+		if err != nil {
+			logger.Error("failed to connect to database", "error", err)
+			os.Exit(1)
+		}
 	}
 	defer db.Close()
 
@@ -52,66 +95,146 @@ func main() {
 	}
 	logger.Info("database connected and migrated")
 
-	// Telegram bot
-	bot := telegram.NewBot(cfg.TelegramToken, db, logger)
+	if *dev {
+		token, err := seedDevUser(ctx, db, cfg.SessionTTL)
+		if err != nil {
+			logger.Error("failed to seed dev user", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("dev session for the demo chat", "tg_chat_id", devChatID, "token", token)
+		// The demo chat is an admin, so /api/admin can be tried locally
+		cfg.Admins.ChatIDs = append(cfg.Admins.ChatIDs, devChatID)
+	}
+
+	// Subscription reads come from memory; writes through subs and Postgres
+	// NOTIFY (from any replica) invalidate it
+	subs := subcache.New(db, logger)
+
+	// Telegram bot (optional in dev mode: alerts are logged instead)
+	var bot *telegram.Bot
+	alertFn := logAlerts(logger)
+	if cfg.TelegramToken != "" {
+		bot = telegram.NewBot(cfg.TelegramToken, subs, logger)
+		alertFn = bot.SendMessage
+	}
 
-	// Redis dedup (retry up to 30s for ExternalSecret to sync)
-	var dd *dedup.Deduplicator
+	// Redis backs rate limits, link-code protection and (by default) alert
+	// dedup. Startup does not depend on it: the limiter fails open, the link
+	// guard fails closed (no linking while Redis is down), and dedup follows
+	// DEDUP_FAIL_MODE. Retry up to 30s for ExternalSecret to sync.
+	redisOpts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		logger.Error("invalid REDIS_URL", "error", err)
+		os.Exit(1)
+	}
+	if cfg.RedisPassword != "" {
+		redisOpts.Password = cfg.RedisPassword
+	}
+	// Socket reads honour context deadlines, so the rate limiter's short
+	// one holds against a Redis that accepts connections but hangs
+	redisOpts.ContextTimeoutEnabled = true
+	rdb := redis.NewClient(redisOpts)
+	defer rdb.Close()
 	for i := 0; i < 6; i++ {
-		dd, err = dedup.New(cfg.RedisURL, cfg.RedisPassword)
+		pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+		err = rdb.Ping(pingCtx).Err()
+		pingCancel()
 		if err == nil {
+			logger.Info("redis connected")
 			break
 		}
 		logger.Warn("redis not ready, retrying...", "attempt", i+1, "error", err)
 		time.Sleep(5 * time.Second)
 	}
 	if err != nil {
-		logger.Error("failed to connect to redis after retries", "error", err)
+		logger.Warn("redis unreachable, continuing without it", "error", err)
+	}
+
+	dedupBackend, err := newDedupBackend(cfg.DedupBackend, db, rdb)
+	if err != nil {
+		logger.Error("failed to set up alert dedup", "error", err)
+		os.Exit(1)
+	}
+	dd := dedup.New(dedupBackend, cfg.DedupFailOpen)
+	logger.Info("alert dedup ready", "backend", cfg.DedupBackend)
+
+	// Link code brute-force protection
+	linkGuard := linkguard.New(rdb, cfg.LinkLimits)
+
+	// Per-client rate limits, also kept in Redis so they hold across replicas
+	limiter := middleware.NewRateLimiter(rdb, logger)
+
+	// Optional config file (CONFIG_FILE), reloaded on SIGHUP
+	settings, err := loadSettings(cfg.ConfigFile)
+	if err != nil {
+		logger.Error("invalid config file", "error", err)
 		os.Exit(1)
 	}
-	defer dd.Close()
-	logger.Info("redis connected for alert dedup")
 
 	// Monitoring engine
-	engine := monitor.NewEngine(db, logger, bot.SendMessage, dd)
-	engine.Register(sources.NewAltura())
-	engine.Register(sources.NewNeverland())
-	engine.Register(sources.NewFearGreed())
-	engine.Register(sources.NewMaxPain(logger, db))
-	engine.Register(sources.NewMerkl(logger))
-	engine.Register(sources.NewTurtle(logger))
-	engine.Register(sources.NewBinance())
-	engine.Register(sources.NewAlpha())
-	engine.Register(sources.NewDefiLlama(logger))
-	engine.Register(sources.NewDefiLlamaLP(logger))
-	defillamaTVLSrc := sources.NewDefiLlamaTVL(logger)
-	engine.Register(defillamaTVLSrc)
-
-	// HTTP routes
-	r := chi.NewRouter()
-	r.Use(middleware.Recover(logger))
-	r.Use(middleware.Logger(logger))
-	r.Use(middleware.Metrics())
-	r.Use(middleware.CORS(cfg.FrontendOrigin))
-
-	r.Handle("/metrics", promhttp.Handler())
-	r.Get("/healthz", handler.Health())
-	r.Get("/readyz", handler.Ready(db))
-
-	r.Route("/api", func(r chi.Router) {
-		r.Get("/events", handler.ListEvents(db))
-		r.Get("/link/status", handler.LinkStatus(db))
-		r.Post("/link", handler.LinkTelegram(db))
-		r.Post("/unlink", handler.UnlinkTelegram(db))
-		r.Get("/subscriptions", handler.ListSubscriptions(db))
-		r.Post("/subscriptions", handler.Subscribe(db))
-		r.Put("/subscriptions/{id}", handler.UpdateSubscription(db))
-		r.Delete("/subscriptions/{id}", handler.Unsubscribe(db, dd))
-		r.Get("/stats", handler.Stats(engine))
-		r.Get("/stats/meta", handler.StatsMetadata(engine))
-		r.Get("/notifications", handler.ListNotifications(db))
-		r.Get("/defillama/protocols/search", handler.SearchDefiLlamaProtocols(defillamaTVLSrc))
-	})
+	engine := monitor.NewEngine(subs, logger, alertFn, dd)
+	srcs := sources.All(logger, db, sources.OptionsFrom(settings.Sources))
+	engine.EnableUpstreamHealth(upstream.Default)
+	// With several replicas the leader shares its snapshots, source health
+	// and alerts over Redis, so followers serve the same live data
+	if cfg.LeaderElection != "none" {
+		engine.EnableFeed(livefeed.NewRedis(rdb, livefeed.DefaultChannel))
+	}
+	var defillamaTVLSrc *sources.DefiLlamaTVL
+	for _, src := range srcs {
+		engine.Register(src)
+		if tvl, ok := src.(*sources.DefiLlamaTVL); ok {
+			defillamaTVLSrc = tvl
+		}
+	}
+
+	if err := validateSettings(cfg.ConfigFile, settings, srcs); err != nil {
+		logger.Error("invalid config file", "error", err)
+		os.Exit(1)
+	}
+	applySettings(settings, engine, srcs)
+	if cfg.ConfigFile != "" {
+		logger.Info("config file loaded", "path", cfg.ConfigFile)
+	}
+	if len(cfg.ChartAlerts) > 0 && bot != nil {
+		engine.EnableCharts(bot.SendPhoto, cfg.ChartAlerts)
+		logger.Info("chart images enabled", "alert_types", cfg.ChartAlerts)
+	}
+
+	// Leader election, so several replicas never double-poll or double-alert
+	leaderLock, err := newLeaderLock(cfg.LeaderElection, db, rdb, cfg.LeaderLeaseTTL)
+	if err != nil {
+		logger.Error("failed to set up leader election", "error", err)
+		os.Exit(1)
+	}
+	elector := leader.New(leaderLock, cfg.LeaderLeaseTTL/3, logger)
+	logger.Info("leader election ready", "mode", cfg.LeaderElection)
+
+	if len(cfg.TrustedProxies) == 0 {
+		logger.Info("TRUSTED_PROXIES not set, ignoring X-Forwarded-For")
+	}
+
+	// HTTP routes, validated against the embedded OpenAPI document
+	spec, err := openapi.Load()
+	if err != nil {
+		logger.Error("failed to load openapi document", "error", err)
+		os.Exit(1)
+	}
+	// Work handlers leave running, like broadcasts; shutdown waits for it
+	tasks := handler.NewTasks(ctx)
+	r := (&server{
+		cfg:       cfg,
+		logger:    logger,
+		db:        subs,
+		dd:        dd,
+		engine:    engine,
+		elector:   elector,
+		linkGuard: linkGuard,
+		limiter:   limiter,
+		tasks:     tasks,
+		protocols: defillamaTVLSrc,
+		spec:      spec,
+	}).routes()
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -124,10 +247,28 @@ func main() {
 	// Graceful lifecycle: all background goroutines tracked via errgroup
 	g, gCtx := errgroup.WithContext(ctx)
 
-	liqCollector := collector.New(db, logger)
-	g.Go(func() error { liqCollector.Run(gCtx); return nil })
-	g.Go(func() error { bot.Run(gCtx); return nil })
-	g.Go(func() error { engine.Run(gCtx); return nil })
+	g.Go(func() error { subs.Run(gCtx); return nil })
+	g.Go(func() error { engine.RunFeed(gCtx); return nil })
+	g.Go(func() error {
+		reloadSettings(gCtx, cfg.ConfigFile, settings, engine, srcs, logger)
+		return nil
+	})
+
+	// Only the leader polls sources, sends alerts, collects liquidations and
+	// answers the bot; every replica serves HTTP.
+	liqCollector := collector.New(db, logger, collectorSettings(settings))
+	g.Go(func() error {
+		elector.Run(gCtx, func(ctx context.Context) {
+			lg, lCtx := errgroup.WithContext(ctx)
+			lg.Go(func() error { liqCollector.Run(lCtx); return nil })
+			if bot != nil {
+				lg.Go(func() error { bot.Run(lCtx); return nil })
+			}
+			lg.Go(func() error { engine.Run(lCtx); return nil })
+			_ = lg.Wait()
+		})
+		return nil
+	})
 
 	g.Go(func() error {
 		logger.Info("server starting", "port", cfg.Port)
@@ -144,6 +285,11 @@ func main() {
 		defer shutdownCancel()
 		return srv.Shutdown(shutdownCtx)
 	})
+	g.Go(func() error {
+		<-gCtx.Done()
+		tasks.Wait()
+		return nil
+	})
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/web3-frozen/onchain-monitor/internal/config"
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/handler"
+	"github.com/web3-frozen/onchain-monitor/internal/leader"
+	"github.com/web3-frozen/onchain-monitor/internal/linkguard"
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor/sources"
+	"github.com/web3-frozen/onchain-monitor/internal/openapi"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// server holds what the HTTP routes depend on.
+type server struct {
+	cfg       config.Config
+	logger    *slog.Logger
+	db        store.Store
+	dd        *dedup.Deduplicator
+	engine    *monitor.Engine
+	elector   *leader.Elector
+	linkGuard *linkguard.Guard
+	limiter   *middleware.RateLimiter
+	tasks     *handler.Tasks
+	protocols *sources.DefiLlamaTVL
+	spec      *openapi.Spec
+}
+
+// routes builds the HTTP router. Every route must be described in
+// internal/openapi/openapi.json; routes_test.go checks both directions.
+func (s *server) routes() *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(middleware.Recover(s.logger))
+	r.Use(middleware.RealIP(s.cfg.TrustedProxies))
+	r.Use(middleware.Logger(s.logger))
+	r.Use(middleware.Metrics())
+	r.Use(middleware.CORS(s.cfg.FrontendOrigin))
+
+	r.Handle("/metrics", promhttp.Handler())
+	r.Get("/healthz", handler.Health())
+	r.Get("/readyz", handler.Ready(s.db, s.elector.IsLeader))
+	r.With(s.limiter.Limit("stats", s.cfg.RateLimits["stats"])).
+		Get("/status", handler.Status(s.engine))
+
+	r.Route("/api", func(r chi.Router) {
+		r.Use(openapi.Validate(s.spec))
+		r.Get("/openapi.json", handler.OpenAPI())
+		r.Get("/events", handler.ListEvents(s.db))
+		r.Post("/link", handler.LinkTelegram(s.db, s.linkGuard, s.cfg.SessionTTL))
+		r.Post("/login/telegram", handler.TelegramLogin(s.db, s.cfg.TelegramToken, s.cfg.SessionTTL, s.cfg.LoginMaxAge))
+		r.Group(func(r chi.Router) {
+			// Public, but an API key used here needs read:stats
+			r.Use(middleware.OptionalAuth(s.db), middleware.RequireScope(store.ScopeReadStats))
+			r.Use(s.limiter.Limit("stats", s.cfg.RateLimits["stats"]))
+			r.Get("/stats", handler.Stats(s.engine))
+			r.Get("/stats/meta", handler.StatsMetadata(s.engine))
+			r.Get("/sources", handler.ListSources(s.engine))
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(s.limiter.Limit("charts", s.cfg.RateLimits["charts"]))
+			r.Get("/charts/metrics/{source}/{metric}", handler.MetricChart(s.engine))
+			r.Get("/charts/liquidations/{symbol}", handler.LiquidationChart(s.engine))
+		})
+		r.Group(func(r chi.Router) {
+			// Live snapshots (and the caller's alerts when authenticated).
+			// EventSource and browser WebSockets cannot set headers, so
+			// the token may come as ?access_token=.
+			r.Use(middleware.QueryToken, middleware.OptionalAuth(s.db), middleware.RequireScope(store.ScopeReadStats))
+			r.Use(s.limiter.Limit("stream", s.cfg.RateLimits["stream"]))
+			r.Get("/stream", handler.StreamSSE(s.engine))
+			r.Get("/stream/ws", handler.StreamWebSocket(s.engine, s.cfg.FrontendOrigin))
+		})
+		r.With(s.limiter.Limit("search", s.cfg.RateLimits["search"])).
+			Get("/defillama/protocols/search", handler.SearchDefiLlamaProtocols(s.protocols))
+
+		// Per-user endpoints: the chat ID comes from the session token or
+		// API key
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Auth(s.db), s.limiter.Limit("user", s.cfg.RateLimits["user"]))
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.SessionOnly)
+				r.Get("/link/status", handler.LinkStatus(s.db))
+				r.Post("/unlink", handler.UnlinkTelegram(s.db))
+				r.Post("/logout", handler.Logout(s.db))
+				r.Put("/language", handler.SetLanguage(s.db))
+				r.Get("/keys", handler.ListAPIKeys(s.db))
+				r.Post("/keys", handler.CreateAPIKey(s.db))
+				r.Delete("/keys/{id}", handler.RevokeAPIKey(s.db))
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireScope(store.ScopeWriteSubscriptions))
+				r.Get("/subscriptions", handler.ListSubscriptions(s.db))
+				r.Post("/subscriptions", handler.Subscribe(s.db))
+				r.Put("/subscriptions/{id}", handler.UpdateSubscription(s.db, s.dd))
+				r.Delete("/subscriptions/{id}", handler.Unsubscribe(s.db, s.dd))
+			})
+
+			r.With(middleware.RequireScope(store.ScopeReadNotifications)).
+				Get("/notifications", handler.ListNotifications(s.db))
+		})
+
+		// Operator endpoints: an admin chat's session or an admin API key
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(middleware.Admin(s.db, s.cfg.Admins), s.limiter.Limit("user", s.cfg.RateLimits["user"]))
+			r.Get("/users", handler.AdminListUsers(s.db))
+			r.Get("/subscriptions", handler.AdminListSubscriptions(s.db))
+			r.Put("/subscriptions/{id}", handler.AdminUpdateSubscription(s.db, s.dd, s.db))
+			r.Get("/events", handler.AdminListEvents(s.db))
+			r.Put("/events/{id}", handler.AdminSetEventEnabled(s.db, s.db))
+			r.Post("/broadcast", handler.AdminBroadcast(s.db, s.engine.Send, s.db, s.tasks))
+			r.Get("/delivery-failures", handler.AdminListDeliveryFailures(s.db))
+			r.Get("/sources", handler.AdminListSources(s.engine, s.db))
+			r.Post("/sources/{name}/pause", handler.AdminSetSourcePaused(s.engine, s.db, s.db, true))
+			r.Post("/sources/{name}/resume", handler.AdminSetSourcePaused(s.engine, s.db, s.db, false))
+			r.Get("/audit-log", handler.AdminListAuditLog(s.db))
+		})
+	})
+
+	return r
+}
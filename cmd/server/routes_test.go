@@ -0,0 +1,98 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/web3-frozen/onchain-monitor/internal/openapi"
+)
+
+// testServer builds the router without backing services; only requests
+// that are answered before reaching them can be served.
+func testServer(t *testing.T) (*server, *chi.Mux) {
+	t.Helper()
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	s := &server{logger: slog.New(slog.DiscardHandler), spec: spec}
+	return s, s.routes()
+}
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	s, router := testServer(t)
+
+	registered := map[openapi.Route]bool{}
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(strings.ReplaceAll(route, "/*/", "/"), "/")
+		registered[openapi.Route{Method: method, Path: route}] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+
+	documented := map[openapi.Route]bool{}
+	for _, rt := range s.spec.Routes() {
+		documented[rt] = true
+		if !registered[rt] {
+			t.Errorf("%s %s is documented but not routed", rt.Method, rt.Path)
+		}
+	}
+	for rt := range registered {
+		// /metrics is mounted for every method; only GET is documented
+		if rt.Path == "/metrics" && rt.Method != http.MethodGet {
+			continue
+		}
+		if !documented[rt] {
+			t.Errorf("%s %s is routed but missing from openapi.json", rt.Method, rt.Path)
+		}
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	_, router := testServer(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{"subscribe bad direction and threshold", "POST", "/api/subscriptions",
+			`{"event_id": 1, "direction": "sideways", "threshold_pct": -5}`, 400, []string{"direction", "threshold_pct"}},
+		{"subscribe missing event", "POST", "/api/subscriptions", `{"threshold_pct": 5}`, 400, []string{"event_id"}},
+		{"update report hour out of range", "PUT", "/api/subscriptions/3", `{"report_hour": 24}`, 400, []string{"report_hour"}},
+		{"non-numeric subscription id", "DELETE", "/api/subscriptions/abc", ``, 400, []string{"id"}},
+		{"notifications limit too large", "GET", "/api/notifications?limit=500", ``, 400, []string{"limit"}},
+		{"bad chart interval", "GET", "/api/charts/liquidations/BTC?interval=5m", ``, 400, []string{"interval"}},
+		{"api key with unknown scope", "POST", "/api/keys", `{"name": "ci", "scopes": ["admin"]}`, 400, []string{"scopes[0]"}},
+		{"link body missing", "POST", "/api/link", ``, 400, []string{""}},
+		{"malformed JSON", "POST", "/api/link", `{"code":`, 400, nil},
+		{"valid body reaches auth", "POST", "/api/subscriptions", `{"event_id": 1, "direction": "increase"}`, 401, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			for _, f := range tt.wantFields {
+				if !strings.Contains(rec.Body.String(), `"field":"`+f+`"`) {
+					t.Errorf("body %s does not list field %q", rec.Body.String(), f)
+				}
+			}
+		})
+	}
+}
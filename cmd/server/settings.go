@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/collector"
+	"github.com/web3-frozen/onchain-monitor/internal/config"
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor/sources"
+)
+
+// loadSettings reads the config file named by CONFIG_FILE. Without a file
+// every setting is the default.
+func loadSettings(path string) (config.Settings, error) {
+	if path == "" {
+		return config.Settings{}, nil
+	}
+	return config.LoadSettings(path)
+}
+
+// validateSettings checks the file's per-source sections against srcs.
+func validateSettings(path string, st config.Settings, srcs []monitor.Source) error {
+	if err := sources.Validate(srcs, st.Sources); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// engineSettings maps the config file onto the engine's settings.
+func engineSettings(st config.Settings) monitor.Settings {
+	s := monitor.DefaultSettings()
+	if st.Engine.PollInterval > 0 {
+		s.PollInterval = time.Duration(st.Engine.PollInterval)
+	}
+	if st.Engine.FetchTimeout > 0 {
+		s.FetchTimeout = time.Duration(st.Engine.FetchTimeout)
+	}
+	if st.Engine.HistoryLen > 0 {
+		s.HistoryLen = st.Engine.HistoryLen
+	}
+	s.Sources = make(map[string]monitor.SourceSettings, len(st.Sources))
+	for name, src := range st.Sources {
+		s.Sources[name] = monitor.SourceSettings{
+			Disabled: src.Disabled(),
+			Interval: time.Duration(src.Interval),
+			Timeout:  time.Duration(src.Timeout),
+		}
+	}
+	return s
+}
+
+// collectorSettings maps the config file onto the liquidation collector's
+// settings.
+func collectorSettings(st config.Settings) collector.Settings {
+	return collector.Settings{
+		Symbols:    st.Collector.Symbols,
+		CleanupAge: time.Duration(st.Collector.CleanupAge),
+	}
+}
+
+// applySettings applies the settings that can change while running.
+func applySettings(st config.Settings, engine *monitor.Engine, srcs []monitor.Source) {
+	engine.Configure(engineSettings(st))
+	sources.ApplyFilters(srcs, st.Sources)
+}
+
+// reloadSettings re-reads the config file on every SIGHUP until ctx ends.
+// An invalid file keeps the current settings; changes that need a restart
+// (see config.Settings.RestartRequired) are logged and left alone.
+func reloadSettings(ctx context.Context, path string, started config.Settings, engine *monitor.Engine, srcs []monitor.Source, logger *slog.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		if path == "" {
+			logger.Warn("SIGHUP ignored: CONFIG_FILE is not set")
+			continue
+		}
+		st, err := loadSettings(path)
+		if err == nil {
+			err = validateSettings(path, st, srcs)
+		}
+		if err != nil {
+			metrics.ConfigReloadsTotal.WithLabelValues("error").Inc()
+			logger.Error("reload config file failed, keeping current settings", "path", path, "error", err)
+			continue
+		}
+		if keys := started.RestartRequired(st); len(keys) > 0 {
+			logger.Warn("config changes need a restart", "keys", keys)
+		}
+		applySettings(st, engine, srcs)
+		metrics.ConfigReloadsTotal.WithLabelValues("success").Inc()
+		logger.Info("config file reloaded", "path", path)
+	}
+}
@@ -0,0 +1,269 @@
+// Package chart renders small PNG charts for alerts, reports and the API
+// using only the standard library, so no external rendering service is
+// needed.
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"sort"
+	"time"
+)
+
+// Default image size, chosen to stay legible as a Telegram photo preview.
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 400
+)
+
+// ErrNoData is returned when there are too few points or bins to draw.
+var ErrNoData = errors.New("not enough data to draw a chart")
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridColor  = color.RGBA{0xe6, 0xe8, 0xec, 0xff}
+	axisColor  = color.RGBA{0x9a, 0xa0, 0xa6, 0xff}
+	labelColor = color.RGBA{0x44, 0x4b, 0x55, 0xff}
+	lineColor  = color.RGBA{0x29, 0x62, 0xff, 0xff}
+	fillColor  = color.RGBA{0xdc, 0xe6, 0xff, 0xff}
+	longColor  = color.RGBA{0xef, 0x53, 0x50, 0xff}
+	shortColor = color.RGBA{0x26, 0xa6, 0x9a, 0xff}
+	priceColor = color.RGBA{0x21, 0x21, 0x21, 0xff}
+)
+
+// Plot-area margins in pixels; the left margin holds the value labels.
+const (
+	marginLeft   = 76
+	marginRight  = 16
+	marginTop    = 16
+	marginBottom = 28
+	gridLines    = 4
+)
+
+// Point is one sample of a time series.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Bin is the liquidated USD volume at one price level.
+type Bin struct {
+	Price float64
+	Long  float64
+	Short float64
+}
+
+// Options controls the image size. Zero values use the defaults.
+type Options struct {
+	Width  int
+	Height int
+}
+
+func (o Options) size() (int, int) {
+	w, h := o.Width, o.Height
+	if w <= 0 {
+		w = DefaultWidth
+	}
+	if h <= 0 {
+		h = DefaultHeight
+	}
+	return w, h
+}
+
+// canvas is an image with a plot area inset by the margins.
+type canvas struct {
+	img                    *image.RGBA
+	left, right, top, bott int
+}
+
+func newCanvas(opts Options) *canvas {
+	w, h := opts.size()
+	c := &canvas{
+		img:   image.NewRGBA(image.Rect(0, 0, w, h)),
+		left:  marginLeft,
+		right: w - marginRight,
+		top:   marginTop,
+		bott:  h - marginBottom,
+	}
+	c.rect(0, 0, w, h, background)
+	return c
+}
+
+func (c *canvas) rect(x0, y0, x1, y1 int, col color.RGBA) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			c.img.SetRGBA(x, y, col)
+		}
+	}
+}
+
+// grid draws horizontal grid lines with value labels from lo (bottom) to
+// hi (top), plus the plot-area axes.
+func (c *canvas) grid(lo, hi float64) {
+	for i := 0; i <= gridLines; i++ {
+		y := c.bott - (c.bott-c.top)*i/gridLines
+		col := gridColor
+		if i == 0 {
+			col = axisColor
+		}
+		c.rect(c.left, y, c.right, y+1, col)
+		v := lo + (hi-lo)*float64(i)/gridLines
+		label := formatValue(v)
+		drawText(c.img, c.left-8-textWidth(label), y-textHeight/2, label, labelColor)
+	}
+	c.rect(c.left, c.top, c.left+1, c.bott+1, axisColor)
+}
+
+// encode writes the canvas as PNG.
+func (c *canvas) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Line renders a filled line chart of points, oldest to newest. Points are
+// placed by time, so gaps in the history show up as longer segments.
+func Line(points []Point, opts Options) ([]byte, error) {
+	if len(points) < 2 {
+		return nil, ErrNoData
+	}
+	points = append([]Point(nil), points...)
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+
+	lo, hi := points[0].Value, points[0].Value
+	for _, p := range points {
+		lo = math.Min(lo, p.Value)
+		hi = math.Max(hi, p.Value)
+	}
+	lo, hi = padRange(lo, hi)
+
+	c := newCanvas(opts)
+	c.grid(lo, hi)
+
+	t0 := points[0].Time
+	span := points[len(points)-1].Time.Sub(t0).Seconds()
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	for i, p := range points {
+		frac := float64(i) / float64(len(points)-1)
+		if span > 0 {
+			frac = p.Time.Sub(t0).Seconds() / span
+		}
+		xs[i] = float64(c.left) + frac*float64(c.right-c.left-1)
+		ys[i] = float64(c.bott) - (p.Value-lo)/(hi-lo)*float64(c.bott-c.top)
+	}
+
+	// Shade the area under the line one column at a time.
+	seg := 0
+	for x := int(math.Ceil(xs[0])); x <= int(xs[len(xs)-1]); x++ {
+		for seg < len(xs)-2 && float64(x) > xs[seg+1] {
+			seg++
+		}
+		y := interpolate(xs[seg], ys[seg], xs[seg+1], ys[seg+1], float64(x))
+		c.rect(x, int(math.Round(y)), x+1, c.bott, fillColor)
+	}
+	for i := 0; i+1 < len(xs); i++ {
+		c.line(xs[i], ys[i], xs[i+1], ys[i+1], 2, lineColor)
+	}
+
+	last := formatValue(points[len(points)-1].Value)
+	drawText(c.img, c.right-textWidth(last), c.bott+8, last, lineColor)
+	return c.encode()
+}
+
+// Histogram renders liquidation volume per price level, long and short
+// stacked, with a vertical marker at the current price (skipped if zero or
+// outside the binned range).
+func Histogram(bins []Bin, price float64, opts Options) ([]byte, error) {
+	if len(bins) == 0 {
+		return nil, ErrNoData
+	}
+	bins = append([]Bin(nil), bins...)
+	sort.Slice(bins, func(i, j int) bool { return bins[i].Price < bins[j].Price })
+
+	// Bin width is the smallest gap between price levels, so sparse
+	// histograms keep their gaps instead of stretching bars.
+	step := 0.0
+	hi := 0.0
+	for i, b := range bins {
+		if i > 0 {
+			if gap := b.Price - bins[i-1].Price; gap > 0 && (step == 0 || gap < step) {
+				step = gap
+			}
+		}
+		hi = math.Max(hi, b.Long+b.Short)
+	}
+	if step == 0 {
+		step = 1
+	}
+	if hi <= 0 {
+		return nil, ErrNoData
+	}
+	minP := bins[0].Price - step/2
+	maxP := bins[len(bins)-1].Price + step/2
+
+	c := newCanvas(opts)
+	c.grid(0, hi)
+
+	plotW := float64(c.right - c.left - 1)
+	xOf := func(p float64) float64 { return float64(c.left+1) + (p-minP)/(maxP-minP)*plotW }
+	barW := math.Max(1, step/(maxP-minP)*plotW-1)
+	scale := float64(c.bott-c.top) / hi
+
+	for _, b := range bins {
+		x0 := int(xOf(b.Price) - barW/2)
+		x1 := x0 + int(math.Max(1, barW))
+		yLong := c.bott - int(math.Round(b.Long*scale))
+		yShort := yLong - int(math.Round(b.Short*scale))
+		c.rect(x0, yLong, x1, c.bott, longColor)
+		c.rect(x0, yShort, x1, yLong, shortColor)
+	}
+
+	if price > minP && price < maxP {
+		x := int(xOf(price))
+		for y := c.top; y < c.bott; y += 6 {
+			c.rect(x, y, x+2, y+3, priceColor)
+		}
+		label := formatValue(price)
+		drawText(c.img, x-textWidth(label)/2, c.bott+8, label, priceColor)
+	}
+	lo, hiLabel := formatValue(bins[0].Price), formatValue(bins[len(bins)-1].Price)
+	drawText(c.img, c.left, c.bott+8, lo, labelColor)
+	drawText(c.img, c.right-textWidth(hiLabel), c.bott+8, hiLabel, labelColor)
+	return c.encode()
+}
+
+// line draws a segment of the given width by stamping squares along it.
+func (c *canvas) line(x0, y0, x1, y1 float64, width int, col color.RGBA) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := int(math.Round(x0 + (x1-x0)*t))
+		y := int(math.Round(y0 + (y1-y0)*t))
+		c.rect(x-width/2, y-width/2, x-width/2+width, y-width/2+width, col)
+	}
+}
+
+func interpolate(x0, y0, x1, y1, x float64) float64 {
+	if x1 == x0 {
+		return y0
+	}
+	return y0 + (y1-y0)*(x-x0)/(x1-x0)
+}
+
+// padRange widens [lo, hi] by 5% on each side so the line does not touch
+// the plot edges, and gives flat series a non-zero range.
+func padRange(lo, hi float64) (float64, float64) {
+	if hi == lo {
+		d := math.Max(math.Abs(lo)*0.01, 1)
+		return lo - d, hi + d
+	}
+	pad := (hi - lo) * 0.05
+	return lo - pad, hi + pad
+}
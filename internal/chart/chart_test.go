@@ -0,0 +1,135 @@
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func decode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	return img
+}
+
+// hasColor reports whether any pixel of img equals want.
+func hasColor(img image.Image, want color.RGBA) bool {
+	wr, wg, wb, wa := want.RGBA()
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			if r == wr && g == wg && bl == wb && a == wa {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestLine(t *testing.T) {
+	start := time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)
+	points := []Point{
+		{start, 12_500_000},
+		{start.Add(time.Minute), 12_400_000},
+		{start.Add(2 * time.Minute), 11_000_000},
+		{start.Add(3 * time.Minute), 10_800_000},
+	}
+	data, err := Line(points, Options{})
+	if err != nil {
+		t.Fatalf("Line: %v", err)
+	}
+	img := decode(t, data)
+	if got := img.Bounds().Size(); got != image.Pt(DefaultWidth, DefaultHeight) {
+		t.Errorf("size = %v, want %dx%d", got, DefaultWidth, DefaultHeight)
+	}
+	if !hasColor(img, lineColor) {
+		t.Error("line color not drawn")
+	}
+	if !hasColor(img, fillColor) {
+		t.Error("area fill not drawn")
+	}
+}
+
+func TestLineFlatAndCustomSize(t *testing.T) {
+	now := time.Now()
+	data, err := Line([]Point{{now, 5}, {now, 5}}, Options{Width: 320, Height: 160})
+	if err != nil {
+		t.Fatalf("Line: %v", err)
+	}
+	if got := decode(t, data).Bounds().Size(); got != image.Pt(320, 160) {
+		t.Errorf("size = %v, want 320x160", got)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	bins := []Bin{
+		{Price: 97_200, Long: 1_500_000},
+		{Price: 96_900, Long: 400_000, Short: 50_000},
+		{Price: 97_600, Short: 2_100_000},
+	}
+	data, err := Histogram(bins, 97_300, Options{})
+	if err != nil {
+		t.Fatalf("Histogram: %v", err)
+	}
+	img := decode(t, data)
+	for name, c := range map[string]color.RGBA{
+		"long":  longColor,
+		"short": shortColor,
+		"price": priceColor,
+	} {
+		if !hasColor(img, c) {
+			t.Errorf("%s color not drawn", name)
+		}
+	}
+}
+
+func TestNoData(t *testing.T) {
+	if _, err := Line([]Point{{time.Now(), 1}}, Options{}); !errors.Is(err, ErrNoData) {
+		t.Errorf("Line(1 point) error = %v, want ErrNoData", err)
+	}
+	if _, err := Histogram(nil, 100, Options{}); !errors.Is(err, ErrNoData) {
+		t.Errorf("Histogram(nil) error = %v, want ErrNoData", err)
+	}
+	if _, err := Histogram([]Bin{{Price: 100}}, 100, Options{}); !errors.Is(err, ErrNoData) {
+		t.Errorf("Histogram(empty bins) error = %v, want ErrNoData", err)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{1_250_000_000, "1.25B"},
+		{12_500_000, "12.50M"},
+		{97_345, "97.3K"},
+		{450, "450"},
+		{1.5, "1.50"},
+		{0.01234, "0.0123"},
+		{0, "0"},
+		{-2_000_000, "-2.00M"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.in); got != tt.want {
+			t.Errorf("formatValue(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGlyphsCoverLabels(t *testing.T) {
+	for _, v := range []float64{-1.5e9, 3.2e6, 45_000, 123, 0.5} {
+		for _, r := range formatValue(v) {
+			if _, ok := glyphs[r]; !ok {
+				t.Errorf("no glyph for %q in label %q", r, formatValue(v))
+			}
+		}
+	}
+}
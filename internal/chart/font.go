@@ -0,0 +1,89 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Axis labels only need numbers, so instead of pulling in a font package
+// the chart carries a 3x5 pixel font for digits and unit suffixes, drawn at
+// twice its size.
+const (
+	glyphScale   = 2
+	glyphWidth   = 3 * glyphScale
+	glyphSpacing = glyphScale
+	textHeight   = 5 * glyphScale
+)
+
+// glyphs holds each character as five rows of three bits, top row first.
+var glyphs = map[rune][5]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b010, 0b010, 0b010},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000},
+	'K': {0b101, 0b110, 0b100, 0b110, 0b101},
+	'M': {0b101, 0b111, 0b111, 0b101, 0b101},
+	'B': {0b110, 0b101, 0b110, 0b101, 0b110},
+	' ': {},
+}
+
+func textWidth(s string) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return n*(glyphWidth+glyphSpacing) - glyphSpacing
+}
+
+// drawText draws s with its top-left corner at (x, y). Characters without a
+// glyph are left blank.
+func drawText(img *image.RGBA, x, y int, s string, col color.RGBA) {
+	for _, r := range s {
+		g := glyphs[r]
+		for row := 0; row < 5; row++ {
+			for bit := 0; bit < 3; bit++ {
+				if g[row]&(0b100>>bit) == 0 {
+					continue
+				}
+				for dy := 0; dy < glyphScale; dy++ {
+					for dx := 0; dx < glyphScale; dx++ {
+						img.SetRGBA(x+bit*glyphScale+dx, y+row*glyphScale+dy, col)
+					}
+				}
+			}
+		}
+		x += glyphWidth + glyphSpacing
+	}
+}
+
+// formatValue formats an axis label compactly: 1.25B, 12.50M, 97.3K, 450,
+// 1.50, 0.0123.
+func formatValue(v float64) string {
+	a := math.Abs(v)
+	switch {
+	case a >= 1e9:
+		return fmt.Sprintf("%.2fB", v/1e9)
+	case a >= 1e6:
+		return fmt.Sprintf("%.2fM", v/1e6)
+	case a >= 1e4:
+		return fmt.Sprintf("%.1fK", v/1e3)
+	case a >= 100:
+		return fmt.Sprintf("%.0f", v)
+	case a >= 1:
+		return fmt.Sprintf("%.2f", v)
+	case a == 0:
+		return "0"
+	default:
+		return fmt.Sprintf("%.4f", v)
+	}
+}
@@ -19,11 +19,21 @@ binanceWSBase = "wss://fstream.binance.com/ws"
 reconnectBase = 2 * time.Second
 reconnectMax  = 60 * time.Second
 flushInterval = 5 * time.Second
-cleanupAge    = 30 * 24 * time.Hour // keep 30 days (supports 1M interval)
 cleanupEvery  = 1 * time.Hour
 )
 
-var trackedSymbols = []string{"btcusdt", "ethusdt"}
+// Settings choose what the collector records; zero values mean the
+// defaults.
+type Settings struct {
+Symbols    []string // Binance futures pairs, e.g. "btcusdt"
+CleanupAge time.Duration
+}
+
+// DefaultSettings track BTC and ETH and keep 30 days (supports 1M interval).
+var DefaultSettings = Settings{
+Symbols:    []string{"btcusdt", "ethusdt"},
+CleanupAge: 30 * 24 * time.Hour,
+}
 
 type binanceForceOrder struct {
 Event     string `json:"e"`
@@ -41,33 +51,41 @@ TradeTime int64  `json:"T"`
 }
 
 type Collector struct {
-store  *store.Store
-logger *slog.Logger
+store    store.LiquidationStore
+logger   *slog.Logger
+settings Settings
 
 mu     sync.Mutex
 buffer []store.LiquidationEvent
 }
 
-func New(db *store.Store, logger *slog.Logger) *Collector {
+func New(db store.LiquidationStore, logger *slog.Logger, settings Settings) *Collector {
+if len(settings.Symbols) == 0 {
+settings.Symbols = DefaultSettings.Symbols
+}
+if settings.CleanupAge <= 0 {
+settings.CleanupAge = DefaultSettings.CleanupAge
+}
 return &Collector{
-store:  db,
-logger: logger,
-buffer: make([]store.LiquidationEvent, 0, 100),
+store:    db,
+logger:   logger,
+settings: settings,
+buffer:   make([]store.LiquidationEvent, 0, 100),
 }
 }
 
 // Run starts the collector. Blocks until ctx is cancelled.
 func (c *Collector) Run(ctx context.Context) {
-streams := make([]string, len(trackedSymbols))
-for i, s := range trackedSymbols {
-streams[i] = s + "@forceOrder"
+streams := make([]string, len(c.settings.Symbols))
+for i, s := range c.settings.Symbols {
+streams[i] = strings.ToLower(s) + "@forceOrder"
 }
 wsURL := binanceWSBase + "/" + strings.Join(streams, "/")
 
 go c.flushLoop(ctx)
 go c.cleanupLoop(ctx)
 
-c.logger.Info("liquidation collector starting", "symbols", trackedSymbols, "url", wsURL)
+c.logger.Info("liquidation collector starting", "symbols", c.settings.Symbols, "url", wsURL)
 
 backoff := reconnectBase
 for {
@@ -154,7 +172,7 @@ select {
 case <-ctx.Done():
 return
 case <-ticker.C:
-deleted, err := c.store.CleanupOldLiquidationEvents(ctx, cleanupAge)
+deleted, err := c.store.CleanupOldLiquidationEvents(ctx, c.settings.CleanupAge)
 if err != nil {
 c.logger.Error("cleanup old liquidation events failed", "error", err)
 } else if deleted > 0 {
@@ -4,9 +4,14 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	infisical "github.com/infisical/go-sdk"
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/linkguard"
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
 )
 
 type Config struct {
@@ -16,6 +21,29 @@ type Config struct {
 	FrontendOrigin string
 	RedisURL       string
 	RedisPassword  string
+	TemplatesDir   string
+	ChartAlerts    []string
+	SessionTTL     time.Duration
+	LoginMaxAge    time.Duration
+	LinkLimits     linkguard.Limits
+	RateLimits     map[string]middleware.Rate
+	TrustedProxies middleware.TrustedProxies
+	DedupBackend   string
+	DedupFailOpen  map[dedup.Severity]bool
+	LeaderElection string
+	LeaderLeaseTTL time.Duration
+	Admins         middleware.Admins
+	ConfigFile     string
+}
+
+// DefaultRateLimits are the per-client budgets of each rate-limited route
+// group; RATE_LIMITS overrides them by name.
+var DefaultRateLimits = map[string]middleware.Rate{
+	"stats":  {Limit: 120, Period: time.Minute},
+	"search": {Limit: 30, Period: time.Minute},
+	"charts": {Limit: 30, Period: time.Minute},
+	"stream": {Limit: 10, Period: time.Minute},
+	"user":   {Limit: 120, Period: time.Minute},
 }
 
 func Load() Config {
@@ -26,6 +54,27 @@ func Load() Config {
 		FrontendOrigin: envOr("FRONTEND_ORIGIN", "*"),
 		RedisURL:       envOr("REDIS_URL", "redis://redis-master.redis.svc.cluster.local:6379/0"),
 		RedisPassword:  os.Getenv("REDIS_PASSWORD"),
+		TemplatesDir:   os.Getenv("MESSAGE_TEMPLATES_DIR"),
+		ChartAlerts:    envList("CHART_ALERTS"),
+		SessionTTL:     envDuration("SESSION_TTL", 30*24*time.Hour),
+		LoginMaxAge:    envDuration("TELEGRAM_LOGIN_MAX_AGE", 24*time.Hour),
+		LinkLimits: linkguard.Limits{
+			PerIP:   envInt("LINK_MAX_FAILURES_PER_IP", linkguard.DefaultLimits.PerIP),
+			Global:  envInt("LINK_MAX_FAILURES_GLOBAL", linkguard.DefaultLimits.Global),
+			Window:  envDuration("LINK_FAILURE_WINDOW", linkguard.DefaultLimits.Window),
+			Lockout: envDuration("LINK_LOCKOUT", linkguard.DefaultLimits.Lockout),
+		},
+		RateLimits:     envRates("RATE_LIMITS", DefaultRateLimits),
+		TrustedProxies: envProxies("TRUSTED_PROXIES"),
+		DedupBackend:   envOr("DEDUP_BACKEND", "redis"),
+		DedupFailOpen:  envFailModes("DEDUP_FAIL_MODE", dedup.DefaultFailOpen),
+		LeaderElection: envOr("LEADER_ELECTION", "none"),
+		LeaderLeaseTTL: envDuration("LEADER_LEASE_TTL", 15*time.Second),
+		Admins: middleware.Admins{
+			ChatIDs: envChatIDs("ADMIN_CHAT_IDS"),
+			APIKeys: envList("ADMIN_API_KEYS"),
+		},
+		ConfigFile: os.Getenv("CONFIG_FILE"),
 	}
 
 	// If Infisical credentials are available, fetch secrets from Infisical
@@ -87,6 +136,124 @@ func loadFromInfisical(cfg *Config, clientID, clientSecret string) {
 	}
 }
 
+// envList splits a comma-separated env var, dropping empty items.
+func envList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// envChatIDs parses a comma-separated list of Telegram chat IDs, skipping
+// invalid ones.
+func envChatIDs(key string) []int64 {
+	var ids []int64
+	for _, v := range envList(key) {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id == 0 {
+			slog.Warn("invalid chat ID, ignoring", "key", key, "value", v)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// envDuration parses a Go duration env var ("720h"), falling back when it
+// is unset or invalid.
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		slog.Warn("invalid duration, using default", "key", key, "value", v, "default", fallback)
+		return fallback
+	}
+	return d
+}
+
+// envInt parses a positive integer env var, falling back when it is unset
+// or invalid.
+func envInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		slog.Warn("invalid integer, using default", "key", key, "value", v, "default", fallback)
+		return fallback
+	}
+	return n
+}
+
+// envProxies parses a comma-separated list of trusted proxy CIDRs or
+// addresses, skipping invalid ones.
+func envProxies(key string) middleware.TrustedProxies {
+	var proxies middleware.TrustedProxies
+	for _, v := range envList(key) {
+		p, err := middleware.ParseTrustedProxies([]string{v})
+		if err != nil {
+			slog.Warn("invalid trusted proxy, ignoring", "key", key, "value", v)
+			continue
+		}
+		proxies = append(proxies, p...)
+	}
+	return proxies
+}
+
+// envRates parses "name=limit/period" pairs ("search=10/1m,stats=0") over a
+// copy of defaults. Invalid pairs are skipped.
+func envRates(key string, defaults map[string]middleware.Rate) map[string]middleware.Rate {
+	rates := make(map[string]middleware.Rate, len(defaults))
+	for name, r := range defaults {
+		rates[name] = r
+	}
+	for _, pair := range envList(key) {
+		name, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			slog.Warn("invalid rate limit, ignoring", "key", key, "value", pair)
+			continue
+		}
+		r, err := middleware.ParseRate(strings.TrimSpace(v))
+		if err != nil {
+			slog.Warn("invalid rate limit, ignoring", "key", key, "value", pair, "error", err)
+			continue
+		}
+		rates[strings.TrimSpace(name)] = r
+	}
+	return rates
+}
+
+// envFailModes parses "severity=open|closed" pairs ("info=open") over a
+// copy of defaults. Invalid pairs and unknown severities are skipped.
+func envFailModes(key string, defaults map[dedup.Severity]bool) map[dedup.Severity]bool {
+	modes := make(map[dedup.Severity]bool, len(defaults))
+	for sev, open := range defaults {
+		modes[sev] = open
+	}
+	for _, pair := range envList(key) {
+		name, v, ok := strings.Cut(pair, "=")
+		sev := dedup.Severity(strings.TrimSpace(name))
+		if _, known := defaults[sev]; !ok || !known {
+			slog.Warn("invalid dedup fail mode, ignoring", "key", key, "value", pair)
+			continue
+		}
+		open, err := dedup.ParseFailMode(strings.TrimSpace(v))
+		if err != nil {
+			slog.Warn("invalid dedup fail mode, ignoring", "key", key, "value", pair, "error", err)
+			continue
+		}
+		modes[sev] = open
+	}
+	return modes
+}
+
 func envOr(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
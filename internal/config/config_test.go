@@ -2,7 +2,12 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
 )
 
 func TestEnvOr(t *testing.T) {
@@ -26,6 +31,122 @@ func TestEnvOr(t *testing.T) {
 	}
 }
 
+func TestEnvList(t *testing.T) {
+	os.Setenv("TEST_ENVLIST_KEY", " metric_alert, ,daily_report,")
+	defer os.Unsetenv("TEST_ENVLIST_KEY")
+	got := envList("TEST_ENVLIST_KEY")
+	if len(got) != 2 || got[0] != "metric_alert" || got[1] != "daily_report" {
+		t.Errorf("envList = %q, want [metric_alert daily_report]", got)
+	}
+
+	os.Unsetenv("TEST_ENVLIST_KEY")
+	if got := envList("TEST_ENVLIST_KEY"); got != nil {
+		t.Errorf("envList unset key = %q, want nil", got)
+	}
+}
+
+func TestEnvChatIDs(t *testing.T) {
+	os.Setenv("TEST_ENVCHATIDS_KEY", "123, -100200,abc,0")
+	defer os.Unsetenv("TEST_ENVCHATIDS_KEY")
+	got := envChatIDs("TEST_ENVCHATIDS_KEY")
+	if len(got) != 2 || got[0] != 123 || got[1] != -100200 {
+		t.Errorf("envChatIDs = %v, want [123 -100200]", got)
+	}
+}
+
+func TestEnvProxies(t *testing.T) {
+	os.Setenv("TEST_ENVPROXIES_KEY", "10.0.0.0/8, proxy.internal,192.0.2.10")
+	defer os.Unsetenv("TEST_ENVPROXIES_KEY")
+	got := envProxies("TEST_ENVPROXIES_KEY")
+	if len(got) != 2 || got[0].String() != "10.0.0.0/8" || got[1].String() != "192.0.2.10/32" {
+		t.Errorf("envProxies = %v, want [10.0.0.0/8 192.0.2.10/32]", got)
+	}
+}
+
+func TestEnvDuration(t *testing.T) {
+	defer os.Unsetenv("TEST_ENVDURATION_KEY")
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", time.Hour},
+		{"90m", 90 * time.Minute},
+		{"soon", time.Hour},
+		{"-5m", time.Hour},
+	}
+	for _, tt := range tests {
+		os.Setenv("TEST_ENVDURATION_KEY", tt.value)
+		if got := envDuration("TEST_ENVDURATION_KEY", time.Hour); got != tt.want {
+			t.Errorf("envDuration(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEnvInt(t *testing.T) {
+	defer os.Unsetenv("TEST_ENVINT_KEY")
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 5},
+		{"12", 12},
+		{"many", 5},
+		{"0", 5},
+	}
+	for _, tt := range tests {
+		os.Setenv("TEST_ENVINT_KEY", tt.value)
+		if got := envInt("TEST_ENVINT_KEY", 5); got != tt.want {
+			t.Errorf("envInt(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEnvRates(t *testing.T) {
+	defer os.Unsetenv("TEST_ENVRATES_KEY")
+	os.Setenv("TEST_ENVRATES_KEY", "search=10/1m, stats=0, charts=fast, bogus")
+	defaults := map[string]middleware.Rate{
+		"search": {Limit: 30, Period: time.Minute},
+		"stats":  {Limit: 120, Period: time.Minute},
+		"charts": {Limit: 30, Period: time.Minute},
+	}
+
+	got := envRates("TEST_ENVRATES_KEY", defaults)
+	want := map[string]middleware.Rate{
+		"search": {Limit: 10, Period: time.Minute},
+		"stats":  {},
+		"charts": {Limit: 30, Period: time.Minute},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("envRates = %v, want %v", got, want)
+	}
+	if defaults["search"].Limit != 30 {
+		t.Error("envRates modified the defaults")
+	}
+}
+
+func TestEnvFailModes(t *testing.T) {
+	defer os.Unsetenv("TEST_ENVFAIL_KEY")
+	os.Setenv("TEST_ENVFAIL_KEY", "info=open, warning=maybe, fatal=open, critical")
+	defaults := map[dedup.Severity]bool{
+		dedup.SeverityCritical: true,
+		dedup.SeverityWarning:  false,
+		dedup.SeverityInfo:     false,
+	}
+
+	got := envFailModes("TEST_ENVFAIL_KEY", defaults)
+	want := map[dedup.Severity]bool{
+		dedup.SeverityCritical: true,
+		dedup.SeverityWarning:  false,
+		dedup.SeverityInfo:     true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("envFailModes = %v, want %v", got, want)
+	}
+	if defaults[dedup.SeverityInfo] {
+		t.Error("envFailModes modified the defaults")
+	}
+}
+
 func TestLoadDefaults(t *testing.T) {
 	// Clear all relevant env vars
 	for _, k := range []string{"PORT", "DATABASE_URL", "TELEGRAM_BOT_TOKEN", "FRONTEND_ORIGIN", "REDIS_URL", "REDIS_PASSWORD", "INFISICAL_CLIENT_ID", "INFISICAL_CLIENT_SECRET"} {
@@ -46,6 +167,9 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.TelegramToken != "" {
 		t.Errorf("TelegramToken = %q, want empty", cfg.TelegramToken)
 	}
+	if cfg.SessionTTL != 30*24*time.Hour {
+		t.Errorf("SessionTTL = %v, want %v", cfg.SessionTTL, 30*24*time.Hour)
+	}
 }
 
 func TestLoadFromEnv(t *testing.T) {
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are the optional config file named by CONFIG_FILE: the polling
+// loop, the liquidation collector and per-source overrides. Zero values
+// mean the built-in default. See config.example.yaml.
+type Settings struct {
+	Engine    EngineSettings            `yaml:"engine"`
+	Collector CollectorSettings         `yaml:"collector"`
+	Sources   map[string]SourceSettings `yaml:"sources"`
+}
+
+type EngineSettings struct {
+	PollInterval Duration `yaml:"poll_interval"`
+	FetchTimeout Duration `yaml:"fetch_timeout"`
+	HistoryLen   int      `yaml:"history_len"` // snapshots kept per source
+}
+
+type CollectorSettings struct {
+	Symbols    []string `yaml:"symbols"` // Binance futures pairs, e.g. btcusdt
+	CleanupAge Duration `yaml:"cleanup_age"`
+}
+
+type SourceSettings struct {
+	Enabled   *bool             `yaml:"enabled"`  // nil means enabled
+	Interval  Duration          `yaml:"interval"` // poll at most this often
+	Timeout   Duration          `yaml:"timeout"`
+	BaseURL   string            `yaml:"base_url"`  // root of the upstream API
+	Upstreams map[string]string `yaml:"upstreams"` // roots of secondary upstreams by name
+	Filters   SourceFilters     `yaml:"filters"`
+}
+
+// SourceFilters tune what a source reports. Each applies to one source
+// only; sources.Validate rejects the rest.
+type SourceFilters struct {
+	MinAPR  *float64           `yaml:"min_apr"`  // merkl
+	MinTVL  *float64           `yaml:"min_tvl"`  // merkl
+	Actions []string           `yaml:"actions"`  // merkl
+	BinSize map[string]float64 `yaml:"bin_size"` // maxpain, by symbol
+}
+
+// Disabled reports whether the source is switched off.
+func (s SourceSettings) Disabled() bool {
+	return s.Enabled != nil && !*s.Enabled
+}
+
+// IsZero reports whether no filter is set.
+func (f SourceFilters) IsZero() bool {
+	return f.MinAPR == nil && f.MinTVL == nil && len(f.Actions) == 0 && len(f.BinSize) == 0
+}
+
+// Duration is a time.Duration written as a Go duration string ("90s").
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// LoadSettings reads and validates the config file. Unknown keys are
+// errors, so a typo does not silently keep a default.
+func LoadSettings(path string) (Settings, error) {
+	var s Settings
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return s, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Validate checks the settings that do not depend on the sources.
+func (s Settings) Validate() error {
+	var errs []error
+	if s.Engine.PollInterval != 0 && time.Duration(s.Engine.PollInterval) < time.Second {
+		errs = append(errs, errors.New("engine.poll_interval must be at least 1s"))
+	}
+	if s.Engine.FetchTimeout < 0 {
+		errs = append(errs, errors.New("engine.fetch_timeout must not be negative"))
+	}
+	if s.Engine.HistoryLen < 0 {
+		errs = append(errs, errors.New("engine.history_len must not be negative"))
+	}
+	for _, sym := range s.Collector.Symbols {
+		if sym == "" || strings.ContainsAny(sym, "/@ ") {
+			errs = append(errs, fmt.Errorf("collector.symbols: invalid symbol %q", sym))
+		}
+	}
+	if s.Collector.CleanupAge < 0 {
+		errs = append(errs, errors.New("collector.cleanup_age must not be negative"))
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.Sources)) {
+		src := s.Sources[name]
+		if src.Interval < 0 || src.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("sources.%s: interval and timeout must not be negative", name))
+		}
+		if src.BaseURL != "" && !validURL(src.BaseURL) {
+			errs = append(errs, fmt.Errorf("sources.%s.base_url: want an http(s) URL, got %q", name, src.BaseURL))
+		}
+		for _, upstream := range slices.Sorted(maps.Keys(src.Upstreams)) {
+			if u := src.Upstreams[upstream]; !validURL(u) {
+				errs = append(errs, fmt.Errorf("sources.%s.upstreams.%s: want an http(s) URL, got %q", name, upstream, u))
+			}
+		}
+		f := src.Filters
+		if (f.MinAPR != nil && *f.MinAPR < 0) || (f.MinTVL != nil && *f.MinTVL < 0) {
+			errs = append(errs, fmt.Errorf("sources.%s.filters: min_apr and min_tvl must not be negative", name))
+		}
+		for sym, size := range f.BinSize {
+			if size <= 0 {
+				errs = append(errs, fmt.Errorf("sources.%s.filters.bin_size.%s must be positive", name, sym))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validURL reports whether u is an absolute http(s) URL.
+func validURL(u string) bool {
+	p, err := url.Parse(u)
+	return err == nil && (p.Scheme == "http" || p.Scheme == "https") && p.Host != ""
+}
+
+// RestartRequired lists the changes from s to next that a reload cannot
+// apply: the collector's streams, and the upstream URLs and HTTP client
+// timeouts sources are built with. A new source timeout still moves the
+// engine's fetch deadline at once.
+func (s Settings) RestartRequired(next Settings) []string {
+	var keys []string
+	if !slices.Equal(s.Collector.Symbols, next.Collector.Symbols) {
+		keys = append(keys, "collector.symbols")
+	}
+	if s.Collector.CleanupAge != next.Collector.CleanupAge {
+		keys = append(keys, "collector.cleanup_age")
+	}
+	names := slices.Sorted(maps.Keys(s.Sources))
+	for name := range next.Sources {
+		if _, ok := s.Sources[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if s.Sources[name].BaseURL != next.Sources[name].BaseURL {
+			keys = append(keys, "sources."+name+".base_url")
+		}
+		if !maps.Equal(s.Sources[name].Upstreams, next.Sources[name].Upstreams) {
+			keys = append(keys, "sources."+name+".upstreams")
+		}
+		if s.Sources[name].Timeout != next.Sources[name].Timeout {
+			keys = append(keys, "sources."+name+".timeout")
+		}
+	}
+	return keys
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSettings(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSettings(t *testing.T) {
+	path := writeSettings(t, `
+engine:
+  poll_interval: 2m
+  history_len: 120
+collector:
+  symbols: [btcusdt, solusdt]
+sources:
+  merkl:
+    interval: 5m
+    timeout: 10s
+    base_url: https://merkl.example.com/v4/opportunities
+    filters:
+      min_apr: 8
+      actions: [LEND]
+  neverland:
+    enabled: false
+`)
+	s, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(s.Engine.PollInterval) != 2*time.Minute || s.Engine.HistoryLen != 120 || s.Engine.FetchTimeout != 0 {
+		t.Errorf("engine = %+v", s.Engine)
+	}
+	if !slices.Equal(s.Collector.Symbols, []string{"btcusdt", "solusdt"}) {
+		t.Errorf("collector.symbols = %v", s.Collector.Symbols)
+	}
+	merkl := s.Sources["merkl"]
+	if time.Duration(merkl.Interval) != 5*time.Minute || time.Duration(merkl.Timeout) != 10*time.Second || merkl.Disabled() {
+		t.Errorf("merkl = %+v", merkl)
+	}
+	if merkl.Filters.MinAPR == nil || *merkl.Filters.MinAPR != 8 || merkl.Filters.MinTVL != nil {
+		t.Errorf("merkl filters = %+v", merkl.Filters)
+	}
+	if !s.Sources["neverland"].Disabled() {
+		t.Error("neverland should be disabled")
+	}
+
+	// An empty file is all defaults
+	if s, err := LoadSettings(writeSettings(t, "")); err != nil || len(s.Sources) != 0 {
+		t.Errorf("empty file = %+v, %v", s, err)
+	}
+}
+
+func TestLoadSettingsRejectsInvalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"unknown key":       "engine:\n  poll_intervall: 1m\n",
+		"bad duration":      "engine:\n  poll_interval: soon\n",
+		"short poll":        "engine:\n  poll_interval: 100ms\n",
+		"negative history":  "engine:\n  history_len: -1\n",
+		"bad symbol":        "collector:\n  symbols: [\"btcusdt@trade\"]\n",
+		"bad base url":      "sources:\n  binance:\n    base_url: ftp://example.com\n",
+		"negative timeout":  "sources:\n  binance:\n    timeout: -5s\n",
+		"zero bin size":     "sources:\n  maxpain:\n    filters:\n      bin_size: {BTC: 0}\n",
+		"negative min apr":  "sources:\n  merkl:\n    filters:\n      min_apr: -1\n",
+		"enabled not bool":  "sources:\n  merkl:\n    enabled: maybe\n",
+		"sources not a map": "sources: [merkl]\n",
+	} {
+		if _, err := LoadSettings(writeSettings(t, body)); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+
+	if _, err := LoadSettings(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing file: want an error")
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	s := Settings{
+		Collector: CollectorSettings{Symbols: []string{"btcusdt"}},
+		Sources:   map[string]SourceSettings{"binance": {BaseURL: "https://a.example.com"}},
+	}
+	next := Settings{
+		Engine:    EngineSettings{PollInterval: Duration(2 * time.Minute)},
+		Collector: CollectorSettings{Symbols: []string{"btcusdt"}},
+		Sources: map[string]SourceSettings{
+			"binance": {BaseURL: "https://a.example.com", Interval: Duration(time.Hour)},
+			"merkl":   {BaseURL: "https://b.example.com"},
+		},
+	}
+	if keys := s.RestartRequired(next); !slices.Equal(keys, []string{"sources.merkl.base_url"}) {
+		t.Errorf("RestartRequired = %v, want [sources.merkl.base_url]", keys)
+	}
+
+	next.Collector.Symbols = []string{"ethusdt"}
+	next.Sources["merkl"] = SourceSettings{}
+	if keys := s.RestartRequired(next); strings.Join(keys, ",") != "collector.symbols" {
+		t.Errorf("RestartRequired = %v, want [collector.symbols]", keys)
+	}
+
+	next.Collector.Symbols = s.Collector.Symbols
+	next.Sources["binance"] = SourceSettings{BaseURL: "https://a.example.com", Timeout: Duration(time.Minute)}
+	if keys := s.RestartRequired(next); strings.Join(keys, ",") != "sources.binance.timeout" {
+		t.Errorf("RestartRequired = %v, want [sources.binance.timeout]", keys)
+	}
+}
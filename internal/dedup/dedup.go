@@ -2,64 +2,128 @@ package dedup
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
 )
 
-// Deduplicator checks and records whether an alert has been sent recently.
-type Deduplicator struct {
-	rdb *redis.Client
+// Backend stores dedup keys. A key set with a zero TTL never expires.
+// Patterns are Redis-style globs where only * is special.
+type Backend interface {
+	Exists(ctx context.Context, key string) (bool, error)
+	Set(ctx context.Context, key string, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	DeletePattern(ctx context.Context, pattern string) error
+	// Keys lists the unexpired keys matching pattern, in no particular order.
+	Keys(ctx context.Context, pattern string) ([]string, error)
 }
 
-// New creates a Deduplicator backed by Redis.
-func New(redisURL, password string) (*Deduplicator, error) {
-	opts, err := redis.ParseURL(redisURL)
-	if err != nil {
-		return nil, err
-	}
-	if password != "" {
-		opts.Password = password
-	}
-	rdb := redis.NewClient(opts)
+// Severity decides what AlreadySent answers when the backend fails.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		return nil, err
+// Severities lists every severity, most urgent first.
+var Severities = []Severity{SeverityCritical, SeverityWarning, SeverityInfo}
+
+// DefaultFailOpen sends critical alerts while the backend is down (a
+// duplicate beats a missed alert) and suppresses the rest.
+var DefaultFailOpen = map[Severity]bool{
+	SeverityCritical: true,
+	SeverityWarning:  false,
+	SeverityInfo:     false,
+}
+
+// ParseFailMode parses "open" or "closed" into whether to fail open.
+func ParseFailMode(s string) (bool, error) {
+	switch s {
+	case "open":
+		return true, nil
+	case "closed":
+		return false, nil
 	}
-	return &Deduplicator{rdb: rdb}, nil
+	return false, fmt.Errorf("fail mode %q: want open or closed", s)
 }
 
-// Close shuts down the Redis connection.
-func (d *Deduplicator) Close() error {
-	return d.rdb.Close()
+// Deduplicator checks and records whether an alert has been sent recently.
+type Deduplicator struct {
+	backend  Backend
+	failOpen map[Severity]bool
 }
 
-// AlreadySent returns true if key was recorded, or if Redis is unreachable
-// (fail-closed: assume sent to avoid duplicate alerts during Redis downtime).
-func (d *Deduplicator) AlreadySent(ctx context.Context, key string) bool {
-	exists, err := d.rdb.Exists(ctx, key).Result()
+// New creates a Deduplicator on backend. failOpen says, per severity,
+// whether an alert is sent (true) or suppressed when the backend errors;
+// severities missing from it fail closed.
+func New(backend Backend, failOpen map[Severity]bool) *Deduplicator {
+	return &Deduplicator{backend: backend, failOpen: failOpen}
+}
+
+// AlreadySent returns true if key was recorded. When the backend fails the
+// answer follows the severity's fail mode, and suppressions are counted.
+func (d *Deduplicator) AlreadySent(ctx context.Context, key string, sev Severity) bool {
+	exists, err := d.backend.Exists(ctx, key)
 	if err != nil {
-		return true // fail closed — suppress alerts when Redis is down
+		metrics.DedupErrorsTotal.WithLabelValues("exists").Inc()
+		if d.failOpen[sev] {
+			return false
+		}
+		metrics.DedupErrorSuppressedTotal.WithLabelValues(string(sev)).Inc()
+		return true
 	}
-	return exists > 0
+	return exists
 }
 
 // Record marks key as sent permanently (no expiry).
 func (d *Deduplicator) Record(ctx context.Context, key string) {
-	d.rdb.Set(ctx, key, "1", 0) // 0 = no expiry
+	if err := d.backend.Set(ctx, key, 0); err != nil {
+		metrics.DedupErrorsTotal.WithLabelValues("set").Inc()
+	}
 }
 
 // Clear removes a dedup key so the alert can fire again when the condition resets.
 func (d *Deduplicator) Clear(ctx context.Context, key string) {
-	d.rdb.Del(ctx, key) //nolint:errcheck
+	if err := d.backend.Delete(ctx, key); err != nil {
+		metrics.DedupErrorsTotal.WithLabelValues("delete").Inc()
+	}
 }
 
 // ClearByPattern removes all dedup keys matching a glob pattern (e.g., "*:12345:*").
 func (d *Deduplicator) ClearByPattern(ctx context.Context, pattern string) {
-	iter := d.rdb.Scan(ctx, 0, pattern, 100).Iterator()
-	for iter.Next(ctx) {
-		d.rdb.Del(ctx, iter.Val()) //nolint:errcheck
+	if err := d.backend.DeletePattern(ctx, pattern); err != nil {
+		metrics.DedupErrorsTotal.WithLabelValues("delete_pattern").Inc()
+	}
+}
+
+// Keys lists the keys matching a glob pattern.
+func (d *Deduplicator) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return d.backend.Keys(ctx, pattern)
+}
+
+// SubscriptionKey namespaces an alert's key under the subscription that
+// produced it, so two subscriptions never share a key and
+// ClearSubscription can drop exactly one subscription's keys.
+func SubscriptionKey(subID int64, key string) string {
+	return fmt.Sprintf("sub:%d:%s", subID, key)
+}
+
+// ClearSubscription removes every key recorded for subscription subID, so
+// its alerts are evaluated afresh after it is changed or deleted.
+func (d *Deduplicator) ClearSubscription(ctx context.Context, subID int64) {
+	d.ClearByPattern(ctx, fmt.Sprintf("sub:%d:*", subID))
+}
+
+// globRegexp compiles a * glob into an anchored regexp.
+func globRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
 	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
 }
@@ -2,99 +2,203 @@ package dedup
 
 import (
 	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
-)
-
-func setupTestDedup(t *testing.T) (*Deduplicator, *miniredis.Miniredis) {
-	t.Helper()
-	mr, err := miniredis.Run()
-	if err != nil {
-		t.Fatalf("miniredis: %v", err)
-	}
-	d, err := New("redis://"+mr.Addr(), "")
-	if err != nil {
-		mr.Close()
-		t.Fatalf("New: %v", err)
-	}
-	return d, mr
-}
 
-func TestAlreadySentNewKey(t *testing.T) {
-	d, mr := setupTestDedup(t)
-	defer mr.Close()
-	defer d.Close()
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
 
+// testBackend checks the behaviour every Backend must share.
+func testBackend(t *testing.T, b Backend) {
 	ctx := context.Background()
-	if d.AlreadySent(ctx, "test:key:1") {
-		t.Error("AlreadySent should return false for new key")
-	}
+	d := New(b, nil)
+
+	t.Run("new key", func(t *testing.T) {
+		if d.AlreadySent(ctx, "test:key:1", SeverityInfo) {
+			t.Error("AlreadySent should return false for new key")
+		}
+	})
+
+	t.Run("record", func(t *testing.T) {
+		d.Record(ctx, "test:key:2")
+		if !d.AlreadySent(ctx, "test:key:2", SeverityInfo) {
+			t.Error("AlreadySent should return true after Record")
+		}
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		d.Record(ctx, "test:key:3")
+		d.Clear(ctx, "test:key:3")
+		if d.AlreadySent(ctx, "test:key:3", SeverityInfo) {
+			t.Error("AlreadySent should return false after Clear")
+		}
+	})
+
+	t.Run("clear by pattern", func(t *testing.T) {
+		d.Record(ctx, "alert:123:metric1")
+		d.Record(ctx, "alert:123:metric2")
+		d.Record(ctx, "alert:456:metric1")
+		d.Record(ctx, "alert_123:metric1") // _ is literal, not a wildcard
+
+		d.ClearByPattern(ctx, "alert:123:*")
+
+		if d.AlreadySent(ctx, "alert:123:metric1", SeverityInfo) {
+			t.Error("key alert:123:metric1 should be cleared")
+		}
+		if d.AlreadySent(ctx, "alert:123:metric2", SeverityInfo) {
+			t.Error("key alert:123:metric2 should be cleared")
+		}
+		if !d.AlreadySent(ctx, "alert:456:metric1", SeverityInfo) {
+			t.Error("key alert:456:metric1 should NOT be cleared")
+		}
+		if !d.AlreadySent(ctx, "alert_123:metric1", SeverityInfo) {
+			t.Error("key alert_123:metric1 should NOT be cleared")
+		}
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		d.Record(ctx, "sub:7:b")
+		d.Record(ctx, "sub:7:a")
+		d.Record(ctx, "sub:70:a")
+		keys, err := b.Keys(ctx, "sub:7:*")
+		sort.Strings(keys)
+		if err != nil || strings.Join(keys, ",") != "sub:7:a,sub:7:b" {
+			t.Errorf("Keys(sub:7:*) = %v, %v; want [sub:7:a sub:7:b]", keys, err)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		if err := b.Set(ctx, "test:ttl", 50*time.Millisecond); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		if ok, err := b.Exists(ctx, "test:ttl"); err != nil || !ok {
+			t.Fatalf("Exists before expiry = %v, %v", ok, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if ok, err := b.Exists(ctx, "test:ttl"); err != nil || ok {
+			t.Errorf("Exists after expiry = %v, %v", ok, err)
+		}
+	})
 }
 
-func TestRecordAndAlreadySent(t *testing.T) {
-	d, mr := setupTestDedup(t)
-	defer mr.Close()
-	defer d.Close()
-
-	ctx := context.Background()
-	d.Record(ctx, "test:key:2")
-
-	if !d.AlreadySent(ctx, "test:key:2") {
-		t.Error("AlreadySent should return true after Record")
-	}
+func TestMemoryBackend(t *testing.T) {
+	testBackend(t, NewMemory())
 }
 
-func TestClear(t *testing.T) {
-	d, mr := setupTestDedup(t)
-	defer mr.Close()
-	defer d.Close()
+func TestRedisBackend(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	// miniredis only expires keys when told time has passed.
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+				mr.FastForward(10 * time.Millisecond)
+			}
+		}
+	}()
+
+	testBackend(t, NewRedis(rdb))
+}
 
+// TestPostgresBackend runs against TEST_DATABASE_URL (a scratch database;
+// it is migrated and dedup_keys is truncated).
+func TestPostgresBackend(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
 	ctx := context.Background()
-	d.Record(ctx, "test:key:3")
-
-	if !d.AlreadySent(ctx, "test:key:3") {
-		t.Fatal("should be sent after Record")
+	pg, err := store.NewPostgres(ctx, url)
+	if err != nil {
+		t.Fatalf("store.NewPostgres: %v", err)
 	}
-
-	d.Clear(ctx, "test:key:3")
-	if d.AlreadySent(ctx, "test:key:3") {
-		t.Error("AlreadySent should return false after Clear")
+	t.Cleanup(pg.Close)
+	if err := pg.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	p := NewPostgres(pg.Pool())
+	if _, err := pg.Pool().Exec(ctx, `TRUNCATE dedup_keys`); err != nil {
+		t.Fatalf("truncate: %v", err)
 	}
+	testBackend(t, p)
 }
 
-func TestClearByPattern(t *testing.T) {
-	d, mr := setupTestDedup(t)
-	defer mr.Close()
-	defer d.Close()
+// failingBackend errors on every call.
+type failingBackend struct{}
 
+var errBackend = errors.New("backend down")
+
+func (failingBackend) Exists(context.Context, string) (bool, error)     { return false, errBackend }
+func (failingBackend) Set(context.Context, string, time.Duration) error { return errBackend }
+func (failingBackend) Delete(context.Context, string) error             { return errBackend }
+func (failingBackend) DeletePattern(context.Context, string) error      { return errBackend }
+func (failingBackend) Keys(context.Context, string) ([]string, error)   { return nil, errBackend }
+
+func TestAlreadySentFailMode(t *testing.T) {
 	ctx := context.Background()
-	d.Record(ctx, "alert:123:metric1")
-	d.Record(ctx, "alert:123:metric2")
-	d.Record(ctx, "alert:456:metric1")
+	d := New(failingBackend{}, DefaultFailOpen)
 
-	d.ClearByPattern(ctx, "alert:123:*")
+	suppressed := func(sev Severity) float64 {
+		return testutil.ToFloat64(metrics.DedupErrorSuppressedTotal.WithLabelValues(string(sev)))
+	}
+	before := suppressed(SeverityWarning)
 
-	if d.AlreadySent(ctx, "alert:123:metric1") {
-		t.Error("key alert:123:metric1 should be cleared")
+	if d.AlreadySent(ctx, "any:key", SeverityCritical) {
+		t.Error("critical alerts should fail open by default")
 	}
-	if d.AlreadySent(ctx, "alert:123:metric2") {
-		t.Error("key alert:123:metric2 should be cleared")
+	if !d.AlreadySent(ctx, "any:key", SeverityWarning) {
+		t.Error("warning alerts should fail closed by default")
 	}
-	if !d.AlreadySent(ctx, "alert:456:metric1") {
-		t.Error("key alert:456:metric1 should NOT be cleared")
+	if !d.AlreadySent(ctx, "any:key", "unknown") {
+		t.Error("severities without a mode should fail closed")
 	}
+	if got := suppressed(SeverityWarning) - before; got != 1 {
+		t.Errorf("error_suppressed_total{warning} grew by %v, want 1", got)
+	}
+
+	// Failed writes are counted, not fatal.
+	d.Record(ctx, "any:key")
+	d.Clear(ctx, "any:key")
+	d.ClearByPattern(ctx, "any:*")
 }
 
-func TestAlreadySentFailClosed(t *testing.T) {
-	d, mr := setupTestDedup(t)
-	defer d.Close()
+func TestAlreadySentFailClosedOnRedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	d := New(NewRedis(rdb), map[Severity]bool{SeverityInfo: false})
 
 	// Stop Redis to simulate failure
 	mr.Close()
 
-	ctx := context.Background()
-	if !d.AlreadySent(ctx, "any:key") {
+	if !d.AlreadySent(context.Background(), "any:key", SeverityInfo) {
 		t.Error("AlreadySent should return true (fail-closed) when Redis is down")
 	}
 }
+
+func TestParseFailMode(t *testing.T) {
+	for in, want := range map[string]bool{"open": true, "closed": false} {
+		if got, err := ParseFailMode(in); err != nil || got != want {
+			t.Errorf("ParseFailMode(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFailMode("maybe"); err == nil {
+		t.Error("ParseFailMode(maybe) should fail")
+	}
+}
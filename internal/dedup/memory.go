@@ -0,0 +1,73 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory keeps dedup keys in process. Keys are lost on restart and not
+// shared between replicas, so it suits dev mode and single instances.
+type Memory struct {
+	mu   sync.Mutex
+	keys map[string]time.Time // zero time = no expiry
+}
+
+func NewMemory() *Memory {
+	return &Memory{keys: make(map[string]time.Time)}
+}
+
+func (m *Memory) Exists(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exp, ok := m.keys[key]
+	if ok && !exp.IsZero() && !time.Now().Before(exp) {
+		delete(m.keys, key)
+		return false, nil
+	}
+	return ok, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, ttl time.Duration) error {
+	var exp time.Time
+	if ttl > 0 {
+		exp = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	m.keys[key] = exp
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.keys, key)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Keys(_ context.Context, pattern string) ([]string, error) {
+	re := globRegexp(pattern)
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key, exp := range m.keys {
+		if re.MatchString(key) && (exp.IsZero() || now.Before(exp)) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *Memory) DeletePattern(_ context.Context, pattern string) error {
+	re := globRegexp(pattern)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.keys {
+		if re.MatchString(key) {
+			delete(m.keys, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package dedup
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres keeps dedup keys in the dedup_keys table, for deployments that
+// run Postgres but no Redis.
+type Postgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgres keeps keys in pool's database, which the 0005_dedup_keys
+// migration has prepared.
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool}
+}
+
+func (p *Postgres) Exists(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	err := p.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM dedup_keys
+			WHERE key = $1 AND (expires_at IS NULL OR expires_at > NOW()))`, key).Scan(&exists)
+	return exists, err
+}
+
+func (p *Postgres) Set(ctx context.Context, key string, ttl time.Duration) error {
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+	_, err := p.pool.Exec(ctx, `
+		INSERT INTO dedup_keys (key, expires_at) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at`, key, expiresAt)
+	return err
+}
+
+func (p *Postgres) Delete(ctx context.Context, key string) error {
+	_, err := p.pool.Exec(ctx, `DELETE FROM dedup_keys WHERE key = $1`, key)
+	return err
+}
+
+// likeEscaper escapes LIKE's own wildcards before * becomes %.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (p *Postgres) DeletePattern(ctx context.Context, pattern string) error {
+	like := strings.ReplaceAll(likeEscaper.Replace(pattern), "*", "%")
+	_, err := p.pool.Exec(ctx, `DELETE FROM dedup_keys WHERE key LIKE $1`, like)
+	return err
+}
+
+func (p *Postgres) Keys(ctx context.Context, pattern string) ([]string, error) {
+	like := strings.ReplaceAll(likeEscaper.Replace(pattern), "*", "%")
+	rows, err := p.pool.Query(ctx, `
+		SELECT key FROM dedup_keys
+		WHERE key LIKE $1 AND (expires_at IS NULL OR expires_at > NOW())`, like)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,50 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keeps dedup keys in Redis, shared by every replica.
+type Redis struct {
+	rdb *redis.Client
+}
+
+// NewRedis uses rdb for dedup keys. The caller owns and closes the client.
+func NewRedis(rdb *redis.Client) *Redis {
+	return &Redis{rdb: rdb}
+}
+
+func (r *Redis) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.rdb.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, ttl time.Duration) error {
+	return r.rdb.Set(ctx, key, "1", ttl).Err() // 0 = no expiry
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.rdb.Del(ctx, key).Err()
+}
+
+func (r *Redis) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := r.rdb.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+func (r *Redis) DeletePattern(ctx context.Context, pattern string) error {
+	iter := r.rdb.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		if err := r.rdb.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// Handlers for /api/admin, mounted behind middleware.Admin.
+
+// audit records a change made through the admin API in the audit log and
+// the server log. The change is already made, so a failed write is only
+// logged.
+func audit(r *http.Request, a store.AuditStore, action, target, detail string) {
+	actor, _ := middleware.AdminActor(r.Context())
+	slog.Info("admin action", "actor", actor, "action", action, "target", target, "detail", detail)
+	if err := a.LogAdminAction(r.Context(), actor, action, target, detail); err != nil {
+		slog.Error("write audit log failed", "action", action, "error", err)
+	}
+}
+
+// AdminListUsers lists chats, optionally only those whose username
+// contains ?q= or whose chat ID is ?q=.
+func AdminListUsers(s store.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		users, err := s.SearchTelegramUsers(r.Context(), r.URL.Query().Get("q"), limit)
+		if err != nil {
+			http.Error(w, `{"error":"failed to list users"}`, http.StatusInternalServerError)
+			return
+		}
+		if users == nil {
+			users = []store.TelegramUser{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(users)
+	}
+}
+
+// adminSubscription is a subscription of any chat, with its event name.
+type adminSubscription struct {
+	ID             int64   `json:"id"`
+	TgChatID       int64   `json:"tg_chat_id"`
+	EventName      string  `json:"event_name"`
+	Linked         bool    `json:"linked"`
+	ThresholdPct   float64 `json:"threshold_pct"`
+	WindowMinutes  int     `json:"window_minutes"`
+	Direction      string  `json:"direction"`
+	ReportHour     int     `json:"report_hour"`
+	ThresholdValue float64 `json:"threshold_value"`
+	Coin           string  `json:"coin"`
+}
+
+// AdminListSubscriptions lists every chat's subscriptions, optionally
+// filtered by ?tg_chat_id= and ?event=.
+func AdminListSubscriptions(s store.SubscriptionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatID, err := queryChatID(r)
+		if err != nil {
+			http.Error(w, `{"error":"invalid tg_chat_id"}`, http.StatusBadRequest)
+			return
+		}
+		event := r.URL.Query().Get("event")
+
+		all, err := s.ListEventSubscriptions(r.Context())
+		if err != nil {
+			http.Error(w, `{"error":"failed to list subscriptions"}`, http.StatusInternalServerError)
+			return
+		}
+		subs := make([]adminSubscription, 0, len(all))
+		for _, es := range all {
+			if (chatID != 0 && es.ChatID != chatID) || (event != "" && es.EventName != event) {
+				continue
+			}
+			subs = append(subs, adminSubscription{
+				ID:             es.SubscriptionID,
+				TgChatID:       es.ChatID,
+				EventName:      es.EventName,
+				Linked:         es.Linked,
+				ThresholdPct:   es.ThresholdPct,
+				WindowMinutes:  es.WindowMinutes,
+				Direction:      es.Direction,
+				ReportHour:     es.ReportHour,
+				ThresholdValue: es.ThresholdValue,
+				Coin:           es.Coin,
+			})
+		}
+		slices.SortFunc(subs, func(a, b adminSubscription) int { return cmp.Compare(a.ID, b.ID) })
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(subs)
+	}
+}
+
+// AdminUpdateSubscription edits any chat's subscription; the body is the
+// same as PUT /api/subscriptions/{id}.
+func AdminUpdateSubscription(s store.SubscriptionStore, d *dedup.Deduplicator, a store.AuditStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			http.Error(w, `{"error":"invalid subscription id"}`, http.StatusBadRequest)
+			return
+		}
+		if updateSubscription(w, r, s, d, id) {
+			audit(r, a, "update_subscription", strconv.FormatInt(id, 10), "")
+		}
+	}
+}
+
+// AdminListEvents lists every event, disabled ones included.
+func AdminListEvents(s store.EventStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		events, err := s.ListAllEvents(r.Context())
+		if err != nil {
+			http.Error(w, `{"error":"failed to list events"}`, http.StatusInternalServerError)
+			return
+		}
+		if events == nil {
+			events = []store.Event{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(events)
+	}
+}
+
+// AdminSetEventEnabled enables or disables an event. Disabled events are
+// hidden from GET /api/events, and the engine stops evaluating them from
+// its next poll cycle.
+func AdminSetEventEnabled(s store.EventStore, a store.AuditStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, `{"error":"invalid event id"}`, http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+			http.Error(w, `{"error":"enabled required"}`, http.StatusBadRequest)
+			return
+		}
+
+		ev, err := s.SetEventEnabled(r.Context(), id, *req.Enabled)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, `{"error":"event not found"}`, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, `{"error":"failed to update event"}`, http.StatusInternalServerError)
+			return
+		}
+		audit(r, a, "set_event_enabled", ev.Name, "enabled="+strconv.FormatBool(ev.Enabled))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ev)
+	}
+}
+
+// broadcastInterval paces announcements below Telegram's limit of about 30
+// messages per second.
+var broadcastInterval = 50 * time.Millisecond
+
+// AdminBroadcast sends an announcement (Telegram HTML) to every linked
+// chat. It answers 202 with the recipient count and sends in the
+// background as one of tasks; failed deliveries show up in GET
+// /api/admin/delivery-failures.
+func AdminBroadcast(s store.UserStore, send monitor.AlertFunc, a store.AuditStore, tasks *Tasks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Message == "" {
+			http.Error(w, `{"error":"message required"}`, http.StatusBadRequest)
+			return
+		}
+
+		users, err := s.ListTelegramUsers(r.Context())
+		if err != nil {
+			http.Error(w, `{"error":"failed to list users"}`, http.StatusInternalServerError)
+			return
+		}
+		var chatIDs []int64
+		for _, u := range users {
+			if u.Linked {
+				chatIDs = append(chatIDs, u.TgChatID)
+			}
+		}
+		audit(r, a, "broadcast", "", fmt.Sprintf("recipients=%d message=%q", len(chatIDs), req.Message))
+
+		tasks.Go(func(ctx context.Context) {
+			sent, failed := 0, 0
+			for i, chatID := range chatIDs {
+				if i > 0 {
+					select {
+					case <-time.After(broadcastInterval):
+					case <-ctx.Done():
+						slog.Warn("broadcast stopped by shutdown", "sent", sent, "failed", failed, "unsent", len(chatIDs)-i)
+						return
+					}
+				}
+				if err := send(chatID, req.Message); err != nil {
+					metrics.BroadcastMessagesTotal.WithLabelValues("failed").Inc()
+					failed++
+					continue
+				}
+				metrics.BroadcastMessagesTotal.WithLabelValues("sent").Inc()
+				sent++
+			}
+			slog.Info("broadcast finished", "sent", sent, "failed", failed)
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]int{"recipients": len(chatIDs)})
+	}
+}
+
+// AdminListDeliveryFailures lists the latest messages that could not be
+// delivered, newest first.
+func AdminListDeliveryFailures(s store.NotificationStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		failures, err := s.ListDeliveryFailures(r.Context(), limit)
+		if err != nil {
+			http.Error(w, `{"error":"failed to list delivery failures"}`, http.StatusInternalServerError)
+			return
+		}
+		if failures == nil {
+			failures = []store.DeliveryFailure{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(failures)
+	}
+}
+
+// adminSource is a registered source and whether it is paused.
+type adminSource struct {
+	Name     string     `json:"name"`
+	Paused   bool       `json:"paused"`
+	PausedAt *time.Time `json:"paused_at"`
+}
+
+// AdminListSources lists the registered sources and which are paused.
+func AdminListSources(engine *monitor.Engine, s store.SourceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paused, err := s.ListPausedSources(r.Context())
+		if err != nil {
+			http.Error(w, `{"error":"failed to list sources"}`, http.StatusInternalServerError)
+			return
+		}
+		names := engine.SourceNames()
+		slices.Sort(names)
+		list := make([]adminSource, 0, len(names))
+		for _, name := range names {
+			src := adminSource{Name: name}
+			for _, p := range paused {
+				if p.Name == name {
+					src.Paused, src.PausedAt = true, &p.PausedAt
+				}
+			}
+			list = append(list, src)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(list)
+	}
+}
+
+// AdminSetSourcePaused pauses or resumes polling of a source on whichever
+// replica leads, from its next poll cycle.
+func AdminSetSourcePaused(engine *monitor.Engine, s store.SourceStore, a store.AuditStore, paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if !slices.Contains(engine.SourceNames(), name) {
+			http.Error(w, `{"error":"source not found"}`, http.StatusNotFound)
+			return
+		}
+		if err := s.SetSourcePaused(r.Context(), name, paused); err != nil {
+			http.Error(w, `{"error":"failed to update source"}`, http.StatusInternalServerError)
+			return
+		}
+		audit(r, a, "set_source_paused", name, "paused="+strconv.FormatBool(paused))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(adminSource{Name: name, Paused: paused})
+	}
+}
+
+// AdminListAuditLog lists the latest changes made through the admin API,
+// newest first.
+func AdminListAuditLog(a store.AuditStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		actions, err := a.ListAdminActions(r.Context(), limit)
+		if err != nil {
+			http.Error(w, `{"error":"failed to list audit log"}`, http.StatusInternalServerError)
+			return
+		}
+		if actions == nil {
+			actions = []store.AdminAction{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(actions)
+	}
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+func TestAdminEndpoints(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	for chatID, name := range map[int64]string{111: "alice", 222: "bob"} {
+		if _, err := db.UpsertLoginUser(ctx, chatID, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, _ := db.ListEvents(ctx)
+	sub, err := db.Subscribe(ctx, 222, events[0].ID, 10, 1, "drop", 8, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := monitor.NewEngine(nil, slog.New(slog.DiscardHandler), nil, nil)
+	engine.Register(&mockSource{name: "altura", chain: "HyperEVM"})
+
+	broadcastInterval = 0
+	sent := make(chan int64, 2)
+	send := func(chatID int64, _ string) error {
+		sent <- chatID
+		if chatID == 222 {
+			return errors.New("blocked by user")
+		}
+		return nil
+	}
+
+	r := chi.NewRouter()
+	r.Get("/users", AdminListUsers(db))
+	r.Get("/subscriptions", AdminListSubscriptions(db))
+	r.Put("/subscriptions/{id}", AdminUpdateSubscription(db, nil, db))
+	r.Put("/events/{id}", AdminSetEventEnabled(db, db))
+	r.Post("/broadcast", AdminBroadcast(db, send, db, NewTasks(ctx)))
+	r.Get("/sources", AdminListSources(engine, db))
+	r.Post("/sources/{name}/pause", AdminSetSourcePaused(engine, db, db, true))
+	r.Get("/audit-log", AdminListAuditLog(db))
+
+	do := func(method, path, body string, wantStatus int, out any) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != wantStatus {
+			t.Fatalf("%s %s: status = %d, want %d; body = %s", method, path, rec.Code, wantStatus, rec.Body.String())
+		}
+		if out != nil {
+			if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+		}
+	}
+
+	var users []store.TelegramUser
+	do(http.MethodGet, "/users?q=ALI", "", http.StatusOK, &users)
+	if len(users) != 1 || users[0].TgChatID != 111 {
+		t.Errorf("search users = %+v, want alice only", users)
+	}
+
+	var subs []adminSubscription
+	do(http.MethodGet, "/subscriptions?tg_chat_id=222", "", http.StatusOK, &subs)
+	if len(subs) != 1 || subs[0].ID != sub.ID {
+		t.Fatalf("subscriptions = %+v, want bob's", subs)
+	}
+	do(http.MethodGet, "/subscriptions?tg_chat_id=111", "", http.StatusOK, &subs)
+	if len(subs) != 0 {
+		t.Errorf("subscriptions of alice = %+v, want none", subs)
+	}
+
+	// Admins edit any chat's subscription, without a session of their own
+	var updated store.Subscription
+	do(http.MethodPut, fmt.Sprintf("/subscriptions/%d", sub.ID), `{"threshold_pct": 25}`, http.StatusOK, &updated)
+	if updated.ThresholdPct != 25 {
+		t.Errorf("threshold_pct = %v, want 25", updated.ThresholdPct)
+	}
+	do(http.MethodPut, "/subscriptions/9999", `{}`, http.StatusNotFound, nil)
+
+	var ev store.Event
+	do(http.MethodPut, fmt.Sprintf("/events/%d", events[0].ID), `{"enabled": false}`, http.StatusOK, &ev)
+	if ev.Enabled {
+		t.Error("event should be disabled")
+	}
+	do(http.MethodPut, fmt.Sprintf("/events/%d", events[0].ID), `{}`, http.StatusBadRequest, nil)
+	do(http.MethodPut, "/events/9999", `{"enabled": true}`, http.StatusNotFound, nil)
+
+	var accepted struct{ Recipients int }
+	do(http.MethodPost, "/broadcast", `{"message": "maintenance at 12:00 UTC"}`, http.StatusAccepted, &accepted)
+	if accepted.Recipients != 2 {
+		t.Errorf("recipients = %d, want 2", accepted.Recipients)
+	}
+	var got []int64
+	for range 2 {
+		select {
+		case chatID := <-sent:
+			got = append(got, chatID)
+		case <-time.After(time.Second):
+			t.Fatal("broadcast did not reach every linked chat")
+		}
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []int64{111, 222}) {
+		t.Errorf("broadcast to %v, want [111 222]", got)
+	}
+	do(http.MethodPost, "/broadcast", `{}`, http.StatusBadRequest, nil)
+
+	do(http.MethodPost, "/sources/nope/pause", "", http.StatusNotFound, nil)
+	do(http.MethodPost, "/sources/altura/pause", "", http.StatusOK, nil)
+	var sources []adminSource
+	do(http.MethodGet, "/sources", "", http.StatusOK, &sources)
+	if len(sources) != 1 || !sources[0].Paused || sources[0].PausedAt == nil {
+		t.Errorf("sources = %+v, want altura paused", sources)
+	}
+
+	// Every successful change is in the audit log, newest first
+	var actions []store.AdminAction
+	do(http.MethodGet, "/audit-log", "", http.StatusOK, &actions)
+	var logged []string
+	for _, a := range actions {
+		logged = append(logged, a.Action+" "+a.Target)
+	}
+	want := []string{
+		"set_source_paused altura",
+		"broadcast ",
+		"set_event_enabled " + events[0].Name,
+		fmt.Sprintf("update_subscription %d", sub.ID),
+	}
+	if !slices.Equal(logged, want) {
+		t.Errorf("audit log = %q, want %q", logged, want)
+	}
+}
+
+func TestAdminBroadcastStopsOnShutdown(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	for _, chatID := range []int64{111, 222, 333} {
+		if _, err := db.UpsertLoginUser(ctx, chatID, "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	broadcastInterval = time.Hour
+	defer func() { broadcastInterval = 0 }()
+	sent := make(chan struct{}, 3)
+	send := func(int64, string) error { sent <- struct{}{}; return nil }
+	shutdown, cancel := context.WithCancel(ctx)
+	tasks := NewTasks(shutdown)
+
+	req := httptest.NewRequest(http.MethodPost, "/broadcast", strings.NewReader(`{"message": "hi"}`))
+	rec := httptest.NewRecorder()
+	AdminBroadcast(db, send, db, tasks)(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", rec.Code)
+	}
+	<-sent
+	cancel()
+
+	done := make(chan struct{})
+	go func() { tasks.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after shutdown")
+	}
+	if len(sent) != 0 {
+		t.Errorf("%d messages sent after shutdown, want none", len(sent))
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// maxAPIKeyName bounds the label users give their keys.
+const maxAPIKeyName = 100
+
+// ListAPIKeys returns the caller's API keys without their secrets.
+func ListAPIKeys(s store.APIKeyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatID, ok := callerChatID(w, r, 0)
+		if !ok {
+			return
+		}
+
+		keys, err := s.ListAPIKeys(r.Context(), chatID)
+		if err != nil {
+			http.Error(w, `{"error":"failed to list api keys"}`, http.StatusInternalServerError)
+			return
+		}
+		if keys == nil {
+			keys = []store.APIKey{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(keys)
+	}
+}
+
+// CreateAPIKey mints a scoped API key for the caller. The key is in the
+// response only; it cannot be retrieved again.
+func CreateAPIKey(s store.APIKeyStore) http.HandlerFunc {
+	type request struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		chatID, ok := callerChatID(w, r, 0)
+		if !ok {
+			return
+		}
+		if req.Name == "" || len(req.Name) > maxAPIKeyName {
+			http.Error(w, `{"error":"name must be 1-100 characters"}`, http.StatusBadRequest)
+			return
+		}
+		if len(req.Scopes) == 0 {
+			http.Error(w, `{"error":"at least one scope required"}`, http.StatusBadRequest)
+			return
+		}
+		for _, scope := range req.Scopes {
+			if !slices.Contains(store.APIKeyScopes, scope) {
+				http.Error(w, `{"error":"unknown scope"}`, http.StatusBadRequest)
+				return
+			}
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			http.Error(w, `{"error":"expires_at must be in the future"}`, http.StatusBadRequest)
+			return
+		}
+
+		slices.Sort(req.Scopes)
+		scopes := slices.Compact(req.Scopes)
+		key, meta, err := s.CreateAPIKey(r.Context(), chatID, req.Name, scopes, req.ExpiresAt)
+		if err != nil {
+			http.Error(w, `{"error":"failed to create api key"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(struct {
+			*store.APIKey
+			Key string `json:"key"`
+		}{meta, key})
+	}
+}
+
+// RevokeAPIKey deletes one of the caller's API keys. Other users' keys are
+// reported as not found.
+func RevokeAPIKey(s store.APIKeyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatID, ok := callerChatID(w, r, 0)
+		if !ok {
+			return
+		}
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			http.Error(w, `{"error":"invalid api key id"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = s.DeleteAPIKey(r.Context(), chatID, id)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, `{"error":"api key not found"}`, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, `{"error":"failed to revoke api key"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
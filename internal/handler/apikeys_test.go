@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	// CreateAPIKey requires a store, but input validation returns before
+	// hitting it.
+	handler := CreateAPIKey(nil)
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name       string
+		body       string
+		noSession  bool
+		wantStatus int
+	}{
+		{"invalid JSON", `{invalid`, false, http.StatusBadRequest},
+		{"no session", `{"name": "ci", "scopes": ["read:stats"]}`, true, http.StatusUnauthorized},
+		{"missing name", `{"scopes": ["read:stats"]}`, false, http.StatusBadRequest},
+		{"name too long", `{"name": "` + strings.Repeat("x", 101) + `", "scopes": ["read:stats"]}`, false, http.StatusBadRequest},
+		{"no scopes", `{"name": "ci"}`, false, http.StatusBadRequest},
+		{"unknown scope", `{"name": "ci", "scopes": ["admin"]}`, false, http.StatusBadRequest},
+		{"expiry in the past", `{"name": "ci", "scopes": ["read:stats"], "expires_at": "` + past + `"}`, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/keys", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if !tt.noSession {
+				req = authed(req, 123)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKeyEndpointsRequireSession(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{
+		"list":   ListAPIKeys(nil),
+		"revoke": RevokeAPIKey(nil),
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/keys", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/web3-frozen/onchain-monitor/internal/chart"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+)
+
+// MetricChart serves a PNG line chart of one source metric from the
+// engine's recent snapshot history.
+func MetricChart(engine *monitor.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		img, err := engine.MetricChart(chi.URLParam(r, "source"), chi.URLParam(r, "metric"))
+		writeChart(w, img, err)
+	}
+}
+
+// LiquidationChart serves a PNG histogram of liquidations by price for a
+// symbol, over ?interval= (default 24h).
+func LiquidationChart(engine *monitor.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		interval := r.URL.Query().Get("interval")
+		if interval == "" {
+			interval = "24h"
+		}
+		if !monitor.ValidInterval(interval) {
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, `{"error":"invalid interval"}`, http.StatusBadRequest)
+			return
+		}
+		img, err := engine.LiquidationChart(r.Context(), chi.URLParam(r, "symbol"), interval)
+		writeChart(w, img, err)
+	}
+}
+
+func writeChart(w http.ResponseWriter, img []byte, err error) {
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, chart.ErrNoData) {
+			http.Error(w, `{"error":"no chart data available yet"}`, http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, `{"error":"failed to render chart"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "max-age=60")
+	_, _ = w.Write(img)
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+)
+
+func TestChartHandlers(t *testing.T) {
+	engine := monitor.NewEngine(nil, slog.Default(), nil, nil)
+	engine.Register(&mockSource{name: "testsrc", chain: "TestChain"})
+
+	r := chi.NewRouter()
+	r.Get("/api/charts/metrics/{source}/{metric}", MetricChart(engine))
+	r.Get("/api/charts/liquidations/{symbol}", LiquidationChart(engine))
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"metric before first poll", "/api/charts/metrics/testsrc/tvl", http.StatusServiceUnavailable},
+		{"unknown source", "/api/charts/metrics/nope/tvl", http.StatusServiceUnavailable},
+		{"invalid interval", "/api/charts/liquidations/BTC?interval=5m", http.StatusBadRequest},
+		{"no maxpain source", "/api/charts/liquidations/BTC?interval=7d", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body: %s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
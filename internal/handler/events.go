@@ -7,7 +7,7 @@ import (
 	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
-func ListEvents(s *store.Store) http.HandlerFunc {
+func ListEvents(s store.EventStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		events, err := s.ListEvents(r.Context())
 		if err != nil {
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/web3-frozen/onchain-monitor/internal/store"
@@ -14,7 +15,9 @@ func Health() http.HandlerFunc {
 	}
 }
 
-func Ready(s *store.Store) http.HandlerFunc {
+// Ready checks the database and reports whether this replica is the
+// leader. Followers are ready too: every replica serves HTTP.
+func Ready(s store.Store, isLeader func() bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		if err := s.Ping(r.Context()); err != nil {
@@ -22,6 +25,6 @@ func Ready(s *store.Store) http.HandlerFunc {
 			return
 		}
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"ready"}`))
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "ready", "leader": isLeader()})
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
 func TestHealthHandler(t *testing.T) {
@@ -31,3 +33,25 @@ func TestHealthHandler(t *testing.T) {
 		t.Errorf("Content-Type = %q, want %q", ct, "application/json")
 	}
 }
+
+func TestReadyReportsLeadership(t *testing.T) {
+	for _, leader := range []bool{true, false} {
+		handler := Ready(store.NewMemory(), func() bool { return leader })
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("leader=%v: status = %d, want %d", leader, rec.Code, http.StatusOK)
+		}
+		var body struct {
+			Status string `json:"status"`
+			Leader bool   `json:"leader"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if body.Status != "ready" || body.Leader != leader {
+			t.Errorf("body = %+v, want ready with leader=%v", body, leader)
+		}
+	}
+}
@@ -2,39 +2,53 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/linkguard"
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
 	"github.com/web3-frozen/onchain-monitor/internal/store"
+	"github.com/web3-frozen/onchain-monitor/internal/telegram"
 )
 
-// LinkStatus checks whether a Telegram chat ID is linked.
-func LinkStatus(s *store.Store) http.HandlerFunc {
+// LinkStatus reports whether the caller's Telegram chat is linked.
+func LinkStatus(s store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		chatIDStr := r.URL.Query().Get("tg_chat_id")
-		if chatIDStr == "" {
-			http.Error(w, `{"error":"tg_chat_id required"}`, http.StatusBadRequest)
-			return
-		}
-		chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+		claimed, err := queryChatID(r)
 		if err != nil {
 			http.Error(w, `{"error":"invalid tg_chat_id"}`, http.StatusBadRequest)
 			return
 		}
+		chatID, ok := callerChatID(w, r, claimed)
+		if !ok {
+			return
+		}
 
 		user, err := s.GetTelegramUser(r.Context(), chatID)
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(map[string]bool{"linked": false})
+			_ = json.NewEncoder(w).Encode(map[string]any{"linked": false, "language": messages.English})
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(map[string]bool{"linked": user.Linked})
+		_ = json.NewEncoder(w).Encode(map[string]any{"linked": user.Linked, "language": messages.Normalize(user.Language)})
 	}
 }
 
-func LinkTelegram(s *store.Store) http.HandlerFunc {
+// LinkTelegram links a chat via its bot-issued code and starts an API
+// session for it. The returned token authenticates all per-user endpoints.
+// Each attempt is reserved against the caller's IP and a global budget in
+// guard (nil disables throttling) before the code is checked, and only
+// unknown codes keep counting; every attempt is written to the audit log.
+func LinkTelegram(s store.Store, guard *linkguard.Guard, sessionTTL time.Duration) http.HandlerFunc {
 	type request struct {
 		Code string `json:"code"`
 	}
@@ -51,39 +65,180 @@ func LinkTelegram(s *store.Store) http.HandlerFunc {
 			return
 		}
 
-		user, err := s.LinkByCode(r.Context(), req.Code)
-		if err != nil {
+		ip := middleware.ClientIP(r)
+		if guard != nil {
+			if ok, retry := guard.Reserve(r.Context(), ip); !ok {
+				auditLinkAttempt(r, s, ip, linkLockedOut, 0)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+				http.Error(w, `{"error":"too many failed attempts, try again later"}`, http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		user, err := s.LinkByCode(r.Context(), telegram.NormalizeLinkCode(req.Code))
+		if errors.Is(err, store.ErrNotFound) {
+			// The reserved attempt stays counted as a failure
+			auditLinkAttempt(r, s, ip, linkInvalidCode, 0)
 			http.Error(w, `{"error":"invalid or expired link code"}`, http.StatusNotFound)
 			return
 		}
+		if err != nil {
+			if guard != nil {
+				guard.Refund(r.Context(), ip)
+			}
+			slog.Error("link by code", "ip", ip, "error", err)
+			http.Error(w, `{"error":"failed to link"}`, http.StatusInternalServerError)
+			return
+		}
+		if guard != nil {
+			guard.Succeed(r.Context(), ip)
+		}
+		auditLinkAttempt(r, s, ip, linkLinked, user.TgChatID)
 
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(user)
+		writeSession(w, r, s, user, sessionTTL)
+	}
+}
+
+// Link attempt outcomes, as recorded in link_attempts and the
+// link_attempts_total metric.
+const (
+	linkLinked      = "linked"
+	linkInvalidCode = "invalid_code"
+	linkLockedOut   = "locked_out"
+)
+
+// auditLinkAttempt counts a link attempt and records it in the audit log.
+// Audit failures are logged but never fail the request.
+func auditLinkAttempt(r *http.Request, s store.UserStore, ip, outcome string, chatID int64) {
+	metrics.LinkAttemptsTotal.WithLabelValues(outcome).Inc()
+	if s == nil {
+		return
+	}
+	if err := s.LogLinkAttempt(r.Context(), ip, outcome, chatID); err != nil {
+		slog.Warn("failed to log link attempt", "ip", ip, "outcome", outcome, "error", err)
 	}
 }
 
-func UnlinkTelegram(s *store.Store) http.HandlerFunc {
+// TelegramLogin signs a user in with the Telegram Login Widget: the widget's
+// callback fields are posted as JSON, verified against the bot token and
+// auth_date freshness, and exchanged for a session like LinkTelegram's. The
+// user does not need to message the bot first.
+func TelegramLogin(s store.Store, botToken string, sessionTTL, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err := dec.Decode(&body); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		// The hash covers the fields exactly as Telegram sent them, so
+		// numbers must keep their original text.
+		fields := make(map[string]string, len(body))
+		for k, v := range body {
+			switch v := v.(type) {
+			case string:
+				fields[k] = v
+			case json.Number:
+				fields[k] = v.String()
+			default:
+				http.Error(w, `{"error":"invalid login field"}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		login, err := telegram.VerifyLogin(botToken, fields, maxAge, time.Now())
+		if errors.Is(err, telegram.ErrLoginExpired) {
+			http.Error(w, `{"error":"login data expired"}`, http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, `{"error":"invalid login data"}`, http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.UpsertLoginUser(r.Context(), login.ID, login.Username)
+		if err != nil {
+			http.Error(w, `{"error":"failed to log in"}`, http.StatusInternalServerError)
+			return
+		}
+
+		writeSession(w, r, s, user, sessionTTL)
+	}
+}
+
+func UnlinkTelegram(s store.Store) http.HandlerFunc {
 	type request struct {
 		TgChatID int64 `json:"tg_chat_id"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		// The body is optional now that the session identifies the chat.
 		var req request
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
 			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
 			return
 		}
-
-		if req.TgChatID == 0 {
-			http.Error(w, `{"error":"tg_chat_id required"}`, http.StatusBadRequest)
+		chatID, ok := callerChatID(w, r, req.TgChatID)
+		if !ok {
 			return
 		}
 
-		if err := s.UnlinkTelegram(r.Context(), req.TgChatID); err != nil {
+		if err := s.UnlinkTelegram(r.Context(), chatID); err != nil {
 			http.Error(w, `{"error":"failed to unlink"}`, http.StatusInternalServerError)
 			return
 		}
+		if err := s.DeleteSessions(r.Context(), chatID); err != nil {
+			http.Error(w, `{"error":"failed to revoke sessions"}`, http.StatusInternalServerError)
+			return
+		}
+		if err := s.DeleteAPIKeys(r.Context(), chatID); err != nil {
+			http.Error(w, `{"error":"failed to revoke api keys"}`, http.StatusInternalServerError)
+			return
+		}
 
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// SetLanguage changes the language of the caller's alerts, reports and bot
+// replies.
+func SetLanguage(s store.Store) http.HandlerFunc {
+	type request struct {
+		TgChatID int64  `json:"tg_chat_id"`
+		Language string `json:"language"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+
+		chatID, ok := callerChatID(w, r, req.TgChatID)
+		if !ok {
+			return
+		}
+		if !messages.Supported(req.Language) {
+			http.Error(w, `{"error":"unsupported language"}`, http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.GetTelegramUser(r.Context(), chatID)
+		if err != nil {
+			http.Error(w, `{"error":"user not found"}`, http.StatusNotFound)
+			return
+		}
+
+		lang := messages.Normalize(req.Language)
+		if err := s.SetUserLanguage(r.Context(), user.TgChatID, user.TgUsername, lang); err != nil {
+			http.Error(w, `{"error":"failed to set language"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"language": lang})
+	}
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/web3-frozen/onchain-monitor/internal/linkguard"
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+func TestSetLanguageValidation(t *testing.T) {
+	// SetLanguage requires a store, but input validation returns before
+	// hitting it.
+	handler := SetLanguage(nil)
+
+	tests := []struct {
+		name       string
+		body       string
+		noSession  bool
+		wantStatus int
+	}{
+		{"invalid JSON", `{invalid`, false, http.StatusBadRequest},
+		{"no session", `{"language": "zh"}`, true, http.StatusUnauthorized},
+		{"tg_chat_id of another user", `{"tg_chat_id": 456, "language": "zh"}`, false, http.StatusForbidden},
+		{"missing language", `{"tg_chat_id": 123}`, false, http.StatusBadRequest},
+		{"unsupported language", `{"language": "fr"}`, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/language", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if !tt.noSession {
+				req = authed(req, 123)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLinkEndpointsRequireSession(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{
+		"status": LinkStatus(nil),
+		"unlink": UnlinkTelegram(nil),
+		"logout": Logout(nil),
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/unlink", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestTelegramLoginValidation(t *testing.T) {
+	// Only requests that fail verification are tested; valid ones need a
+	// store. Signature checks are covered in internal/telegram.
+	handler := TelegramLogin(nil, "123456:TEST-TOKEN", time.Hour, 24*time.Hour)
+	fresh := strconv.FormatInt(time.Now().Unix(), 10)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"invalid JSON", `{invalid`, http.StatusBadRequest},
+		{"non-scalar field", `{"id": 1, "photo_url": {"x": 1}}`, http.StatusBadRequest},
+		{"missing hash", `{"id": 1, "auth_date": ` + fresh + `}`, http.StatusUnauthorized},
+		{"wrong hash", `{"id": 1, "auth_date": ` + fresh + `, "hash": "00ff"}`, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/login/telegram", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLinkTelegramLockedOut(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		rdb.Close()
+		mr.Close()
+	})
+	guard := linkguard.New(rdb, linkguard.Limits{PerIP: 2, Global: 100, Window: time.Minute, Lockout: 90 * time.Second})
+	for i := 0; i < 2; i++ {
+		guard.Reserve(context.Background(), "198.51.100.4")
+	}
+
+	// A locked-out caller is rejected before the code is looked up, so no
+	// store is needed. Requests come through a proxy at 192.0.2.1.
+	proxies, _ := middleware.ParseTrustedProxies([]string{"192.0.2.1"})
+	handler := middleware.RealIP(proxies)(LinkTelegram(nil, guard, time.Hour))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		body       string
+		wantStatus int
+	}{
+		{"missing code", "192.0.2.1:1234", "198.51.100.4", `{}`, http.StatusBadRequest},
+		{"locked out", "192.0.2.1:1234", "198.51.100.4", `{"code": "ABCDE-FGHJK"}`, http.StatusTooManyRequests},
+		{"spoofed first hop still locked out", "192.0.2.1:1234", "203.0.113.9, 198.51.100.4", `{"code": "ABCDE-FGHJK"}`, http.StatusTooManyRequests},
+		{"direct client spoofing another IP still locked out", "198.51.100.4:5555", "203.0.113.9", `{"code": "ABCDE-FGHJK"}`, http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/link", strings.NewReader(tt.body))
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Forwarded-For", tt.xff)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "90" {
+				t.Errorf("Retry-After = %q, want %q", rec.Header().Get("Retry-After"), "90")
+			}
+		})
+	}
+}
+
+// downLinkStore fails every code lookup, as Postgres does in an outage.
+type downLinkStore struct{ *store.Memory }
+
+func (downLinkStore) LinkByCode(context.Context, string) (*store.TelegramUser, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestLinkTelegramStoreErrorsAreNotGuesses(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		rdb.Close()
+		mr.Close()
+	})
+	guard := linkguard.New(rdb, linkguard.Limits{PerIP: 1, Global: 100, Window: time.Minute, Lockout: time.Minute})
+	db := store.NewMemory()
+
+	post := func(s store.Store) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/link", strings.NewReader(`{"code": "ABCDE-FGHJK"}`))
+		rec := httptest.NewRecorder()
+		LinkTelegram(s, guard, time.Hour).ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for i := 0; i < 3; i++ {
+		if code := post(downLinkStore{db}); code != http.StatusInternalServerError {
+			t.Fatalf("store down: status = %d, want 500", code)
+		}
+	}
+	if code := post(db); code != http.StatusNotFound {
+		t.Errorf("unknown code after an outage: status = %d, want 404", code)
+	}
+	if code := post(db); code != http.StatusTooManyRequests {
+		t.Errorf("second unknown code: status = %d, want 429", code)
+	}
+}
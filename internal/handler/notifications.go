@@ -8,19 +8,17 @@ import (
 	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
-func ListNotifications(s *store.Store) http.HandlerFunc {
+func ListNotifications(s store.NotificationStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		tgChatIDStr := r.URL.Query().Get("tg_chat_id")
-		if tgChatIDStr == "" {
-			http.Error(w, `{"error":"tg_chat_id required"}`, http.StatusBadRequest)
-			return
-		}
-
-		tgChatID, err := strconv.ParseInt(tgChatIDStr, 10, 64)
+		claimed, err := queryChatID(r)
 		if err != nil {
 			http.Error(w, `{"error":"invalid tg_chat_id"}`, http.StatusBadRequest)
 			return
 		}
+		tgChatID, ok := callerChatID(w, r, claimed)
+		if !ok {
+			return
+		}
 
 		limit := 50
 		if v := r.URL.Query().Get("limit"); v != "" {
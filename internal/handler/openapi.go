@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/web3-frozen/onchain-monitor/internal/openapi"
+)
+
+// OpenAPI serves the API's OpenAPI 3 document.
+func OpenAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openapi.JSON())
+	}
+}
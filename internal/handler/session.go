@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// callerChatID returns the chat ID of the authenticated caller, writing an
+// error response if there is none. A tg_chat_id still sent by the client
+// (claimed, 0 if absent) must match the session.
+func callerChatID(w http.ResponseWriter, r *http.Request, claimed int64) (int64, bool) {
+	chatID, ok := middleware.ChatID(r.Context())
+	if !ok {
+		http.Error(w, `{"error":"authentication required"}`, http.StatusUnauthorized)
+		return 0, false
+	}
+	if claimed != 0 && claimed != chatID {
+		http.Error(w, `{"error":"tg_chat_id does not match session"}`, http.StatusForbidden)
+		return 0, false
+	}
+	return chatID, true
+}
+
+// queryChatID parses the optional tg_chat_id query parameter (0 if absent).
+func queryChatID(r *http.Request) (int64, error) {
+	v := r.URL.Query().Get("tg_chat_id")
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// writeSession starts an API session for user and responds with the user
+// plus the session token.
+func writeSession(w http.ResponseWriter, r *http.Request, s store.SessionStore, user *store.TelegramUser, ttl time.Duration) {
+	token, expiresAt, err := s.CreateSession(r.Context(), user.TgChatID, ttl)
+	if err != nil {
+		http.Error(w, `{"error":"failed to create session"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		*store.TelegramUser
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{user, token, expiresAt})
+}
+
+// Logout revokes the session token the request was made with.
+func Logout(s store.SessionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := callerChatID(w, r, 0); !ok {
+			return
+		}
+		if err := s.DeleteSession(r.Context(), middleware.BearerToken(r)); err != nil {
+			http.Error(w, `{"error":"failed to log out"}`, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
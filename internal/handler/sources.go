@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+)
+
+//go:embed templates/status.html
+var statusFS embed.FS
+
+var statusPage = template.Must(template.New("status.html").Funcs(template.FuncMap{
+	"since":   func(t *time.Time) string { return roundAge(time.Since(*t)) },
+	"seconds": func(s *float64) string { return roundAge(time.Duration(*s * float64(time.Second))) },
+	"join":    strings.Join,
+}).ParseFS(statusFS, "templates/status.html"))
+
+// ListSources reports the health of every registered source's fetches.
+// Like snapshots, it is the leader's view.
+func ListSources(engine *monitor.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(engine.Health())
+	}
+}
+
+// Status renders the source health as an HTML page for people without API
+// tooling.
+func Status(engine *monitor.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		health := engine.Health()
+		ok := 0
+		for _, h := range health {
+			if h.Status == monitor.HealthOK {
+				ok++
+			}
+		}
+		var buf bytes.Buffer
+		err := statusPage.Execute(&buf, struct {
+			Summary string
+			Now     time.Time
+			Sources []monitor.SourceHealth
+		}{
+			Summary: fmt.Sprintf("%d of %d sources OK", ok, len(health)),
+			Now:     time.Now().UTC(),
+			Sources: health,
+		})
+		if err != nil {
+			http.Error(w, "failed to render status page", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+// roundAge formats a duration for people: "42s", "5m", "3h10m".
+func roundAge(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
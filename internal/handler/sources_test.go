@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+)
+
+func TestListSources(t *testing.T) {
+	engine := monitor.NewEngine(nil, slog.New(slog.DiscardHandler), nil, nil)
+	engine.Register(&mockSource{name: "merkl", chain: "Multi"})
+	engine.Register(&mockSource{name: "altura", chain: "HyperEVM"})
+
+	rec := httptest.NewRecorder()
+	ListSources(engine).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sources", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var list []monitor.SourceHealth
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "altura" || list[1].Status != monitor.HealthPending || list[1].PollInterval != "60s" {
+		t.Errorf("sources = %+v, want altura and merkl pending at 60s", list)
+	}
+}
+
+func TestStatusPage(t *testing.T) {
+	engine := monitor.NewEngine(nil, slog.New(slog.DiscardHandler), nil, nil)
+	engine.Register(&mockSource{name: "<merkl>", chain: "Multi"})
+
+	rec := httptest.NewRecorder()
+	Status(engine).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("status = %d, content type %q; want an HTML page", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"0 of 1 sources OK", "&lt;merkl&gt;", "pending", "never"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+}
+
+func TestRoundAge(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		42*time.Second + 300*time.Millisecond: "42s",
+		5*time.Minute + 20*time.Second:        "5m",
+		3*time.Hour + 10*time.Minute:          "3h10m",
+	} {
+		if got := roundAge(d); got != want {
+			t.Errorf("roundAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
 func Stats(engine *monitor.Engine) http.HandlerFunc {
@@ -38,16 +42,37 @@ func Stats(engine *monitor.Engine) http.HandlerFunc {
 	}
 }
 
-// StatsMetadata returns available chains for filtering.
+// StatsMetadata returns available chains for filtering, and the runtime
+// state admins set: paused sources and disabled events. The state is read
+// from the store, so every replica reports the same.
 func StatsMetadata(engine *monitor.Engine) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		control, err := engine.LoadControl(r.Context())
+		if err != nil {
+			http.Error(w, `{"error":"failed to load runtime state"}`, http.StatusInternalServerError)
+			return
+		}
+		paused := make([]store.PausedSource, 0, len(control.PausedSources))
+		for name, at := range control.PausedSources {
+			paused = append(paused, store.PausedSource{Name: name, PausedAt: at})
+		}
+		slices.SortFunc(paused, func(a, b store.PausedSource) int { return strings.Compare(a.Name, b.Name) })
+		disabled := slices.Sorted(maps.Keys(control.DisabledEvents))
+		if disabled == nil {
+			disabled = []string{}
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		meta := struct {
-			Chains       []string `json:"chains"`
-			PollInterval string   `json:"poll_interval"`
+			Chains         []string             `json:"chains"`
+			PollInterval   string               `json:"poll_interval"`
+			PausedSources  []store.PausedSource `json:"paused_sources"`
+			DisabledEvents []string             `json:"disabled_events"`
 		}{
-			Chains:       engine.Chains(),
-			PollInterval: "60s",
+			Chains:         engine.Chains(),
+			PollInterval:   fmt.Sprintf("%gs", engine.PollInterval().Seconds()),
+			PausedSources:  paused,
+			DisabledEvents: disabled,
 		}
 		_ = json.NewEncoder(w).Encode(meta)
 	}
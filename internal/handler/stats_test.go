@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
 func TestStatsHandler(t *testing.T) {
@@ -86,6 +88,38 @@ func TestStatsMetadataHandler(t *testing.T) {
 	}
 }
 
+func TestStatsMetadataRuntimeState(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	engine := monitor.NewEngine(db, slog.New(slog.DiscardHandler), nil, nil)
+	engine.Register(&mockSource{name: "altura", chain: "HyperEVM"})
+
+	events, _ := db.ListEvents(ctx)
+	if _, err := db.SetEventEnabled(ctx, events[0].ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSourcePaused(ctx, "altura", true); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	StatsMetadata(engine).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats/meta", nil))
+
+	var meta struct {
+		PausedSources  []store.PausedSource `json:"paused_sources"`
+		DisabledEvents []string             `json:"disabled_events"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&meta); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(meta.PausedSources) != 1 || meta.PausedSources[0].Name != "altura" {
+		t.Errorf("PausedSources = %+v, want altura", meta.PausedSources)
+	}
+	if len(meta.DisabledEvents) != 1 || meta.DisabledEvents[0] != events[0].Name {
+		t.Errorf("DisabledEvents = %v, want [%s]", meta.DisabledEvents, events[0].Name)
+	}
+}
+
 // mockSource implements monitor.Source for testing.
 type mockSource struct {
 	name     string
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+const (
+	// streamHeartbeat keeps idle connections open through proxies.
+	streamHeartbeat = 25 * time.Second
+	// streamWriteTimeout bounds a single WebSocket write to a stalled client.
+	streamWriteTimeout = 10 * time.Second
+)
+
+// StreamSSE pushes new snapshots, and the caller's alerts when
+// authenticated, as server-sent events. ?source= and ?chain= take
+// comma-separated lists. The latest snapshot of each matching source is
+// sent first.
+func StreamSSE(engine *monitor.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		// The stream outlives the server's WriteTimeout.
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		filter := streamFilter(r)
+		sub := engine.Hub().Subscribe(filter)
+		defer engine.Hub().Unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		for _, ev := range latestSnapshots(engine, filter) {
+			if err := writeSSE(w, ev); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev, ok := <-sub.C:
+				if !ok {
+					// Dropped for falling behind; EventSource reconnects.
+					return
+				}
+				if err := writeSSE(w, ev); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, ev monitor.StreamEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
+
+// StreamWebSocket is StreamSSE over a WebSocket: each event is a JSON text
+// message. Cross-origin upgrades are accepted from allowedOrigin (the CORS
+// origin; "*" allows any).
+func StreamWebSocket(engine *monitor.Engine, allowedOrigin string) http.HandlerFunc {
+	var patterns []string
+	if allowedOrigin == "*" {
+		patterns = []string{"*"}
+	} else if u, err := url.Parse(allowedOrigin); err == nil && u.Host != "" {
+		patterns = []string{u.Host}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The hijacked connection keeps the server's deadlines otherwise.
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		filter := streamFilter(r)
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: patterns})
+		if err != nil {
+			return // Accept has written the error response
+		}
+		defer conn.CloseNow()
+
+		sub := engine.Hub().Subscribe(filter)
+		defer engine.Hub().Unsubscribe(sub)
+
+		// Clients only receive; CloseRead handles their pings and close.
+		ctx := conn.CloseRead(r.Context())
+		write := func(ev monitor.StreamEvent) error {
+			ctx, cancel := context.WithTimeout(ctx, streamWriteTimeout)
+			defer cancel()
+			return wsjson.Write(ctx, conn, ev)
+		}
+
+		for _, ev := range latestSnapshots(engine, filter) {
+			if err := write(ev); err != nil {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-sub.C:
+				if !ok {
+					conn.Close(websocket.StatusTryAgainLater, "client too slow")
+					return
+				}
+				if err := write(ev); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				pingCtx, cancel := context.WithTimeout(ctx, streamWriteTimeout)
+				err := conn.Ping(pingCtx)
+				cancel()
+				if err != nil && !errors.Is(err, context.Canceled) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// streamFilter builds the hub filter of a stream request. Alerts are only
+// streamed to an authenticated caller; API keys need read:notifications.
+func streamFilter(r *http.Request) monitor.StreamFilter {
+	q := r.URL.Query()
+	f := monitor.StreamFilter{Sources: splitList(q.Get("source")), Chains: splitList(q.Get("chain"))}
+	if chatID, ok := middleware.ChatID(r.Context()); ok {
+		scopes, isKey := middleware.APIKeyScopes(r.Context())
+		if !isKey || slices.Contains(scopes, store.ScopeReadNotifications) {
+			f.ChatID = chatID
+		}
+	}
+	return f
+}
+
+// latestSnapshots returns the current snapshot of each source matching f.
+func latestSnapshots(engine *monitor.Engine, f monitor.StreamFilter) []monitor.StreamEvent {
+	names := engine.SourceNames()
+	slices.Sort(names)
+	var out []monitor.StreamEvent
+	for _, name := range names {
+		snap := engine.GetSnapshot(name)
+		if snap == nil {
+			continue
+		}
+		ev := monitor.StreamEvent{Type: "snapshot", Snapshot: snap}
+		if f.Match(ev) {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
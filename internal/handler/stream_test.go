@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+)
+
+func TestStreamSSE(t *testing.T) {
+	engine := monitor.NewEngine(nil, slog.New(slog.DiscardHandler), nil, nil)
+	srv := httptest.NewServer(StreamSSE(engine))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?source=altura", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	// The response headers arrive after Subscribe, so these are delivered.
+	engine.Hub().Publish(monitor.StreamEvent{Type: "snapshot", Snapshot: &monitor.Snapshot{Source: "merkl"}})
+	engine.Hub().Publish(monitor.StreamEvent{Type: "snapshot", Snapshot: &monitor.Snapshot{Source: "altura"}})
+
+	sc := bufio.NewScanner(resp.Body)
+	var lines []string
+	for sc.Scan() && len(lines) < 2 {
+		if sc.Text() != "" {
+			lines = append(lines, sc.Text())
+		}
+	}
+	if len(lines) != 2 || lines[0] != "event: snapshot" || !strings.Contains(lines[1], `"source":"altura"`) {
+		t.Errorf("stream = %q, want the altura snapshot only", lines)
+	}
+}
+
+func TestStreamWebSocket(t *testing.T) {
+	engine := monitor.NewEngine(nil, slog.New(slog.DiscardHandler), nil, nil)
+	srv := httptest.NewServer(StreamWebSocket(engine, "*"))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, srv.URL+"?chain=HyperEVM", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+
+	// Subscribe happens after the handshake; publish until delivery.
+	got := make(chan monitor.StreamEvent, 1)
+	go func() {
+		var ev monitor.StreamEvent
+		if err := wsjson.Read(ctx, conn, &ev); err == nil {
+			got <- ev
+		}
+	}()
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		select {
+		case ev := <-got:
+			if ev.Snapshot == nil || ev.Snapshot.Chain != "HyperEVM" {
+				t.Fatalf("event = %+v, want HyperEVM snapshot", ev)
+			}
+			return
+		case <-tick.C:
+			engine.Hub().Publish(monitor.StreamEvent{Type: "snapshot", Snapshot: &monitor.Snapshot{Source: "x", Chain: "Ethereum"}})
+			engine.Hub().Publish(monitor.StreamEvent{Type: "snapshot", Snapshot: &monitor.Snapshot{Source: "altura", Chain: "HyperEVM"}})
+		case <-ctx.Done():
+			t.Fatal("no event received")
+		}
+	}
+}
+
+func TestStreamFilterAlerts(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/stream?source=a,+b,,", nil)
+	f := streamFilter(req)
+	if f.ChatID != 0 {
+		t.Errorf("anonymous ChatID = %d, want 0", f.ChatID)
+	}
+	if len(f.Sources) != 2 || f.Sources[0] != "a" || f.Sources[1] != "b" {
+		t.Errorf("Sources = %q, want [a b]", f.Sources)
+	}
+}
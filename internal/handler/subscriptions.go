@@ -2,7 +2,7 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -11,19 +11,17 @@ import (
 	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
-func ListSubscriptions(s *store.Store) http.HandlerFunc {
+func ListSubscriptions(s store.SubscriptionStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		tgChatIDStr := r.URL.Query().Get("tg_chat_id")
-		if tgChatIDStr == "" {
-			http.Error(w, `{"error":"tg_chat_id required"}`, http.StatusBadRequest)
-			return
-		}
-
-		tgChatID, err := strconv.ParseInt(tgChatIDStr, 10, 64)
+		claimed, err := queryChatID(r)
 		if err != nil {
 			http.Error(w, `{"error":"invalid tg_chat_id"}`, http.StatusBadRequest)
 			return
 		}
+		tgChatID, ok := callerChatID(w, r, claimed)
+		if !ok {
+			return
+		}
 
 		subs, err := s.ListSubscriptions(r.Context(), tgChatID)
 		if err != nil {
@@ -39,7 +37,7 @@ func ListSubscriptions(s *store.Store) http.HandlerFunc {
 	}
 }
 
-func Subscribe(s *store.Store) http.HandlerFunc {
+func Subscribe(s store.SubscriptionStore) http.HandlerFunc {
 	type request struct {
 		TgChatID       int64   `json:"tg_chat_id"`
 		EventID        int     `json:"event_id"`
@@ -58,30 +56,29 @@ func Subscribe(s *store.Store) http.HandlerFunc {
 			return
 		}
 
-		if req.TgChatID == 0 || req.EventID == 0 {
-			http.Error(w, `{"error":"tg_chat_id and event_id required"}`, http.StatusBadRequest)
+		tgChatID, ok := callerChatID(w, r, req.TgChatID)
+		if !ok {
 			return
 		}
-
-		if req.ThresholdPct < 0 {
-			req.ThresholdPct = 10
+		if req.EventID == 0 {
+			http.Error(w, `{"error":"event_id required"}`, http.StatusBadRequest)
+			return
 		}
-		if req.WindowMinutes <= 0 {
+
+		// Out-of-range values were rejected by openapi.Validate; only
+		// omitted fields get defaults here.
+		if req.WindowMinutes == 0 {
 			req.WindowMinutes = 1
 		}
-		validDirs := map[string]bool{"drop": true, "increase": true, "decrease": true, "higher": true, "lower": true, "long": true, "short": true, "stablecoin": true, "non-stablecoin": true, "any": true}
-		if !validDirs[req.Direction] {
+		if req.Direction == "" {
 			req.Direction = "drop"
 		}
 		reportHour := 8
-		if req.ReportHour != nil && *req.ReportHour >= 0 && *req.ReportHour <= 23 {
+		if req.ReportHour != nil {
 			reportHour = *req.ReportHour
 		}
-		if req.ThresholdValue < 0 {
-			req.ThresholdValue = 0
-		}
 
-		sub, err := s.Subscribe(r.Context(), req.TgChatID, req.EventID, req.ThresholdPct, req.WindowMinutes, req.Direction, reportHour, req.ThresholdValue, req.Coin)
+		sub, err := s.Subscribe(r.Context(), tgChatID, req.EventID, req.ThresholdPct, req.WindowMinutes, req.Direction, reportHour, req.ThresholdValue, req.Coin)
 		if err != nil {
 			http.Error(w, `{"error":"failed to subscribe"}`, http.StatusInternalServerError)
 			return
@@ -93,82 +90,111 @@ func Subscribe(s *store.Store) http.HandlerFunc {
 	}
 }
 
-func UpdateSubscription(s *store.Store) http.HandlerFunc {
-	type request struct {
-		ThresholdPct   float64 `json:"threshold_pct"`
-		WindowMinutes  int     `json:"window_minutes"`
-		Direction      string  `json:"direction"`
-		ReportHour     *int    `json:"report_hour"`
-		ThresholdValue float64 `json:"threshold_value"`
-		Coin           string  `json:"coin"`
-	}
-
+func UpdateSubscription(s store.SubscriptionStore, d *dedup.Deduplicator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		chatID, ok := callerChatID(w, r, 0)
+		if !ok {
+			return
+		}
 		idStr := chi.URLParam(r, "id")
 		id, err := strconv.ParseInt(idStr, 10, 64)
 		if err != nil {
 			http.Error(w, `{"error":"invalid subscription id"}`, http.StatusBadRequest)
 			return
 		}
-
-		var req request
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		if !ownsSubscription(r, s, chatID, id) {
+			http.Error(w, `{"error":"subscription not found"}`, http.StatusNotFound)
 			return
 		}
+		updateSubscription(w, r, s, d, id)
+	}
+}
 
-		if req.ThresholdPct < 0 {
-			req.ThresholdPct = 10
-		}
-		if req.WindowMinutes <= 0 {
-			req.WindowMinutes = 1
-		}
-		validDirs := map[string]bool{"drop": true, "increase": true, "decrease": true, "higher": true, "lower": true, "long": true, "short": true, "stablecoin": true, "non-stablecoin": true, "any": true}
-		if !validDirs[req.Direction] {
-			req.Direction = "drop"
-		}
-		reportHour := 8
-		if req.ReportHour != nil && *req.ReportHour >= 0 && *req.ReportHour <= 23 {
-			reportHour = *req.ReportHour
-		}
-		if req.ThresholdValue < 0 {
-			req.ThresholdValue = 0
-		}
+// updateSubscription applies the request body to subscription id, for its
+// owner or an admin, and reports whether it did.
+func updateSubscription(w http.ResponseWriter, r *http.Request, s store.SubscriptionStore, d *dedup.Deduplicator, id int64) bool {
+	var req struct {
+		ThresholdPct   float64 `json:"threshold_pct"`
+		WindowMinutes  int     `json:"window_minutes"`
+		Direction      string  `json:"direction"`
+		ReportHour     *int    `json:"report_hour"`
+		ThresholdValue float64 `json:"threshold_value"`
+		Coin           string  `json:"coin"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return false
+	}
 
-		sub, err := s.UpdateSubscription(r.Context(), id, req.ThresholdPct, req.WindowMinutes, req.Direction, reportHour, req.ThresholdValue, req.Coin)
-		if err != nil {
-			http.Error(w, `{"error":"failed to update subscription"}`, http.StatusInternalServerError)
-			return
-		}
+	// Out-of-range values were rejected by openapi.Validate; only
+	// omitted fields get defaults here.
+	if req.WindowMinutes == 0 {
+		req.WindowMinutes = 1
+	}
+	if req.Direction == "" {
+		req.Direction = "drop"
+	}
+	reportHour := 8
+	if req.ReportHour != nil {
+		reportHour = *req.ReportHour
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(sub)
+	sub, err := s.UpdateSubscription(r.Context(), id, req.ThresholdPct, req.WindowMinutes, req.Direction, reportHour, req.ThresholdValue, req.Coin)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, `{"error":"subscription not found"}`, http.StatusNotFound)
+		return false
 	}
+	if err != nil {
+		http.Error(w, `{"error":"failed to update subscription"}`, http.StatusInternalServerError)
+		return false
+	}
+
+	// New settings start with a clean slate for this subscription only
+	if d != nil {
+		d.ClearSubscription(r.Context(), id)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(sub)
+	return true
 }
 
-func Unsubscribe(s *store.Store, d *dedup.Deduplicator) http.HandlerFunc {
+func Unsubscribe(s store.SubscriptionStore, d *dedup.Deduplicator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		chatID, ok := callerChatID(w, r, 0)
+		if !ok {
+			return
+		}
 		idStr := chi.URLParam(r, "id")
 		id, err := strconv.ParseInt(idStr, 10, 64)
 		if err != nil {
 			http.Error(w, `{"error":"invalid subscription id"}`, http.StatusBadRequest)
 			return
 		}
-
-		// Get chat_id before deleting so we can clear dedup keys
-		chatID, _ := s.GetSubscriptionChatID(r.Context(), id)
+		if !ownsSubscription(r, s, chatID, id) {
+			http.Error(w, `{"error":"subscription not found"}`, http.StatusNotFound)
+			return
+		}
 
 		if err := s.Unsubscribe(r.Context(), id); err != nil {
 			http.Error(w, `{"error":"failed to unsubscribe"}`, http.StatusInternalServerError)
 			return
 		}
 
-		// Clear all dedup keys for this user so re-subscribing starts fresh
-		if chatID != 0 && d != nil {
-			d.ClearByPattern(r.Context(), fmt.Sprintf("*%d:*", chatID))
-			d.ClearByPattern(r.Context(), fmt.Sprintf("merkl:%d:*", chatID))
+		// Drop this subscription's dedup keys; the user's other
+		// subscriptions keep theirs
+		if d != nil {
+			d.ClearSubscription(r.Context(), id)
 		}
 
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// ownsSubscription reports whether subscription id belongs to chatID. Other
+// users' subscriptions are reported as not found rather than forbidden, so
+// IDs cannot be probed.
+func ownsSubscription(r *http.Request, s store.SubscriptionStore, chatID, id int64) bool {
+	owner, err := s.GetSubscriptionChatID(r.Context(), id)
+	return err == nil && owner == chatID
+}
@@ -1,12 +1,24 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/middleware"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
+// authed returns r as if middleware.Auth had resolved its session to chatID.
+func authed(r *http.Request, chatID int64) *http.Request {
+	return r.WithContext(middleware.WithChatID(r.Context(), chatID))
+}
+
 func TestSubscribeValidation(t *testing.T) {
 	// Subscribe requires a store, but we can test input validation
 	// that returns before hitting the store.
@@ -15,6 +27,7 @@ func TestSubscribeValidation(t *testing.T) {
 	tests := []struct {
 		name       string
 		body       string
+		noSession  bool
 		wantStatus int
 	}{
 		{
@@ -23,9 +36,10 @@ func TestSubscribeValidation(t *testing.T) {
 			wantStatus: http.StatusBadRequest,
 		},
 		{
-			name:       "missing tg_chat_id",
+			name:       "no session",
 			body:       `{"event_id": 1}`,
-			wantStatus: http.StatusBadRequest,
+			noSession:  true,
+			wantStatus: http.StatusUnauthorized,
 		},
 		{
 			name:       "missing event_id",
@@ -33,9 +47,9 @@ func TestSubscribeValidation(t *testing.T) {
 			wantStatus: http.StatusBadRequest,
 		},
 		{
-			name:       "zero tg_chat_id",
-			body:       `{"tg_chat_id": 0, "event_id": 1}`,
-			wantStatus: http.StatusBadRequest,
+			name:       "tg_chat_id of another user",
+			body:       `{"tg_chat_id": 456, "event_id": 1}`,
+			wantStatus: http.StatusForbidden,
 		},
 	}
 
@@ -43,6 +57,9 @@ func TestSubscribeValidation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", strings.NewReader(tt.body))
 			req.Header.Set("Content-Type", "application/json")
+			if !tt.noSession {
+				req = authed(req, 123)
+			}
 			rec := httptest.NewRecorder()
 
 			handler.ServeHTTP(rec, req)
@@ -54,22 +71,86 @@ func TestSubscribeValidation(t *testing.T) {
 	}
 }
 
-func TestListSubscriptionsMissingParam(t *testing.T) {
+func TestListSubscriptionsAuth(t *testing.T) {
 	handler := ListSubscriptions(nil)
 
-	// Missing tg_chat_id
+	// No session
 	req := httptest.NewRequest(http.MethodGet, "/api/subscriptions", nil)
 	rec := httptest.NewRecorder()
 	handler.ServeHTTP(rec, req)
-	if rec.Code != http.StatusBadRequest {
-		t.Errorf("missing param: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("no session: status = %d, want %d", rec.Code, http.StatusUnauthorized)
 	}
 
 	// Invalid tg_chat_id
 	req = httptest.NewRequest(http.MethodGet, "/api/subscriptions?tg_chat_id=abc", nil)
 	rec = httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	handler.ServeHTTP(rec, authed(req, 123))
 	if rec.Code != http.StatusBadRequest {
 		t.Errorf("invalid param: status = %d, want %d", rec.Code, http.StatusBadRequest)
 	}
+
+	// Another user's tg_chat_id
+	req = httptest.NewRequest(http.MethodGet, "/api/subscriptions?tg_chat_id=456", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, authed(req, 123))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("other user: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestSubscriptionByIDRequiresSession(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{
+		"update":      UpdateSubscription(nil, nil),
+		"unsubscribe": Unsubscribe(nil, nil),
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/1", strings.NewReader(`{}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestSubscriptionChangesClearOnlyTheirDedupKeys(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	if _, err := db.UpsertLoginUser(ctx, 123, "ada"); err != nil {
+		t.Fatal(err)
+	}
+	events, _ := db.ListEvents(ctx)
+	a, err := db.Subscribe(ctx, 123, events[0].ID, 10, 1, "drop", 8, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := db.Subscribe(ctx, 123, events[0].ID, 20, 1, "drop", 8, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dd := dedup.New(dedup.NewMemory(), nil)
+	keyA := dedup.SubscriptionKey(a.ID, "altura:tvl:drop")
+	keyB := dedup.SubscriptionKey(b.ID, "altura:tvl:drop")
+	r := chi.NewRouter()
+	r.Put("/api/subscriptions/{id}", UpdateSubscription(db, dd))
+	r.Delete("/api/subscriptions/{id}", Unsubscribe(db, dd))
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		dd.Record(ctx, keyA)
+		dd.Record(ctx, keyB)
+
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/subscriptions/%d", a.ID), strings.NewReader(`{"threshold_pct": 15}`))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, authed(req, 123))
+		if rec.Code >= 300 {
+			t.Fatalf("%s: status = %d", method, rec.Code)
+		}
+		if dd.AlreadySent(ctx, keyA, dedup.SeverityInfo) {
+			t.Errorf("%s: changed subscription's key should be cleared", method)
+		}
+		if !dd.AlreadySent(ctx, keyB, dedup.SeverityInfo) {
+			t.Errorf("%s: other subscription's key should be kept", method)
+		}
+	}
 }
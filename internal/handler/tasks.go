@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// Tasks runs work a handler starts but does not wait for, such as a
+// broadcast. Shutdown cancels the tasks' context and waits for them, so
+// the work is ended, and logged, rather than dropped with the process.
+type Tasks struct {
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+// NewTasks creates a task group whose tasks stop when ctx is done.
+func NewTasks(ctx context.Context) *Tasks {
+	return &Tasks{ctx: ctx}
+}
+
+// Go runs f in the background with the group's context.
+func (t *Tasks) Go(f func(ctx context.Context)) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		f(t.ctx)
+	}()
+}
+
+// Wait blocks until every task has returned.
+func (t *Tasks) Wait() {
+	t.wg.Wait()
+}
@@ -0,0 +1,126 @@
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+)
+
+// Lock is held by at most one replica at a time.
+type Lock interface {
+	// TryAcquire takes the lock, or renews it if this replica already
+	// holds it, and reports whether this replica is now the holder.
+	TryAcquire(ctx context.Context) (bool, error)
+	// Release gives the lock up so another replica can take it at once.
+	Release(ctx context.Context) error
+}
+
+// Always returns a Lock that is always held, for single-replica deployments.
+func Always() Lock { return always{} }
+
+type always struct{}
+
+func (always) TryAcquire(context.Context) (bool, error) { return true, nil }
+func (always) Release(context.Context) error            { return nil }
+
+// InstanceID names this replica: the hostname (the pod name on Kubernetes)
+// plus a random suffix, so restarts never reuse an identity.
+func InstanceID() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "instance"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
+
+// Elector campaigns for a Lock and runs the leader-only work while holding
+// it.
+type Elector struct {
+	lock     Lock
+	interval time.Duration
+	logger   *slog.Logger
+	leading  atomic.Bool
+}
+
+// New creates an Elector that tries to take or renew lock every interval.
+// For leases interval must be well under the lease TTL.
+func New(lock Lock, interval time.Duration, logger *slog.Logger) *Elector {
+	return &Elector{lock: lock, interval: interval, logger: logger}
+}
+
+// IsLeader reports whether this replica currently runs the leader work.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run campaigns until ctx is done. While this replica holds the lock, lead
+// runs with a context that is cancelled as soon as the lock is lost or
+// cannot be renewed, and Run waits for it to return. On shutdown the lock
+// is released so a standby takes over on its next attempt instead of
+// waiting for a lease to expire.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	metrics.LeaderIsLeader.Set(0)
+
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+	stepDown := func(reason string, err error) {
+		if cancel == nil {
+			return
+		}
+		cancel()
+		<-done
+		cancel = nil
+		e.leading.Store(false)
+		metrics.LeaderIsLeader.Set(0)
+		metrics.LeaderTransitionsTotal.WithLabelValues("lost").Inc()
+		e.logger.Warn("leadership lost", "reason", reason, "error", err)
+	}
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		held, err := e.lock.TryAcquire(ctx)
+		switch {
+		case ctx.Err() != nil:
+			// Shutting down; handled below.
+		case err != nil:
+			// Renewal state unknown: step down rather than risk two leaders.
+			stepDown("lock error", err)
+		case !held:
+			stepDown("lock held by another replica", nil)
+		case cancel == nil:
+			leadCtx, leadCancel := context.WithCancel(ctx)
+			cancel, done = leadCancel, make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				lead(leadCtx)
+			}(done)
+			e.leading.Store(true)
+			metrics.LeaderIsLeader.Set(1)
+			metrics.LeaderTransitionsTotal.WithLabelValues("acquired").Inc()
+			e.logger.Info("leadership acquired")
+		}
+
+		select {
+		case <-ctx.Done():
+			stepDown("shutting down", nil)
+			releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := e.lock.Release(releaseCtx); err != nil {
+				e.logger.Warn("release leader lock failed", "error", err)
+			}
+			releaseCancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeLock is held while held is true and fails while err is set.
+type fakeLock struct {
+	mu       sync.Mutex
+	held     bool
+	err      error
+	released bool
+}
+
+func (f *fakeLock) set(held bool, err error) {
+	f.mu.Lock()
+	f.held, f.err = held, err
+	f.mu.Unlock()
+}
+
+func (f *fakeLock) TryAcquire(context.Context) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.held, f.err
+}
+
+func (f *fakeLock) Release(context.Context) error {
+	f.mu.Lock()
+	f.released = true
+	f.mu.Unlock()
+	return nil
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestElectorRunsLeadOnlyWhileHoldingLock(t *testing.T) {
+	lock := &fakeLock{}
+	e := New(lock, 5*time.Millisecond, slog.New(slog.DiscardHandler))
+
+	var running atomic.Int32
+	var starts atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		e.Run(ctx, func(ctx context.Context) {
+			starts.Add(1)
+			running.Add(1)
+			<-ctx.Done()
+			running.Add(-1)
+		})
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if e.IsLeader() || running.Load() != 0 {
+		t.Fatal("follower must not run the leader work")
+	}
+
+	lock.set(true, nil)
+	waitFor(t, "leadership", func() bool { return e.IsLeader() && running.Load() == 1 })
+
+	lock.set(true, errors.New("redis down"))
+	waitFor(t, "step down on lock error", func() bool { return !e.IsLeader() && running.Load() == 0 })
+
+	lock.set(true, nil)
+	waitFor(t, "re-election", func() bool { return e.IsLeader() && running.Load() == 1 })
+
+	lock.set(false, nil)
+	waitFor(t, "step down on lost lock", func() bool { return !e.IsLeader() && running.Load() == 0 })
+
+	lock.set(true, nil)
+	waitFor(t, "re-election", func() bool { return e.IsLeader() })
+	cancel()
+	<-stopped
+	if running.Load() != 0 {
+		t.Error("leader work still running after shutdown")
+	}
+	if !lock.released {
+		t.Error("lock not released on shutdown")
+	}
+	if starts.Load() != 3 {
+		t.Errorf("leader work started %d times, want 3", starts.Load())
+	}
+}
+
+// testLock checks mutual exclusion and release between two instances.
+func testLock(t *testing.T, a, b Lock) {
+	ctx := context.Background()
+	if ok, err := a.TryAcquire(ctx); err != nil || !ok {
+		t.Fatalf("a.TryAcquire = %v, %v; want held", ok, err)
+	}
+	if ok, err := a.TryAcquire(ctx); err != nil || !ok {
+		t.Fatalf("a renew = %v, %v; want held", ok, err)
+	}
+	if ok, err := b.TryAcquire(ctx); err != nil || ok {
+		t.Fatalf("b.TryAcquire while a holds = %v, %v; want not held", ok, err)
+	}
+	if err := b.Release(ctx); err != nil {
+		t.Fatalf("b.Release: %v", err)
+	}
+	if ok, _ := a.TryAcquire(ctx); !ok {
+		t.Fatal("release by a non-holder must not free the lock")
+	}
+	if err := a.Release(ctx); err != nil {
+		t.Fatalf("a.Release: %v", err)
+	}
+	if ok, err := b.TryAcquire(ctx); err != nil || !ok {
+		t.Fatalf("b.TryAcquire after release = %v, %v; want held", ok, err)
+	}
+	if err := b.Release(ctx); err != nil {
+		t.Fatalf("b.Release: %v", err)
+	}
+}
+
+func TestRedisLock(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	a := NewRedis(rdb, "leader:test", "a", 15*time.Second)
+	b := NewRedis(rdb, "leader:test", "b", 15*time.Second)
+	testLock(t, a, b)
+
+	// A crashed leader's lease expires.
+	ctx := context.Background()
+	if ok, _ := a.TryAcquire(ctx); !ok {
+		t.Fatal("a should take the free lease")
+	}
+	mr.FastForward(16 * time.Second)
+	if ok, err := b.TryAcquire(ctx); err != nil || !ok {
+		t.Errorf("b.TryAcquire after lease expiry = %v, %v; want held", ok, err)
+	}
+}
+
+// TestPostgresLock runs against TEST_DATABASE_URL.
+func TestPostgresLock(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("pgxpool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	const id = DefaultLockID + 1 // keep clear of a running server
+	testLock(t, NewPostgres(pool, id), NewPostgres(pool, id))
+}
@@ -0,0 +1,76 @@
+package leader
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultLockID is the advisory lock key used for leader election.
+const DefaultLockID int64 = 0x6f6e6d6f6e // "onmon"
+
+// Postgres is a session-level advisory lock. It is held on a connection
+// taken out of the pool, so Postgres releases it as soon as the leader's
+// session ends.
+type Postgres struct {
+	pool   *pgxpool.Pool
+	lockID int64
+
+	mu   sync.Mutex
+	conn *pgx.Conn // non-nil while the lock is held
+}
+
+// NewPostgres creates an advisory lock on lockID.
+func NewPostgres(pool *pgxpool.Pool, lockID int64) *Postgres {
+	return &Postgres{pool: pool, lockID: lockID}
+}
+
+func (p *Postgres) TryAcquire(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn != nil {
+		// Still held as long as the session is alive.
+		if err := p.conn.Ping(ctx); err != nil {
+			p.drop()
+			return false, err
+		}
+		return true, nil
+	}
+
+	c, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	var held bool
+	if err := c.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, p.lockID).Scan(&held); err != nil {
+		c.Release()
+		return false, err
+	}
+	if !held {
+		c.Release()
+		return false, nil
+	}
+	// The lock belongs to this session: keep it out of the pool.
+	p.conn = c.Hijack()
+	return true, nil
+}
+
+func (p *Postgres) Release(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	_, err := p.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, p.lockID)
+	p.drop()
+	return err
+}
+
+// drop closes the lock's session, which also releases the lock.
+func (p *Postgres) drop() {
+	p.conn.Close(context.Background()) //nolint:errcheck // closing a dead session
+	p.conn = nil
+}
@@ -0,0 +1,52 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript renews the lease when this instance holds it and takes it
+// when nobody does.
+var acquireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the lease only if this instance still holds it.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Redis is a lease: a key holding the leader's ID that expires after ttl
+// unless renewed. A crashed leader is replaced within ttl.
+type Redis struct {
+	rdb *redis.Client
+	key string
+	id  string
+	ttl time.Duration
+}
+
+// NewRedis creates a lease on key for the instance id.
+func NewRedis(rdb *redis.Client, key, id string, ttl time.Duration) *Redis {
+	return &Redis{rdb: rdb, key: key, id: id, ttl: ttl}
+}
+
+func (r *Redis) TryAcquire(ctx context.Context) (bool, error) {
+	n, err := acquireScript.Run(ctx, r.rdb, []string{r.key}, r.id, r.ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+func (r *Redis) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, r.rdb, []string{r.key}, r.id).Err()
+}
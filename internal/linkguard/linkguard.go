@@ -0,0 +1,112 @@
+// Package linkguard throttles link code guessing: attempts are counted per
+// client IP and globally in Redis before the code is checked, successful
+// ones are given back, and either counter going over its limit locks
+// further attempts out for a while.
+package linkguard
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "linkguard:"
+
+// Limits configures the guard: at most PerIP and Global failed attempts
+// within Window; the next one locks attempts out for Lockout.
+type Limits struct {
+	PerIP   int
+	Global  int
+	Window  time.Duration
+	Lockout time.Duration
+}
+
+// DefaultLimits allows 5 failures per IP and 100 overall per 15 minutes.
+var DefaultLimits = Limits{PerIP: 5, Global: 100, Window: 15 * time.Minute, Lockout: 15 * time.Minute}
+
+// Guard tracks link attempts.
+type Guard struct {
+	rdb    *redis.Client
+	limits Limits
+}
+
+// New creates a Guard on an existing Redis client (the dedup connection).
+func New(rdb *redis.Client, limits Limits) *Guard {
+	return &Guard{rdb: rdb, limits: limits}
+}
+
+// reserve takes an attempt from the per-IP and global budgets in one step,
+// so parallel attempts cannot all pass a check before any is counted.
+// KEYS: IP lock, global lock, IP counter, global counter.
+// ARGV: per-IP limit, global limit, window (ms), lockout (ms); a limit of
+// 0 is unlimited. A counter going over its limit locks out, resets the
+// counter and gives the other counter its attempt back.
+// Returns {allowed, ms until allowed}.
+var reserve = redis.NewScript(`
+local wait = math.max(redis.call("PTTL", KEYS[1]), redis.call("PTTL", KEYS[2]))
+if wait > 0 then
+  return {0, wait}
+end
+local limits = {tonumber(ARGV[1]), tonumber(ARGV[2])}
+local counts = {}
+for i = 1, 2 do
+  if limits[i] > 0 then
+    counts[i] = redis.call("INCR", KEYS[i + 2])
+    if counts[i] == 1 then
+      redis.call("PEXPIRE", KEYS[i + 2], ARGV[3])
+    end
+  end
+end
+for i = 1, 2 do
+  if limits[i] > 0 and counts[i] > limits[i] then
+    redis.call("SET", KEYS[i], "1", "PX", ARGV[4])
+    redis.call("DEL", KEYS[i + 2])
+    local other = 3 - i
+    if counts[other] and tonumber(redis.call("GET", KEYS[other + 2]) or "0") > 0 then
+      redis.call("DECR", KEYS[other + 2])
+    end
+    return {0, tonumber(ARGV[4])}
+  end
+end
+return {1, 0}
+`)
+
+// refund gives back an attempt: it decrements each counter in KEYS that
+// is still above zero, never recreating one that expired or was reset.
+var refund = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+  if tonumber(redis.call("GET", key) or "0") > 0 then
+    redis.call("DECR", key)
+  end
+end
+return 0
+`)
+
+// Reserve counts an attempt from ip before its code is checked, and reports
+// whether it may go ahead, and if not, how long until it may. The attempt
+// stays counted as a failure unless Succeed or Refund gives it back. Like
+// dedup it fails closed: if Redis is unreachable nobody can link until it
+// is back.
+func (g *Guard) Reserve(ctx context.Context, ip string) (bool, time.Duration) {
+	res, err := reserve.Run(ctx, g.rdb,
+		[]string{keyPrefix + "lock:ip:" + ip, keyPrefix + "lock:global", keyPrefix + "fail:ip:" + ip, keyPrefix + "fail:global"},
+		g.limits.PerIP, g.limits.Global, g.limits.Window.Milliseconds(), g.limits.Lockout.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, time.Minute
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond
+}
+
+// Succeed clears ip's failure count after a successful link, and gives the
+// global budget its attempt back.
+func (g *Guard) Succeed(ctx context.Context, ip string) {
+	g.rdb.Del(ctx, keyPrefix+"fail:ip:"+ip)                     //nolint:errcheck
+	refund.Run(ctx, g.rdb, []string{keyPrefix + "fail:global"}) //nolint:errcheck
+}
+
+// Refund gives back a reserved attempt that could not be checked, such as
+// when the store is down, so outages do not count as guesses.
+func (g *Guard) Refund(ctx context.Context, ip string) {
+	refund.Run(ctx, g.rdb, []string{keyPrefix + "fail:ip:" + ip, keyPrefix + "fail:global"}) //nolint:errcheck
+}
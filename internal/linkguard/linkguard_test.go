@@ -0,0 +1,138 @@
+package linkguard
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func setupTestGuard(t *testing.T, limits Limits) (*Guard, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		rdb.Close()
+		mr.Close()
+	})
+	return New(rdb, limits), mr
+}
+
+func TestPerIPLockout(t *testing.T) {
+	g, mr := setupTestGuard(t, Limits{PerIP: 3, Global: 100, Window: time.Minute, Lockout: 10 * time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := g.Reserve(ctx, "1.2.3.4"); !ok {
+			t.Fatalf("attempt %d locked out before going over the limit", i+1)
+		}
+	}
+
+	ok, retry := g.Reserve(ctx, "1.2.3.4")
+	if ok {
+		t.Fatal("not locked out after going over the limit")
+	}
+	if retry <= 0 || retry > 10*time.Minute {
+		t.Errorf("retry = %v, want within lockout", retry)
+	}
+	if ok, _ := g.Reserve(ctx, "5.6.7.8"); !ok {
+		t.Error("other IP should not be locked out")
+	}
+
+	mr.FastForward(11 * time.Minute)
+	if ok, _ := g.Reserve(ctx, "1.2.3.4"); !ok {
+		t.Error("lockout should expire")
+	}
+}
+
+func TestParallelAttemptsStayWithinLimit(t *testing.T) {
+	g, _ := setupTestGuard(t, Limits{PerIP: 5, Global: 100, Window: time.Minute, Lockout: time.Minute})
+	ctx := context.Background()
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := g.Reserve(ctx, "1.2.3.4"); ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := allowed.Load(); n != 5 {
+		t.Errorf("%d parallel attempts allowed, want 5", n)
+	}
+}
+
+func TestGlobalLockout(t *testing.T) {
+	g, _ := setupTestGuard(t, Limits{PerIP: 100, Global: 3, Window: time.Minute, Lockout: time.Minute})
+	ctx := context.Background()
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"} {
+		g.Reserve(ctx, ip)
+	}
+	if ok, _ := g.Reserve(ctx, "10.0.0.99"); ok {
+		t.Error("distributed guessing should trigger the global lockout")
+	}
+}
+
+func TestWindowExpiresFailures(t *testing.T) {
+	g, mr := setupTestGuard(t, Limits{PerIP: 1, Global: 100, Window: time.Minute, Lockout: time.Minute})
+	ctx := context.Background()
+
+	g.Reserve(ctx, "1.2.3.4")
+	mr.FastForward(2 * time.Minute)
+	if ok, _ := g.Reserve(ctx, "1.2.3.4"); !ok {
+		t.Error("failures outside the window should not add up")
+	}
+}
+
+func TestSucceedResetsIP(t *testing.T) {
+	g, mr := setupTestGuard(t, Limits{PerIP: 1, Global: 100, Window: time.Minute, Lockout: time.Minute})
+	ctx := context.Background()
+
+	g.Reserve(ctx, "1.2.3.4")
+	g.Succeed(ctx, "1.2.3.4")
+	if ok, _ := g.Reserve(ctx, "1.2.3.4"); !ok {
+		t.Error("success should reset the IP's failure count")
+	}
+	if n, _ := mr.Get(keyPrefix + "fail:global"); n != "1" {
+		t.Errorf("global failures = %s, want only the unchecked attempt", n)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	g, mr := setupTestGuard(t, Limits{PerIP: 1, Global: 100, Window: time.Minute, Lockout: time.Minute})
+	ctx := context.Background()
+
+	g.Reserve(ctx, "1.2.3.4")
+	g.Refund(ctx, "1.2.3.4")
+	if ok, _ := g.Reserve(ctx, "1.2.3.4"); !ok {
+		t.Error("a refunded attempt should not count")
+	}
+
+	// A counter that expired is not recreated below zero
+	mr.FastForward(2 * time.Minute)
+	g.Refund(ctx, "1.2.3.4")
+	if mr.Exists(keyPrefix + "fail:ip:1.2.3.4") {
+		t.Error("refund recreated an expired counter")
+	}
+}
+
+func TestFailClosedWhenRedisDown(t *testing.T) {
+	g, mr := setupTestGuard(t, DefaultLimits)
+	mr.Close()
+
+	if ok, _ := g.Reserve(context.Background(), "1.2.3.4"); ok {
+		t.Error("Reserve should fail closed when Redis is unreachable")
+	}
+}
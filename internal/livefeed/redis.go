@@ -0,0 +1,52 @@
+// Package livefeed carries the leader's live state (see monitor.Feed) to
+// the other replicas over Redis pub/sub.
+package livefeed
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultChannel is the pub/sub channel replicas share.
+const DefaultChannel = "onchain-monitor:live"
+
+// Redis publishes to and subscribes on one Redis channel. Pub/sub keeps
+// nothing: a replica sees only what is published while it is subscribed.
+type Redis struct {
+	rdb     *redis.Client
+	channel string
+}
+
+// NewRedis uses rdb for the feed on channel. The caller owns and closes
+// the client.
+func NewRedis(rdb *redis.Client, channel string) *Redis {
+	return &Redis{rdb: rdb, channel: channel}
+}
+
+func (r *Redis) Publish(ctx context.Context, msg []byte) error {
+	return r.rdb.Publish(ctx, r.channel, msg).Err()
+}
+
+// Subscribe calls fn with each message until ctx is done. It returns an
+// error if the subscription cannot be set up; once it is, the client
+// reconnects by itself.
+func (r *Redis) Subscribe(ctx context.Context, fn func(msg []byte)) error {
+	sub := r.rdb.Subscribe(ctx, r.channel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			fn([]byte(m.Payload))
+		}
+	}
+}
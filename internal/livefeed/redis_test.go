@@ -0,0 +1,58 @@
+package livefeed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisFeed(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		rdb.Close()
+		mr.Close()
+	})
+	feed := NewRedis(rdb, DefaultChannel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- feed.Subscribe(ctx, func(msg []byte) {
+			select {
+			case got <- string(msg):
+			default:
+			}
+		})
+	}()
+
+	// Publish until the subscription is up: pub/sub keeps nothing
+	deadline := time.After(2 * time.Second)
+	for received := false; !received; {
+		if err := feed.Publish(ctx, []byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case msg := <-got:
+			if msg != "hello" {
+				t.Errorf("received %q, want hello", msg)
+			}
+			received = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no message received")
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Subscribe after cancel = %v, want nil", err)
+	}
+}
@@ -0,0 +1,58 @@
+package messages
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Catalog keys for short strings built in Go code (bot replies, labels).
+// The English text is the key itself; longer alert and report bodies live in
+// the per-language templates instead.
+const (
+	MsgUnknownCommand     = "Unknown command. Send /help for available commands."
+	MsgLinkCodeError      = "❌ Error generating link code. Please try again."
+	MsgWelcome            = "👋 Welcome to Onchain Monitor!\n\nYour link code: <code>%s</code>\n\nEnter this code on the monitoring dashboard to link your Telegram account and subscribe to alerts.\n\n⏰ This code expires in 10 minutes."
+	MsgHelp               = "🤖 <b>Onchain Monitor Bot</b>\n\nCommands:\n/start — Get a link code to connect your Telegram\n/status — Check your subscription status\n/lang — Change the language of alerts and reports (en, zh)\n/help — Show this message\n\nManage subscriptions on the web dashboard."
+	MsgNotLinked          = "You haven't linked your account yet. Send /start to get a link code."
+	MsgNotLinkedYet       = "Your account is registered but not linked yet. Send /start to get a new link code."
+	MsgSubsError          = "Error fetching subscriptions."
+	MsgNoSubscriptions    = "✅ Account linked!\n\nYou have no active subscriptions. Visit the dashboard to subscribe to alerts."
+	MsgLinkedHeader       = "✅ Account linked! (@%s)\n\n📋 Active subscriptions:\n"
+	MsgUnknownEvent       = "Unknown event"
+	MsgLangUsage          = "🌐 Current language: %s\n\nUsage: /lang en or /lang zh"
+	MsgLangSet            = "🌐 Alerts and reports will now be sent in English."
+	MsgLangError          = "❌ Could not save your language. Please try again."
+	SentimentExtremeFear  = "😱 Extreme Fear"
+	SentimentFear         = "😰 Fear"
+	SentimentNeutral      = "😐 Neutral"
+	SentimentGreed        = "😀 Greed"
+	SentimentExtremeGreed = "🤑 Extreme Greed"
+)
+
+func init() {
+	zh := map[string]string{
+		MsgUnknownCommand:     "未知命令。发送 /help 查看可用命令。",
+		MsgLinkCodeError:      "❌ 生成绑定码失败，请重试。",
+		MsgWelcome:            "👋 欢迎使用 Onchain Monitor！\n\n你的绑定码：<code>%s</code>\n\n在监控面板中输入此绑定码即可绑定 Telegram 账号并订阅提醒。\n\n⏰ 绑定码 10 分钟内有效。",
+		MsgHelp:               "🤖 <b>Onchain Monitor 机器人</b>\n\n命令：\n/start — 获取绑定码以连接 Telegram\n/status — 查看订阅状态\n/lang — 切换提醒和报告的语言（en、zh）\n/help — 显示此帮助\n\n请在网页面板中管理订阅。",
+		MsgNotLinked:          "你还没有绑定账号。发送 /start 获取绑定码。",
+		MsgNotLinkedYet:       "你的账号已注册但尚未绑定。发送 /start 获取新的绑定码。",
+		MsgSubsError:          "获取订阅失败。",
+		MsgNoSubscriptions:    "✅ 账号已绑定！\n\n你目前没有订阅。请前往面板订阅提醒。",
+		MsgLinkedHeader:       "✅ 账号已绑定！(@%s)\n\n📋 当前订阅：\n",
+		MsgUnknownEvent:       "未知事件",
+		MsgLangUsage:          "🌐 当前语言：%s\n\n用法：/lang en 或 /lang zh",
+		MsgLangSet:            "🌐 之后的提醒和报告将使用中文发送。",
+		MsgLangError:          "❌ 保存语言设置失败，请重试。",
+		SentimentExtremeFear:  "😱 极度恐惧",
+		SentimentFear:         "😰 恐惧",
+		SentimentNeutral:      "😐 中性",
+		SentimentGreed:        "😀 贪婪",
+		SentimentExtremeGreed: "🤑 极度贪婪",
+	}
+	for key, msg := range zh {
+		if err := message.SetString(language.Chinese, key, msg); err != nil {
+			panic(err)
+		}
+	}
+}
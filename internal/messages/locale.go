@@ -0,0 +1,161 @@
+package messages
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Supported message languages. Anything else falls back to English.
+const (
+	English = "en"
+	Chinese = "zh"
+)
+
+var (
+	languages = []string{English, Chinese}
+	matcher   = language.NewMatcher([]language.Tag{language.English, language.Chinese})
+)
+
+// Languages returns the supported language codes, English first.
+func Languages() []string {
+	return append([]string(nil), languages...)
+}
+
+// Normalize maps a user-supplied language ("zh-CN", "zh_Hant", "EN") to a
+// supported code. Unknown or empty input yields English.
+func Normalize(lang string) string {
+	tag, _ := language.MatchStrings(matcher, strings.ReplaceAll(lang, "_", "-"))
+	if base, _ := tag.Base(); base.String() == Chinese {
+		return Chinese
+	}
+	return English
+}
+
+// Supported reports whether lang names a supported language, ignoring region
+// and script subtags.
+func Supported(lang string) bool {
+	tag, err := language.Parse(strings.ReplaceAll(lang, "_", "-"))
+	if err != nil {
+		return false
+	}
+	base, _ := tag.Base()
+	for _, l := range languages {
+		if base.String() == l {
+			return true
+		}
+	}
+	return false
+}
+
+// unit is a large-number suffix such as "M" or "万".
+type unit struct {
+	value  float64
+	suffix string
+	small  bool // only used by the compact styles (tvl, amount), not usd
+}
+
+// Locale formats numbers, dates and catalog strings for one language.
+type Locale struct {
+	lang    string
+	printer *message.Printer
+	units   []unit
+	date    string
+}
+
+var locales = map[string]*Locale{
+	English: {
+		lang:    English,
+		printer: message.NewPrinter(language.English),
+		units:   []unit{{1_000_000, "M", false}, {1_000, "K", true}},
+		date:    "2006-01-02",
+	},
+	Chinese: {
+		lang:    Chinese,
+		printer: message.NewPrinter(language.Chinese),
+		// Chinese groups large numbers by 10^4: 万 (ten thousand) and 亿 (10^8).
+		units: []unit{{100_000_000, "亿", false}, {10_000, "万", false}},
+		date:  "2006年1月2日",
+	},
+}
+
+// LocaleFor returns the Locale for lang, normalizing it first.
+func LocaleFor(lang string) *Locale {
+	return locales[Normalize(lang)]
+}
+
+// Lang returns the locale's language code.
+func (l *Locale) Lang() string { return l.lang }
+
+// T translates a catalog key (see catalog.go), formatting args into it.
+// Keys without a translation are formatted as-is.
+func (l *Locale) T(key string, args ...any) string {
+	return l.printer.Sprintf(key, args...)
+}
+
+// USD formats a price or metric value: large values get a unit suffix with two
+// decimals, values from 1,000 are digit-grouped, smaller ones keep four
+// decimals. English: 1.50M, 97,000.00, 0.1234.
+func (l *Locale) USD(v float64) string {
+	for _, u := range l.units {
+		if !u.small && v >= u.value {
+			return l.printer.Sprintf("%.2f%s", v/u.value, u.suffix)
+		}
+	}
+	if v >= 1_000 {
+		return l.printer.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%.4f", v)
+}
+
+// Price formats an exchange price: digit-grouped with two decimals from 1,000
+// up, four decimals below.
+func (l *Locale) Price(v float64) string {
+	if v >= 1_000 {
+		return l.printer.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%.4f", v)
+}
+
+// TVL formats a pool or vault TVL compactly. English: 12.3M, 450K, 900.
+func (l *Locale) TVL(v float64) string {
+	for _, u := range l.units {
+		if v >= u.value {
+			if u.small {
+				return fmt.Sprintf("%.0f%s", v/u.value, u.suffix)
+			}
+			return l.printer.Sprintf("%.1f%s", v/u.value, u.suffix)
+		}
+	}
+	return l.printer.Sprintf("%.0f", v)
+}
+
+// Amount formats a protocol-level amount with two decimals and a unit
+// suffix. English: 12.35M, 4.50K, 12.00.
+func (l *Locale) Amount(v float64) string {
+	for _, u := range l.units {
+		if v >= u.value {
+			return l.printer.Sprintf("%.2f%s", v/u.value, u.suffix)
+		}
+	}
+	return l.printer.Sprintf("%.2f", v)
+}
+
+// Date formats a calendar date in the locale's customary order.
+func (l *Locale) Date(t time.Time) string {
+	return t.Format(l.date)
+}
+
+func (l *Locale) funcs() map[string]any {
+	return map[string]any{
+		"usd":    l.USD,
+		"price":  l.Price,
+		"tvl":    l.TVL,
+		"amount": l.Amount,
+		"date":   l.Date,
+		"t":      func(key string) string { return l.T(key) },
+	}
+}
@@ -0,0 +1,162 @@
+package messages
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"en", English},
+		{"EN", English},
+		{"en-US", English},
+		{"zh", Chinese},
+		{"zh-CN", Chinese},
+		{"zh_TW", Chinese},
+		{"zh-Hans", Chinese},
+		{"fr", English},
+		{"", English},
+		{"not a language", English},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.input); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for _, lang := range []string{"en", "zh", "zh-CN", "en_GB"} {
+		if !Supported(lang) {
+			t.Errorf("Supported(%q) = false, want true", lang)
+		}
+	}
+	for _, lang := range []string{"", "fr", "ja", "xx-invalid-!"} {
+		if Supported(lang) {
+			t.Errorf("Supported(%q) = true, want false", lang)
+		}
+	}
+}
+
+func TestLocaleUSD(t *testing.T) {
+	tests := []struct {
+		lang  string
+		input float64
+		want  string
+	}{
+		{English, 0.12345, "0.1235"},
+		{English, 999.5, "999.5000"},
+		{English, 1000, "1,000.00"},
+		{English, 97000.456, "97,000.46"},
+		{English, 1_500_000, "1.50M"},
+		{Chinese, 999.5, "999.5000"},
+		{Chinese, 9_999, "9,999.00"},
+		{Chinese, 12_345, "1.23万"},
+		{Chinese, 1_500_000, "150.00万"},
+		{Chinese, 123_456_789, "1.23亿"},
+	}
+	for _, tt := range tests {
+		if got := LocaleFor(tt.lang).USD(tt.input); got != tt.want {
+			t.Errorf("%s USD(%v) = %q, want %q", tt.lang, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLocalePrice(t *testing.T) {
+	tests := []struct {
+		input float64
+		want  string
+	}{
+		{0.0045, "0.0045"},
+		{0.5, "0.5000"},
+		{999.99, "999.9900"},
+		{1000, "1,000.00"},
+		{12345.67, "12,345.67"},
+		{95432.10, "95,432.10"},
+		{100000.00, "100,000.00"},
+	}
+	for _, lang := range Languages() {
+		for _, tt := range tests {
+			if got := LocaleFor(lang).Price(tt.input); got != tt.want {
+				t.Errorf("%s Price(%v) = %q, want %q", lang, tt.input, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestLocaleTVL(t *testing.T) {
+	tests := []struct {
+		lang  string
+		input float64
+		want  string
+	}{
+		{English, 500, "500"},
+		{English, 1500, "2K"},
+		{English, 50000, "50K"},
+		{English, 1000000, "1.0M"},
+		{English, 2500000, "2.5M"},
+		{English, 123456789, "123.5M"},
+		{Chinese, 500, "500"},
+		{Chinese, 1500, "1,500"},
+		{Chinese, 50000, "5.0万"},
+		{Chinese, 2500000, "250.0万"},
+		{Chinese, 123456789, "1.2亿"},
+	}
+	for _, tt := range tests {
+		if got := LocaleFor(tt.lang).TVL(tt.input); got != tt.want {
+			t.Errorf("%s TVL(%v) = %q, want %q", tt.lang, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleAmount(t *testing.T) {
+	tests := []struct {
+		lang  string
+		input float64
+		want  string
+	}{
+		{English, 12, "12.00"},
+		{English, 4500, "4.50K"},
+		{English, 12_345_678, "12.35M"},
+		{Chinese, 4500, "4,500.00"},
+		{Chinese, 45_000, "4.50万"},
+		{Chinese, 1_234_567_890, "12.35亿"},
+	}
+	for _, tt := range tests {
+		if got := LocaleFor(tt.lang).Amount(tt.input); got != tt.want {
+			t.Errorf("%s Amount(%v) = %q, want %q", tt.lang, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleDate(t *testing.T) {
+	d := time.Date(2025, 3, 7, 23, 0, 0, 0, time.UTC)
+	if got := LocaleFor(English).Date(d); got != "2025-03-07" {
+		t.Errorf("English Date = %q", got)
+	}
+	if got := LocaleFor(Chinese).Date(d); got != "2025年3月7日" {
+		t.Errorf("Chinese Date = %q", got)
+	}
+}
+
+func TestLocaleCatalog(t *testing.T) {
+	if got := LocaleFor(English).T(MsgLinkedHeader, "alice"); got != "✅ Account linked! (@alice)\n\n📋 Active subscriptions:\n" {
+		t.Errorf("English T = %q", got)
+	}
+	if got := LocaleFor(Chinese).T(MsgLinkedHeader, "alice"); got != "✅ 账号已绑定！(@alice)\n\n📋 当前订阅：\n" {
+		t.Errorf("Chinese T = %q", got)
+	}
+	// Every catalog key used by the bot has a Chinese translation.
+	for _, key := range []string{
+		MsgUnknownCommand, MsgLinkCodeError, MsgWelcome, MsgHelp, MsgNotLinked, MsgNotLinkedYet,
+		MsgSubsError, MsgNoSubscriptions, MsgLinkedHeader, MsgUnknownEvent, MsgLangUsage, MsgLangSet, MsgLangError,
+		SentimentExtremeFear, SentimentFear, SentimentNeutral, SentimentGreed, SentimentExtremeGreed,
+	} {
+		if LocaleFor(Chinese).T(key, "x") == LocaleFor(English).T(key, "x") {
+			t.Errorf("no Chinese translation for %q", key)
+		}
+	}
+}
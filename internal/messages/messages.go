@@ -0,0 +1,142 @@
+// Package messages renders Telegram alert and report text from templates.
+//
+// Messages are sent with parse_mode HTML, so templates are parsed with
+// html/template: every interpolated value (opportunity names, protocol names,
+// tokens from upstream APIs) is escaped automatically and a stray "<" or "&"
+// can no longer make Telegram reject a whole grouped alert.
+//
+// Each supported language has its own template set under templates/<lang>/.
+// Numbers and dates are formatted by locale-aware template functions (usd,
+// price, tvl, amount, date), and short strings built in Go code come from the
+// x/text catalog in catalog.go. A template missing from a language's set falls
+// back to English.
+//
+// The default templates are embedded in the binary. A deployment can override
+// any of them by placing a file with the same name (e.g. "merkl_alert.tmpl")
+// in <dir>/<lang>/ of the directory passed to New; files directly in <dir>
+// override the English set.
+package messages
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+var funcs = template.FuncMap{
+	"inc":   func(i int) int { return i + 1 },
+	"upper": strings.ToUpper,
+}
+
+// Renderer executes named message templates in every supported language.
+type Renderer struct {
+	sets map[string]*template.Template
+}
+
+// New parses the embedded default templates for every language, replacing
+// any of them with a same-named *.tmpl file found in overrideDir. An empty
+// overrideDir uses the defaults only.
+func New(overrideDir string) (*Renderer, error) {
+	r := &Renderer{sets: make(map[string]*template.Template)}
+	for _, lang := range languages {
+		texts := make(map[string]string)
+
+		defaults, err := fs.Glob(defaultTemplates, "templates/"+lang+"/*.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range defaults {
+			b, err := defaultTemplates.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read default template %s: %w", path, err)
+			}
+			texts[templateName(path)] = string(b)
+		}
+
+		if overrideDir != "" {
+			patterns := []string{filepath.Join(overrideDir, lang, "*.tmpl")}
+			if lang == English {
+				// Files directly in the directory are English overrides;
+				// <dir>/en/ ones are read after them and win.
+				patterns = append([]string{filepath.Join(overrideDir, "*.tmpl")}, patterns...)
+			}
+			for _, pattern := range patterns {
+				overrides, err := filepath.Glob(pattern)
+				if err != nil {
+					return nil, err
+				}
+				for _, path := range overrides {
+					b, err := os.ReadFile(path)
+					if err != nil {
+						return nil, fmt.Errorf("read template override %s: %w", path, err)
+					}
+					texts[templateName(path)] = string(b)
+				}
+			}
+		}
+
+		root := template.New("").Funcs(funcs).Funcs(locales[lang].funcs())
+		for name, text := range texts {
+			if _, err := root.New(name).Parse(text); err != nil {
+				return nil, fmt.Errorf("parse template %s/%s: %w", lang, name, err)
+			}
+		}
+		r.sets[lang] = root
+	}
+	return r, nil
+}
+
+// Render executes the named template (file name without ".tmpl") in lang
+// with data, falling back to English when lang has no such template.
+// Trailing newlines are trimmed so template files can end with one.
+func (r *Renderer) Render(lang, name string, data any) (string, error) {
+	lang = Normalize(lang)
+	set := r.sets[lang]
+	if set.Lookup(name) == nil {
+		lang, set = English, r.sets[English]
+	}
+	var b bytes.Buffer
+	if err := set.ExecuteTemplate(&b, name, data); err != nil {
+		return "", fmt.Errorf("render %s/%s: %w", lang, name, err)
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+func templateName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".tmpl")
+}
+
+var defaultRenderer atomic.Pointer[Renderer]
+
+func init() {
+	r, err := New("")
+	if err != nil {
+		panic(err) // embedded templates are covered by tests
+	}
+	defaultRenderer.Store(r)
+}
+
+// Default returns the process-wide renderer used by the engine and sources.
+func Default() *Renderer { return defaultRenderer.Load() }
+
+// SetDefault replaces the process-wide renderer (e.g. after loading overrides).
+func SetDefault(r *Renderer) { defaultRenderer.Store(r) }
+
+// Render executes a template with the default renderer.
+func Render(lang, name string, data any) (string, error) {
+	return Default().Render(lang, name, data)
+}
+
+// Escape escapes s for use in hand-built HTML message text.
+func Escape(s string) string {
+	return template.HTMLEscapeString(s)
+}
@@ -0,0 +1,382 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var reportDate = time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)
+
+// fixtures holds representative data for every default template, shaped the
+// way the engine and sources build it.
+var fixtures = map[string]map[string]any{
+	"metric_alert": {
+		"Source": "altura", "Metric": "tvl", "Direction": "drop", "ChangePct": 12.5, "Window": 5,
+		"Previous": 1_000_000.0, "Current": 875_000.0, "Diff": 125_000.0, "URL": "https://app.altura.trade",
+	},
+	"value_alert": {
+		"Source": "feargreed", "Metric": "fear_greed_index", "Direction": "lower",
+		"Threshold": 20.0, "Current": 15.0, "URL": "https://alternative.me",
+	},
+	"maxpain_alert": {
+		"Coin": "BTC", "Side": "long", "Interval": "24h", "Price": 97_000.0, "MaxPain": 95_000.0,
+		"Distance": 2.1, "URL": "https://www.coinglass.com",
+	},
+	"merkl_alert": {"Opps": []map[string]any{{
+		"Name": "USDC Lending", "Stablecoin": true, "APR": 8.4, "TVL": 12_300_000.0,
+		"Chain": "Ethereum", "Action": "LEND", "Protocol": "Aave", "URL": "https://app.merkl.xyz/x",
+	}}},
+	"turtle_alert": {"Opps": []map[string]any{{
+		"Name": "USDT Vault", "Stablecoin": true, "APR": 9.0, "TVL": 5_000_000.0, "Chain": "Ethereum",
+		"Type": "vault", "Organization": "Turtle", "URL": "https://app.turtle.xyz/x",
+		"Incentives": []map[string]any{{"Name": "Base", "Yield": 6.0}, {"Name": "Points", "Yield": 3.0}},
+	}}},
+	"binance_price_alert": {"Coin": "BTC", "Direction": "increase", "Price": 100_000.0, "Target": 99_000.0},
+	"defillama_alert": {
+		"Pools": []map[string]any{{
+			"Project": "Aave V3", "Symbol": "USDC", "Chain": "Ethereum", "APY": 5.5, "HasBreakdown": true,
+			"Base": 4.0, "Reward": 1.5, "TVL": 100_000_000.0, "WithdrawalDays": 0, "URL": "https://defillama.com/yields/pool/1",
+		}},
+		"MinAPY": 5.0, "MinTVL": 10.0, "MaxDays": 7,
+	},
+	"defillama_lp_alert": {
+		"Pools": []map[string]any{{
+			"Project": "Uniswap V3", "Symbol": "USDC-WETH", "Chain": "Base", "APY": 30.0,
+			"Base": 10.0, "Reward": 20.0, "TVL": 2_000_000.0, "URL": "https://defillama.com/yields/pool/2",
+		}},
+		"MinRewardAPY": 5.0, "MinTVL": 1.0, "Chain": "ALL",
+	},
+	"defillama_tvl_alert": {
+		"Slug": "aave", "Direction": "drop", "Period": "1d", "AbsChange": 12.3, "Change": -12.3, "Threshold": 10.0,
+	},
+	"alpha_alert": {"Token": "ABC", "Date": "2025-01-01", "Time": "12:00", "Points": 200, "Name": "Alpha ABC"},
+	"altura_report": {
+		"Date": reportDate, "TVL": 10_000_000.0, "Price": 1.0123, "APR": 12.34,
+		"History": []map[string]any{{"Label": "1d", "TVL": 9_000_000.0, "Change": 11.1}},
+	},
+	"neverland_report": {
+		"Date": reportDate, "TVL": 10_000_000.0, "VeDUST": 1_000_000.0, "Price": 0.5,
+		"Fees24h": 1_000.0, "Fees7d": 7_000.0, "Fees30d": 30_000.0,
+		"History": []map[string]any{{"Label": "7d", "TVL": 11_000_000.0, "Change": -9.1}},
+	},
+	"feargreed_report": {"Date": reportDate, "Value": 25.0, "Sentiment": SentimentExtremeFear},
+	"binance_report":   {"Date": reportDate, "Price": 100_000.0},
+	"maxpain_report": {"Entries": []map[string]any{{
+		"Symbol": "BTC", "Price": 97_000.0, "Long": 95_000.0, "Short": 99_000.0, "LongDist": -2.1, "ShortDist": -2.1,
+	}}},
+	"merkl_report": {
+		"Opps": []map[string]any{{
+			"Name": "USDC Lending", "Stablecoin": true, "APR": 8.4, "TVL": 12_300_000.0,
+			"Chain": "Ethereum", "Action": "LEND", "DepositURL": "https://app.aave.com",
+		}},
+		"Total": 42,
+	},
+	"turtle_report": {
+		"Opps": []map[string]any{{
+			"Name": "USDT Vault", "Stablecoin": false, "Yield": 9.0, "TVL": 5_000_000.0, "Chain": "Ethereum",
+			"Type": "vault", "Protocol": "Turtle", "Token": "USDT", "Incentives": []map[string]any{},
+		}},
+		"Total": 7,
+	},
+	"defillama_report": {
+		"Pools": []map[string]any{{
+			"Project": "Venus", "Symbol": "USDT", "Chain": "BSC", "APY": 6.0, "HasBreakdown": false,
+			"TVL": 50_000_000.0, "WithdrawalDays": 7, "URL": "https://defillama.com/yields/pool/3",
+		}},
+		"Total": 1,
+	},
+	"defillama_lp_report": {
+		"Pools": []map[string]any{{
+			"Project": "Uniswap V3", "Symbol": "USDC-WETH", "Chain": "Base", "APY": 30.0,
+			"Base": 10.0, "Reward": 20.0, "TVL": 2_000_000.0, "URL": "https://defillama.com/yields/pool/2",
+		}},
+		"Total": 1,
+	},
+	"alpha_report": {"Airdrops": []map[string]any{{"Token": "ABC", "Date": "2025-01-01", "Time": "12:00", "Points": 200}}},
+}
+
+func TestDefaultTemplatesRender(t *testing.T) {
+	r, err := New("")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, lang := range Languages() {
+		paths, err := filepath.Glob(filepath.Join("templates", lang, "*.tmpl"))
+		if err != nil || len(paths) == 0 {
+			t.Fatalf("no %s templates found: %v", lang, err)
+		}
+		if len(paths) != len(fixtures) {
+			t.Errorf("%s has %d templates, want %d (one per fixture)", lang, len(paths), len(fixtures))
+		}
+		for _, path := range paths {
+			name := templateName(path)
+			data, ok := fixtures[name]
+			if !ok {
+				t.Errorf("no fixture for template %s/%s", lang, name)
+				continue
+			}
+			msg, err := r.Render(lang, name, data)
+			if err != nil {
+				t.Errorf("Render(%s, %s): %v", lang, name, err)
+				continue
+			}
+			if msg == "" || strings.HasSuffix(msg, "\n") {
+				t.Errorf("Render(%s, %s) = %q, want non-empty without trailing newline", lang, name, msg)
+			}
+		}
+	}
+}
+
+func TestRenderExactOutput(t *testing.T) {
+	tests := []struct {
+		lang string
+		name string
+		want string
+	}{
+		{English, "metric_alert", "🚨 ALTURA TVL DROP ALERT\n\n" +
+			"TVL dropped by 12.5% in the last 5 minute(s)!\n" +
+			"Previous: $1.00M\n" +
+			"Current:  $875,000.00\n" +
+			"Change:   -$125,000.00\n\n" +
+			"🔗 https://app.altura.trade"},
+		{English, "value_alert", "🚨 FEARGREED FEAR_GREED_INDEX BELOW THRESHOLD\n\n" +
+			"FEAR_GREED_INDEX is now &lt; BELOW 20!\n" +
+			"Current: 15\n" +
+			"Threshold: 20\n\n" +
+			"🔗 https://alternative.me"},
+		{English, "feargreed_report", "📊 CRYPTO FEAR &amp; GREED INDEX — 2025-01-02\n\n" +
+			"Index: 25 / 100\n" +
+			"Sentiment: 😱 Extreme Fear\n\n" +
+			"🔗 https://alternative.me/crypto/fear-and-greed-index/"},
+		{Chinese, "metric_alert", "🚨 ALTURA TVL 下跌提醒\n\n" +
+			"TVL 在过去 5 分钟内下跌了 12.5%！\n" +
+			"之前：$100.00万\n" +
+			"当前：$87.50万\n" +
+			"变化：-$12.50万\n\n" +
+			"🔗 https://app.altura.trade"},
+		{Chinese, "feargreed_report", "📊 加密货币恐惧与贪婪指数 — 2025年1月2日\n\n" +
+			"指数：25 / 100\n" +
+			"情绪：😱 极度恐惧\n\n" +
+			"🔗 https://alternative.me/crypto/fear-and-greed-index/"},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.lang, tt.name, fixtures[tt.name])
+		if err != nil {
+			t.Fatalf("Render(%s, %s): %v", tt.lang, tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("Render(%s, %s) =\n%q\nwant\n%q", tt.lang, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderFallsBackToEnglish(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "custom.tmpl"), []byte("hello {{.Name}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, lang := range []string{Chinese, "fr", ""} {
+		got, err := r.Render(lang, "custom", map[string]any{"Name": "x"})
+		if err != nil || got != "hello x" {
+			t.Errorf("Render(%q, custom) = %q, %v; want English fallback", lang, got, err)
+		}
+	}
+}
+
+func TestRenderEscapesUpstreamValues(t *testing.T) {
+	r, err := New("")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	msg, err := r.Render(English, "merkl_alert", map[string]any{
+		"Opps": []map[string]any{{
+			"Name":     "USDC <> USDT & Co",
+			"APR":      12.5,
+			"TVL":      1_200_000.0,
+			"Chain":    "Ethereum",
+			"Action":   "LEND",
+			"Protocol": "Aave",
+			"URL":      "https://app.merkl.xyz/?a=1&b=2",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(msg, "USDC &lt;&gt; USDT &amp; Co") {
+		t.Errorf("name not escaped:\n%s", msg)
+	}
+	if !strings.Contains(msg, "?a=1&amp;b=2") {
+		t.Errorf("url not escaped:\n%s", msg)
+	}
+	if !strings.Contains(msg, "APR: 12.5%") {
+		t.Errorf("expected APR line:\n%s", msg)
+	}
+}
+
+func TestRenderGroupedHeader(t *testing.T) {
+	opp := map[string]any{"Name": "A", "TVL": 1.0, "URL": "u"}
+
+	single, err := Render(English, "merkl_alert", map[string]any{"Opps": []map[string]any{opp}})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.HasPrefix(single, "💰 New Yield Opportunity\n") {
+		t.Errorf("single header wrong:\n%s", single)
+	}
+
+	multi, err := Render(English, "merkl_alert", map[string]any{"Opps": []map[string]any{opp, opp}})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.HasPrefix(multi, "💰 2 New Yield Opportunities\n") {
+		t.Errorf("multi header wrong:\n%s", multi)
+	}
+	if !strings.Contains(multi, "\n2. A") {
+		t.Errorf("expected numbered second entry:\n%s", multi)
+	}
+}
+
+func TestOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "binance_report.tmpl"), []byte("BTC is {{price .Price}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, Chinese), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, Chinese, "binance_report.tmpl"), []byte("BTC 价格 {{usd .Price}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	data := map[string]any{"Price": 100_000.0}
+	if msg, err := r.Render(English, "binance_report", data); err != nil || msg != "BTC is 100,000.00" {
+		t.Errorf("English override not used, got %q, %v", msg, err)
+	}
+	if msg, err := r.Render(Chinese, "binance_report", data); err != nil || msg != "BTC 价格 10.00万" {
+		t.Errorf("Chinese override not used, got %q, %v", msg, err)
+	}
+
+	// Templates that were not overridden still come from the defaults.
+	if _, err := r.Render(English, "feargreed_report", fixtures["feargreed_report"]); err != nil {
+		t.Errorf("default template missing after override: %v", err)
+	}
+}
+
+func TestOverrideDirInvalidTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "alpha_alert.tmpl"), []byte("{{.Token"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(dir); err == nil {
+		t.Error("expected parse error for broken override")
+	}
+}
+
+func TestSplitShortMessage(t *testing.T) {
+	chunks := Split("hello", MaxLength)
+	if len(chunks) != 1 || chunks[0] != "hello" {
+		t.Errorf("Split short = %q", chunks)
+	}
+}
+
+func TestSplitOnBlocks(t *testing.T) {
+	block := strings.Repeat("x", 40)
+	text := strings.Join([]string{block, block, block}, "\n\n")
+
+	chunks := Split(text, 90)
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2: %q", len(chunks), chunks)
+	}
+	if chunks[0] != block+"\n\n"+block || chunks[1] != block {
+		t.Errorf("unexpected chunks: %q", chunks)
+	}
+}
+
+func TestSplitLongLineKeepsEntities(t *testing.T) {
+	line := strings.Repeat("a", 8) + "&amp;" + strings.Repeat("b", 8)
+
+	chunks := Split(line, 10)
+	if strings.Join(chunks, "") != line {
+		t.Fatalf("chunks lost text: %q", chunks)
+	}
+	for _, c := range chunks {
+		if utf8.RuneCountInString(c) > 10 {
+			t.Errorf("chunk %q exceeds limit", c)
+		}
+		if i := strings.LastIndexByte(c, '&'); i >= 0 && !strings.Contains(c[i:], ";") {
+			t.Errorf("chunk %q ends inside an entity", c)
+		}
+	}
+}
+
+func TestSplitCountsRunes(t *testing.T) {
+	text := strings.Repeat("🟢", 10)
+	if chunks := Split(text, 10); len(chunks) != 1 {
+		t.Errorf("emoji text split by bytes: %q", chunks)
+	}
+}
+
+func TestSplitReopensTags(t *testing.T) {
+	line := `<b>` + strings.Repeat("a", 30) + `</b> <a href="https://x.io">` + strings.Repeat("b", 30) + `</a>`
+
+	chunks := Split(line, 45)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want a split: %q", len(chunks), chunks)
+	}
+	var text strings.Builder
+	for _, c := range chunks {
+		if utf8.RuneCountInString(c) > 45 {
+			t.Errorf("chunk %q exceeds limit", c)
+		}
+		if strings.Count(c, "<b>") != strings.Count(c, "</b>") || strings.Count(c, "<a ") != strings.Count(c, "</a>") {
+			t.Errorf("chunk %q leaves a tag open", c)
+		}
+		if strings.Contains(stripTags(c), "b") && !strings.Contains(c, `<a href="https://x.io">`) {
+			t.Errorf("chunk %q lost its link", c)
+		}
+		text.WriteString(stripTags(c))
+	}
+	if want := strings.Repeat("a", 30) + " " + strings.Repeat("b", 30); text.String() != want {
+		t.Errorf("chunks lost text: %q", chunks)
+	}
+}
+
+func TestSplitReopensTagsAcrossLines(t *testing.T) {
+	text := "<pre>" + strings.Repeat(strings.Repeat("x", 20)+"\n", 4) + "</pre>"
+
+	for _, c := range Split(text, 50) {
+		if !strings.HasPrefix(c, "<pre>") || !strings.HasSuffix(c, "</pre>") {
+			t.Errorf("chunk %q is not wrapped in <pre>", c)
+		}
+	}
+}
+
+func stripTags(s string) string {
+	var b strings.Builder
+	in := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			in = true
+		case r == '>':
+			in = false
+		case !in:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package messages
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLength is Telegram's limit on the text of a single message.
+const MaxLength = 4096
+
+// MaxCaptionLength is Telegram's limit on a photo caption.
+const MaxCaptionLength = 1024
+
+// Split breaks text into chunks of at most limit characters. It prefers to
+// cut between blank-line separated blocks (one opportunity per block in
+// grouped alerts), then between lines, and only cuts inside a line as a last
+// resort, never in the middle of an HTML tag or entity. Tags open at a cut
+// are closed at the end of the chunk and reopened at the start of the next,
+// so every chunk is valid HTML on its own.
+func Split(text string, limit int) []string {
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+	// Closing and reopening tags takes room, so shrink the budget for
+	// the text until every chunk fits with its tags
+	budget := limit
+	for {
+		chunks := balance(split(text, budget))
+		over := 0
+		for _, c := range chunks {
+			over = max(over, utf8.RuneCountInString(c)-limit)
+		}
+		if over == 0 || budget-over < 1 {
+			return chunks
+		}
+		budget -= over
+	}
+}
+
+func split(text string, limit int) []string {
+	return pack(strings.Split(text, "\n\n"), "\n\n", limit, func(block string) []string {
+		return pack(strings.Split(block, "\n"), "\n", limit, func(line string) []string {
+			return cut(line, limit)
+		})
+	})
+}
+
+// balance closes the tags each chunk leaves open and reopens them, with
+// their attributes, at the start of the next chunk.
+func balance(chunks []string) []string {
+	type tag struct{ name, open string }
+	var open []tag
+	out := make([]string, len(chunks))
+	for i, c := range chunks {
+		var b strings.Builder
+		for _, t := range open {
+			b.WriteString(t.open)
+		}
+		b.WriteString(c)
+		for rest := c; ; {
+			start := strings.IndexByte(rest, '<')
+			if start < 0 {
+				break
+			}
+			end := strings.IndexByte(rest[start:], '>')
+			if end < 0 {
+				break
+			}
+			raw := rest[start : start+end+1]
+			rest = rest[start+end+1:]
+			name, closing := tagName(raw)
+			if !closing {
+				open = append(open, tag{name, raw})
+				continue
+			}
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j].name == name {
+					open = open[:j]
+					break
+				}
+			}
+		}
+		for j := len(open) - 1; j >= 0; j-- {
+			b.WriteString("</" + open[j].name + ">")
+		}
+		out[i] = b.String()
+	}
+	return out
+}
+
+// tagName returns the name of an HTML tag such as <a href="..."> or </b>.
+func tagName(raw string) (name string, closing bool) {
+	name = strings.TrimSuffix(strings.TrimPrefix(raw, "<"), ">")
+	if closing = strings.HasPrefix(name, "/"); closing {
+		name = name[1:]
+	}
+	if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ToLower(name), closing
+}
+
+// pack greedily joins parts with sep into chunks no longer than limit.
+// Parts that are too long on their own are handed to tooLong.
+func pack(parts []string, sep string, limit int, tooLong func(string) []string) []string {
+	var chunks []string
+	var cur strings.Builder
+	curLen := 0
+	sepLen := utf8.RuneCountInString(sep)
+
+	flush := func() {
+		if curLen > 0 {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+			curLen = 0
+		}
+	}
+
+	for _, p := range parts {
+		n := utf8.RuneCountInString(p)
+		if n > limit {
+			flush()
+			chunks = append(chunks, tooLong(p)...)
+			continue
+		}
+		if curLen > 0 && curLen+sepLen+n > limit {
+			flush()
+		}
+		if curLen > 0 {
+			cur.WriteString(sep)
+			curLen += sepLen
+		}
+		cur.WriteString(p)
+		curLen += n
+	}
+	flush()
+	return chunks
+}
+
+// cut hard-splits a single line, backing off so a chunk never ends inside
+// an HTML tag ("<a href=...") or entity ("&amp;").
+func cut(line string, limit int) []string {
+	var chunks []string
+	runes := []rune(line)
+	for len(runes) > limit {
+		end := limit
+		chunk := string(runes[:end])
+		if i := strings.LastIndexByte(chunk, '<'); i > strings.LastIndexByte(chunk, '>') {
+			end = utf8.RuneCountInString(chunk[:i])
+		} else if i := strings.LastIndexByte(chunk, '&'); i >= 0 && !strings.Contains(chunk[i:], ";") {
+			end = utf8.RuneCountInString(chunk[:i])
+		}
+		if end == 0 {
+			end = limit // a single tag longer than limit; nothing better to do
+		}
+		chunks = append(chunks, string(runes[:end]))
+		runes = runes[end:]
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
@@ -29,6 +29,38 @@ var (
 		Name:      "requests_in_flight",
 		Help:      "Number of HTTP requests currently being processed.",
 	})
+
+	HTTPRateLimitedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Total number of requests rejected by the rate limiter.",
+	}, []string{"route"})
+)
+
+// ── Live stream metrics ─────────────────────────────────────────────────
+
+var (
+	StreamClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "stream",
+		Name:      "clients",
+		Help:      "Number of connected live stream (SSE/WebSocket) clients.",
+	})
+
+	StreamDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "stream",
+		Name:      "dropped_total",
+		Help:      "Total number of stream clients disconnected for falling behind.",
+	})
+
+	LiveFeedEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "live_feed",
+		Name:      "events_total",
+		Help:      "Live state events the leader shared with followers (published, dropped, failed) or a follower mirrored (applied, invalid).",
+	}, []string{"result"})
 )
 
 // ── Polling / source metrics ───────────────────────────────────────────
@@ -69,6 +101,13 @@ var (
 		Name:      "age_seconds",
 		Help:      "Age of the latest snapshot in seconds per source.",
 	}, []string{"source"})
+
+	SourcePaused = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "poll",
+		Name:      "source_paused",
+		Help:      "1 while an admin has paused polling of the source.",
+	}, []string{"source"})
 )
 
 // ── Alert delivery metrics ─────────────────────────────────────────────
@@ -94,6 +133,128 @@ var (
 		Name:      "deduplicated_total",
 		Help:      "Total alerts suppressed by deduplication.",
 	}, []string{"source", "type"})
+
+	BroadcastMessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "alerts",
+		Name:      "broadcast_messages_total",
+		Help:      "Total admin announcement messages by status (sent, failed).",
+	}, []string{"status"})
+)
+
+// ── Dedup backend metrics ──────────────────────────────────────────────
+
+var (
+	DedupErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "dedup",
+		Name:      "errors_total",
+		Help:      "Total dedup backend errors by operation.",
+	}, []string{"operation"})
+
+	DedupErrorSuppressedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "dedup",
+		Name:      "error_suppressed_total",
+		Help:      "Total alerts suppressed because the dedup backend failed (fail-closed severities).",
+	}, []string{"severity"})
+)
+
+// ── Leader election metrics ────────────────────────────────────────────
+
+var (
+	LeaderIsLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "leader",
+		Name:      "is_leader",
+		Help:      "1 while this replica is the leader running polling, alerts and collectors.",
+	})
+
+	LeaderTransitionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "leader",
+		Name:      "transitions_total",
+		Help:      "Total leadership changes of this replica (acquired, lost).",
+	}, []string{"transition"})
+)
+
+// ── Subscription cache metrics ─────────────────────────────────────────
+
+var (
+	SubscriptionCacheReloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "subscription_cache",
+		Name:      "reloads_total",
+		Help:      "Total subscription cache reloads from the database by status.",
+	}, []string{"status"})
+
+	SubscriptionCacheInvalidationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "subscription_cache",
+		Name:      "invalidations_total",
+		Help:      "Total subscription cache invalidations by reason (write, notify).",
+	}, []string{"reason"})
+
+	SubscriptionCacheAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "subscription_cache",
+		Name:      "age_seconds",
+		Help:      "Age of the subscriptions being served; grows while reloads fail.",
+	})
+)
+
+// ── Upstream API metrics (internal/upstream) ─────────────────────────
+
+var (
+	UpstreamRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "upstream",
+		Name:      "retries_total",
+		Help:      "Total retried requests to upstream APIs by host.",
+	}, []string{"host"})
+
+	UpstreamRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "upstream",
+		Name:      "rejected_total",
+		Help:      "Total requests failed fast without reaching the host, by reason (circuit_open, rate_limited).",
+	}, []string{"host", "reason"})
+
+	UpstreamCircuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "upstream",
+		Name:      "circuit_state",
+		Help:      "Circuit breaker state per host (0 closed, 1 half-open, 2 open).",
+	}, []string{"host"})
+
+	UpstreamInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "upstream",
+		Name:      "requests_in_flight",
+		Help:      "Requests in flight per upstream host.",
+	}, []string{"host"})
+)
+
+// ── DefiLlama pools cache metrics ──────────────────────────────────────
+
+var (
+	PoolsCacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "pools_cache",
+		Name:      "requests_total",
+		Help:      "Total reads of the DefiLlama pools cache by result (hit, revalidated, miss, stale, error).",
+	}, []string{"result"})
+)
+
+// ── Config file metrics ────────────────────────────────────────────────
+
+var (
+	ConfigReloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "config",
+		Name:      "reloads_total",
+		Help:      "Total config file reloads on SIGHUP by status (success, error).",
+	}, []string{"status"})
 )
 
 // ── Business metrics ───────────────────────────────────────────────────
@@ -120,3 +281,14 @@ var (
 		Help:      "Total number of linked Telegram users.",
 	})
 )
+
+// ── Auth / security metrics ────────────────────────────────────────────
+
+var (
+	LinkAttemptsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onchain_monitor",
+		Subsystem: "auth",
+		Name:      "link_attempts_total",
+		Help:      "Link code attempts by outcome (linked, invalid_code, locked_out).",
+	}, []string{"outcome"})
+)
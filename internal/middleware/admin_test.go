@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdmin(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := AdminActor(r.Context())
+		if !ok {
+			t.Error("admin actor missing from context")
+		}
+		_, _ = w.Write([]byte(actor))
+	})
+	admins := Admins{ChatIDs: []int64{12345}, APIKeys: []string{"static-admin-key"}}
+	handler := Admin(fakeSessions{"admin": 12345, "user": 42}, admins)(echo)
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"no header", "", http.StatusUnauthorized, ""},
+		{"unknown token", "Bearer bad", http.StatusUnauthorized, ""},
+		{"non-admin session", "Bearer user", http.StatusForbidden, ""},
+		{"admin session", "Bearer admin", http.StatusOK, "chat:12345"},
+		{"admin api key", "Bearer static-admin-key", http.StatusOK, "key:"},
+		{"user api key", "Bearer omk_stats", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && !strings.HasPrefix(rec.Body.String(), tt.wantBody) {
+				t.Errorf("actor = %q, want prefix %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
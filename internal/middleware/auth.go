@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// SessionStore resolves session tokens and API keys to Telegram chat IDs.
+type SessionStore interface {
+	SessionChatID(ctx context.Context, token string) (int64, error)
+	APIKeyChatID(ctx context.Context, key string) (int64, []string, error)
+}
+
+type (
+	chatIDKey struct{}
+	scopesKey struct{}
+)
+
+// Auth rejects requests without a valid "Authorization: Bearer <token>"
+// session or API key and stores the caller's chat ID in the request
+// context. API keys also carry their scopes, checked by RequireScope.
+func Auth(s SessionStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if BearerToken(r) == "" {
+				http.Error(w, `{"error":"authentication required"}`, http.StatusUnauthorized)
+				return
+			}
+			authenticate(s, next, w, r)
+		})
+	}
+}
+
+// OptionalAuth is Auth for public endpoints: anonymous requests pass
+// through, but a credential that is sent must be valid.
+func OptionalAuth(s SessionStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if BearerToken(r) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticate(s, next, w, r)
+		})
+	}
+}
+
+func authenticate(s SessionStore, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	token := BearerToken(r)
+	ctx := r.Context()
+	if strings.HasPrefix(token, store.APIKeyPrefix) {
+		chatID, scopes, err := s.APIKeyChatID(ctx, token)
+		if err != nil {
+			http.Error(w, `{"error":"invalid or expired api key"}`, http.StatusUnauthorized)
+			return
+		}
+		ctx = WithAPIKeyScopes(WithChatID(ctx, chatID), scopes)
+	} else {
+		chatID, err := s.SessionChatID(ctx, token)
+		if err != nil {
+			http.Error(w, `{"error":"invalid or expired session"}`, http.StatusUnauthorized)
+			return
+		}
+		ctx = WithChatID(ctx, chatID)
+	}
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireScope rejects requests made with an API key that lacks scope.
+// Sessions and anonymous requests (behind OptionalAuth) pass.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if scopes, isKey := APIKeyScopes(r.Context()); isKey && !slices.Contains(scopes, scope) {
+				http.Error(w, `{"error":"api key lacks scope `+scope+`"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SessionOnly rejects requests made with an API key, for account
+// management that scripts must not do (such as minting more keys).
+func SessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, isKey := APIKeyScopes(r.Context()); isKey {
+			http.Error(w, `{"error":"endpoint requires a session, not an api key"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header, or "".
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// QueryToken moves an access_token query parameter into the Authorization
+// header, for clients that cannot set headers (EventSource, browser
+// WebSockets). Only use it on routes that need it: query strings end up in
+// proxy logs more easily than headers.
+func QueryToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if token := q.Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", "Bearer "+token)
+			q.Del("access_token")
+			r.URL.RawQuery = q.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithChatID returns a context carrying an authenticated chat ID.
+func WithChatID(ctx context.Context, chatID int64) context.Context {
+	return context.WithValue(ctx, chatIDKey{}, chatID)
+}
+
+// WithAPIKeyScopes returns a context marking the caller as an API key with
+// the given scopes.
+func WithAPIKeyScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// APIKeyScopes returns the scopes of the API key the request was made with;
+// ok is false for sessions and anonymous requests.
+func APIKeyScopes(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(scopesKey{}).([]string)
+	return scopes, ok
+}
+
+// ChatID returns the authenticated chat ID set by Auth.
+func ChatID(ctx context.Context) (int64, bool) {
+	chatID, ok := ctx.Value(chatIDKey{}).(int64)
+	return chatID, ok
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type fakeSessions map[string]int64
+
+func (f fakeSessions) SessionChatID(_ context.Context, token string) (int64, error) {
+	if id, ok := f[token]; ok {
+		return id, nil
+	}
+	return 0, errors.New("no rows")
+}
+
+// fakeSessions also knows one API key, "omk_stats", scoped to read:stats.
+func (f fakeSessions) APIKeyChatID(_ context.Context, key string) (int64, []string, error) {
+	if key == "omk_stats" {
+		return 777, []string{"read:stats"}, nil
+	}
+	return 0, nil, errors.New("no rows")
+}
+
+func TestAuth(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chatID, ok := ChatID(r.Context())
+		if !ok {
+			t.Error("chat ID missing from context")
+		}
+		_, _ = w.Write([]byte(strconv.FormatInt(chatID, 10)))
+	})
+	handler := Auth(fakeSessions{"good": 12345})(echo)
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"no header", "", http.StatusUnauthorized, ""},
+		{"wrong scheme", "Basic good", http.StatusUnauthorized, ""},
+		{"unknown token", "Bearer bad", http.StatusUnauthorized, ""},
+		{"valid token", "Bearer good", http.StatusOK, "12345"},
+		{"lowercase scheme", "bearer good", http.StatusOK, "12345"},
+		{"api key", "Bearer omk_stats", http.StatusOK, "777"},
+		{"unknown api key", "Bearer omk_nope", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/subscriptions", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestChatIDMissing(t *testing.T) {
+	if _, ok := ChatID(context.Background()); ok {
+		t.Error("ChatID on empty context should report false")
+	}
+}
+
+func TestScopes(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	sessions := fakeSessions{"good": 12345}
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		header     string
+		wantStatus int
+	}{
+		{"anonymous on optional", OptionalAuth(sessions)(RequireScope("read:stats")(ok)), "", http.StatusOK},
+		{"bad token on optional", OptionalAuth(sessions)(RequireScope("read:stats")(ok)), "Bearer bad", http.StatusUnauthorized},
+		{"key with scope", Auth(sessions)(RequireScope("read:stats")(ok)), "Bearer omk_stats", http.StatusOK},
+		{"key without scope", Auth(sessions)(RequireScope("write:subscriptions")(ok)), "Bearer omk_stats", http.StatusForbidden},
+		{"session has every scope", Auth(sessions)(RequireScope("write:subscriptions")(ok)), "Bearer good", http.StatusOK},
+		{"session on session-only", Auth(sessions)(SessionOnly(ok)), "Bearer good", http.StatusOK},
+		{"key on session-only", Auth(sessions)(SessionOnly(ok)), "Bearer omk_stats", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the networks of the ingress, load balancers or CDN in
+// front of the server. Only they may say who the client is through
+// X-Forwarded-For.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses CIDRs ("10.0.0.0/8") and bare addresses
+// (one host).
+func ParseTrustedProxies(items []string) (TrustedProxies, error) {
+	var t TrustedProxies
+	for _, item := range items {
+		if p, err := netip.ParsePrefix(item); err == nil {
+			t = append(t, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: want a CIDR or an IP address", item)
+		}
+		a = a.Unmap()
+		t = append(t, netip.PrefixFrom(a, a.BitLen()))
+	}
+	return t, nil
+}
+
+func (t TrustedProxies) contains(a netip.Addr) bool {
+	a = a.Unmap()
+	for _, p := range t {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+type clientIPKey struct{}
+
+// RealIP works out each request's client IP for ClientIP. X-Forwarded-For
+// is read only when the connection comes from a trusted proxy, from the
+// last hop back: the first address that is not a trusted proxy is the
+// client, so entries a client wrote itself are never reached. With no
+// trusted proxies the header is ignored.
+func RealIP(trusted TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r, trusted)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+		})
+	}
+}
+
+// ClientIP returns the caller's IP address as RealIP found it, or the
+// connection's address on routes RealIP does not wrap.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return remoteHost(r)
+}
+
+func clientIP(r *http.Request, trusted TrustedProxies) string {
+	ip := remoteHost(r)
+	a, err := netip.ParseAddr(ip)
+	if err != nil || !trusted.contains(a) {
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		a, err := netip.ParseAddr(hop)
+		if err != nil {
+			// Garbage from before the proxies: the last trusted hop is
+			// as close to the client as we can tell
+			return ip
+		}
+		ip = a.Unmap().String()
+		if !trusted.contains(a) {
+			return ip
+		}
+	}
+	return ip
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		trusted    TrustedProxies
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{"remote addr", trusted, "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"remote addr without port", trusted, "203.0.113.7", nil, "203.0.113.7"},
+		{"single forwarded", trusted, "10.0.0.2:80", []string{"198.51.100.4"}, "198.51.100.4"},
+		{"spoofed first hop ignored", trusted, "10.0.0.2:80", []string{"1.1.1.1, 198.51.100.4"}, "198.51.100.4"},
+		{"chain of trusted proxies", trusted, "10.0.0.2:80", []string{"1.1.1.1, 198.51.100.4, 192.0.2.10, 10.1.2.3"}, "198.51.100.4"},
+		{"headers joined", trusted, "10.0.0.2:80", []string{"1.1.1.1", "198.51.100.4"}, "198.51.100.4"},
+		{"only proxies", trusted, "10.0.0.2:80", []string{"10.0.0.9"}, "10.0.0.9"},
+		{"garbage hop", trusted, "10.0.0.2:80", []string{"1.1.1.1, unknown"}, "10.0.0.2"},
+		{"ipv4-mapped proxy", trusted, "[::ffff:10.0.0.2]:80", []string{"198.51.100.4"}, "198.51.100.4"},
+		// A client connecting directly cannot pick its own IP
+		{"spoofed by direct client", trusted, "203.0.113.7:51234", []string{"198.51.100.4"}, "203.0.113.7"},
+		{"no trusted proxies", nil, "10.0.0.2:80", []string{"198.51.100.4"}, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			var got string
+			RealIP(tt.trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without RealIP the header is never read
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.2:80"
+	req.Header.Set("X-Forwarded-For", "198.51.100.4")
+	if got := ClientIP(req); got != "10.0.0.2" {
+		t.Errorf("ClientIP without RealIP = %q, want the remote addr", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::/32", "::1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"10.0.0.0/33", "proxy.internal", ""} {
+		if _, err := ParseTrustedProxies([]string{bad}); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+)
+
+// Rate is a token bucket budget: up to Limit requests at once, refilled
+// at Limit per Period. A zero Rate disables limiting.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRate parses "<limit>/<period>", e.g. "60/1m". "0" disables limiting.
+func ParseRate(s string) (Rate, error) {
+	if s == "0" {
+		return Rate{}, nil
+	}
+	n, p, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("rate %q: want <limit>/<period>", s)
+	}
+	limit, err := strconv.Atoi(n)
+	if err != nil || limit <= 0 {
+		return Rate{}, fmt.Errorf("rate %q: invalid limit", s)
+	}
+	period, err := time.ParseDuration(p)
+	if err != nil || period <= 0 {
+		return Rate{}, fmt.Errorf("rate %q: invalid period", s)
+	}
+	return Rate{Limit: limit, Period: period}, nil
+}
+
+func (r Rate) String() string {
+	if r.Limit == 0 {
+		return "0"
+	}
+	return strconv.Itoa(r.Limit) + "/" + r.Period.String()
+}
+
+// tokenBucket refills and takes one token from the bucket in KEYS[1].
+// ARGV: capacity, refill rate (tokens/ms), now (ms), key TTL (ms).
+// Returns {allowed, tokens left (floored), ms until the next token}.
+var tokenBucket = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+local wait = 0
+if tokens < 1 then
+  wait = math.ceil((1 - tokens) / rate)
+end
+return {allowed, math.floor(tokens), wait}
+`)
+
+const (
+	// takeTimeout bounds the Redis call, so a request waits at most this
+	// long for the limiter before it fails open.
+	takeTimeout = 50 * time.Millisecond
+	// unavailableLogEvery is how often failing open is logged.
+	unavailableLogEvery = time.Minute
+)
+
+// RateLimiter enforces per-client token buckets kept in Redis, so limits
+// hold across replicas.
+type RateLimiter struct {
+	rdb    *redis.Client
+	logger *slog.Logger
+	now    func() time.Time
+
+	timeout   time.Duration
+	loggedAt  atomic.Int64 // unix ms of the last "unavailable" warning
+	unlimited atomic.Int64 // requests let through since then
+}
+
+// NewRateLimiter creates a RateLimiter on an existing Redis client (the
+// dedup connection).
+func NewRateLimiter(rdb *redis.Client, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{rdb: rdb, logger: logger, now: time.Now, timeout: takeTimeout}
+}
+
+// Limit rate limits a route group. Each client gets its own bucket per
+// route name: the API key or session it authenticated with, else its IP.
+// It must therefore run after Auth/OptionalAuth. If Redis is unreachable
+// or slower than takeTimeout requests are let through — unlike dedup,
+// limiting fails open — and a warning is logged at most once a minute.
+func (l *RateLimiter) Limit(route string, rate Rate) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if rate.Limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, remaining, wait, err := l.take(r.Context(), "ratelimit:"+route+":"+clientKey(r), rate)
+			if err != nil {
+				l.unavailable(route, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Seconds until the bucket is full again
+			perToken := float64(rate.Period) / float64(rate.Limit)
+			reset := time.Duration(float64(rate.Limit-remaining) * perToken)
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(rate.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+
+			if !allowed {
+				metrics.HTTPRateLimitedTotal.WithLabelValues(route).Inc()
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(wait)))
+				http.Error(w, `{"error":"rate limit exceeded"}`, http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unavailable logs that requests are let through, once per
+// unavailableLogEvery with the number allowed since the last warning.
+func (l *RateLimiter) unavailable(route string, err error) {
+	n := l.unlimited.Add(1)
+	now := l.now().UnixMilli()
+	last := l.loggedAt.Load()
+	if now-last < unavailableLogEvery.Milliseconds() || !l.loggedAt.CompareAndSwap(last, now) {
+		return
+	}
+	l.unlimited.Add(-n)
+	l.logger.Warn("rate limiter unavailable, allowing requests", "route", route, "allowed", n, "error", err)
+}
+
+func (l *RateLimiter) take(ctx context.Context, key string, rate Rate) (bool, int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+	perMs := float64(rate.Limit) / float64(rate.Period.Milliseconds())
+	res, err := tokenBucket.Run(ctx, l.rdb, []string{key},
+		rate.Limit, strconv.FormatFloat(perMs, 'g', -1, 64), l.now().UnixMilli(), rate.Period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, 0, err
+	}
+	return res[0] == 1, int(res[1]), time.Duration(res[2]) * time.Millisecond, nil
+}
+
+// clientKey identifies the caller for rate limiting. Credentials are
+// hashed so they never appear in Redis.
+func clientKey(r *http.Request) string {
+	if _, ok := ChatID(r.Context()); ok {
+		if token := BearerToken(r); token != "" {
+			sum := sha256.Sum256([]byte(token))
+			kind := "session:"
+			if _, isKey := APIKeyScopes(r.Context()); isKey {
+				kind = "apikey:"
+			}
+			return kind + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + ClientIP(r)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
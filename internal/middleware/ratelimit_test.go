@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func setupTestLimiter(t *testing.T) (*RateLimiter, *time.Time) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		rdb.Close()
+		mr.Close()
+	})
+	now := time.Unix(1_700_000_000, 0)
+	l := NewRateLimiter(rdb, slog.New(slog.DiscardHandler))
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimit(t *testing.T) {
+	l, now := setupTestLimiter(t)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := l.Limit("stats", Rate{Limit: 2, Period: time.Minute})(ok)
+
+	do := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("1.1.1.1"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("first: status %d, remaining %q", rec.Code, rec.Header().Get("RateLimit-Remaining"))
+	}
+	do("1.1.1.1")
+	rec := do("1.1.1.1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third: status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	if got := rec.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("RateLimit-Limit = %q, want 2", got)
+	}
+
+	if rec := do("2.2.2.2"); rec.Code != http.StatusOK {
+		t.Errorf("other client: status = %d, want 200", rec.Code)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if rec := do("1.1.1.1"); rec.Code != http.StatusOK {
+		t.Errorf("after refill: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestRateLimitKeysByCredential(t *testing.T) {
+	l, _ := setupTestLimiter(t)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := l.Limit("user", Rate{Limit: 1, Period: time.Minute})(ok)
+
+	do := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/subscriptions", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req = req.WithContext(WithChatID(req.Context(), 1))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Same IP and chat, but separate sessions get separate buckets
+	if code := do("session-a"); code != http.StatusOK {
+		t.Fatalf("session a: status = %d", code)
+	}
+	if code := do("session-a"); code != http.StatusTooManyRequests {
+		t.Errorf("session a again: status = %d, want 429", code)
+	}
+	if code := do("session-b"); code != http.StatusOK {
+		t.Errorf("session b: status = %d, want 200", code)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer rdb.Close()
+	l := NewRateLimiter(rdb, slog.New(slog.DiscardHandler))
+	handler := l.Limit("stats", Rate{Limit: 1, Period: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 when Redis is down", rec.Code)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rate
+		wantErr bool
+	}{
+		{"60/1m", Rate{60, time.Minute}, false},
+		{"5/10s", Rate{5, 10 * time.Second}, false},
+		{"0", Rate{}, false},
+		{"60", Rate{}, true},
+		{"x/1m", Rate{}, true},
+		{"60/soon", Rate{}, true},
+		{"-1/1m", Rate{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRate(%q) = %v, %v; want %v, err %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	l, _ := setupTestLimiter(t)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	proxies, _ := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	handler := RealIP(proxies)(l.Limit("stats", Rate{Limit: 1, Period: time.Minute})(ok))
+
+	do := func(remoteAddr, xff string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", xff)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A direct client gets no fresh bucket by inventing an address
+	do("203.0.113.7:1234", "1.1.1.1")
+	if code := do("203.0.113.7:1234", "2.2.2.2"); code != http.StatusTooManyRequests {
+		t.Errorf("spoofed X-Forwarded-For: status = %d, want 429", code)
+	}
+	// Clients behind the proxy each get their own
+	do("10.0.0.2:80", "198.51.100.4")
+	if code := do("10.0.0.2:80", "198.51.100.5"); code != http.StatusOK {
+		t.Errorf("second client behind the proxy: status = %d, want 200", code)
+	}
+}
+
+func TestRateLimitHungRedis(t *testing.T) {
+	// Accepts connections but never answers
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), MaxRetries: -1, ContextTimeoutEnabled: true})
+	defer rdb.Close()
+	var logs bytes.Buffer
+	l := NewRateLimiter(rdb, slog.New(slog.NewTextHandler(&logs, nil)))
+	handler := l.Limit("stats", Rate{Limit: 1, Period: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	start := time.Now()
+	for range 3 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200 when Redis hangs", rec.Code)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("3 requests took %v waiting for Redis", d)
+	}
+	if n := strings.Count(logs.String(), "rate limiter unavailable"); n != 1 {
+		t.Errorf("logged %d warnings for 3 requests, want 1", n)
+	}
+}
@@ -0,0 +1,81 @@
+// Package migrate implements the migrate command shared by the server
+// (server migrate ...) and monitorctl (monitorctl migrate ...).
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+const usage = "usage: migrate status|up|down"
+
+// Run executes the migrate command in args against databaseURL: status
+// lists every migration, up applies the pending ones (as every server boot
+// does), and down undoes the latest one.
+func Run(ctx context.Context, databaseURL string, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "status", "up", "down":
+	default:
+		return fmt.Errorf("unknown migrate command %q (%s)", args[0], usage)
+	}
+	if databaseURL == "" {
+		return errors.New("DATABASE_URL is required")
+	}
+
+	db, err := store.Open(ctx, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m, ok := db.(store.Migrator)
+	if !ok {
+		return errors.New("migrate needs a postgres DATABASE_URL (SQLite creates its schema on boot)")
+	}
+
+	switch args[0] {
+	case "status":
+		status, err := m.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+		for _, st := range status {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", st.Version, st.Name, applied)
+		}
+		return tw.Flush()
+	case "up":
+		done, err := m.MigrateUp(ctx)
+		for _, mig := range done {
+			fmt.Fprintf(out, "applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+	default:
+		mig, err := m.MigrateDown(ctx)
+		if err != nil {
+			return err
+		}
+		if mig == nil {
+			fmt.Fprintln(out, "no migrations applied")
+			return nil
+		}
+		fmt.Fprintf(out, "undid %04d_%s\n", mig.Version, mig.Name)
+		return nil
+	}
+}
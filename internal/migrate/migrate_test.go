@@ -0,0 +1,29 @@
+package migrate
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunRejectsBadUsage(t *testing.T) {
+	ctx := context.Background()
+	sqlite := "sqlite:" + filepath.Join(t.TempDir(), "monitor.db")
+	for _, tc := range []struct {
+		url  string
+		args []string
+		want string
+	}{
+		{sqlite, nil, "usage"},
+		{sqlite, []string{"sideways"}, "unknown migrate command"},
+		{"", []string{"status"}, "DATABASE_URL is required"},
+		{sqlite, []string{"status"}, "needs a postgres DATABASE_URL"},
+	} {
+		err := Run(ctx, tc.url, tc.args, io.Discard)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Run(%q, %v) error = %v, want it to mention %q", tc.url, tc.args, err, tc.want)
+		}
+	}
+}
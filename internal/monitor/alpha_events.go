@@ -19,7 +19,7 @@ type AlphaAirdrop struct {
 // checkAlphaAirdrops queries the registered alpha source for current airdrops
 // and sends Telegram alerts to subscribers of the "general_alpha_alert" event.
 func (e *Engine) checkAlphaAirdrops(ctx context.Context) {
-	alphaSrc, ok := e.sources["alpha"]
+	alphaSrc, ok := e.activeSource("alpha")
 	if !ok {
 		return
 	}
@@ -38,20 +38,30 @@ func (e *Engine) checkAlphaAirdrops(ctx context.Context) {
 		return
 	}
 
-	chatIDs, err := e.store.GetSubscriberChatIDs(ctx, "general_alpha_alert")
-	if err != nil || len(chatIDs) == 0 {
+	subscribers, err := e.subscribers(ctx, "general_alpha_alert")
+	if err != nil || len(subscribers) == 0 {
 		return
 	}
 
 	for _, ad := range airdrops {
-		for _, chatID := range chatIDs {
-			dedupKey := fmt.Sprintf("alpha:%d:%s:%s:%s", chatID, ad.Token, ad.Date, ad.Time)
-			if e.dedup.AlreadySent(ctx, dedupKey) {
+		for _, sub := range subscribers {
+			chatID := sub.ChatID
+			dedupKey := newSubKey(sub.SubscriptionID, "alpha", fmt.Sprintf("%s:%s:%s", ad.Token, ad.Date, ad.Time))
+			if e.alreadySent(ctx, dedupKey, "alpha_airdrop") {
 				continue
 			}
 
-			msg := fmt.Sprintf("🎉 New Alpha Airdrop: %s\n\nDate: %s %s\nPoints: %d\nName: %s\n",
-				ad.Token, ad.Date, ad.Time, ad.Points, ad.Name)
+			msg, err := e.render(chatID, "alpha_alert", map[string]any{
+				"Token":  ad.Token,
+				"Date":   ad.Date,
+				"Time":   ad.Time,
+				"Points": ad.Points,
+				"Name":   ad.Name,
+			})
+			if err != nil {
+				metrics.AlertsFailedTotal.WithLabelValues("alpha", "alpha_airdrop").Inc()
+				continue
+			}
 
 			if err := e.alertFn(chatID, msg); err != nil {
 				metrics.AlertsFailedTotal.WithLabelValues("alpha", "alpha_airdrop").Inc()
@@ -59,9 +69,9 @@ func (e *Engine) checkAlphaAirdrops(ctx context.Context) {
 				continue
 			}
 			metrics.AlertsSentTotal.WithLabelValues("alpha", "alpha_airdrop").Inc()
-			e.logNotification(chatID, "alpha", "general_alpha_alert",
+			e.logNotification(chatID, "alpha", "alpha", "general_alpha_alert",
 				fmt.Sprintf("%s on %s %s (%d points)", ad.Token, ad.Date, ad.Time, ad.Points))
-			e.dedup.Record(ctx, dedupKey)
+			e.record(ctx, dedupKey)
 		}
 	}
 }
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/web3-frozen/onchain-monitor/internal/chart"
+)
+
+// PhotoFunc sends a PNG image with an HTML caption to a Telegram chat.
+type PhotoFunc func(chatID int64, png []byte, caption string) error
+
+// ChartAlertTypes lists the alert types that can carry a chart image
+// (CHART_ALERTS values).
+var ChartAlertTypes = []string{"metric_alert", "value_alert", "maxpain_alert", "binance_price_alert", "daily_report"}
+
+// reportChartMetrics picks the metric charted with each source's daily
+// report. The maxpain report gets the BTC liquidation histogram instead.
+var reportChartMetrics = map[string]string{
+	"altura":    "tvl",
+	"neverland": "tvl",
+	"feargreed": "fear_greed_index",
+	"binance":   "btc_price",
+}
+
+// EnableCharts attaches a chart image to the given alert types, sending the
+// alert text as the photo caption. Other alert types stay text-only.
+func (e *Engine) EnableCharts(photoFn PhotoFunc, alertTypes []string) {
+	e.photoFn = func(chatID int64, png []byte, caption string) error {
+		err := photoFn(chatID, png, caption)
+		if err != nil {
+			e.logDeliveryFailure(chatID, caption, err)
+		}
+		return err
+	}
+	e.chartAlerts = make(map[string]bool, len(alertTypes))
+	for _, t := range alertTypes {
+		known := false
+		for _, k := range ChartAlertTypes {
+			known = known || k == t
+		}
+		if !known {
+			e.logger.Warn("ignoring unknown chart alert type", "alert_type", t)
+			continue
+		}
+		e.chartAlerts[t] = true
+	}
+}
+
+// MetricChart renders a line chart of one metric from the source's
+// in-memory snapshot history (the last Settings.HistoryLen polls).
+func (e *Engine) MetricChart(source, metric string) ([]byte, error) {
+	e.mu.RLock()
+	hist := e.snapHistory[source]
+	points := make([]chart.Point, 0, len(hist))
+	for _, snap := range hist {
+		if v, ok := snap.Metrics[metric]; ok {
+			points = append(points, chart.Point{Time: snap.FetchedAt, Value: v})
+		}
+	}
+	e.mu.RUnlock()
+	return chart.Line(points, chart.Options{})
+}
+
+// LiquidationChart renders the liquidation histogram behind a max pain
+// reading for symbol over interval.
+func (e *Engine) LiquidationChart(ctx context.Context, symbol, interval string) ([]byte, error) {
+	lc, ok := e.sources["maxpain"].(LiquidationCharter)
+	if !ok {
+		return nil, chart.ErrNoData
+	}
+	bins, price, err := lc.LiquidationBins(ctx, strings.ToUpper(symbol), interval)
+	if err != nil {
+		return nil, fmt.Errorf("liquidation bins %s %s: %w", symbol, interval, err)
+	}
+	return chart.Histogram(bins, price, chart.Options{})
+}
+
+// reportChart returns the chart sent with a source's daily report, or nil
+// if the source has none.
+func (e *Engine) reportChart(ctx context.Context, source string) func() ([]byte, error) {
+	if source == "maxpain" {
+		return func() ([]byte, error) { return e.LiquidationChart(ctx, "BTC", "24h") }
+	}
+	metric, ok := reportChartMetrics[source]
+	if !ok {
+		return nil
+	}
+	return func() ([]byte, error) { return e.MetricChart(source, metric) }
+}
+
+// deliver sends msg to a chat: as the caption of a chart when charts are
+// enabled for alertType and the chart renders, as plain text otherwise. A
+// missing chart never blocks the alert itself.
+func (e *Engine) deliver(chatID int64, alertType, msg string, chartFn func() ([]byte, error)) error {
+	if e.photoFn == nil || chartFn == nil || !e.chartAlerts[alertType] {
+		return e.alertFn(chatID, msg)
+	}
+	img, err := chartFn()
+	if err != nil {
+		if !errors.Is(err, chart.ErrNoData) {
+			e.logger.Warn("render chart failed", "alert_type", alertType, "error", err)
+		}
+		return e.alertFn(chatID, msg)
+	}
+	return e.photoFn(chatID, img, msg)
+}
@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// Control is the runtime state admins change through /api/admin: sources
+// that are not polled and events that are not evaluated. It lives in the
+// store, so every replica and the next leader agree on it.
+type Control struct {
+	PausedSources  map[string]time.Time // name → paused at
+	DisabledEvents map[string]bool
+}
+
+// LoadControl reads the control state from the store.
+func (e *Engine) LoadControl(ctx context.Context) (Control, error) {
+	c := Control{
+		PausedSources:  make(map[string]time.Time),
+		DisabledEvents: make(map[string]bool),
+	}
+	if e.store == nil {
+		return c, nil
+	}
+	paused, err := e.store.ListPausedSources(ctx)
+	if err != nil {
+		return c, fmt.Errorf("list paused sources: %w", err)
+	}
+	for _, p := range paused {
+		c.PausedSources[p.Name] = p.PausedAt
+	}
+	events, err := e.store.ListAllEvents(ctx)
+	if err != nil {
+		return c, fmt.Errorf("list events: %w", err)
+	}
+	for _, ev := range events {
+		if !ev.Enabled {
+			c.DisabledEvents[ev.Name] = true
+		}
+	}
+	return c, nil
+}
+
+// refreshControl applies the store's control state at the start of a poll
+// cycle, so admin changes take effect without a restart. When the store
+// cannot be read the previous state is kept.
+func (e *Engine) refreshControl(ctx context.Context) {
+	c, err := e.LoadControl(ctx)
+	if err != nil {
+		e.logger.Error("load runtime control state failed", "error", err)
+		return
+	}
+
+	e.mu.Lock()
+	prev := e.control
+	e.control = c
+	e.mu.Unlock()
+
+	for name := range e.sources {
+		_, paused := c.PausedSources[name]
+		_, wasPaused := prev.PausedSources[name]
+		switch {
+		case paused && !wasPaused:
+			e.logger.Info("source paused", "source", name)
+			e.markStale(name)
+		case !paused && wasPaused:
+			e.logger.Info("source resumed", "source", name)
+		}
+		if paused {
+			metrics.SourcePaused.WithLabelValues(name).Set(1)
+		} else {
+			metrics.SourcePaused.WithLabelValues(name).Set(0)
+		}
+	}
+	for name := range c.DisabledEvents {
+		if !prev.DisabledEvents[name] {
+			e.logger.Info("event disabled", "event", name)
+		}
+	}
+	for name := range prev.DisabledEvents {
+		if !c.DisabledEvents[name] {
+			e.logger.Info("event enabled", "event", name)
+		}
+	}
+}
+
+// markStale replaces a paused source's latest snapshot with a copy marked
+// stale and pushes it to stream clients. The next fresh snapshot starts a
+// new history, so percentage windows never span the pause.
+func (e *Engine) markStale(name string) {
+	e.mu.Lock()
+	history := e.snapHistory[name]
+	if len(history) == 0 || history[len(history)-1].Stale {
+		e.mu.Unlock()
+		return
+	}
+	stale := *history[len(history)-1]
+	stale.Stale = true
+	history[len(history)-1] = &stale
+	e.mu.Unlock()
+	e.hub.Publish(StreamEvent{Type: "snapshot", Snapshot: &stale})
+	e.publish(feedEvent{Type: "snapshot", Source: name, Snapshot: &stale})
+}
+
+// sourcePaused reports whether an admin paused the source or the settings
+// disable it.
+func (e *Engine) sourcePaused(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, paused := e.control.PausedSources[name]
+	return paused || e.settings.Sources[name].Disabled
+}
+
+// activeSource returns a registered source that is neither paused nor
+// disabled.
+func (e *Engine) activeSource(name string) (Source, bool) {
+	src, ok := e.sources[name]
+	if !ok || e.sourcePaused(name) {
+		return nil, false
+	}
+	return src, true
+}
+
+// eventEnabled reports whether the event is evaluated; unknown events are.
+func (e *Engine) eventEnabled(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return !e.control.DisabledEvents[name]
+}
+
+// subscribers returns the threshold subscribers of an event, none while it
+// is disabled.
+func (e *Engine) subscribers(ctx context.Context, eventName string) ([]store.SubscriberConfig, error) {
+	if !e.eventEnabled(eventName) {
+		return nil, nil
+	}
+	return e.store.GetSubscribersWithThresholds(ctx, eventName)
+}
+
+// reportSubscribers returns the chats due a daily report of an event this
+// hour, none while it is disabled.
+func (e *Engine) reportSubscribers(ctx context.Context, eventName string, hour int) ([]store.DailyReportSubscriber, error) {
+	if !e.eventEnabled(eventName) {
+		return nil, nil
+	}
+	return e.store.GetDailyReportSubscribers(ctx, eventName, hour)
+}
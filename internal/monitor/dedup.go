@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// alertSeverity ranks alert types for the dedup fail mode: critical alerts
+// are threshold crossings the user asked to hear about immediately,
+// informational ones are opportunity digests and reports.
+var alertSeverity = map[string]dedup.Severity{
+	"value_alert":         dedup.SeverityCritical,
+	"metric_alert":        dedup.SeverityCritical,
+	"binance_price_alert": dedup.SeverityCritical,
+	"maxpain_alert":       dedup.SeverityWarning,
+	"defillama_tvl_alert": dedup.SeverityWarning,
+	"merkl_alert":         dedup.SeverityInfo,
+	"turtle_alert":        dedup.SeverityInfo,
+	"defillama_alert":     dedup.SeverityInfo,
+	"defillama_lp_alert":  dedup.SeverityInfo,
+	"alpha_airdrop":       dedup.SeverityInfo,
+	"daily_report":        dedup.SeverityInfo,
+}
+
+// newSubKey builds the key of an alert of subscription subID. kind is the
+// alert family ("merkl", "" for source metric alerts) and rest identifies
+// the alert within the subscription.
+func newSubKey(subID int64, kind, rest string) string {
+	if kind == "" {
+		return dedup.SubscriptionKey(subID, rest)
+	}
+	return dedup.SubscriptionKey(subID, kind+":"+rest)
+}
+
+// alreadySent checks key for an alert of alertType. Unknown types are
+// treated as informational.
+func (e *Engine) alreadySent(ctx context.Context, key, alertType string) bool {
+	sev, ok := alertSeverity[alertType]
+	if !ok {
+		sev = dedup.SeverityInfo
+	}
+	return e.dedup.AlreadySent(ctx, key, sev)
+}
+
+// record marks key as sent.
+func (e *Engine) record(ctx context.Context, key string) {
+	e.dedup.Record(ctx, key)
+}
+
+// clear lets the alert under key fire again.
+func (e *Engine) clear(ctx context.Context, key string) {
+	e.dedup.Clear(ctx, key)
+}
+
+// legacyEvents maps the kind prefix of an old chat-scoped key to the event
+// whose subscriptions wrote it.
+var legacyEvents = map[string]string{
+	"alpha":         "general_alpha_alert",
+	"maxpain":       "general_maxpain_alert",
+	"merkl":         "general_merkl_alert",
+	"turtle":        "general_turtle_alert",
+	"binance":       "general_binance_price_alert",
+	"defillama":     "general_defillama_alert",
+	"defillama_lp":  "general_defillama_lp_alert",
+	"defillama_tvl": "general_defillama_tvl_alert",
+}
+
+// parseLegacyKey reads a key written before keys were namespaced by
+// subscription: "<chat>:<source>:…" for metric alerts,
+// "report:<date>:<chat>:<source>" for daily reports and
+// "<kind>:<chat>:<rest>" for the rest. It returns the chat, the event of
+// the subscriptions that could have written it and the key's part under
+// "sub:<id>:".
+func parseLegacyKey(key string) (chatID int64, event, rest string, ok bool) {
+	head, tail, _ := strings.Cut(key, ":")
+	if id, err := strconv.ParseInt(head, 10, 64); err == nil {
+		source, _, _ := strings.Cut(tail, ":")
+		return id, source + "_metric_alert", tail, tail != ""
+	}
+	parts := strings.SplitN(key, ":", 4)
+	if head == "report" && len(parts) == 4 {
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		return id, parts[3] + "_daily_report", "report:" + parts[1] + ":" + parts[3], err == nil
+	}
+	event, known := legacyEvents[head]
+	parts = strings.SplitN(key, ":", 3)
+	if !known || len(parts) != 3 {
+		return 0, "", "", false
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	return id, event, head + ":" + parts[2], err == nil
+}
+
+// migrateLegacyKeys moves the chat-scoped keys of older releases under
+// the subscriptions that could have written them, so upgrading does not
+// re-fire every active alert. A chat's key is copied to each of its
+// subscriptions to the event, as all of them were suppressed by it, and
+// then deleted; from then on ClearSubscription resets a subscription
+// fully. It runs before the first poll and is a no-op once no legacy key
+// is left.
+func (e *Engine) migrateLegacyKeys(ctx context.Context) {
+	if e.dedup == nil || e.store == nil {
+		return
+	}
+	keys, err := e.dedup.Keys(ctx, "*")
+	if err != nil {
+		e.logger.Error("list dedup keys for migration failed", "error", err)
+		return
+	}
+	var subs []store.EventSubscription
+	migrated := 0
+	for _, key := range keys {
+		if strings.HasPrefix(key, "sub:") {
+			continue
+		}
+		chatID, event, rest, ok := parseLegacyKey(key)
+		if !ok {
+			continue
+		}
+		if subs == nil {
+			if subs, err = e.store.ListEventSubscriptions(ctx); err != nil {
+				e.logger.Error("list subscriptions for dedup migration failed", "error", err)
+				return
+			}
+		}
+		for _, sub := range subs {
+			if sub.ChatID == chatID && sub.EventName == event {
+				e.dedup.Record(ctx, dedup.SubscriptionKey(sub.SubscriptionID, rest))
+			}
+		}
+		e.dedup.Clear(ctx, key)
+		migrated++
+	}
+	if migrated > 0 {
+		e.logger.Info("migrated chat-scoped dedup keys to subscriptions", "keys", migrated)
+	}
+}
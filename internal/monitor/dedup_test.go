@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+func TestNewSubKey(t *testing.T) {
+	tests := []struct {
+		kind, rest, key string
+	}{
+		{"", "altura:tvl:drop", "sub:7:altura:tvl:drop"},
+		{"merkl", "opp-1", "sub:7:merkl:opp-1"},
+		{"report", "2026-01-01:altura", "sub:7:report:2026-01-01:altura"},
+	}
+	for _, tt := range tests {
+		if k := newSubKey(7, tt.kind, tt.rest); k != tt.key {
+			t.Errorf("newSubKey(%q, %q) = %s, want %s", tt.kind, tt.rest, k, tt.key)
+		}
+	}
+}
+
+func TestParseLegacyKey(t *testing.T) {
+	tests := []struct {
+		key         string
+		chatID      int64
+		event, rest string
+		ok          bool
+	}{
+		{"42:altura:tvl:drop", 42, "altura_metric_alert", "altura:tvl:drop", true},
+		{"merkl:42:opp-1", 42, "general_merkl_alert", "merkl:opp-1", true},
+		{"defillama_lp:-1001:pool:a", -1001, "general_defillama_lp_alert", "defillama_lp:pool:a", true},
+		{"report:2026-01-01:42:altura", 42, "altura_daily_report", "report:2026-01-01:altura", true},
+		{"unknown:42:x", 0, "", "", false},
+		{"merkl:ada:opp-1", 0, "", "", false},
+		{"42", 0, "", "", false},
+	}
+	for _, tt := range tests {
+		chatID, event, rest, ok := parseLegacyKey(tt.key)
+		if ok != tt.ok || (ok && (chatID != tt.chatID || event != tt.event || rest != tt.rest)) {
+			t.Errorf("parseLegacyKey(%q) = %d, %q, %q, %v; want %d, %q, %q, %v",
+				tt.key, chatID, event, rest, ok, tt.chatID, tt.event, tt.rest, tt.ok)
+		}
+	}
+}
+
+// TestMigrateLegacyKeys upgrades a chat whose alert was sent under the
+// old chat-scoped key: it stays suppressed, and updating the subscription
+// lets it fire again.
+func TestMigrateLegacyKeys(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	events, _ := db.ListEvents(ctx)
+	eventIDs := make(map[string]int)
+	for _, ev := range events {
+		eventIDs[ev.Name] = ev.ID
+	}
+	for _, chatID := range []int64{42, 43} {
+		if _, err := db.UpsertLoginUser(ctx, chatID, "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var subIDs []int64
+	for range 2 {
+		sub, err := db.Subscribe(ctx, 42, eventIDs["altura_metric_alert"], 0, 1, "higher", 8, 40, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		subIDs = append(subIDs, sub.ID)
+	}
+	merkl, err := db.Subscribe(ctx, 43, eventIDs["general_merkl_alert"], 0, 1, "", 8, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dd := dedup.New(dedup.NewMemory(), nil)
+	// Written by an older release
+	for _, key := range []string{"42:altura:test_metric:higher:40", "merkl:42:opp-1", "merkl:43:opp-1", "unknown:42:x"} {
+		dd.Record(ctx, key)
+	}
+	sent := 0
+	e := NewEngine(db, slog.New(slog.DiscardHandler), func(int64, string) error {
+		sent++
+		return nil
+	}, dd)
+	e.Register(&mockSource{name: "altura", chain: "HyperEVM"}) // test_metric = 42
+
+	e.migrateLegacyKeys(ctx)
+	keys, _ := dd.Keys(ctx, "*")
+	slices.Sort(keys)
+	want := []string{
+		newSubKey(subIDs[0], "", "altura:test_metric:higher:40"),
+		newSubKey(subIDs[1], "", "altura:test_metric:higher:40"),
+		newSubKey(merkl.ID, "merkl", "opp-1"),
+		"unknown:42:x",
+	}
+	slices.Sort(want)
+	if !slices.Equal(keys, want) {
+		t.Fatalf("keys after migration = %q, want %q", keys, want)
+	}
+
+	e.pollAll(ctx)
+	if sent != 0 {
+		t.Fatalf("alerts sent after migration = %d, want the legacy hit to suppress both", sent)
+	}
+
+	// What updating or deleting a subscription does
+	dd.ClearSubscription(ctx, subIDs[0])
+	e.pollAll(ctx)
+	if sent != 1 {
+		t.Errorf("alerts sent after updating one subscription = %d, want 1", sent)
+	}
+}
+
+func TestPollAllDedupPerSubscription(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	var eventID int
+	events, _ := db.ListEvents(ctx)
+	for _, ev := range events {
+		if ev.Name == "altura_metric_alert" {
+			eventID = ev.ID
+		}
+	}
+	if _, err := db.UpsertLoginUser(ctx, 42, "ada"); err != nil {
+		t.Fatal(err)
+	}
+	// Two subscriptions with the same settings used to share one key.
+	for range 2 {
+		if _, err := db.Subscribe(ctx, 42, eventID, 0, 1, "higher", 8, 40, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sent := 0
+	e := NewEngine(db, slog.New(slog.DiscardHandler), func(int64, string) error {
+		sent++
+		return nil
+	}, dedup.New(dedup.NewMemory(), nil))
+	e.Register(&mockSource{name: "altura", chain: "HyperEVM"}) // test_metric = 42
+
+	e.pollAll(ctx)
+	e.pollAll(ctx)
+
+	if sent != 2 {
+		t.Errorf("alerts sent = %d, want one per subscription (2)", sent)
+	}
+}
@@ -8,17 +8,21 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/metrics"
 	"github.com/web3-frozen/onchain-monitor/internal/store"
+	"github.com/web3-frozen/onchain-monitor/internal/upstream"
 )
 
+// Defaults of the polling loop, see Settings.
 const (
-	pollInterval     = 1 * time.Minute
-	maxHistoryLen    = 60 // keep 60 minutes of snapshots
-	fetchTimeout     = 30 * time.Second
+	defaultPollInterval = 1 * time.Minute
+	defaultHistoryLen   = 60 // keep 60 minutes of snapshots
+	defaultFetchTimeout = 30 * time.Second
 )
 
 // AlertFunc sends a message to a Telegram chat.
@@ -27,24 +31,66 @@ type AlertFunc func(chatID int64, message string) error
 // Engine is the core monitoring engine that polls registered data sources
 // and triggers alerts based on rules.
 type Engine struct {
-	store       *store.Store
+	store       store.Store
 	logger      *slog.Logger
 	alertFn     AlertFunc
 	dedup       *dedup.Deduplicator
 	sources     map[string]Source
 	snapHistory map[string][]*Snapshot
 	mu          sync.RWMutex
+	hub         *Hub
+	control     Control // guarded by mu, see refreshControl
+
+	// Polling settings, guarded by mu; see Configure.
+	settings     Settings
+	lastPoll     map[string]time.Time
+	reconfigured chan struct{}
+	pollStats    map[string]*pollStats // see Health
+	upstreams    *upstream.Pool        // optional, see EnableUpstreamHealth
+
+	// Live state shared between replicas, see EnableFeed.
+	feed          Feed
+	feedOut       chan []byte
+	leading       atomic.Bool                      // while Run is running
+	feedUpstreams map[string][]upstream.HostState // the leader's breakers, guarded by mu
+
+	// Optional chart images, see EnableCharts.
+	photoFn     PhotoFunc
+	chartAlerts map[string]bool
 }
 
-func NewEngine(s *store.Store, logger *slog.Logger, alertFn AlertFunc, dd *dedup.Deduplicator) *Engine {
-	return &Engine{
+func NewEngine(s store.Store, logger *slog.Logger, alertFn AlertFunc, dd *dedup.Deduplicator) *Engine {
+	e := &Engine{
 		store:       s,
 		logger:      logger,
-		alertFn:     alertFn,
 		dedup:       dd,
 		sources:     make(map[string]Source),
 		snapHistory: make(map[string][]*Snapshot),
+		hub:         NewHub(),
+
+		settings:     DefaultSettings(),
+		lastPoll:     make(map[string]time.Time),
+		reconfigured: make(chan struct{}, 1),
+		pollStats:    make(map[string]*pollStats),
+
+		feedUpstreams: make(map[string][]upstream.HostState),
 	}
+	if alertFn != nil {
+		e.alertFn = func(chatID int64, msg string) error {
+			err := alertFn(chatID, msg)
+			if err != nil {
+				e.logDeliveryFailure(chatID, msg, err)
+			}
+			return err
+		}
+	}
+	return e
+}
+
+// Send delivers a message through the alert transport, recording a failure
+// like any alert's. Used for admin announcements.
+func (e *Engine) Send(chatID int64, msg string) error {
+	return e.alertFn(chatID, msg)
 }
 
 // Register adds a data source to the engine.
@@ -87,19 +133,55 @@ func (e *Engine) GetSnapshot(source string) *Snapshot {
 	return history[len(history)-1]
 }
 
-// Run starts the polling loop and daily report scheduler.
+// addSnapshot appends snap to the source's history, pushes it to stream
+// clients and, on the leader, to the followers.
+func (e *Engine) addSnapshot(name string, snap *Snapshot) {
+	e.mu.Lock()
+	history := e.snapHistory[name]
+	if len(history) > 0 && history[len(history)-1].Stale {
+		history = nil // resumed after a pause
+	}
+	history = append(history, snap)
+	if n := e.settings.HistoryLen; len(history) > n {
+		history = history[len(history)-n:]
+	}
+	e.snapHistory[name] = history
+	metrics.SnapshotCount.WithLabelValues(name).Set(float64(len(history)))
+	metrics.SnapshotAge.WithLabelValues(name).Set(time.Since(snap.FetchedAt).Seconds())
+	e.mu.Unlock()
+	e.hub.Publish(StreamEvent{Type: "snapshot", Snapshot: snap})
+	e.publish(feedEvent{Type: "snapshot", Source: name, Snapshot: snap})
+}
+
+// Run starts the polling loop and daily report scheduler. The replica
+// leads while it runs: see RunFeed.
 func (e *Engine) Run(ctx context.Context) {
+	e.leading.Store(true)
+	defer e.leading.Store(false)
+	e.mu.Lock()
+	clear(e.feedUpstreams) // this replica's own breakers from now on
+	e.mu.Unlock()
+
+	e.migrateLegacyKeys(ctx)
+
 	// Initial fetch
 	e.pollAll(ctx)
 	e.refreshBusinessGauges(ctx)
 
-	pollTicker := time.NewTicker(pollInterval)
+	interval := e.PollInterval()
+	pollTicker := time.NewTicker(interval)
 	defer pollTicker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-e.reconfigured:
+			if next := e.PollInterval(); next != interval {
+				interval = next
+				pollTicker.Reset(interval)
+				e.logger.Info("poll interval changed", "interval", interval.String())
+			}
 		case <-pollTicker.C:
 			e.pollAll(ctx)
 			e.refreshBusinessGauges(ctx)
@@ -155,11 +237,25 @@ func fetchWithTimeout(fn func() (*Snapshot, error), timeout time.Duration) (*Sna
 }
 
 func (e *Engine) pollAll(ctx context.Context) {
+	e.refreshControl(ctx)
 	for name, src := range e.sources {
 		pollStart := time.Now()
-		snap, err := fetchWithTimeout(src.FetchSnapshot, fetchTimeout)
-		pollDur := time.Since(pollStart).Seconds()
-		metrics.PollDuration.WithLabelValues(name).Observe(pollDur)
+		if e.sourcePaused(name) || !e.pollDue(name, pollStart) {
+			if snap := e.GetSnapshot(name); snap != nil {
+				metrics.SnapshotAge.WithLabelValues(name).Set(time.Since(snap.FetchedAt).Seconds())
+			}
+			continue
+		}
+		e.mu.Lock()
+		e.lastPoll[name] = pollStart
+		e.mu.Unlock()
+
+		snap, err := fetchWithTimeout(src.FetchSnapshot, e.fetchTimeout(name))
+		pollDur := time.Since(pollStart)
+		metrics.PollDuration.WithLabelValues(name).Observe(pollDur.Seconds())
+		fetchedAt := time.Now()
+		e.recordFetch(name, fetchedAt, pollDur, err)
+		e.publishFetch(name, fetchedAt, pollDur, err)
 
 		if err != nil {
 			metrics.PollTotal.WithLabelValues(name, "error").Inc()
@@ -169,16 +265,7 @@ func (e *Engine) pollAll(ctx context.Context) {
 		metrics.PollTotal.WithLabelValues(name, "success").Inc()
 		metrics.PollLastSuccess.WithLabelValues(name).Set(float64(time.Now().Unix()))
 
-		e.mu.Lock()
-		history := e.snapHistory[name]
-		history = append(history, snap)
-		if len(history) > maxHistoryLen {
-			history = history[len(history)-maxHistoryLen:]
-		}
-		e.snapHistory[name] = history
-		metrics.SnapshotCount.WithLabelValues(name).Set(float64(len(history)))
-		metrics.SnapshotAge.WithLabelValues(name).Set(time.Since(snap.FetchedAt).Seconds())
-		e.mu.Unlock()
+		e.addSnapshot(name, snap)
 
 		// Export business metric values as Prometheus gauges
 		for metricName, val := range snap.Metrics {
@@ -189,7 +276,7 @@ func (e *Engine) pollAll(ctx context.Context) {
 
 		// Per-subscriber threshold checking
 		eventName := name + "_metric_alert"
-		subscribers, err := e.store.GetSubscribersWithThresholds(ctx, eventName)
+		subscribers, err := e.subscribers(ctx, eventName)
 		if err != nil {
 			e.logger.Error("get subscribers with thresholds failed", "event", eventName, "error", err)
 			continue
@@ -203,7 +290,7 @@ func (e *Engine) pollAll(ctx context.Context) {
 			// Value-based alerts (higher/lower than threshold_value)
 			if sub.ThresholdValue > 0 && (sub.Direction == "higher" || sub.Direction == "lower") {
 				for metric, currVal := range snap.Metrics {
-					alertKey := fmt.Sprintf("%d:%s:%s:%s:%.0f", sub.ChatID, name, metric, sub.Direction, sub.ThresholdValue)
+					alertKey := newSubKey(sub.SubscriptionID, "", fmt.Sprintf("%s:%s:%s:%.0f", name, metric, sub.Direction, sub.ThresholdValue))
 					var triggered bool
 					if sub.Direction == "higher" && currVal > sub.ThresholdValue {
 						triggered = true
@@ -211,25 +298,26 @@ func (e *Engine) pollAll(ctx context.Context) {
 						triggered = true
 					}
 					if triggered {
-						if e.dedup.AlreadySent(ctx, alertKey) {
+						if e.alreadySent(ctx, alertKey, "value_alert") {
 							metrics.AlertsDeduplicatedTotal.WithLabelValues(name, "value_alert").Inc()
 							continue
 						}
 						e.sendValueAlert(sub.ChatID, src, metric, currVal, sub.ThresholdValue, sub.Direction)
-						e.dedup.Record(ctx, alertKey)
+						e.record(ctx, alertKey)
 					} else {
 						// Condition no longer met — clear so alert can fire again next time
-						e.dedup.Clear(ctx, alertKey)
+						e.clear(ctx, alertKey)
 					}
 				}
 				continue
 			}
 
 			// Percentage-based alerts (drop/increase)
-			if sub.WindowMinutes < 1 || sub.WindowMinutes >= len(hist) {
+			steps := e.windowSteps(name, sub.WindowMinutes)
+			if sub.WindowMinutes < 1 || steps >= len(hist) {
 				continue
 			}
-			pastSnap := hist[len(hist)-1-sub.WindowMinutes]
+			pastSnap := hist[len(hist)-1-steps]
 			threshold := sub.ThresholdPct / 100
 
 			for metric, currVal := range snap.Metrics {
@@ -246,17 +334,17 @@ func (e *Engine) pollAll(ctx context.Context) {
 				}
 
 				if change >= threshold {
-					alertKey := fmt.Sprintf("%d:%s:%s:%s", sub.ChatID, name, metric, sub.Direction)
-					if e.dedup.AlreadySent(ctx, alertKey) {
+					alertKey := newSubKey(sub.SubscriptionID, "", fmt.Sprintf("%s:%s:%s", name, metric, sub.Direction))
+					if e.alreadySent(ctx, alertKey, "metric_alert") {
 						metrics.AlertsDeduplicatedTotal.WithLabelValues(name, "metric_alert").Inc()
 						continue
 					}
 					e.sendMetricAlertToUser(sub.ChatID, src, metric, prevVal, currVal, change, sub.WindowMinutes, sub.Direction)
-					e.dedup.Record(ctx, alertKey)
+					e.record(ctx, alertKey)
 				} else {
 					// Condition no longer met — clear so alert can fire again
-					alertKey := fmt.Sprintf("%d:%s:%s:%s", sub.ChatID, name, metric, sub.Direction)
-					e.dedup.Clear(ctx, alertKey)
+					alertKey := newSubKey(sub.SubscriptionID, "", fmt.Sprintf("%s:%s:%s", name, metric, sub.Direction))
+					e.clear(ctx, alertKey)
 				}
 			}
 		}
@@ -289,7 +377,7 @@ func (e *Engine) pollAll(ctx context.Context) {
 
 // checkMaxpainAlerts checks if current prices have crossed liquidation max pain levels.
 func (e *Engine) checkMaxpainAlerts(ctx context.Context) {
-	maxpainSrc, ok := e.sources["maxpain"]
+	maxpainSrc, ok := e.activeSource("maxpain")
 	if !ok {
 		return
 	}
@@ -304,7 +392,7 @@ func (e *Engine) checkMaxpainAlerts(ctx context.Context) {
 		return
 	}
 
-	subscribers, err := e.store.GetSubscribersWithThresholds(ctx, "general_maxpain_alert")
+	subscribers, err := e.subscribers(ctx, "general_maxpain_alert")
 	if err != nil || len(subscribers) == 0 {
 		return
 	}
@@ -363,44 +451,46 @@ func (e *Engine) checkMaxpainAlerts(ctx context.Context) {
 		}
 
 		if dist <= threshold {
-			alertKey := fmt.Sprintf("maxpain:%d:%s:%s:%s", sub.ChatID, coin, side, interval)
-			if e.dedup.AlreadySent(ctx, alertKey) {
+			alertKey := newSubKey(sub.SubscriptionID, "maxpain", fmt.Sprintf("%s:%s:%s", coin, side, interval))
+			if e.alreadySent(ctx, alertKey, "maxpain_alert") {
 				metrics.AlertsDeduplicatedTotal.WithLabelValues("maxpain", "maxpain_alert").Inc()
 				continue
 			}
 			e.sendMaxpainAlert(sub.ChatID, maxpainSrc, coin, side, interval, entry.Price, maxpainPrice, dist)
-			e.dedup.Record(ctx, alertKey)
+			e.record(ctx, alertKey)
 		} else {
-			alertKey := fmt.Sprintf("maxpain:%d:%s:%s:%s", sub.ChatID, coin, side, interval)
-			e.dedup.Clear(ctx, alertKey)
+			alertKey := newSubKey(sub.SubscriptionID, "maxpain", fmt.Sprintf("%s:%s:%s", coin, side, interval))
+			e.clear(ctx, alertKey)
 		}
 	}
 }
 
 func (e *Engine) sendMaxpainAlert(chatID int64, src Source, coin, side, interval string, price, maxpainPrice, dist float64) {
-	sideLabel := "LONG"
-	if side == "short" {
-		sideLabel = "SHORT"
-	}
-	msg := fmt.Sprintf("🚨 %s %s MAX PAIN ALERT (%s)\n\n"+
-		"%s price ($%s) is within %.1f%% of %s max pain ($%s)!\n\n"+
-		"Current Price: $%s\n"+
-		"%s Max Pain:   $%s\n"+
-		"Interval:      %s\n\n"+
-		"🔗 %s?type=%s",
-		coin, sideLabel, interval,
-		coin, formatNum(price), dist, sideLabel, formatNum(maxpainPrice),
-		formatNum(price),
-		sideLabel, formatNum(maxpainPrice),
-		interval,
-		src.URL(), interval)
+	msg, err := e.render(chatID, "maxpain_alert", map[string]any{
+		"Coin":     coin,
+		"Side":     side,
+		"Interval": interval,
+		"Price":    price,
+		"MaxPain":  maxpainPrice,
+		"Distance": dist,
+		"URL":      src.URL(),
+	})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues("maxpain", "maxpain_alert").Inc()
+		return
+	}
 
-	if err := e.alertFn(chatID, msg); err != nil {
+	chartFn := func() ([]byte, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return e.LiquidationChart(ctx, coin, interval)
+	}
+	if err := e.deliver(chatID, "maxpain_alert", msg, chartFn); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues("maxpain", "maxpain_alert").Inc()
 		e.logger.Error("send maxpain alert failed", "chat_id", chatID, "error", err)
 	} else {
 		metrics.AlertsSentTotal.WithLabelValues("maxpain", "maxpain_alert").Inc()
-		e.logNotification(chatID, "maxpain", "general_maxpain_alert",
+		e.logNotification(chatID, "maxpain", "maxpain", "general_maxpain_alert",
 			fmt.Sprintf("%s %s within %.1f%% of max pain $%s (interval: %s)", coin, side, dist, formatNum(maxpainPrice), interval))
 	}
 }
@@ -421,12 +511,12 @@ type MerklOpp struct {
 
 // checkMerklAlerts checks for new yield opportunities matching subscriber criteria.
 func (e *Engine) checkMerklAlerts(ctx context.Context) {
-	merklSrc, ok := e.sources["merkl"]
+	merklSrc, ok := e.activeSource("merkl")
 	if !ok {
 		return
 	}
 
-	subscribers, err := e.store.GetSubscribersWithThresholds(ctx, "general_merkl_alert")
+	subscribers, err := e.subscribers(ctx, "general_merkl_alert")
 	if err != nil || len(subscribers) == 0 {
 		return
 	}
@@ -463,8 +553,8 @@ func (e *Engine) checkMerklAlerts(ctx context.Context) {
 		// Collect new (unseen) opportunities
 		var newOpps []MerklOpp
 		for _, opp := range opps {
-			alertKey := fmt.Sprintf("merkl:%d:%s", sub.ChatID, opp.ID)
-			if e.dedup.AlreadySent(ctx, alertKey) {
+			alertKey := newSubKey(sub.SubscriptionID, "merkl", opp.ID)
+			if e.alreadySent(ctx, alertKey, "merkl_alert") {
 				continue
 			}
 			newOpps = append(newOpps, opp)
@@ -479,8 +569,8 @@ func (e *Engine) checkMerklAlerts(ctx context.Context) {
 
 		// Mark all as alerted permanently — each opportunity alerts only once per user
 		for _, opp := range newOpps {
-			alertKey := fmt.Sprintf("merkl:%d:%s", sub.ChatID, opp.ID)
-			e.dedup.Record(ctx, alertKey)
+			alertKey := newSubKey(sub.SubscriptionID, "merkl", opp.ID)
+			e.record(ctx, alertKey)
 		}
 	}
 }
@@ -509,29 +599,26 @@ func (e *Engine) sendMerklGroupedAlert(chatID int64, opps []MerklOpp) {
 		return opps[i].APR > opps[j].APR
 	})
 
-	var b strings.Builder
-
-	if len(opps) == 1 {
-		b.WriteString("💰 New Yield Opportunity\n\n")
-	} else {
-		b.WriteString(fmt.Sprintf("💰 %d New Yield Opportunities\n\n", len(opps)))
-	}
-
+	items := make([]map[string]any, len(opps))
 	for i, opp := range opps {
-		stable := ""
-		if opp.Stablecoin {
-			stable = " 🟢"
-		}
-		b.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, opp.Name, stable))
-		b.WriteString(fmt.Sprintf("   APR: %.1f%% | TVL: $%s\n", opp.APR, formatMerklTVL(opp.TVL)))
-		b.WriteString(fmt.Sprintf("   %s · %s · %s\n", opp.ChainName, opp.Action, opp.Protocol))
-		b.WriteString(fmt.Sprintf("   🔗 %s\n", opp.MerklURL))
-		if i < len(opps)-1 {
-			b.WriteString("\n")
-		}
+		items[i] = map[string]any{
+			"Name":       opp.Name,
+			"Stablecoin": opp.Stablecoin,
+			"APR":        opp.APR,
+			"TVL":        opp.TVL,
+			"Chain":      opp.ChainName,
+			"Action":     opp.Action,
+			"Protocol":   opp.Protocol,
+			"URL":        opp.MerklURL,
+		}
+	}
+	msg, err := e.render(chatID, "merkl_alert", map[string]any{"Opps": items})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues("merkl", "merkl_alert").Inc()
+		return
 	}
 
-	if err := e.alertFn(chatID, b.String()); err != nil {
+	if err := e.alertFn(chatID, msg); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues("merkl", "merkl_alert").Inc()
 		e.logger.Error("send merkl alert failed", "chat_id", chatID, "error", err)
 	} else {
@@ -540,7 +627,7 @@ func (e *Engine) sendMerklGroupedAlert(chatID int64, opps []MerklOpp) {
 		for i, o := range opps {
 			names[i] = o.Name
 		}
-		e.logNotification(chatID, "merkl", "general_merkl_alert",
+		e.logNotification(chatID, "merkl", "merkl", "general_merkl_alert",
 			fmt.Sprintf("%d new opportunities: %s", len(opps), strings.Join(names, ", ")))
 	}
 }
@@ -597,12 +684,12 @@ type DefiLlamaLPOpp struct {
 
 // checkTurtleAlerts checks for new Turtle yield opportunities matching subscriber criteria.
 func (e *Engine) checkTurtleAlerts(ctx context.Context) {
-	turtleSrc, ok := e.sources["turtle"]
+	turtleSrc, ok := e.activeSource("turtle")
 	if !ok {
 		return
 	}
 
-	subscribers, err := e.store.GetSubscribersWithThresholds(ctx, "general_turtle_alert")
+	subscribers, err := e.subscribers(ctx, "general_turtle_alert")
 	if err != nil || len(subscribers) == 0 {
 		return
 	}
@@ -638,8 +725,8 @@ func (e *Engine) checkTurtleAlerts(ctx context.Context) {
 
 		var newOpps []TurtleOpp
 		for _, opp := range opps {
-			alertKey := fmt.Sprintf("turtle:%d:%s", sub.ChatID, opp.ID)
-			if e.dedup.AlreadySent(ctx, alertKey) {
+			alertKey := newSubKey(sub.SubscriptionID, "turtle", opp.ID)
+			if e.alreadySent(ctx, alertKey, "turtle_alert") {
 				continue
 			}
 			newOpps = append(newOpps, opp)
@@ -652,8 +739,8 @@ func (e *Engine) checkTurtleAlerts(ctx context.Context) {
 		e.sendTurtleGroupedAlert(sub.ChatID, newOpps)
 
 		for _, opp := range newOpps {
-			alertKey := fmt.Sprintf("turtle:%d:%s", sub.ChatID, opp.ID)
-			e.dedup.Record(ctx, alertKey)
+			alertKey := newSubKey(sub.SubscriptionID, "turtle", opp.ID)
+			e.record(ctx, alertKey)
 		}
 	}
 }
@@ -663,36 +750,27 @@ func (e *Engine) sendTurtleGroupedAlert(chatID int64, opps []TurtleOpp) {
 		return opps[i].APR > opps[j].APR
 	})
 
-	var b strings.Builder
-	if len(opps) == 1 {
-		b.WriteString("🐢 New Turtle Yield Opportunity\n\n")
-	} else {
-		b.WriteString(fmt.Sprintf("🐢 %d New Turtle Yield Opportunities\n\n", len(opps)))
-	}
-
+	items := make([]map[string]any, len(opps))
 	for i, opp := range opps {
-		stable := ""
-		if opp.Stablecoin {
-			stable = " 🟢"
-		}
-		b.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, opp.Name, stable))
-		b.WriteString(fmt.Sprintf("   Yield: %.1f%%", opp.APR))
-		if len(opp.Incentives) > 1 {
-			parts := make([]string, len(opp.Incentives))
-			for j, inc := range opp.Incentives {
-				parts[j] = fmt.Sprintf("%s: %.1f%%", inc.Name, inc.Yield)
-			}
-			b.WriteString(fmt.Sprintf(" (%s)", strings.Join(parts, " + ")))
-		}
-		b.WriteString(fmt.Sprintf(" | TVL: $%s\n", formatMerklTVL(opp.TVL)))
-		b.WriteString(fmt.Sprintf("   %s · %s · %s\n", opp.ChainName, opp.Type, opp.Organization))
-		b.WriteString(fmt.Sprintf("   🔗 %s\n", opp.TurtleURL))
-		if i < len(opps)-1 {
-			b.WriteString("\n")
-		}
+		items[i] = map[string]any{
+			"Name":         opp.Name,
+			"Stablecoin":   opp.Stablecoin,
+			"APR":          opp.APR,
+			"Incentives":   opp.Incentives,
+			"TVL":          opp.TVL,
+			"Chain":        opp.ChainName,
+			"Type":         opp.Type,
+			"Organization": opp.Organization,
+			"URL":          opp.TurtleURL,
+		}
+	}
+	msg, err := e.render(chatID, "turtle_alert", map[string]any{"Opps": items})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues("turtle", "turtle_alert").Inc()
+		return
 	}
 
-	if err := e.alertFn(chatID, b.String()); err != nil {
+	if err := e.alertFn(chatID, msg); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues("turtle", "turtle_alert").Inc()
 		e.logger.Error("send turtle alert failed", "chat_id", chatID, "error", err)
 	} else {
@@ -701,14 +779,14 @@ func (e *Engine) sendTurtleGroupedAlert(chatID int64, opps []TurtleOpp) {
 		for i, o := range opps {
 			names[i] = o.Name
 		}
-		e.logNotification(chatID, "turtle", "general_turtle_alert",
+		e.logNotification(chatID, "turtle", "turtle", "general_turtle_alert",
 			fmt.Sprintf("%d new opportunities: %s", len(opps), strings.Join(names, ", ")))
 	}
 }
 
 // checkBinancePriceAlerts checks Binance prices against subscriber thresholds.
 func (e *Engine) checkBinancePriceAlerts(ctx context.Context) {
-	binanceSrc, ok := e.sources["binance"]
+	binanceSrc, ok := e.activeSource("binance")
 	if !ok {
 		return
 	}
@@ -721,7 +799,7 @@ func (e *Engine) checkBinancePriceAlerts(ctx context.Context) {
 		return
 	}
 
-	subscribers, err := e.store.GetSubscribersWithThresholds(ctx, "general_binance_price_alert")
+	subscribers, err := e.subscribers(ctx, "general_binance_price_alert")
 	if err != nil || len(subscribers) == 0 {
 		return
 	}
@@ -760,129 +838,100 @@ func (e *Engine) checkBinancePriceAlerts(ctx context.Context) {
 			continue
 		}
 
-		alertKey := fmt.Sprintf("binance:%d:%s:%s:%.2f", sub.ChatID, coin, direction, sub.ThresholdValue)
+		alertKey := newSubKey(sub.SubscriptionID, "binance", fmt.Sprintf("%s:%s:%.2f", coin, direction, sub.ThresholdValue))
 		if triggered {
-			if e.dedup.AlreadySent(ctx, alertKey) {
+			if e.alreadySent(ctx, alertKey, "binance_price_alert") {
 				metrics.AlertsDeduplicatedTotal.WithLabelValues("binance", "binance_price_alert").Inc()
 				continue
 			}
 			e.sendBinancePriceAlert(sub.ChatID, binanceSrc, coin, price, sub.ThresholdValue, direction)
-			e.dedup.Record(ctx, alertKey)
+			e.record(ctx, alertKey)
 		} else {
-			e.dedup.Clear(ctx, alertKey)
+			e.clear(ctx, alertKey)
 		}
 	}
 }
 
 func (e *Engine) sendBinancePriceAlert(chatID int64, src Source, coin string, price, targetPrice float64, direction string) {
-	dirLabel := "⬆️ INCREASE"
-	if direction == "decrease" {
-		dirLabel = "⬇️ DECREASE"
-	}
-	msg := fmt.Sprintf("🚨 %s/USDT PRICE %s ALERT\n\n"+
-		"%s has reached your target price!\n\n"+
-		"Current Price: $%s\n"+
-		"Target Price:  $%s\n"+
-		"Direction:     %s\n\n"+
-		"🔗 https://www.binance.com/en/trade/%s_USDT",
-		coin, dirLabel,
-		coin,
-		formatNum(price),
-		formatNum(targetPrice),
-		strings.ToUpper(direction),
-		coin)
+	msg, err := e.render(chatID, "binance_price_alert", map[string]any{
+		"Coin":      coin,
+		"Direction": direction,
+		"Price":     price,
+		"Target":    targetPrice,
+	})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues("binance", "binance_price_alert").Inc()
+		return
+	}
 
-	if err := e.alertFn(chatID, msg); err != nil {
+	chartFn := func() ([]byte, error) { return e.MetricChart(src.Name(), strings.ToLower(coin)+"_price") }
+	if err := e.deliver(chatID, "binance_price_alert", msg, chartFn); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues("binance", "binance_price_alert").Inc()
 		e.logger.Error("send binance price alert failed", "chat_id", chatID, "error", err)
 	} else {
 		metrics.AlertsSentTotal.WithLabelValues("binance", "binance_price_alert").Inc()
-		e.logNotification(chatID, "binance_price", "general_binance_price_alert",
+		e.logNotification(chatID, "binance", "binance_price", "general_binance_price_alert",
 			fmt.Sprintf("%s/USDT %s to $%s (current: $%s)", coin, direction, formatNum(targetPrice), formatNum(price)))
 	}
 }
 
-func formatMerklTVL(v float64) string {
-	if v >= 1_000_000 {
-		return fmt.Sprintf("%.1fM", v/1_000_000)
-	}
-	if v >= 1_000 {
-		return fmt.Sprintf("%.0fK", v/1_000)
-	}
-	return fmt.Sprintf("%.0f", v)
-}
-
 func (e *Engine) sendMetricAlertToUser(chatID int64, src Source, metric string, prevVal, currVal, changePct float64, windowMin int, direction string) {
-	dirLabel := "DROP"
-	verb := "dropped"
-	diffSign := "-"
-	if direction == "increase" {
-		dirLabel = "INCREASE"
-		verb = "increased"
-		diffSign = "+"
-	}
 	diff := prevVal - currVal
 	if diff < 0 {
 		diff = -diff
 	}
-	msg := fmt.Sprintf("🚨 %s %s %s ALERT\n\n"+
-		"%s %s by %.1f%% in the last %d minute(s)!\n"+
-		"Previous: $%s\n"+
-		"Current:  $%s\n"+
-		"Change:   %s$%s\n\n"+
-		"🔗 %s",
-		stringToUpper(src.Name()),
-		stringToUpper(metric),
-		dirLabel,
-		stringToUpper(metric),
-		verb,
-		changePct*100,
-		windowMin,
-		formatNum(prevVal),
-		formatNum(currVal),
-		diffSign,
-		formatNum(diff),
-		src.URL())
+	msg, err := e.render(chatID, "metric_alert", map[string]any{
+		"Source":    src.Name(),
+		"Metric":    metric,
+		"Direction": direction,
+		"ChangePct": changePct * 100,
+		"Window":    windowMin,
+		"Previous":  prevVal,
+		"Current":   currVal,
+		"Diff":      diff,
+		"URL":       src.URL(),
+	})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues(src.Name(), "metric_alert").Inc()
+		return
+	}
 
-	if err := e.alertFn(chatID, msg); err != nil {
+	chartFn := func() ([]byte, error) { return e.MetricChart(src.Name(), metric) }
+	if err := e.deliver(chatID, "metric_alert", msg, chartFn); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues(src.Name(), "metric_alert").Inc()
 		e.logger.Error("send alert failed", "chat_id", chatID, "error", err)
 	} else {
 		metrics.AlertsSentTotal.WithLabelValues(src.Name(), "metric_alert").Inc()
-		e.logNotification(chatID, "metric", src.Name()+"_metric_alert",
+		e.logNotification(chatID, src.Name(), "metric", src.Name()+"_metric_alert",
 			fmt.Sprintf("%s %s %.1f%% in %dm (prev: %s, curr: %s)", metric, direction, changePct*100, windowMin, formatNum(prevVal), formatNum(currVal)))
 	}
 }
 
 func (e *Engine) sendValueAlert(chatID int64, src Source, metric string, currVal, thresholdVal float64, direction string) {
 	dirLabel := "ABOVE"
-	cmp := "&gt;"
 	if direction == "lower" {
 		dirLabel = "BELOW"
-		cmp = "&lt;"
-	}
-	msg := fmt.Sprintf("🚨 %s %s %s THRESHOLD\n\n"+
-		"%s is now %s %s %.0f!\n"+
-		"Current: %.0f\n"+
-		"Threshold: %.0f\n\n"+
-		"🔗 %s",
-		stringToUpper(src.Name()),
-		stringToUpper(metric),
-		dirLabel,
-		stringToUpper(metric),
-		cmp,
-		dirLabel,
-		thresholdVal,
-		currVal,
-		thresholdVal,
-		src.URL())
+	}
+	msg, err := e.render(chatID, "value_alert", map[string]any{
+		"Source":    src.Name(),
+		"Metric":    metric,
+		"Direction": direction,
+		"Current":   currVal,
+		"Threshold": thresholdVal,
+		"URL":       src.URL(),
+	})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues(src.Name(), "value_alert").Inc()
+		return
+	}
 
-	if err := e.alertFn(chatID, msg); err != nil {
+	chartFn := func() ([]byte, error) { return e.MetricChart(src.Name(), metric) }
+	if err := e.deliver(chatID, "value_alert", msg, chartFn); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues(src.Name(), "value_alert").Inc()
 		e.logger.Error("send alert failed", "chat_id", chatID, "error", err)
 	} else {
 		metrics.AlertsSentTotal.WithLabelValues(src.Name(), "value_alert").Inc()
-		e.logNotification(chatID, "value", src.Name()+"_metric_alert",
+		e.logNotification(chatID, src.Name(), "value", src.Name()+"_metric_alert",
 			fmt.Sprintf("%s %s %s %.0f (current: %.0f)", metric, direction, dirLabel, thresholdVal, currVal))
 	}
 }
@@ -892,38 +941,69 @@ func (e *Engine) sendDueReports(ctx context.Context, hour int) {
 	today := time.Now().In(utc8).Format("2006-01-02")
 
 	for name, src := range e.sources {
+		if e.sourcePaused(name) {
+			continue
+		}
 		eventName := name + "_daily_report"
-		chatIDs, err := e.store.GetDailyReportSubscribers(ctx, eventName, hour)
+		subs, err := e.reportSubscribers(ctx, eventName, hour)
 		if err != nil {
 			e.logger.Error("get daily report subscribers failed", "event", eventName, "hour", hour, "error", err)
 			continue
 		}
-		if len(chatIDs) == 0 {
+		if len(subs) == 0 {
 			continue
 		}
 
-		report, err := src.FetchDailyReport()
-		if err != nil {
-			e.logger.Error("fetch daily report failed", "source", name, "error", err)
-			continue
+		// Reports are fetched once per language, not once per subscriber.
+		type report struct {
+			text string
+			err  error
+		}
+		reports := make(map[string]report)
+
+		// Likewise the chart, rendered on first use and shared by everyone.
+		var chartFn func() ([]byte, error)
+		if render := e.reportChart(ctx, name); render != nil {
+			var (
+				once   sync.Once
+				img    []byte
+				imgErr error
+			)
+			chartFn = func() ([]byte, error) {
+				once.Do(func() { img, imgErr = render() })
+				return img, imgErr
+			}
 		}
 
 		sent := 0
-		for _, chatID := range chatIDs {
-			dedupKey := fmt.Sprintf("report:%s:%d:%s", today, chatID, name)
-			if e.dedup.AlreadySent(ctx, dedupKey) {
+		for _, sub := range subs {
+			chatID := sub.ChatID
+			dedupKey := newSubKey(sub.SubscriptionID, "report", today+":"+name)
+			if e.alreadySent(ctx, dedupKey, "daily_report") {
 				metrics.AlertsDeduplicatedTotal.WithLabelValues(name, "daily_report").Inc()
 				continue
 			}
-			if err := e.alertFn(chatID, report); err != nil {
+			lang := e.userLanguage(chatID)
+			r, ok := reports[lang]
+			if !ok {
+				r.text, r.err = DailyReport(src, lang)
+				reports[lang] = r
+				if r.err != nil {
+					e.logger.Error("fetch daily report failed", "source", name, "lang", lang, "error", r.err)
+				}
+			}
+			if r.err != nil {
+				continue
+			}
+			if err := e.deliver(chatID, "daily_report", r.text, chartFn); err != nil {
 				metrics.AlertsFailedTotal.WithLabelValues(name, "daily_report").Inc()
 				e.logger.Error("send alert failed", "chat_id", chatID, "error", err)
 				continue
 			}
 			metrics.AlertsSentTotal.WithLabelValues(name, "daily_report").Inc()
-			e.logNotification(chatID, "daily_report", name+"_daily_report",
+			e.logNotification(chatID, name, "daily_report", name+"_daily_report",
 				fmt.Sprintf("Daily %s report (hour %d UTC+8)", name, hour))
-			e.dedup.Record(ctx, dedupKey)
+			e.record(ctx, dedupKey)
 			sent++
 		}
 		if sent > 0 {
@@ -979,8 +1059,55 @@ func stringToUpper(s string) string {
 	return string(b)
 }
 
-// logNotification persists a notification record for debugging and audit trail.
-func (e *Engine) logNotification(chatID int64, alertType, eventName, summary string) {
+// render executes a message template in the chat's language, logging
+// failures so a broken override template shows up in logs instead of
+// silently dropping alerts.
+func (e *Engine) render(chatID int64, name string, data any) (string, error) {
+	lang := e.userLanguage(chatID)
+	msg, err := messages.Render(lang, name, data)
+	if err != nil {
+		e.logger.Error("render message failed", "template", name, "lang", lang, "error", err)
+	}
+	return msg, err
+}
+
+// userLanguage returns the chat's message language, defaulting to English
+// when it is unknown or the lookup fails.
+func (e *Engine) userLanguage(chatID int64) string {
+	if e.store == nil {
+		return messages.English
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lang, err := e.store.GetUserLanguage(ctx, chatID)
+	if err != nil {
+		e.logger.Warn("get user language failed", "chat_id", chatID, "error", err)
+		return messages.English
+	}
+	return messages.Normalize(lang)
+}
+
+// DailyReport renders src's daily report in lang when the source
+// supports it, and in English otherwise.
+func DailyReport(src Source, lang string) (string, error) {
+	if lr, ok := src.(LocalizedReporter); ok {
+		return lr.FetchDailyReportLang(lang)
+	}
+	return src.FetchDailyReport()
+}
+
+func derefFloat(p *float64) float64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// logNotification persists a notification record for debugging and audit
+// trail, and pushes it to the chat's live streams.
+func (e *Engine) logNotification(chatID int64, source, alertType, eventName, summary string) {
+	e.publishAlert(chatID, source, alertType, eventName, summary)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := e.store.LogNotification(ctx, chatID, alertType, eventName, summary); err != nil {
@@ -989,14 +1116,27 @@ func (e *Engine) logNotification(chatID int64, alertType, eventName, summary str
 	e.logger.Info("notification sent", "chat_id", chatID, "alert_type", alertType, "event", eventName, "summary", summary)
 }
 
+// logDeliveryFailure records a message the transport could not deliver,
+// for the admin API.
+func (e *Engine) logDeliveryFailure(chatID int64, msg string, sendErr error) {
+	if e.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.store.LogDeliveryFailure(ctx, chatID, msg, sendErr.Error()); err != nil {
+		e.logger.Error("log delivery failure failed", "chat_id", chatID, "error", err)
+	}
+}
+
 // checkDefiLlamaAlerts checks for USDC/USDT yield opportunities matching subscriber criteria.
 func (e *Engine) checkDefiLlamaAlerts(ctx context.Context) {
-	defillamaSrc, ok := e.sources["defillama"]
+	defillamaSrc, ok := e.activeSource("defillama")
 	if !ok {
 		return
 	}
 
-	subscribers, err := e.store.GetSubscribersWithThresholds(ctx, "general_defillama_alert")
+	subscribers, err := e.subscribers(ctx, "general_defillama_alert")
 	if err != nil || len(subscribers) == 0 {
 		return
 	}
@@ -1048,13 +1188,13 @@ func (e *Engine) checkDefiLlamaAlerts(ctx context.Context) {
 		// clear when it drops below so it can re-trigger on the next crossing.
 		var newPools []DefiLlamaOpp
 		for _, pool := range pools {
-			alertKey := fmt.Sprintf("defillama:%d:%s", sub.ChatID, pool.Pool)
+			alertKey := newSubKey(sub.SubscriptionID, "defillama", pool.Pool)
 			if pool.APY >= minAPY {
-				if !e.dedup.AlreadySent(ctx, alertKey) {
+				if !e.alreadySent(ctx, alertKey, "defillama_alert") {
 					newPools = append(newPools, pool)
 				}
 			} else {
-				e.dedup.Clear(ctx, alertKey)
+				e.clear(ctx, alertKey)
 			}
 		}
 
@@ -1070,8 +1210,8 @@ func (e *Engine) checkDefiLlamaAlerts(ctx context.Context) {
 		e.sendDefiLlamaGroupedAlert(sub.ChatID, newPools, minAPY, minTVL/1_000_000, maxWithdrawDays)
 
 		for _, pool := range newPools {
-			alertKey := fmt.Sprintf("defillama:%d:%s", sub.ChatID, pool.Pool)
-			e.dedup.Record(ctx, alertKey)
+			alertKey := newSubKey(sub.SubscriptionID, "defillama", pool.Pool)
+			e.record(ctx, alertKey)
 		}
 	}
 }
@@ -1082,43 +1222,33 @@ func (e *Engine) sendDefiLlamaGroupedAlert(chatID int64, pools []DefiLlamaOpp, m
 		return pools[i].APY > pools[j].APY
 	})
 
-	var b strings.Builder
-	if len(pools) == 1 {
-		b.WriteString("💰 New Stablecoin Yield Alert\n\n")
-	} else {
-		b.WriteString(fmt.Sprintf("💰 %d New Stablecoin Yields\n\n", len(pools)))
-	}
-
+	items := make([]map[string]any, len(pools))
 	for i, pool := range pools {
-		withdrawalInfo := "✅ Immediate"
-		if pool.WithdrawalDays > 0 {
-			withdrawalInfo = fmt.Sprintf("⏱️ %dd", pool.WithdrawalDays)
-		}
-
-		b.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, pool.Project, pool.Symbol))
-		b.WriteString(fmt.Sprintf("   Chain: %s | APY: %.2f%%", pool.Chain, pool.APY))
-		if pool.APYBase != nil || pool.APYReward != nil {
-			base := 0.0
-			reward := 0.0
-			if pool.APYBase != nil {
-				base = *pool.APYBase
-			}
-			if pool.APYReward != nil {
-				reward = *pool.APYReward
-			}
-			b.WriteString(fmt.Sprintf(" (Base: %.2f%% + Reward: %.2f%%)", base, reward))
-		}
-		b.WriteString("\n")
-		b.WriteString(fmt.Sprintf("   TVL: $%s | %s\n", formatMerklTVL(pool.TVLUsd), withdrawalInfo))
-		b.WriteString(fmt.Sprintf("   🔗 %s\n", pool.URL))
-		if i < len(pools)-1 {
-			b.WriteString("\n")
-		}
+		items[i] = map[string]any{
+			"Project":        pool.Project,
+			"Symbol":         pool.Symbol,
+			"Chain":          pool.Chain,
+			"APY":            pool.APY,
+			"HasBreakdown":   pool.APYBase != nil || pool.APYReward != nil,
+			"Base":           derefFloat(pool.APYBase),
+			"Reward":         derefFloat(pool.APYReward),
+			"TVL":            pool.TVLUsd,
+			"WithdrawalDays": pool.WithdrawalDays,
+			"URL":            pool.URL,
+		}
+	}
+	msg, err := e.render(chatID, "defillama_alert", map[string]any{
+		"Pools":   items,
+		"MinAPY":  minAPY,
+		"MinTVL":  minTVLMil,
+		"MaxDays": maxDays,
+	})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues("defillama", "defillama_alert").Inc()
+		return
 	}
 
-	b.WriteString(fmt.Sprintf("\n📋 Your filters: APY ≥%.1f%%, TVL ≥$%.0fM, ≤%dd withdrawal", minAPY, minTVLMil, maxDays))
-
-	if err := e.alertFn(chatID, b.String()); err != nil {
+	if err := e.alertFn(chatID, msg); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues("defillama", "defillama_alert").Inc()
 		e.logger.Error("send defillama alert failed", "chat_id", chatID, "error", err)
 	} else {
@@ -1127,19 +1257,19 @@ func (e *Engine) sendDefiLlamaGroupedAlert(chatID int64, pools []DefiLlamaOpp, m
 		for i, p := range pools {
 			symbols[i] = p.Symbol
 		}
-		e.logNotification(chatID, "defillama", "general_defillama_alert",
+		e.logNotification(chatID, "defillama", "defillama", "general_defillama_alert",
 			fmt.Sprintf("%d new pools: %s", len(pools), strings.Join(symbols, ", ")))
 	}
 }
 
 // checkDefiLlamaLPAlerts checks for LP/DEX reward opportunities matching subscriber criteria.
 func (e *Engine) checkDefiLlamaLPAlerts(ctx context.Context) {
-	lpSrc, ok := e.sources["defillama_lp"]
+	lpSrc, ok := e.activeSource("defillama_lp")
 	if !ok {
 		return
 	}
 
-	subscribers, err := e.store.GetSubscribersWithThresholds(ctx, "general_defillama_lp_alert")
+	subscribers, err := e.subscribers(ctx, "general_defillama_lp_alert")
 	if err != nil || len(subscribers) == 0 {
 		return
 	}
@@ -1183,13 +1313,13 @@ func (e *Engine) checkDefiLlamaLPAlerts(ctx context.Context) {
 			if pool.APYReward != nil {
 				rewardAPY = *pool.APYReward
 			}
-			alertKey := fmt.Sprintf("defillama_lp:%d:%s", sub.ChatID, pool.Pool)
+			alertKey := newSubKey(sub.SubscriptionID, "defillama_lp", pool.Pool)
 			if rewardAPY >= minRewardAPY {
-				if !e.dedup.AlreadySent(ctx, alertKey) {
+				if !e.alreadySent(ctx, alertKey, "defillama_lp_alert") {
 					newPools = append(newPools, pool)
 				}
 			} else {
-				e.dedup.Clear(ctx, alertKey)
+				e.clear(ctx, alertKey)
 			}
 		}
 
@@ -1205,8 +1335,8 @@ func (e *Engine) checkDefiLlamaLPAlerts(ctx context.Context) {
 		e.sendDefiLlamaLPGroupedAlert(sub.ChatID, newPools, minRewardAPY, minTVL/1_000_000, chainFilter)
 
 		for _, pool := range newPools {
-			alertKey := fmt.Sprintf("defillama_lp:%d:%s", sub.ChatID, pool.Pool)
-			e.dedup.Record(ctx, alertKey)
+			alertKey := newSubKey(sub.SubscriptionID, "defillama_lp", pool.Pool)
+			e.record(ctx, alertKey)
 		}
 	}
 }
@@ -1224,40 +1354,31 @@ func (e *Engine) sendDefiLlamaLPGroupedAlert(chatID int64, pools []DefiLlamaLPOp
 		return ri > rj
 	})
 
-	var b strings.Builder
-	if len(pools) == 1 {
-		b.WriteString("🏊 New LP Reward Alert\n\n")
-	} else {
-		b.WriteString(fmt.Sprintf("🏊 %d New LP Rewards\n\n", len(pools)))
-	}
-
+	items := make([]map[string]any, len(pools))
 	for i, pool := range pools {
-		rewardAPY := 0.0
-		baseAPY := 0.0
-		if pool.APYReward != nil {
-			rewardAPY = *pool.APYReward
-		}
-		if pool.APYBase != nil {
-			baseAPY = *pool.APYBase
-		}
-
-		b.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, pool.Project, pool.Symbol))
-		b.WriteString(fmt.Sprintf("   Chain: %s | Reward: %.2f%% + Base: %.2f%% = %.2f%%\n",
-			pool.Chain, rewardAPY, baseAPY, pool.APY))
-		b.WriteString(fmt.Sprintf("   TVL: $%s\n", formatMerklTVL(pool.TVLUsd)))
-		b.WriteString(fmt.Sprintf("   🔗 %s\n", pool.URL))
-		if i < len(pools)-1 {
-			b.WriteString("\n")
-		}
-	}
-
-	chainLabel := chainFilter
-	if chainFilter == "ALL" {
-		chainLabel = "All chains"
+		items[i] = map[string]any{
+			"Project": pool.Project,
+			"Symbol":  pool.Symbol,
+			"Chain":   pool.Chain,
+			"APY":     pool.APY,
+			"Base":    derefFloat(pool.APYBase),
+			"Reward":  derefFloat(pool.APYReward),
+			"TVL":     pool.TVLUsd,
+			"URL":     pool.URL,
+		}
+	}
+	msg, err := e.render(chatID, "defillama_lp_alert", map[string]any{
+		"Pools":        items,
+		"MinRewardAPY": minRewardAPY,
+		"MinTVL":       minTVLMil,
+		"Chain":        chainFilter,
+	})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues("defillama_lp", "defillama_lp_alert").Inc()
+		return
 	}
-	b.WriteString(fmt.Sprintf("\n📋 Your filters: Reward APY ≥%.1f%%, TVL ≥$%.1fM, Chain: %s", minRewardAPY, minTVLMil, chainLabel))
 
-	if err := e.alertFn(chatID, b.String()); err != nil {
+	if err := e.alertFn(chatID, msg); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues("defillama_lp", "defillama_lp_alert").Inc()
 		e.logger.Error("send defillama LP alert failed", "chat_id", chatID, "error", err)
 	} else {
@@ -1266,19 +1387,19 @@ func (e *Engine) sendDefiLlamaLPGroupedAlert(chatID int64, pools []DefiLlamaLPOp
 		for i, p := range pools {
 			symbols[i] = p.Symbol
 		}
-		e.logNotification(chatID, "defillama_lp", "general_defillama_lp_alert",
+		e.logNotification(chatID, "defillama_lp", "defillama_lp", "general_defillama_lp_alert",
 			fmt.Sprintf("%d new LP pools (%s): %s", len(pools), chainFilter, strings.Join(symbols, ", ")))
 	}
 }
 
 // checkDefiLlamaTVLAlerts checks protocol TVL changes against subscriber thresholds.
 func (e *Engine) checkDefiLlamaTVLAlerts(ctx context.Context) {
-	tvlSrc, ok := e.sources["defillama_tvl"]
+	tvlSrc, ok := e.activeSource("defillama_tvl")
 	if !ok {
 		return
 	}
 
-	subscribers, err := e.store.GetSubscribersWithThresholds(ctx, "general_defillama_tvl_alert")
+	subscribers, err := e.subscribers(ctx, "general_defillama_tvl_alert")
 	if err != nil || len(subscribers) == 0 {
 		return
 	}
@@ -1352,47 +1473,47 @@ func (e *Engine) checkDefiLlamaTVLAlerts(ctx context.Context) {
 			periodLabel = "30d"
 		}
 
-		alertKey := fmt.Sprintf("defillama_tvl:%d:%s:%s:%d:%.1f", sub.ChatID, slug, direction, period, threshold)
+		alertKey := newSubKey(sub.SubscriptionID, "defillama_tvl", fmt.Sprintf("%s:%s:%d:%.1f", slug, direction, period, threshold))
 		if triggered {
-			if e.dedup.AlreadySent(ctx, alertKey) {
+			if e.alreadySent(ctx, alertKey, "defillama_tvl_alert") {
 				metrics.AlertsDeduplicatedTotal.WithLabelValues("defillama_tvl", "defillama_tvl_alert").Inc()
 				continue
 			}
 			e.sendDefiLlamaTVLAlert(sub.ChatID, tvlSrc, slug, changePct, threshold, direction, periodLabel)
-			e.dedup.Record(ctx, alertKey)
+			e.record(ctx, alertKey)
 		} else {
-			e.dedup.Clear(ctx, alertKey)
+			e.clear(ctx, alertKey)
 		}
 	}
 }
 
 func (e *Engine) sendDefiLlamaTVLAlert(chatID int64, src Source, slug string, changePct, threshold float64, direction, periodLabel string) {
-	dirLabel := "⬇️ DROP"
 	verb := "dropped"
 	if direction == "increase" {
-		dirLabel = "⬆️ INCREASE"
 		verb = "increased"
 	}
 
 	absChange := math.Abs(changePct)
 
-	msg := fmt.Sprintf("🚨 %s TVL %s ALERT (%s)\n\n"+
-		"%s TVL has %s by %.2f%% in the last %s!\n\n"+
-		"TVL Change: %.2f%%\n"+
-		"Threshold:  %.1f%%\n"+
-		"Period:     %s\n\n"+
-		"🔗 https://defillama.com/protocol/%s",
-		strings.ToUpper(slug), dirLabel, periodLabel,
-		slug, verb, absChange, periodLabel,
-		changePct, threshold, periodLabel,
-		slug)
+	msg, err := e.render(chatID, "defillama_tvl_alert", map[string]any{
+		"Slug":      slug,
+		"Direction": direction,
+		"Period":    periodLabel,
+		"AbsChange": absChange,
+		"Change":    changePct,
+		"Threshold": threshold,
+	})
+	if err != nil {
+		metrics.AlertsFailedTotal.WithLabelValues("defillama_tvl", "defillama_tvl_alert").Inc()
+		return
+	}
 
 	if err := e.alertFn(chatID, msg); err != nil {
 		metrics.AlertsFailedTotal.WithLabelValues("defillama_tvl", "defillama_tvl_alert").Inc()
 		e.logger.Error("send defillama TVL alert failed", "chat_id", chatID, "error", err)
 	} else {
 		metrics.AlertsSentTotal.WithLabelValues("defillama_tvl", "defillama_tvl_alert").Inc()
-		e.logNotification(chatID, "defillama_tvl", "general_defillama_tvl_alert",
+		e.logNotification(chatID, "defillama_tvl", "defillama_tvl", "general_defillama_tvl_alert",
 			fmt.Sprintf("%s TVL %s %.2f%% (%s, threshold: %.1f%%)", slug, verb, absChange, periodLabel, threshold))
 	}
 }
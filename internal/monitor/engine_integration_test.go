@@ -1,10 +1,16 @@
 package monitor
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"slices"
 	"sort"
 	"testing"
 	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/dedup"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
 // mockSource implements Source for testing.
@@ -12,6 +18,7 @@ type mockSource struct {
 	name  string
 	chain string
 	snap  *Snapshot
+	err   error
 }
 
 func (m *mockSource) Name() string  { return m.name }
@@ -19,6 +26,9 @@ func (m *mockSource) Chain() string { return m.chain }
 func (m *mockSource) URL() string   { return "https://example.com" }
 
 func (m *mockSource) FetchSnapshot() (*Snapshot, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
 	if m.snap != nil {
 		return m.snap, nil
 	}
@@ -80,7 +90,7 @@ func TestEngineGetSnapshotEmpty(t *testing.T) {
 func TestFetchWithTimeout(t *testing.T) {
 	// Fast source should succeed
 	fast := &mockSource{name: "fast", chain: "Test"}
-	snap, err := fetchWithTimeout(fast.FetchSnapshot, fetchTimeout)
+	snap, err := fetchWithTimeout(fast.FetchSnapshot, defaultFetchTimeout)
 	if err != nil {
 		t.Fatalf("fetchWithTimeout(fast) error: %v", err)
 	}
@@ -108,3 +118,278 @@ func TestSnapshotGetters(t *testing.T) {
 		t.Errorf("APR() = %v, want 12.5", snap.APR())
 	}
 }
+
+func TestDeliverCharts(t *testing.T) {
+	var texts, photos []string
+	e := NewEngine(nil, slog.Default(), func(chatID int64, msg string) error {
+		texts = append(texts, msg)
+		return nil
+	}, nil)
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		e.snapHistory["src"] = append(e.snapHistory["src"], &Snapshot{
+			Metrics:   map[string]float64{"tvl": float64(100 - i)},
+			FetchedAt: now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	chartFn := func() ([]byte, error) { return e.MetricChart("src", "tvl") }
+
+	// Charts disabled: plain text.
+	if err := e.deliver(1, "metric_alert", "drop", chartFn); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	e.EnableCharts(func(chatID int64, png []byte, caption string) error {
+		if len(png) == 0 {
+			t.Error("empty chart image")
+		}
+		photos = append(photos, caption)
+		return nil
+	}, []string{"metric_alert", "bogus"})
+
+	// Enabled type with data: photo with caption.
+	_ = e.deliver(1, "metric_alert", "drop", chartFn)
+	// Type not opted in: text.
+	_ = e.deliver(1, "value_alert", "above", chartFn)
+	// Opted in but no data for the metric: falls back to text.
+	_ = e.deliver(1, "metric_alert", "apr", func() ([]byte, error) { return e.MetricChart("src", "apr") })
+
+	if want := []string{"drop", "above", "apr"}; len(texts) != len(want) || texts[0] != want[0] || texts[1] != want[1] || texts[2] != want[2] {
+		t.Errorf("texts = %q, want %q", texts, want)
+	}
+	if len(photos) != 1 || photos[0] != "drop" {
+		t.Errorf("photos = %q, want [drop]", photos)
+	}
+	if e.chartAlerts["bogus"] {
+		t.Error("unknown alert type should be ignored")
+	}
+}
+
+func TestPollAllValueAlert(t *testing.T) {
+	ctx := context.Background()
+	dd := dedup.New(dedup.NewMemory(), dedup.DefaultFailOpen)
+
+	db := store.NewMemory()
+	var eventID int
+	events, _ := db.ListEvents(ctx)
+	for _, ev := range events {
+		if ev.Name == "altura_metric_alert" {
+			eventID = ev.ID
+		}
+	}
+	if _, err := db.UpsertLoginUser(ctx, 42, "ada"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Subscribe(ctx, 42, eventID, 0, 1, "higher", 8, 40, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []int64
+	e := NewEngine(db, slog.New(slog.DiscardHandler), func(chatID int64, msg string) error {
+		sent = append(sent, chatID)
+		return nil
+	}, dd)
+	e.Register(&mockSource{name: "altura", chain: "HyperEVM"}) // test_metric = 42
+
+	// Above the threshold twice: alerted once, then deduplicated.
+	e.pollAll(ctx)
+	e.pollAll(ctx)
+
+	if len(sent) != 1 || sent[0] != 42 {
+		t.Errorf("alerts sent to %v, want [42]", sent)
+	}
+	logs, err := db.ListNotifications(ctx, 42, 10)
+	if err != nil || len(logs) != 1 || logs[0].AlertType != "value" {
+		t.Errorf("notification log = %+v, %v; want one value alert", logs, err)
+	}
+}
+
+func TestPollAllSkipsPausedSources(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	e := NewEngine(db, slog.New(slog.DiscardHandler), nil, nil)
+	e.Register(&mockSource{name: "altura", chain: "HyperEVM"})
+	e.Register(&mockSource{name: "hyperlend", chain: "HyperEVM"})
+	e.pollAll(ctx)
+
+	if err := db.SetSourcePaused(ctx, "altura", true); err != nil {
+		t.Fatal(err)
+	}
+	e.pollAll(ctx)
+	if snap := e.GetSnapshot("altura"); snap == nil || !snap.Stale {
+		t.Errorf("paused source's snapshot = %+v, want it kept and marked stale", snap)
+	}
+	if n := len(e.snapHistory["altura"]); n != 1 {
+		t.Errorf("paused source was polled: %d snapshots, want 1", n)
+	}
+	if snap := e.GetSnapshot("hyperlend"); snap == nil || snap.Stale || len(e.snapHistory["hyperlend"]) != 2 {
+		t.Error("active source was not polled")
+	}
+
+	// Resuming starts a new history, so windows never span the pause
+	if err := db.SetSourcePaused(ctx, "altura", false); err != nil {
+		t.Fatal(err)
+	}
+	e.pollAll(ctx)
+	if snap := e.GetSnapshot("altura"); snap == nil || snap.Stale || len(e.snapHistory["altura"]) != 1 {
+		t.Errorf("resumed source: %d snapshots, latest %+v; want one fresh", len(e.snapHistory["altura"]), snap)
+	}
+}
+
+func TestPollAllSkipsDisabledEvents(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	var event store.Event
+	events, _ := db.ListEvents(ctx)
+	for _, ev := range events {
+		if ev.Name == "altura_metric_alert" {
+			event = ev
+		}
+	}
+	if _, err := db.UpsertLoginUser(ctx, 42, "ada"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Subscribe(ctx, 42, event.ID, 0, 1, "higher", 8, 40, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []int64
+	e := NewEngine(db, slog.New(slog.DiscardHandler), func(chatID int64, msg string) error {
+		sent = append(sent, chatID)
+		return nil
+	}, dedup.New(dedup.NewMemory(), dedup.DefaultFailOpen))
+	e.Register(&mockSource{name: "altura", chain: "HyperEVM"}) // test_metric = 42
+
+	if _, err := db.SetEventEnabled(ctx, event.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	e.pollAll(ctx)
+	if len(sent) != 0 {
+		t.Errorf("disabled event alerted %v", sent)
+	}
+
+	// Re-enabled without a restart: evaluated from the next cycle
+	if _, err := db.SetEventEnabled(ctx, event.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	e.pollAll(ctx)
+	if len(sent) != 1 || sent[0] != 42 {
+		t.Errorf("alerts sent to %v, want [42]", sent)
+	}
+}
+
+func TestSendRecordsDeliveryFailures(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	e := NewEngine(db, slog.New(slog.DiscardHandler), func(chatID int64, msg string) error {
+		if chatID == 13 {
+			return errors.New("Forbidden: bot was blocked by the user")
+		}
+		return nil
+	}, nil)
+
+	if err := e.Send(42, "hello"); err != nil {
+		t.Fatalf("Send(42) = %v", err)
+	}
+	if err := e.Send(13, "hello"); err == nil {
+		t.Fatal("Send(13) should return the transport error")
+	}
+
+	failures, err := db.ListDeliveryFailures(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || failures[0].TgChatID != 13 || failures[0].Message != "hello" {
+		t.Errorf("delivery failures = %+v, want one for chat 13", failures)
+	}
+}
+
+func TestPollAllAppliesSettings(t *testing.T) {
+	ctx := context.Background()
+	e := NewEngine(store.NewMemory(), slog.New(slog.DiscardHandler), nil, nil)
+	e.Register(&mockSource{name: "altura", chain: "HyperEVM"})
+	e.Register(&mockSource{name: "hyperlend", chain: "HyperEVM"})
+	e.Register(&mockSource{name: "felix", chain: "HyperEVM"})
+	e.pollAll(ctx)
+
+	s := DefaultSettings()
+	s.HistoryLen = 2
+	s.Sources = map[string]SourceSettings{
+		"altura":    {Disabled: true},
+		"hyperlend": {Interval: time.Hour},
+	}
+	e.Configure(s)
+	for range 3 {
+		e.pollAll(ctx)
+	}
+	if snap := e.GetSnapshot("altura"); snap == nil || !snap.Stale || len(e.snapHistory["altura"]) != 1 {
+		t.Errorf("disabled source: %d snapshots, latest %+v; want one stale", len(e.snapHistory["altura"]), snap)
+	}
+	if n := len(e.snapHistory["hyperlend"]); n != 1 {
+		t.Errorf("hourly source polled %d times, want once", n)
+	}
+	if n := len(e.snapHistory["felix"]); n != 2 {
+		t.Errorf("felix history = %d snapshots, want history_len 2", n)
+	}
+
+	// Windows count minutes, whatever the interval between snapshots
+	if got := e.windowSteps("felix", 5); got != 5 {
+		t.Errorf("windowSteps(felix, 5m) = %d, want 5", got)
+	}
+	if got := e.windowSteps("hyperlend", 150); got != 3 {
+		t.Errorf("windowSteps(hyperlend, 150m) = %d, want 3", got)
+	}
+
+	e.Configure(DefaultSettings())
+	e.pollAll(ctx)
+	if snap := e.GetSnapshot("altura"); snap == nil || snap.Stale {
+		t.Error("re-enabled source was not polled")
+	}
+	if e.PollInterval() != time.Minute {
+		t.Errorf("PollInterval = %v, want 1m", e.PollInterval())
+	}
+}
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+	e := NewEngine(store.NewMemory(), slog.New(slog.DiscardHandler), nil, nil)
+	merkl := &mockSource{name: "merkl", chain: "Multi"}
+	e.Register(merkl)
+	e.Register(&mockSource{name: "altura", chain: "HyperEVM"})
+	e.Register(&mockSource{name: "turtle", chain: "Multi"})
+	s := DefaultSettings()
+	s.Sources = map[string]SourceSettings{"altura": {Disabled: true}, "turtle": {Interval: 5 * time.Minute}}
+	e.Configure(s)
+
+	health := e.Health()
+	if len(health) != 3 || health[1].Name != "merkl" || health[1].Status != HealthPending || health[1].LastSuccess != nil {
+		t.Fatalf("before polling: %+v, want merkl pending", health)
+	}
+	if health[2].PollInterval != "300s" {
+		t.Errorf("turtle poll_interval = %q, want its own 300s", health[2].PollInterval)
+	}
+
+	e.pollAll(ctx)
+	merkl.err = errors.New("HTTP 502 from merkl")
+	e.pollAll(ctx)
+	e.pollAll(ctx)
+
+	health = e.Health()
+	altura, m := health[0], health[1]
+	if altura.Status != HealthPaused {
+		t.Errorf("disabled source status = %q, want paused", altura.Status)
+	}
+	if m.Status != HealthFailing || m.ConsecutiveFailures != 2 || m.LastError != "HTTP 502 from merkl" || m.LastErrorAt == nil {
+		t.Errorf("failing source = %+v, want 2 failures and the error", m)
+	}
+	if m.LastSuccess == nil || m.SnapshotAgeSeconds == nil || !slices.Equal(m.Metrics, []string{"test_metric"}) {
+		t.Errorf("failing source lost its last success: %+v", m)
+	}
+
+	merkl.err = nil
+	e.pollAll(ctx)
+	if m := e.Health()[1]; m.Status != HealthOK || m.ConsecutiveFailures != 0 || m.LastError == "" {
+		t.Errorf("recovered source = %+v, want ok with the last error kept", m)
+	}
+}
@@ -0,0 +1,208 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+	"github.com/web3-frozen/onchain-monitor/internal/upstream"
+)
+
+// Feed carries live state between replicas, such as Redis pub/sub (see
+// internal/livefeed). Only the leader polls, so without one a follower's
+// snapshots, history, source health and stream stay empty.
+type Feed interface {
+	Publish(ctx context.Context, msg []byte) error
+	// Subscribe calls fn with each message until ctx is done.
+	Subscribe(ctx context.Context, fn func(msg []byte)) error
+}
+
+const (
+	// feedBuffer is how many events may wait to be published; more are
+	// dropped rather than slowing the poll loop down.
+	feedBuffer         = 256
+	feedPublishTimeout = 2 * time.Second
+	// feedRetry is the wait before subscribing again.
+	feedRetry = 5 * time.Second
+)
+
+// feedEvent is one change to the leader's live state: a fetch's outcome,
+// a snapshot (a stale one when the source was paused) or a delivered
+// alert.
+type feedEvent struct {
+	Type   string `json:"type"` // "fetch", "snapshot" or "alert"
+	Source string `json:"source,omitempty"`
+
+	At        time.Time            `json:"at,omitzero"`
+	Latency   time.Duration        `json:"latency,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	Upstreams []upstream.HostState `json:"upstreams,omitempty"`
+
+	Snapshot *Snapshot   `json:"snapshot,omitempty"`
+	Alert    *AlertEvent `json:"alert,omitempty"`
+	ChatID   int64       `json:"chat_id,omitempty"` // the alert's, which AlertEvent leaves out of JSON
+}
+
+// EnableFeed shares this replica's live state through f; see RunFeed.
+func (e *Engine) EnableFeed(f Feed) {
+	e.feed = f
+	e.feedOut = make(chan []byte, feedBuffer)
+}
+
+// RunFeed shares live state with the other replicas until ctx is done.
+// While this replica leads (Run is running), its fetches, snapshots and
+// delivered alerts are published. While it follows, the leader's are
+// applied here, so it serves the same snapshots, history, source health
+// and stream, and it reloads the control state every poll interval as
+// the leader does. It returns at once without a feed.
+func (e *Engine) RunFeed(ctx context.Context) {
+	if e.feed == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		e.sendFeed(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		e.followControl(ctx)
+	}()
+
+	for {
+		err := e.feed.Subscribe(ctx, e.applyFeed)
+		if ctx.Err() != nil {
+			return
+		}
+		e.logger.Warn("live feed subscription failed, retrying", "error", err, "retry_in", feedRetry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(feedRetry):
+		}
+	}
+}
+
+// publish queues ev for the followers while this replica leads.
+func (e *Engine) publish(ev feedEvent) {
+	if e.feed == nil || !e.leading.Load() {
+		return
+	}
+	msg, err := json.Marshal(ev)
+	if err != nil {
+		e.logger.Error("encode live feed event failed", "type", ev.Type, "error", err)
+		return
+	}
+	select {
+	case e.feedOut <- msg:
+	default:
+		metrics.LiveFeedEventsTotal.WithLabelValues("dropped").Inc()
+	}
+}
+
+// publishFetch sends a fetch's outcome, with the breakers of the hosts the
+// source called, to the followers.
+func (e *Engine) publishFetch(name string, at time.Time, latency time.Duration, err error) {
+	ev := feedEvent{Type: "fetch", Source: name, At: at, Latency: latency}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	if e.upstreams != nil {
+		ev.Upstreams = e.upstreams.Hosts(name)
+	}
+	e.publish(ev)
+}
+
+// sendFeed publishes queued events. Failures are logged when they start
+// and stop, not for every event.
+func (e *Engine) sendFeed(ctx context.Context) {
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-e.feedOut:
+			pubCtx, cancel := context.WithTimeout(ctx, feedPublishTimeout)
+			err := e.feed.Publish(pubCtx, msg)
+			cancel()
+			switch {
+			case err != nil:
+				metrics.LiveFeedEventsTotal.WithLabelValues("failed").Inc()
+				if !failing {
+					e.logger.Warn("live feed publish failed, followers will go stale", "error", err)
+				}
+				failing = true
+			default:
+				metrics.LiveFeedEventsTotal.WithLabelValues("published").Inc()
+				if failing {
+					e.logger.Info("live feed publishing again")
+				}
+				failing = false
+			}
+		}
+	}
+}
+
+// applyFeed mirrors one of the leader's events. The leader ignores them:
+// it has its own.
+func (e *Engine) applyFeed(msg []byte) {
+	if e.leading.Load() {
+		return
+	}
+	var ev feedEvent
+	if err := json.Unmarshal(msg, &ev); err != nil {
+		metrics.LiveFeedEventsTotal.WithLabelValues("invalid").Inc()
+		e.logger.Warn("invalid live feed event", "error", err)
+		return
+	}
+	switch {
+	case ev.Type == "fetch" && ev.Source != "":
+		var err error
+		if ev.Error != "" {
+			err = errors.New(ev.Error)
+		}
+		e.recordFetch(ev.Source, ev.At, ev.Latency, err)
+		if ev.Upstreams == nil {
+			ev.Upstreams = []upstream.HostState{}
+		}
+		e.mu.Lock()
+		e.feedUpstreams[ev.Source] = ev.Upstreams
+		e.mu.Unlock()
+	case ev.Type == "snapshot" && ev.Source != "" && ev.Snapshot != nil:
+		if ev.Snapshot.Stale {
+			e.markStale(ev.Source)
+		} else {
+			e.addSnapshot(ev.Source, ev.Snapshot)
+		}
+	case ev.Type == "alert" && ev.Alert != nil:
+		ev.Alert.ChatID = ev.ChatID
+		e.hub.Publish(StreamEvent{Type: "alert", Alert: ev.Alert})
+	default:
+		metrics.LiveFeedEventsTotal.WithLabelValues("invalid").Inc()
+		e.logger.Warn("invalid live feed event", "type", ev.Type)
+		return
+	}
+	metrics.LiveFeedEventsTotal.WithLabelValues("applied").Inc()
+}
+
+// followControl reloads the control state while this replica follows, so
+// its source health shows paused sources like the leader's.
+func (e *Engine) followControl(ctx context.Context) {
+	ticker := time.NewTicker(e.PollInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !e.leading.Load() {
+				e.refreshControl(ctx)
+			}
+		}
+	}
+}
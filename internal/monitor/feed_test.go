@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// nopFeed satisfies Feed; the tests move events between engines by hand.
+type nopFeed struct{}
+
+func (nopFeed) Publish(context.Context, []byte) error { return nil }
+
+func (nopFeed) Subscribe(ctx context.Context, _ func([]byte)) error {
+	<-ctx.Done()
+	return nil
+}
+
+// relay applies every event the leader has queued to the follower.
+func relay(t *testing.T, leader, follower *Engine) int {
+	t.Helper()
+	n := 0
+	for {
+		select {
+		case msg := <-leader.feedOut:
+			follower.applyFeed(msg)
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+func TestFeedMirrorsLeader(t *testing.T) {
+	ctx := context.Background()
+	newEngine := func() (*Engine, *mockSource) {
+		e := NewEngine(store.NewMemory(), slog.New(slog.DiscardHandler), nil, nil)
+		src := &mockSource{name: "merkl", chain: "Multi"}
+		e.Register(src)
+		e.EnableFeed(nopFeed{})
+		return e, src
+	}
+	leader, merkl := newEngine()
+	follower, _ := newEngine()
+
+	// Not leading yet: nothing is published
+	leader.pollAll(ctx)
+	if n := relay(t, leader, follower); n != 0 {
+		t.Fatalf("non-leader published %d events", n)
+	}
+
+	leader.leading.Store(true)
+	leader.pollAll(ctx)
+	if n := relay(t, leader, follower); n != 2 {
+		t.Fatalf("relayed %d events, want a fetch and a snapshot", n)
+	}
+	snap := follower.GetSnapshot("merkl")
+	if snap == nil || snap.Metrics["test_metric"] != 42 {
+		t.Fatalf("follower snapshot = %+v, want the leader's", snap)
+	}
+	if h := follower.Health()[0]; h.Status != HealthOK || h.LastSuccess == nil {
+		t.Errorf("follower health = %+v, want ok", h)
+	}
+
+	merkl.err = errors.New("HTTP 502 from merkl")
+	leader.pollAll(ctx)
+	relay(t, leader, follower)
+	if h := follower.Health()[0]; h.Status != HealthFailing || h.LastError != "HTTP 502 from merkl" {
+		t.Errorf("follower health = %+v, want the leader's failure", h)
+	}
+
+	leader.markStale("merkl")
+	relay(t, leader, follower)
+	if snap := follower.GetSnapshot("merkl"); snap == nil || !snap.Stale {
+		t.Errorf("follower snapshot = %+v, want it marked stale", snap)
+	}
+
+	sub := follower.Hub().Subscribe(StreamFilter{ChatID: 42})
+	defer follower.Hub().Unsubscribe(sub)
+	leader.publishAlert(42, "merkl", "threshold", "tvl_drop", "TVL fell")
+	relay(t, leader, follower)
+	select {
+	case ev := <-sub.C:
+		if ev.Alert == nil || ev.Alert.ChatID != 42 || ev.Alert.Summary != "TVL fell" || ev.Alert.Chain != "Multi" {
+			t.Errorf("follower stream got %+v, want the leader's alert", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("follower stream got no alert")
+	}
+}
+
+func TestFeedIgnoredWhileLeading(t *testing.T) {
+	ctx := context.Background()
+	e := NewEngine(store.NewMemory(), slog.New(slog.DiscardHandler), nil, nil)
+	e.Register(&mockSource{name: "merkl", chain: "Multi"})
+	e.EnableFeed(nopFeed{})
+	e.leading.Store(true)
+	e.pollAll(ctx)
+
+	// The leader hears its own events back from pub/sub
+	if n := relay(t, e, e); n != 2 {
+		t.Fatalf("relayed %d events, want 2", n)
+	}
+	e.mu.RLock()
+	n := len(e.snapHistory["merkl"])
+	e.mu.RUnlock()
+	if n != 1 {
+		t.Errorf("history has %d snapshots, want 1", n)
+	}
+}
@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/upstream"
+)
+
+// latencyWindow is the number of recent fetches averaged into
+// SourceHealth.AvgLatencyMS.
+const latencyWindow = 20
+
+// Source statuses reported in SourceHealth.Status.
+const (
+	HealthOK      = "ok"      // the last fetch succeeded
+	HealthFailing = "failing" // the last fetch failed
+	HealthPaused  = "paused"  // paused by an admin or disabled by config
+	HealthPending = "pending" // not fetched yet
+)
+
+// SourceHealth is how a source's recent fetches went, for GET /api/sources
+// and the /status page.
+type SourceHealth struct {
+	Name                string     `json:"name"`
+	Chain               string     `json:"chain"`
+	Status              string     `json:"status"`
+	LastSuccess         *time.Time `json:"last_success"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	AvgLatencyMS        float64    `json:"avg_latency_ms"`
+	SnapshotAgeSeconds  *float64   `json:"snapshot_age_seconds"`
+	Metrics             []string   `json:"metrics"`
+	PollInterval        string     `json:"poll_interval"`
+
+	// Breakers of the hosts the source called, see EnableUpstreamHealth.
+	Upstreams []upstream.HostState `json:"upstreams"`
+}
+
+// pollStats is what pollAll records about a source's fetches.
+type pollStats struct {
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+	failures    int             // consecutive
+	latencies   []time.Duration // the last latencyWindow fetches
+}
+
+// EnableUpstreamHealth adds the circuit breakers of the hosts each source
+// calls through p to its health.
+func (e *Engine) EnableUpstreamHealth(p *upstream.Pool) {
+	e.upstreams = p
+}
+
+// recordFetch updates the source's poll stats with one fetch.
+func (e *Engine) recordFetch(name string, at time.Time, latency time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.pollStats[name]
+	if st == nil {
+		st = &pollStats{}
+		e.pollStats[name] = st
+	}
+	st.latencies = append(st.latencies, latency)
+	if len(st.latencies) > latencyWindow {
+		st.latencies = st.latencies[len(st.latencies)-latencyWindow:]
+	}
+	if err != nil {
+		st.lastError, st.lastErrorAt = err.Error(), at
+		st.failures++
+		return
+	}
+	st.lastSuccess = at
+	st.failures = 0
+}
+
+// Health reports every registered source's fetches, sorted by name. Only
+// the leader polls; a follower reports what the leader sent it (RunFeed).
+func (e *Engine) Health() []SourceHealth {
+	now := time.Now()
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	list := make([]SourceHealth, 0, len(e.sources))
+	for _, name := range slices.Sorted(maps.Keys(e.sources)) {
+		h := SourceHealth{
+			Name:         name,
+			Chain:        e.sources[name].Chain(),
+			Status:       HealthPending,
+			Metrics:      []string{},
+			Upstreams:    []upstream.HostState{},
+			PollInterval: fmt.Sprintf("%gs", e.settings.sourceInterval(name).Seconds()),
+		}
+		if p := e.pollStats[name]; p != nil {
+			st := *p // copy: the pointers below must not see later fetches
+			if !st.lastSuccess.IsZero() {
+				h.LastSuccess = &st.lastSuccess
+			}
+			if !st.lastErrorAt.IsZero() {
+				h.LastError, h.LastErrorAt = st.lastError, &st.lastErrorAt
+			}
+			h.ConsecutiveFailures = st.failures
+			var total time.Duration
+			for _, l := range st.latencies {
+				total += l
+			}
+			if len(st.latencies) > 0 {
+				avg := float64(total) / float64(len(st.latencies)) / float64(time.Millisecond)
+				h.AvgLatencyMS = math.Round(avg*10) / 10
+			}
+			h.Status = HealthOK
+			if st.failures > 0 {
+				h.Status = HealthFailing
+			}
+		}
+		if history := e.snapHistory[name]; len(history) > 0 {
+			snap := history[len(history)-1]
+			age := now.Sub(snap.FetchedAt).Seconds()
+			h.SnapshotAgeSeconds = &age
+			h.Metrics = slices.Sorted(maps.Keys(snap.Metrics))
+		}
+		if hosts, ok := e.feedUpstreams[name]; ok {
+			h.Upstreams = hosts // a follower shows the leader's breakers
+		} else if e.upstreams != nil {
+			h.Upstreams = e.upstreams.Hosts(name)
+		}
+		if _, paused := e.control.PausedSources[name]; paused || e.settings.Sources[name].Disabled {
+			h.Status = HealthPaused
+		}
+		list = append(list, h)
+	}
+	return list
+}
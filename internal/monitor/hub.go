@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+)
+
+// streamBuffer is how many events a stream subscriber may fall behind
+// before it is disconnected.
+const streamBuffer = 64
+
+// StreamEvent is pushed to live stream subscribers: a new snapshot, or an
+// alert sent to the subscriber's chat.
+type StreamEvent struct {
+	Type     string      `json:"type"` // "snapshot" or "alert"
+	Snapshot *Snapshot   `json:"snapshot,omitempty"`
+	Alert    *AlertEvent `json:"alert,omitempty"`
+}
+
+// AlertEvent describes an alert that was delivered to a chat.
+type AlertEvent struct {
+	ChatID    int64     `json:"-"`
+	Source    string    `json:"source"`
+	Chain     string    `json:"chain"`
+	AlertType string    `json:"alert_type"`
+	EventName string    `json:"event_name"`
+	Summary   string    `json:"summary"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// StreamFilter selects the events a subscriber receives. Empty Sources or
+// Chains match everything; alerts are only delivered to their own ChatID
+// (0 receives none).
+type StreamFilter struct {
+	ChatID  int64
+	Sources []string
+	Chains  []string
+}
+
+// Match reports whether ev passes the filter.
+func (f StreamFilter) Match(ev StreamEvent) bool {
+	var source, chain string
+	switch {
+	case ev.Snapshot != nil:
+		source, chain = ev.Snapshot.Source, ev.Snapshot.Chain
+	case ev.Alert != nil:
+		if f.ChatID == 0 || ev.Alert.ChatID != f.ChatID {
+			return false
+		}
+		source, chain = ev.Alert.Source, ev.Alert.Chain
+	default:
+		return false
+	}
+	return matchAny(f.Sources, source) && matchAny(f.Chains, chain)
+}
+
+func matchAny(allowed []string, v string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(a, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamSubscription receives events on C until it is closed, either by
+// Hub.Unsubscribe or because the subscriber fell streamBuffer events
+// behind.
+type StreamSubscription struct {
+	C      <-chan StreamEvent
+	ch     chan StreamEvent
+	filter StreamFilter
+}
+
+// Hub fans engine events out to live stream subscribers. Publishing never
+// blocks the engine: a subscriber whose buffer is full is dropped, and its
+// client is expected to reconnect.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*StreamSubscription]struct{}
+}
+
+// NewHub creates an empty Hub.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*StreamSubscription]struct{})}
+}
+
+// Subscribe registers a subscriber for the events matching f.
+func (h *Hub) Subscribe(f StreamFilter) *StreamSubscription {
+	ch := make(chan StreamEvent, streamBuffer)
+	sub := &StreamSubscription{C: ch, ch: ch, filter: f}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	metrics.StreamClients.Set(float64(len(h.subs)))
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel. It is safe to
+// call for a subscriber the hub already dropped.
+func (h *Hub) Unsubscribe(sub *StreamSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Publish delivers ev to every matching subscriber.
+func (h *Hub) Publish(ev StreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.filter.Match(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			metrics.StreamDroppedTotal.Inc()
+			h.remove(sub)
+		}
+	}
+}
+
+func (h *Hub) remove(sub *StreamSubscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.ch)
+	metrics.StreamClients.Set(float64(len(h.subs)))
+}
+
+// Hub returns the engine's live event hub.
+func (e *Engine) Hub() *Hub {
+	return e.hub
+}
+
+// publishAlert pushes a delivered alert to the chat's streams.
+func (e *Engine) publishAlert(chatID int64, source, alertType, eventName, summary string) {
+	var chain string
+	if src, ok := e.sources[source]; ok {
+		chain = src.Chain()
+	}
+	alert := &AlertEvent{
+		ChatID:    chatID,
+		Source:    source,
+		Chain:     chain,
+		AlertType: alertType,
+		EventName: eventName,
+		Summary:   summary,
+		SentAt:    time.Now(),
+	}
+	e.hub.Publish(StreamEvent{Type: "alert", Alert: alert})
+	e.publish(feedEvent{Type: "alert", Alert: alert, ChatID: chatID})
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import "testing"
+
+func TestStreamFilterMatch(t *testing.T) {
+	snap := StreamEvent{Type: "snapshot", Snapshot: &Snapshot{Source: "altura", Chain: "HyperEVM"}}
+	alert := StreamEvent{Type: "alert", Alert: &AlertEvent{ChatID: 42, Source: "altura", Chain: "HyperEVM"}}
+
+	tests := []struct {
+		name   string
+		filter StreamFilter
+		ev     StreamEvent
+		want   bool
+	}{
+		{"snapshot no filter", StreamFilter{}, snap, true},
+		{"snapshot source match", StreamFilter{Sources: []string{"merkl", "altura"}}, snap, true},
+		{"snapshot source miss", StreamFilter{Sources: []string{"merkl"}}, snap, false},
+		{"snapshot chain case-insensitive", StreamFilter{Chains: []string{"hyperevm"}}, snap, true},
+		{"snapshot chain miss", StreamFilter{Chains: []string{"Ethereum"}}, snap, false},
+		{"alert anonymous", StreamFilter{}, alert, false},
+		{"alert own chat", StreamFilter{ChatID: 42}, alert, true},
+		{"alert other chat", StreamFilter{ChatID: 7}, alert, false},
+		{"alert own chat source miss", StreamFilter{ChatID: 42, Sources: []string{"merkl"}}, alert, false},
+		{"empty event", StreamFilter{}, StreamEvent{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(tt.ev); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	h := NewHub()
+	all := h.Subscribe(StreamFilter{})
+	merkl := h.Subscribe(StreamFilter{Sources: []string{"merkl"}})
+	defer h.Unsubscribe(all)
+	defer h.Unsubscribe(merkl)
+
+	h.Publish(StreamEvent{Type: "snapshot", Snapshot: &Snapshot{Source: "altura"}})
+
+	select {
+	case ev := <-all.C:
+		if ev.Snapshot.Source != "altura" {
+			t.Errorf("source = %q, want altura", ev.Snapshot.Source)
+		}
+	default:
+		t.Fatal("unfiltered subscriber got no event")
+	}
+	select {
+	case ev := <-merkl.C:
+		t.Fatalf("filtered subscriber got %+v", ev)
+	default:
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	h := NewHub()
+	slow := h.Subscribe(StreamFilter{})
+	ev := StreamEvent{Type: "snapshot", Snapshot: &Snapshot{Source: "altura"}}
+
+	// Fill the buffer, then one more: Publish must not block.
+	for i := 0; i <= streamBuffer; i++ {
+		h.Publish(ev)
+	}
+
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != streamBuffer {
+		t.Errorf("received %d events before close, want %d", n, streamBuffer)
+	}
+
+	// Unsubscribing an already-dropped subscriber is a no-op.
+	h.Unsubscribe(slow)
+	h.Publish(ev)
+}
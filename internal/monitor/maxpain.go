@@ -27,3 +27,13 @@ func IntervalFromMinutes(minutes int) string {
 	}
 	return "24h"
 }
+
+// ValidInterval reports whether iv is a supported max pain interval.
+func ValidInterval(iv string) bool {
+	for _, v := range maxpainIntervals {
+		if v == iv {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"math"
+	"time"
+)
+
+// Settings tune the polling loop. They come from the config file and can
+// change at runtime through Configure.
+type Settings struct {
+	PollInterval time.Duration
+	FetchTimeout time.Duration
+	HistoryLen   int // snapshots kept per source
+	Sources      map[string]SourceSettings
+}
+
+// SourceSettings override the polling loop for one source.
+type SourceSettings struct {
+	Disabled bool
+	Interval time.Duration // poll at most this often; 0 means every cycle
+	Timeout  time.Duration // 0 means Settings.FetchTimeout
+}
+
+// DefaultSettings are the settings used without a config file.
+func DefaultSettings() Settings {
+	return Settings{
+		PollInterval: defaultPollInterval,
+		FetchTimeout: defaultFetchTimeout,
+		HistoryLen:   defaultHistoryLen,
+	}
+}
+
+// Configure replaces the engine's settings. A running loop picks up a new
+// poll interval right away and everything else from its next cycle;
+// sources the settings disable are handled like paused ones.
+func (e *Engine) Configure(s Settings) {
+	e.mu.Lock()
+	prev := e.settings
+	e.settings = s
+	e.mu.Unlock()
+
+	for name := range e.sources {
+		disabled, wasDisabled := s.Sources[name].Disabled, prev.Sources[name].Disabled
+		switch {
+		case disabled && !wasDisabled:
+			e.logger.Info("source disabled by config", "source", name)
+			e.markStale(name)
+		case !disabled && wasDisabled:
+			e.logger.Info("source enabled by config", "source", name)
+		}
+	}
+
+	select {
+	case e.reconfigured <- struct{}{}:
+	default:
+	}
+}
+
+// PollInterval returns the interval between poll cycles.
+func (e *Engine) PollInterval() time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.settings.PollInterval
+}
+
+// fetchTimeout returns the deadline of one fetch from the source.
+func (e *Engine) fetchTimeout(name string) time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if t := e.settings.Sources[name].Timeout; t > 0 {
+		return t
+	}
+	return e.settings.FetchTimeout
+}
+
+// pollDue reports whether the source's own interval has passed since its
+// last poll. Cycles do not start at exact instants, so a source is due once
+// less than half a cycle of its interval remains.
+func (e *Engine) pollDue(name string, now time.Time) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	interval := e.settings.Sources[name].Interval
+	last, ok := e.lastPoll[name]
+	return !ok || interval <= 0 || now.Sub(last) >= interval-e.settings.PollInterval/2
+}
+
+// windowSteps converts a comparison window to a number of snapshots back
+// in the source's history.
+func (e *Engine) windowSteps(name string, minutes int) int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	step := e.settings.sourceInterval(name)
+	if step <= 0 {
+		return minutes
+	}
+	return int(math.Ceil(float64(time.Duration(minutes)*time.Minute) / float64(step)))
+}
+
+// sourceInterval returns how often the source is polled: every cycle, or
+// its own interval when that is longer.
+func (s Settings) sourceInterval(name string) time.Duration {
+	if iv := s.Sources[name].Interval; iv > s.PollInterval {
+		return iv
+	}
+	return s.PollInterval
+}
@@ -1,6 +1,11 @@
 package monitor
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/chart"
+)
 
 // Source defines the interface that all data sources must implement.
 // To add a new on-chain data source, create a struct that implements this
@@ -22,6 +27,20 @@ type Source interface {
 	URL() string
 }
 
+// LocalizedReporter is implemented by sources that can render their daily
+// report in a subscriber's language ("en", "zh"; see internal/messages).
+// FetchDailyReport is then expected to return the English report.
+type LocalizedReporter interface {
+	FetchDailyReportLang(lang string) (string, error)
+}
+
+// LiquidationCharter is implemented by sources that can bin recent
+// liquidations by price for a symbol and max pain interval ("24h", "7d"),
+// returning the bins and the current price.
+type LiquidationCharter interface {
+	LiquidationBins(ctx context.Context, symbol, interval string) ([]chart.Bin, float64, error)
+}
+
 // Snapshot represents a point-in-time reading from a data source.
 type Snapshot struct {
 	Source      string             `json:"source"`
@@ -29,6 +48,8 @@ type Snapshot struct {
 	Metrics     map[string]float64 `json:"metrics"`
 	DataSources map[string]string  `json:"data_sources"`
 	FetchedAt   time.Time          `json:"fetched_at"`
+	// Stale is set on the last snapshot of a source an admin paused.
+	Stale bool `json:"stale,omitempty"`
 }
 
 // legacy convenience getters used by existing code
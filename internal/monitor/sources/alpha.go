@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 )
 
-const alphaAPI = "https://alpha123.uk/api/data?fresh=1"
+const (
+	alphaAPI  = "https://alpha123.uk"
+	alphaPath = "/api/data?fresh=1"
+)
 
 type alphaAirdropResp struct {
 	Token            string          `json:"token"`
@@ -36,10 +39,11 @@ type Alpha struct {
 	airdrops []monitor.AlphaAirdrop
 }
 
-func NewAlpha() *Alpha {
+func NewAlpha(opts ...Option) *Alpha {
+	o := newOptions("alpha", alphaAPI, 15*time.Second, opts)
 	return &Alpha{
-		client:  &http.Client{Timeout: 15 * time.Second},
-		baseURL: alphaAPI,
+		client:  o.httpClient(),
+		baseURL: o.baseURL,
 	}
 }
 
@@ -48,7 +52,7 @@ func (a *Alpha) Chain() string { return "General" }
 func (a *Alpha) URL() string   { return "https://alpha123.uk/" }
 
 func (a *Alpha) FetchSnapshot() (*monitor.Snapshot, error) {
-	resp, err := a.client.Get(a.baseURL)
+	resp, err := a.client.Get(a.baseURL + alphaPath)
 	if err != nil {
 		return nil, fmt.Errorf("alpha API: %w", err)
 	}
@@ -122,16 +126,16 @@ func (a *Alpha) GetAirdrops() []monitor.AlphaAirdrop {
 }
 
 func (a *Alpha) FetchDailyReport() (string, error) {
+	return a.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (a *Alpha) FetchDailyReportLang(lang string) (string, error) {
 	a.mu.RLock()
 	ads := a.airdrops
 	a.mu.RUnlock()
 	if len(ads) == 0 {
 		return "", fmt.Errorf("no alpha airdrops available")
 	}
-	var b strings.Builder
-	b.WriteString("📣 Alpha Airdrops\n\n")
-	for i, ad := range ads {
-		b.WriteString(fmt.Sprintf("%d. %s — %s %s (%d points)\n", i+1, ad.Token, ad.Date, ad.Time, ad.Points))
-	}
-	return b.String(), nil
+	return messages.Render(lang, "alpha_report", map[string]any{"Airdrops": ads})
 }
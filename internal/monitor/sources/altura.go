@@ -9,23 +9,28 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 )
 
 const (
-	alturaVaultAPI  = "https://api.subgraph.ormilabs.com/api/public/3c4075ed-8f9c-4f62-9c5b-68a0df8bd207/subgraphs/altura-vaultservice/0.0.3/gn"
-	alturaOracleAPI = "https://api.subgraph.ormilabs.com/api/public/3c4075ed-8f9c-4f62-9c5b-68a0df8bd207/subgraphs/altura-oracle/0.0.1/gn"
+	alturaAPI        = "https://api.subgraph.ormilabs.com/api/public/3c4075ed-8f9c-4f62-9c5b-68a0df8bd207/subgraphs"
+	alturaVaultPath  = "/altura-vaultservice/0.0.3/gn"
+	alturaOraclePath = "/altura-oracle/0.0.1/gn"
 	alturaLaunchDate = "2025-12-23T20:52:36Z"
 	usdtDecimals     = 6
 )
 
 type Altura struct {
-	client *http.Client
+	client  *http.Client
+	baseURL string
 }
 
-func NewAltura() *Altura {
+func NewAltura(opts ...Option) *Altura {
+	o := newOptions("altura", alturaAPI, 15*time.Second, opts)
 	return &Altura{
-		client: &http.Client{Timeout: 15 * time.Second},
+		client:  o.httpClient(),
+		baseURL: o.baseURL,
 	}
 }
 
@@ -66,6 +71,11 @@ func (a *Altura) FetchSnapshot() (*monitor.Snapshot, error) {
 }
 
 func (a *Altura) FetchDailyReport() (string, error) {
+	return a.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (a *Altura) FetchDailyReportLang(lang string) (string, error) {
 	snap, err := a.FetchSnapshot()
 	if err != nil {
 		return "", err
@@ -76,35 +86,31 @@ func (a *Altura) FetchDailyReport() (string, error) {
 		return "", fmt.Errorf("fetch day stats: %w", err)
 	}
 
-	now := time.Now().Format("2006-01-02")
-	msg := fmt.Sprintf("📊 ALTURA DAILY REPORT — %s\n\n", now)
-	msg += fmt.Sprintf("TVL: $%s\n", formatNumber(snap.TVL()))
-	msg += fmt.Sprintf("AVLT Price: $%.4f\n", snap.Price())
-	msg += fmt.Sprintf("APR: %.2f%%\n", snap.APR())
-
-	if len(dayStats) > 0 {
-		msg += "\nTVL History:\n"
-		currentTVL := snap.TVL()
-		for _, period := range []struct {
-			label string
-			days  int
-		}{{"1d", 1}, {"7d", 7}, {"30d", 30}} {
-			if period.days < len(dayStats) {
-				pastTVL := parseAssets(dayStats[period.days].TVLAssets)
-				if pastTVL > 0 {
-					pctChange := (currentTVL - pastTVL) / pastTVL * 100
-					sign := "+"
-					if pctChange < 0 {
-						sign = ""
-					}
-					msg += fmt.Sprintf("  %s: $%s (%s%.1f%%)\n", period.label, formatNumber(pastTVL), sign, pctChange)
-				}
+	var history []map[string]any
+	currentTVL := snap.TVL()
+	for _, period := range []struct {
+		label string
+		days  int
+	}{{"1d", 1}, {"7d", 7}, {"30d", 30}} {
+		if period.days < len(dayStats) {
+			pastTVL := parseAssets(dayStats[period.days].TVLAssets)
+			if pastTVL > 0 {
+				history = append(history, map[string]any{
+					"Label":  period.label,
+					"TVL":    pastTVL,
+					"Change": (currentTVL - pastTVL) / pastTVL * 100,
+				})
 			}
 		}
 	}
 
-	msg += "\n🔗 https://app.altura.trade/stats"
-	return msg, nil
+	return messages.Render(lang, "altura_report", map[string]any{
+		"Date":    time.Now(),
+		"TVL":     currentTVL,
+		"Price":   snap.Price(),
+		"APR":     snap.APR(),
+		"History": history,
+	})
 }
 
 // --- Internal GraphQL helpers ---
@@ -124,7 +130,7 @@ func (a *Altura) fetchGlobals() (*struct {
 	LastOraclePpsUsd string
 }, error) {
 	body := `{"query":"{ globals(first: 1) { tvlAssets lastOraclePpsUsd lastOracleUpdatedAt } }"}`
-	resp, err := a.graphql(alturaVaultAPI, body)
+	resp, err := a.graphql(a.baseURL+alturaVaultPath, body)
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +164,7 @@ type oracleNavResponse struct {
 
 func (a *Altura) fetchLatestPPS() (float64, error) {
 	body := `{"query":"{ oracleNavs(first: 1, orderBy: timestamp, orderDirection: desc) { ppsUsd timestamp } }"}`
-	resp, err := a.graphql(alturaOracleAPI, body)
+	resp, err := a.graphql(a.baseURL+alturaOraclePath, body)
 	if err != nil {
 		return 0, err
 	}
@@ -188,7 +194,7 @@ func (a *Altura) fetchDayStats(count int) ([]struct {
 	TVLAssets string
 }, error) {
 	query := fmt.Sprintf(`{"query":"{ dayStats(first: %d, orderBy: date, orderDirection: desc) { id date tvlAssets } }"}`, count)
-	resp, err := a.graphql(alturaVaultAPI, query)
+	resp, err := a.graphql(a.baseURL+alturaVaultPath, query)
 	if err != nil {
 		return nil, err
 	}
@@ -250,12 +256,3 @@ func calcAPR(pps float64) float64 {
 	return (math.Pow(pps, 1/elapsed) - 1) * 100
 }
 
-func formatNumber(v float64) string {
-	if v >= 1_000_000 {
-		return fmt.Sprintf("%.2fM", v/1_000_000)
-	}
-	if v >= 1_000 {
-		return fmt.Sprintf("%.2fK", v/1_000)
-	}
-	return fmt.Sprintf("%.2f", v)
-}
@@ -8,10 +8,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 )
 
-const binanceTickerAPI = "https://api.binance.com/api/v3/ticker/price"
+const (
+	binanceAPI        = "https://api.binance.com"
+	binanceTickerPath = "/api/v3/ticker/price"
+)
 
 type binanceTickerResp struct {
 	Symbol string `json:"symbol"`
@@ -24,10 +28,11 @@ type Binance struct {
 	baseURL string
 }
 
-func NewBinance() *Binance {
+func NewBinance(opts ...Option) *Binance {
+	o := newOptions("binance", binanceAPI, 10*time.Second, opts)
 	return &Binance{
-		client:  &http.Client{Timeout: 10 * time.Second},
-		baseURL: binanceTickerAPI,
+		client:  o.httpClient(),
+		baseURL: o.baseURL,
 	}
 }
 
@@ -40,7 +45,7 @@ func (b *Binance) URL() string   { return "https://www.binance.com/en/trade/" }
 // It pairs with USDT by default.
 func (b *Binance) FetchPrice(symbol string) (float64, error) {
 	pair := strings.ToUpper(symbol) + "USDT"
-	url := fmt.Sprintf("%s?symbol=%s", b.baseURL, pair)
+	url := fmt.Sprintf("%s%s?symbol=%s", b.baseURL, binanceTickerPath, pair)
 
 	resp, err := b.client.Get(url)
 	if err != nil {
@@ -84,32 +89,18 @@ func (b *Binance) FetchSnapshot() (*monitor.Snapshot, error) {
 }
 
 func (b *Binance) FetchDailyReport() (string, error) {
+	return b.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (b *Binance) FetchDailyReportLang(lang string) (string, error) {
 	price, err := b.FetchPrice("BTC")
 	if err != nil {
 		return "", err
 	}
 
-	now := time.Now().Format("2006-01-02")
-	return fmt.Sprintf("📊 BINANCE BTC PRICE — %s\n\nBTC/USDT: $%s\n\n🔗 https://www.binance.com/en/trade/BTC_USDT",
-		now, formatBinancePrice(price)), nil
-}
-
-func formatBinancePrice(v float64) string {
-	if v >= 1_000 {
-		intPart := fmt.Sprintf("%.2f", v)
-		parts := strings.SplitN(intPart, ".", 2)
-		n := len(parts[0])
-		var result []byte
-		for i, c := range parts[0] {
-			if i > 0 && (n-i)%3 == 0 {
-				result = append(result, ',')
-			}
-			result = append(result, byte(c))
-		}
-		if len(parts) == 2 {
-			return string(result) + "." + parts[1]
-		}
-		return string(result)
-	}
-	return fmt.Sprintf("%.4f", v)
+	return messages.Render(lang, "binance_report", map[string]any{
+		"Date":  time.Now(),
+		"Price": price,
+	})
 }
@@ -7,27 +7,6 @@ import (
 	"testing"
 )
 
-func TestFormatBinancePrice(t *testing.T) {
-	tests := []struct {
-		input float64
-		want  string
-	}{
-		{0.0045, "0.0045"},
-		{0.5, "0.5000"},
-		{999.99, "999.9900"},
-		{1000, "1,000.00"},
-		{12345.67, "12,345.67"},
-		{95432.10, "95,432.10"},
-		{100000.00, "100,000.00"},
-	}
-	for _, tt := range tests {
-		got := formatBinancePrice(tt.input)
-		if got != tt.want {
-			t.Errorf("formatBinancePrice(%v) = %q, want %q", tt.input, got, tt.want)
-		}
-	}
-}
-
 func TestFetchPrice(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		symbol := r.URL.Query().Get("symbol")
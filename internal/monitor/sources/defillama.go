@@ -1,7 +1,6 @@
 package sources
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -12,12 +11,16 @@ import (
 	"sync"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
-const defillamaAPI = "https://yields.llama.fi/pools?include=flexible"
+const (
+	defillamaAPI       = "https://yields.llama.fi"
+	defillamaPoolsPath = "/pools?include=flexible"
+)
 
 // Pre-compiled regex patterns for withdrawal day parsing
 var (
@@ -122,24 +125,22 @@ func (p *DefiLlamaPool) DefiLlamaURL() string {
 	return "https://defillama.com/yields/pool/" + p.Pool
 }
 
-type defillamaResponse struct {
-	Status string          `json:"status"`
-	Data   []DefiLlamaPool `json:"data"`
-}
-
 // DefiLlama fetches stablecoin yield opportunities from DeFi Llama.
 type DefiLlama struct {
 	baseURL string
 	client  *http.Client
+	cache   *PoolsCache
 	logger  *slog.Logger
 	mu      sync.RWMutex
 	pools   []DefiLlamaPool
 }
 
-func NewDefiLlama(logger *slog.Logger) *DefiLlama {
+func NewDefiLlama(logger *slog.Logger, opts ...Option) *DefiLlama {
+	o := newOptions("defillama", defillamaAPI, 30*time.Second, opts)
 	return &DefiLlama{
-		baseURL: defillamaAPI,
-		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: o.baseURL,
+		client:  o.httpClient(),
+		cache:   o.poolsCache(),
 		logger:  logger,
 	}
 }
@@ -148,28 +149,10 @@ func (d *DefiLlama) Name() string  { return "defillama" }
 func (d *DefiLlama) Chain() string { return "General" }
 func (d *DefiLlama) URL() string   { return "https://defillama.com/yields" }
 
-// FetchAllPools fetches all pools from DeFi Llama API.
+// FetchAllPools returns all pools from DeFi Llama, through the pools
+// cache. The slice is shared and must not be modified.
 func (d *DefiLlama) FetchAllPools() ([]DefiLlamaPool, error) {
-	resp, err := d.client.Get(d.baseURL)
-	if err != nil {
-		return nil, fmt.Errorf("defillama API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("defillama API status: %d", resp.StatusCode)
-	}
-
-	var data defillamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("decode defillama: %w", err)
-	}
-
-	if data.Status != "success" {
-		return nil, fmt.Errorf("defillama API returned status: %s", data.Status)
-	}
-
-	return data.Data, nil
+	return d.cache.Pools(d.client, d.baseURL+defillamaPoolsPath)
 }
 
 // FilterStablePools filters pools for stablecoin yields with given criteria.
@@ -342,6 +325,11 @@ func (d *DefiLlama) FetchSnapshot() (*monitor.Snapshot, error) {
 }
 
 func (d *DefiLlama) FetchDailyReport() (string, error) {
+	return d.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (d *DefiLlama) FetchDailyReportLang(lang string) (string, error) {
 	d.mu.RLock()
 	pools := d.pools
 	d.mu.RUnlock()
@@ -357,40 +345,36 @@ func (d *DefiLlama) FetchDailyReport() (string, error) {
 		return sorted[i].APY > sorted[j].APY
 	})
 
-	var b strings.Builder
-	b.WriteString("💰 DeFi Llama USDC/USDT Yields Report\n\n")
-
 	count := 10
 	if len(sorted) < count {
 		count = len(sorted)
 	}
 
-	for i := 0; i < count; i++ {
-		p := sorted[i]
-		withdrawalInfo := "✅ Immediate"
-		if days := p.WithdrawalDays(); days > 0 {
-			withdrawalInfo = fmt.Sprintf("⏱️ %dd", days)
+	items := make([]map[string]any, count)
+	for i, p := range sorted[:count] {
+		base, reward := 0.0, 0.0
+		if p.APYBase != nil {
+			base = *p.APYBase
 		}
-
-		b.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, p.ProjectDisplayName(), p.Symbol))
-		b.WriteString(fmt.Sprintf("   Chain: %s | APY: %.2f%%", p.Chain, p.APY))
-		if p.APYBase != nil || p.APYReward != nil {
-			base := 0.0
-			reward := 0.0
-			if p.APYBase != nil {
-				base = *p.APYBase
-			}
-			if p.APYReward != nil {
-				reward = *p.APYReward
-			}
-			b.WriteString(fmt.Sprintf(" (Base: %.2f%% + Reward: %.2f%%)", base, reward))
+		if p.APYReward != nil {
+			reward = *p.APYReward
+		}
+		items[i] = map[string]any{
+			"Project":        p.ProjectDisplayName(),
+			"Symbol":         p.Symbol,
+			"Chain":          p.Chain,
+			"APY":            p.APY,
+			"HasBreakdown":   p.APYBase != nil || p.APYReward != nil,
+			"Base":           base,
+			"Reward":         reward,
+			"TVL":            p.TVLUsd,
+			"WithdrawalDays": p.WithdrawalDays(),
+			"URL":            p.DefiLlamaURL(),
 		}
-		b.WriteString(fmt.Sprintf(" | TVL: $%s\n", fmtTVL(p.TVLUsd)))
-		b.WriteString(fmt.Sprintf("   Withdrawal: %s\n", withdrawalInfo))
-		b.WriteString(fmt.Sprintf("   🔗 %s\n", p.DefiLlamaURL()))
-		b.WriteString("\n")
 	}
 
-	b.WriteString(fmt.Sprintf("Total: %d USDC/USDT pools tracked\n", len(pools)))
-	return b.String(), nil
+	return messages.Render(lang, "defillama_report", map[string]any{
+		"Pools": items,
+		"Total": len(pools),
+	})
 }
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 )
 
@@ -18,16 +19,19 @@ import (
 type DefiLlamaLP struct {
 	baseURL string
 	client  *http.Client
+	cache   *PoolsCache
 	logger  *slog.Logger
 	mu      sync.RWMutex
 	pools   []DefiLlamaPool
 	chains  []string // cached list of available chains
 }
 
-func NewDefiLlamaLP(logger *slog.Logger) *DefiLlamaLP {
+func NewDefiLlamaLP(logger *slog.Logger, opts ...Option) *DefiLlamaLP {
+	o := newOptions("defillama_lp", defillamaAPI, 30*time.Second, opts)
 	return &DefiLlamaLP{
-		baseURL: defillamaAPI,
-		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: o.baseURL,
+		client:  o.httpClient(),
+		cache:   o.poolsCache(),
 		logger:  logger,
 	}
 }
@@ -167,6 +171,11 @@ func (d *DefiLlamaLP) FetchSnapshot() (*monitor.Snapshot, error) {
 }
 
 func (d *DefiLlamaLP) FetchDailyReport() (string, error) {
+	return d.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (d *DefiLlamaLP) FetchDailyReportLang(lang string) (string, error) {
 	d.mu.RLock()
 	pools := d.pools
 	d.mu.RUnlock()
@@ -176,16 +185,13 @@ func (d *DefiLlamaLP) FetchDailyReport() (string, error) {
 	}
 
 	// Already sorted by reward APY in FilterLPPools
-	var b strings.Builder
-	b.WriteString("🏊 DeFi Llama LP Rewards Report\n\n")
-
 	count := 15
 	if len(pools) < count {
 		count = len(pools)
 	}
 
-	for i := 0; i < count; i++ {
-		p := pools[i]
+	items := make([]map[string]any, count)
+	for i, p := range pools[:count] {
 		rewardAPY := 0.0
 		baseAPY := 0.0
 		if p.APYReward != nil {
@@ -194,23 +200,28 @@ func (d *DefiLlamaLP) FetchDailyReport() (string, error) {
 		if p.APYBase != nil {
 			baseAPY = *p.APYBase
 		}
-
-		b.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, p.ProjectDisplayName(), p.Symbol))
-		b.WriteString(fmt.Sprintf("   Chain: %s | Reward: %.2f%% + Base: %.2f%% = %.2f%%\n",
-			p.Chain, rewardAPY, baseAPY, p.APY))
-		b.WriteString(fmt.Sprintf("   TVL: $%s\n", fmtTVL(p.TVLUsd)))
-		b.WriteString(fmt.Sprintf("   🔗 %s\n", p.DefiLlamaURL()))
-		b.WriteString("\n")
+		items[i] = map[string]any{
+			"Project": p.ProjectDisplayName(),
+			"Symbol":  p.Symbol,
+			"Chain":   p.Chain,
+			"Reward":  rewardAPY,
+			"Base":    baseAPY,
+			"APY":     p.APY,
+			"TVL":     p.TVLUsd,
+			"URL":     p.DefiLlamaURL(),
+		}
 	}
 
-	b.WriteString(fmt.Sprintf("Total: %d LP pools tracked (reward APY ≥ 0.1%%, TVL ≥ $100K)\n", len(pools)))
-	return b.String(), nil
+	return messages.Render(lang, "defillama_lp_report", map[string]any{
+		"Pools": items,
+		"Total": len(pools),
+	})
 }
 
 // fetchAllPools wraps the shared DefiLlama API call.
 func (d *DefiLlamaLP) fetchAllPools() ([]DefiLlamaPool, error) {
 	// Reuse the same API fetching logic as the stablecoin source.
-	tmp := &DefiLlama{baseURL: d.baseURL, client: d.client, logger: d.logger}
+	tmp := &DefiLlama{baseURL: d.baseURL, client: d.client, cache: d.cache, logger: d.logger}
 	return tmp.FetchAllPools()
 }
 
@@ -0,0 +1,170 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+)
+
+// defaultPoolsTTL is how long a pools download is served before it is
+// revalidated. DeFi Llama recomputes yields about hourly.
+const defaultPoolsTTL = 5 * time.Minute
+
+// PoolsCache shares the yields.llama.fi pools list, tens of MB, between the
+// sources that read it, so one download serves DefiLlama, DefiLlamaLP and
+// their alert checks. Entries are keyed by URL, so sources pointed at
+// different base URLs never mix.
+type PoolsCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*poolsEntry
+}
+
+type poolsEntry struct {
+	mu           sync.Mutex // held while refreshing: callers wait for one download
+	pools        []DefiLlamaPool
+	fetchedAt    time.Time
+	etag         string
+	lastModified string
+}
+
+// NewPoolsCache creates a cache serving a download for ttl.
+func NewPoolsCache(ttl time.Duration) *PoolsCache {
+	return &PoolsCache{ttl: ttl, now: time.Now, entries: make(map[string]*poolsEntry)}
+}
+
+// Pools returns the pools at url, fetching them through client once the
+// cached copy is older than the TTL. A cached copy is revalidated with
+// If-None-Match and If-Modified-Since, so an unchanged list is not sent
+// again. While the API fails, the last list downloaded is served and every
+// call tries again. The slice is shared: callers must not modify it.
+func (c *PoolsCache) Pools(client *http.Client, url string) ([]DefiLlamaPool, error) {
+	c.mu.Lock()
+	e := c.entries[url]
+	if e == nil {
+		e = &poolsEntry{}
+		c.entries[url] = e
+	}
+	c.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pools != nil && c.now().Sub(e.fetchedAt) < c.ttl {
+		metrics.PoolsCacheRequestsTotal.WithLabelValues("hit").Inc()
+		return e.pools, nil
+	}
+	pools, err := c.fetch(client, url, e)
+	if err != nil {
+		if e.pools != nil {
+			metrics.PoolsCacheRequestsTotal.WithLabelValues("stale").Inc()
+			return e.pools, nil
+		}
+		metrics.PoolsCacheRequestsTotal.WithLabelValues("error").Inc()
+		return nil, err
+	}
+	return pools, nil
+}
+
+// fetch downloads the pools into e, or keeps e's if they have not changed.
+func (c *PoolsCache) fetch(client *http.Client, url string, e *poolsEntry) ([]DefiLlamaPool, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("defillama API: %w", err)
+	}
+	if e.pools != nil {
+		if e.etag != "" {
+			req.Header.Set("If-None-Match", e.etag)
+		}
+		if e.lastModified != "" {
+			req.Header.Set("If-Modified-Since", e.lastModified)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("defillama API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && e.pools != nil {
+		e.fetchedAt = c.now()
+		metrics.PoolsCacheRequestsTotal.WithLabelValues("revalidated").Inc()
+		return e.pools, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("defillama API status: %d", resp.StatusCode)
+	}
+	pools, err := decodePools(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	e.pools, e.fetchedAt = pools, c.now()
+	e.etag, e.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	metrics.PoolsCacheRequestsTotal.WithLabelValues("miss").Inc()
+	return pools, nil
+}
+
+// decodePools reads a pools response one pool at a time, so the decoder
+// buffers a single pool rather than the whole payload.
+func decodePools(r io.Reader) ([]DefiLlamaPool, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("decode defillama: want an object, got %v (%v)", tok, err)
+	}
+	var (
+		status string
+		pools  = []DefiLlamaPool{}
+	)
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("decode defillama: %w", err)
+		}
+		switch key {
+		case "status":
+			err = dec.Decode(&status)
+		case "data":
+			pools, err = decodePoolArray(dec)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode defillama %v: %w", key, err)
+		}
+	}
+	if status != "success" {
+		return nil, fmt.Errorf("defillama API returned status: %s", status)
+	}
+	return pools, nil
+}
+
+// decodePoolArray decodes the "data" array (or null) element by element.
+func decodePoolArray(dec *json.Decoder) ([]DefiLlamaPool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return []DefiLlamaPool{}, nil
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("want an array, got %v", tok)
+	}
+	var pools []DefiLlamaPool
+	for dec.More() {
+		var p DefiLlamaPool
+		if err := dec.Decode(&p); err != nil {
+			return nil, err
+		}
+		pools = append(pools, p)
+	}
+	_, err = dec.Token() // ]
+	return pools, err
+}
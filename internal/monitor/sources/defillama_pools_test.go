@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolsCache(t *testing.T) {
+	var downloads, revalidations atomic.Int32
+	var failing atomic.Bool
+	body := `{"status":"success","data":[{"pool":"1","symbol":"USDC","apy":4.5,"tvlUsd":2000000,"stablecoin":true},` +
+		`{"pool":"2","symbol":"WETH-USDC","chain":"Sui","apy":20,"apyReward":15,"tvlUsd":300000,"exposure":"multi"}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pools" {
+			http.NotFound(w, r)
+			return
+		}
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	cache := NewPoolsCache(time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	stable := NewDefiLlama(nil, WithBaseURL(srv.URL), WithPoolsCache(cache))
+	lp := NewDefiLlamaLP(nil, WithBaseURL(srv.URL), WithPoolsCache(cache))
+
+	if _, err := stable.FetchSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := lp.FetchSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloads.Load() != 1 {
+		t.Errorf("two sources downloaded the pools %d times, want once", downloads.Load())
+	}
+	if snap.Metrics["lp_pools"] != 1 {
+		t.Errorf("lp_pools = %v, want 1 from the shared download", snap.Metrics["lp_pools"])
+	}
+
+	// After the TTL the list is revalidated, not downloaded again
+	now = now.Add(2 * time.Minute)
+	pools, err := stable.FetchAllPools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 2 || downloads.Load() != 1 || revalidations.Load() != 1 {
+		t.Errorf("after TTL: %d pools, %d downloads, %d revalidations; want 2, 1, 1", len(pools), downloads.Load(), revalidations.Load())
+	}
+
+	// While the API fails the last good list is served
+	failing.Store(true)
+	now = now.Add(2 * time.Minute)
+	pools, err = stable.FetchAllPools()
+	if err != nil || len(pools) != 2 {
+		t.Errorf("API down: %d pools, %v; want the last 2", len(pools), err)
+	}
+
+	// Another base URL is another entry
+	other := NewDefiLlama(nil, WithBaseURL(srv.URL+"/other"), WithPoolsCache(cache))
+	if _, err := other.FetchAllPools(); err == nil {
+		t.Error("entry for another base URL was served from the shared one")
+	}
+}
+
+func TestDecodePools(t *testing.T) {
+	pools, err := decodePools(strings.NewReader(`{"extra":{"a":[1,2]},"data":[{"pool":"1","symbol":"USDT","unknown":true}],"status":"success"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 1 || pools[0].Symbol != "USDT" {
+		t.Errorf("pools = %+v, want the USDT pool", pools)
+	}
+
+	for name, body := range map[string]string{
+		"error status":  `{"status":"error","data":null}`,
+		"not an object": `[]`,
+		"bad pool":      `{"status":"success","data":[{"apy":"high"}]}`,
+		"truncated":     `{"status":"success","data":[{"pool":"1"}`,
+	} {
+		if _, err := decodePools(strings.NewReader(body)); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+}
@@ -153,15 +153,17 @@ func TestDefiLlama_FilterStablePools(t *testing.T) {
 	}
 }
 
+// poolsResponse is a yields.llama.fi pools payload.
+func poolsResponse(status string, pools []DefiLlamaPool) map[string]any {
+	return map[string]any{"status": status, "data": pools}
+}
+
 func TestDefiLlama_FetchSnapshot(t *testing.T) {
-	data := defillamaResponse{
-		Status: "success",
-		Data: []DefiLlamaPool{
-			{Pool: "1", Symbol: "USDC", Chain: "Ethereum", Project: "aave-v3", APY: 4.5, TVLUsd: 2000000, Stablecoin: true},
-			{Pool: "2", Symbol: "USDT", Chain: "Ethereum", Project: "compound", APY: 3.2, TVLUsd: 1500000, Stablecoin: true},
-			{Pool: "3", Symbol: "ETH", Chain: "Ethereum", Project: "lido", APY: 2.0, TVLUsd: 10000000, Stablecoin: false},
-		},
-	}
+	data := poolsResponse("success", []DefiLlamaPool{
+		{Pool: "1", Symbol: "USDC", Chain: "Ethereum", Project: "aave-v3", APY: 4.5, TVLUsd: 2000000, Stablecoin: true},
+		{Pool: "2", Symbol: "USDT", Chain: "Ethereum", Project: "compound", APY: 3.2, TVLUsd: 1500000, Stablecoin: true},
+		{Pool: "3", Symbol: "ETH", Chain: "Ethereum", Project: "lido", APY: 2.0, TVLUsd: 10000000, Stablecoin: false},
+	})
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -234,13 +236,10 @@ func TestDefiLlama_NameChainURL(t *testing.T) {
 }
 
 func TestDefiLlama_FetchAllPools(t *testing.T) {
-	data := defillamaResponse{
-		Status: "success",
-		Data: []DefiLlamaPool{
-			{Pool: "1", Symbol: "USDC", APY: 4.5, TVLUsd: 1000000, Stablecoin: true},
-			{Pool: "2", Symbol: "USDT", APY: 3.2, TVLUsd: 500000, Stablecoin: true},
-		},
-	}
+	data := poolsResponse("success", []DefiLlamaPool{
+		{Pool: "1", Symbol: "USDC", APY: 4.5, TVLUsd: 1000000, Stablecoin: true},
+		{Pool: "2", Symbol: "USDT", APY: 3.2, TVLUsd: 500000, Stablecoin: true},
+	})
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -276,7 +275,7 @@ func TestDefiLlama_FetchAllPools_ErrorStatus(t *testing.T) {
 }
 
 func TestDefiLlama_FetchAllPools_NonSuccessStatus(t *testing.T) {
-	data := defillamaResponse{Status: "error"}
+	data := poolsResponse("error", nil)
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(data)
@@ -355,14 +354,11 @@ func TestDefiLlama_GetPools(t *testing.T) {
 }
 
 func TestDefiLlama_GetFilteredPools(t *testing.T) {
-	data := defillamaResponse{
-		Status: "success",
-		Data: []DefiLlamaPool{
-			{Pool: "1", Symbol: "USDC", Chain: "Ethereum", Project: "aave-v3", APY: 5.0, TVLUsd: 2000000, Stablecoin: true},
-			{Pool: "2", Symbol: "USDT", Chain: "Ethereum", Project: "compound", APY: 3.0, TVLUsd: 1000000, Stablecoin: true},
-			{Pool: "3", Symbol: "ETH", Chain: "Ethereum", Project: "lido", APY: 8.0, TVLUsd: 5000000, Stablecoin: false},
-		},
-	}
+	data := poolsResponse("success", []DefiLlamaPool{
+		{Pool: "1", Symbol: "USDC", Chain: "Ethereum", Project: "aave-v3", APY: 5.0, TVLUsd: 2000000, Stablecoin: true},
+		{Pool: "2", Symbol: "USDT", Chain: "Ethereum", Project: "compound", APY: 3.0, TVLUsd: 1000000, Stablecoin: true},
+		{Pool: "3", Symbol: "ETH", Chain: "Ethereum", Project: "lido", APY: 8.0, TVLUsd: 5000000, Stablecoin: false},
+	})
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -387,15 +383,12 @@ func TestDefiLlama_GetFilteredPools(t *testing.T) {
 }
 
 func TestDefiLlama_FetchSnapshot_AllStableMetrics(t *testing.T) {
-	data := defillamaResponse{
-		Status: "success",
-		Data: []DefiLlamaPool{
-			{Pool: "1", Symbol: "USDC", Chain: "Ethereum", Project: "aave-v3", APY: 4.5, TVLUsd: 2000000, Stablecoin: true},
-			{Pool: "2", Symbol: "USDT", Chain: "Ethereum", Project: "compound", APY: 3.2, TVLUsd: 1500000, Stablecoin: true},
-			{Pool: "3", Symbol: "DAI", Chain: "Ethereum", Project: "spark", APY: 6.0, TVLUsd: 3000000, Stablecoin: true},
-			{Pool: "4", Symbol: "ETH", Chain: "Ethereum", Project: "lido", APY: 2.0, TVLUsd: 10000000, Stablecoin: false},
-		},
-	}
+	data := poolsResponse("success", []DefiLlamaPool{
+		{Pool: "1", Symbol: "USDC", Chain: "Ethereum", Project: "aave-v3", APY: 4.5, TVLUsd: 2000000, Stablecoin: true},
+		{Pool: "2", Symbol: "USDT", Chain: "Ethereum", Project: "compound", APY: 3.2, TVLUsd: 1500000, Stablecoin: true},
+		{Pool: "3", Symbol: "DAI", Chain: "Ethereum", Project: "spark", APY: 6.0, TVLUsd: 3000000, Stablecoin: true},
+		{Pool: "4", Symbol: "ETH", Chain: "Ethereum", Project: "lido", APY: 2.0, TVLUsd: 10000000, Stablecoin: false},
+	})
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
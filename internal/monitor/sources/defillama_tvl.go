@@ -14,8 +14,9 @@ import (
 )
 
 const (
-	defillamaTVLProtocolsAPI = "https://api.llama.fi/protocols"
-	defillamaTVLProtocolAPI  = "https://api.llama.fi/protocol/"
+	defillamaTVLAPI           = "https://api.llama.fi"
+	defillamaTVLProtocolsPath = "/protocols"
+	defillamaTVLProtocolPath  = "/protocol/"
 )
 
 // DefiLlamaProtocol represents a protocol from the DeFi Llama protocols API.
@@ -43,6 +44,7 @@ type defillamaProtocolDetail struct {
 
 // DefiLlamaTVL fetches protocol TVL data from DeFi Llama for TVL change alerts.
 type DefiLlamaTVL struct {
+	baseURL   string
 	client    *http.Client
 	logger    *slog.Logger
 	mu        sync.RWMutex
@@ -55,9 +57,11 @@ type DefiLlamaTVL struct {
 
 const protocolsCacheTTL = 5 * time.Minute
 
-func NewDefiLlamaTVL(logger *slog.Logger) *DefiLlamaTVL {
+func NewDefiLlamaTVL(logger *slog.Logger, opts ...Option) *DefiLlamaTVL {
+	o := newOptions("defillama_tvl", defillamaTVLAPI, 30*time.Second, opts)
 	return &DefiLlamaTVL{
-		client:      &http.Client{Timeout: 30 * time.Second},
+		baseURL:     o.baseURL,
+		client:      o.httpClient(),
 		logger:      logger,
 		tvl30dCache: make(map[string]float64),
 	}
@@ -106,7 +110,7 @@ func (d *DefiLlamaTVL) FetchDailyReport() (string, error) {
 }
 
 func (d *DefiLlamaTVL) fetchProtocols() ([]DefiLlamaProtocol, error) {
-	resp, err := d.client.Get(defillamaTVLProtocolsAPI)
+	resp, err := d.client.Get(d.baseURL + defillamaTVLProtocolsPath)
 	if err != nil {
 		return nil, fmt.Errorf("defillama protocols API: %w", err)
 	}
@@ -268,7 +272,7 @@ func (d *DefiLlamaTVL) fetch30dChange(slug string, currentTVL float64) (float64,
 		return cached, nil
 	}
 
-	resp, err := d.client.Get(defillamaTVLProtocolAPI + slug)
+	resp, err := d.client.Get(d.baseURL + defillamaTVLProtocolPath + slug)
 	if err != nil {
 		if hasCached {
 			return cached, nil // Use stale cache on error
@@ -7,20 +7,25 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 )
 
-const fngAPI = "https://api.alternative.me/fng/"
+const (
+	fngAPI  = "https://api.alternative.me"
+	fngPath = "/fng/"
+)
 
 type FearGreed struct {
 	client  *http.Client
 	baseURL string
 }
 
-func NewFearGreed() *FearGreed {
+func NewFearGreed(opts ...Option) *FearGreed {
+	o := newOptions("general", fngAPI, 15*time.Second, opts)
 	return &FearGreed{
-		client:  &http.Client{Timeout: 15 * time.Second},
-		baseURL: fngAPI,
+		client:  o.httpClient(),
+		baseURL: o.baseURL,
 	}
 }
 
@@ -36,7 +41,7 @@ type fngResponse struct {
 }
 
 func (f *FearGreed) FetchSnapshot() (*monitor.Snapshot, error) {
-	resp, err := f.client.Get(f.baseURL)
+	resp, err := f.client.Get(f.baseURL + fngPath)
 	if err != nil {
 		return nil, fmt.Errorf("fear & greed API: %w", err)
 	}
@@ -69,33 +74,35 @@ func (f *FearGreed) FetchSnapshot() (*monitor.Snapshot, error) {
 }
 
 func (f *FearGreed) FetchDailyReport() (string, error) {
+	return f.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (f *FearGreed) FetchDailyReportLang(lang string) (string, error) {
 	snap, err := f.FetchSnapshot()
 	if err != nil {
 		return "", err
 	}
 
 	val := snap.Metrics["fear_greed_index"]
-	classification := classifyFng(val)
-	now := time.Now().Format("2006-01-02")
-
-	msg := fmt.Sprintf("📊 CRYPTO FEAR & GREED INDEX — %s\n\n", now)
-	msg += fmt.Sprintf("Index: %.0f / 100\n", val)
-	msg += fmt.Sprintf("Sentiment: %s\n\n", classification)
-	msg += "🔗 https://alternative.me/crypto/fear-and-greed-index/"
-	return msg, nil
+	return messages.Render(lang, "feargreed_report", map[string]any{
+		"Date":      time.Now(),
+		"Value":     val,
+		"Sentiment": classifyFng(val),
+	})
 }
 
 func classifyFng(v float64) string {
 	switch {
 	case v <= 25:
-		return "😱 Extreme Fear"
+		return messages.SentimentExtremeFear
 	case v <= 45:
-		return "😰 Fear"
+		return messages.SentimentFear
 	case v <= 55:
-		return "😐 Neutral"
+		return messages.SentimentNeutral
 	case v <= 75:
-		return "😀 Greed"
+		return messages.SentimentGreed
 	default:
-		return "🤑 Extreme Greed"
+		return messages.SentimentExtremeGreed
 	}
 }
@@ -4,17 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"math"
+	"maps"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/chart"
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
-// binSize maps symbol to price bin width for max pain calculation.
-var binSize = map[string]float64{
+// DefaultBinSizes maps symbol to price bin width for max pain calculation.
+var DefaultBinSizes = map[string]float64{
 	"BTC": 100,
 	"ETH": 10,
 }
@@ -36,19 +38,41 @@ var windowFromInterval = map[string]time.Duration{
 // MaxPain calculates liquidation max pain from Binance forceOrder data stored in Postgres.
 type MaxPain struct {
 	logger  *slog.Logger
-	store   *store.Store
+	store   store.LiquidationStore
 	mu      sync.RWMutex
 	entries map[string]monitor.MaxPainEntry // keyed by "SYMBOL:interval"
+	binSize map[string]float64
 }
 
-func NewMaxPain(logger *slog.Logger, db *store.Store) *MaxPain {
+func NewMaxPain(logger *slog.Logger, db store.LiquidationStore) *MaxPain {
 	return &MaxPain{
 		logger:  logger,
 		store:   db,
 		entries: make(map[string]monitor.MaxPainEntry),
+		binSize: DefaultBinSizes,
 	}
 }
 
+// SetBinSizes changes the price bin width per symbol from the next
+// calculation; symbols left out keep their default.
+func (m *MaxPain) SetBinSizes(sizes map[string]float64) {
+	merged := maps.Clone(DefaultBinSizes)
+	maps.Copy(merged, sizes)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.binSize = merged
+}
+
+// binWidth returns the price bin width of a symbol.
+func (m *MaxPain) binWidth(symbol string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if bs, ok := m.binSize[symbol]; ok {
+		return bs
+	}
+	return 100
+}
+
 func (m *MaxPain) Name() string  { return "maxpain" }
 func (m *MaxPain) Chain() string { return "General" }
 func (m *MaxPain) URL() string   { return "https://www.binance.com/en/futures/BTCUSDT" }
@@ -140,39 +164,65 @@ func (m *MaxPain) ScrapeIntervals(intervals []string) error {
 }
 
 func (m *MaxPain) FetchDailyReport() (string, error) {
+	return m.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (m *MaxPain) FetchDailyReportLang(lang string) (string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	if len(m.entries) == 0 {
 		return "", fmt.Errorf("no maxpain data available")
 	}
 
-	var b strings.Builder
-	b.WriteString("📊 Liquidation Max Pain Report (24h)\nData: Binance Futures liquidations\n\n")
+	var entries []map[string]any
 	for _, sym := range trackedCoins {
 		e, ok := m.entries[sym+":24h"]
 		if !ok {
 			continue
 		}
-		longDist := (e.MaxLongLiquidationPrice - e.Price) / e.Price * 100
-		shortDist := (e.Price - e.MaxShortLiquidationPrice) / e.Price * 100
-		b.WriteString(fmt.Sprintf("%s  $%s\n", sym, fmtNum(e.Price)))
-		b.WriteString(fmt.Sprintf("  Long Max Pain:  $%s (%.1f%%)\n", fmtNum(e.MaxLongLiquidationPrice), longDist))
-		b.WriteString(fmt.Sprintf("  Short Max Pain: $%s (%.1f%%)\n\n", fmtNum(e.MaxShortLiquidationPrice), shortDist))
+		entries = append(entries, map[string]any{
+			"Symbol":    sym,
+			"Price":     e.Price,
+			"Long":      e.MaxLongLiquidationPrice,
+			"Short":     e.MaxShortLiquidationPrice,
+			"LongDist":  (e.MaxLongLiquidationPrice - e.Price) / e.Price * 100,
+			"ShortDist": (e.Price - e.MaxShortLiquidationPrice) / e.Price * 100,
+		})
 	}
-	b.WriteString("🔗 https://www.binance.com/en/futures/BTCUSDT")
-	return b.String(), nil
+	return messages.Render(lang, "maxpain_report", map[string]any{"Entries": entries})
 }
 
-// queryMaxPain queries Postgres for liquidation max pain for a single symbol+interval.
-func (m *MaxPain) queryMaxPain(ctx context.Context, symbol, interval string) (monitor.MaxPainEntry, error) {
+// LiquidationBins returns the liquidation volume per price bin behind the
+// max pain reading for symbol and interval, plus the current price.
+func (m *MaxPain) LiquidationBins(ctx context.Context, symbol, interval string) ([]chart.Bin, float64, error) {
 	window, ok := windowFromInterval[interval]
 	if !ok {
 		window = 24 * time.Hour
 	}
-	bs, ok := binSize[symbol]
+	bs := m.binWidth(symbol)
+
+	rows, err := m.store.QueryLiquidationBins(ctx, symbol, window, bs)
+	if err != nil {
+		return nil, 0, err
+	}
+	bins := make([]chart.Bin, len(rows))
+	for i, r := range rows {
+		bins[i] = chart.Bin{Price: r.PriceBin, Long: r.LongUSD, Short: r.ShortUSD}
+	}
+
+	// No recent trade just means no price marker on the chart.
+	price, _ := m.store.GetCurrentPrice(ctx, symbol)
+	return bins, price, nil
+}
+
+// queryMaxPain queries Postgres for liquidation max pain for a single symbol+interval.
+func (m *MaxPain) queryMaxPain(ctx context.Context, symbol, interval string) (monitor.MaxPainEntry, error) {
+	window, ok := windowFromInterval[interval]
 	if !ok {
-		bs = 100
+		window = 24 * time.Hour
 	}
+	bs := m.binWidth(symbol)
 
 	price, err := m.store.GetCurrentPrice(ctx, symbol)
 	if err != nil {
@@ -193,13 +243,3 @@ func (m *MaxPain) queryMaxPain(ctx context.Context, symbol, interval string) (mo
 		Interval:                 interval,
 	}, nil
 }
-
-func fmtNum(v float64) string {
-	if v >= 1_000_000 {
-		return fmt.Sprintf("%.2fM", v/1_000_000)
-	}
-	if v >= 1_000 {
-		return fmt.Sprintf("%.2f", math.Round(v*100)/100)
-	}
-	return fmt.Sprintf("%.4f", v)
-}
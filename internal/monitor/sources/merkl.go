@@ -9,10 +9,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 )
 
-const merklAPI = "https://api.merkl.xyz/v4/opportunities"
+const (
+	merklAPI               = "https://api.merkl.xyz"
+	merklOpportunitiesPath = "/v4/opportunities"
+)
 
 // MerklOpportunity represents a single yield opportunity from Merkl.
 type MerklOpportunity struct {
@@ -78,20 +82,42 @@ func (o *MerklOpportunity) ProtocolName() string {
 }
 
 // Merkl fetches yield opportunities from the Merkl API.
+// MerklFilters select the opportunities behind the dashboard snapshot.
+type MerklFilters struct {
+	MinAPR  float64
+	MinTVL  float64
+	Actions string // comma-separated, e.g. "LEND,BORROW"
+}
+
+// DefaultMerklFilters are the snapshot filters without a config file.
+var DefaultMerklFilters = MerklFilters{MinAPR: 5, MinTVL: 500000, Actions: "LEND,BORROW,HOLD"}
+
 type Merkl struct {
-	client *http.Client
-	logger *slog.Logger
-	mu     sync.RWMutex
-	opps   []MerklOpportunity // latest fetched opportunities
+	client  *http.Client
+	logger  *slog.Logger
+	baseURL string
+	mu      sync.RWMutex
+	opps    []MerklOpportunity // latest fetched opportunities
+	filters MerklFilters
 }
 
-func NewMerkl(logger *slog.Logger) *Merkl {
+func NewMerkl(logger *slog.Logger, opts ...Option) *Merkl {
+	o := newOptions("merkl", merklAPI, 30*time.Second, opts)
 	return &Merkl{
-		client: &http.Client{Timeout: 30 * time.Second},
-		logger: logger,
+		client:  o.httpClient(),
+		logger:  logger,
+		baseURL: o.baseURL,
+		filters: DefaultMerklFilters,
 	}
 }
 
+// SetFilters changes the snapshot filters from the next fetch.
+func (m *Merkl) SetFilters(f MerklFilters) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filters = f
+}
+
 func (m *Merkl) Name() string  { return "merkl" }
 func (m *Merkl) Chain() string { return "General" }
 func (m *Merkl) URL() string   { return "https://app.merkl.xyz/" }
@@ -121,7 +147,7 @@ func (m *Merkl) GetFilteredOpportunities(minAPR, minTVL float64, action, stableF
 	}
 
 	url := fmt.Sprintf("%s?action=%s&minimumApr=%.0f&minimumTvl=%.0f&sort=apr&order=desc&status=LIVE%s",
-		merklAPI, action, minAPR, minTVL, stableParam)
+		m.baseURL+merklOpportunitiesPath, action, minAPR, minTVL, stableParam)
 
 	resp, err := m.client.Get(url)
 	if err != nil {
@@ -160,7 +186,7 @@ func (m *Merkl) GetFilteredOpportunities(minAPR, minTVL float64, action, stableF
 // FetchOpportunities fetches opportunities from Merkl with given filters.
 func (m *Merkl) FetchOpportunities(minAPR, minTVL float64, action string) ([]MerklOpportunity, error) {
 	url := fmt.Sprintf("%s?action=%s&minimumApr=%.0f&minimumTvl=%.0f&sort=apr&order=desc&status=LIVE",
-		merklAPI, action, minAPR, minTVL)
+		m.baseURL+merklOpportunitiesPath, action, minAPR, minTVL)
 
 	resp, err := m.client.Get(url)
 	if err != nil {
@@ -181,7 +207,10 @@ func (m *Merkl) FetchOpportunities(minAPR, minTVL float64, action string) ([]Mer
 
 func (m *Merkl) FetchSnapshot() (*monitor.Snapshot, error) {
 	// Fetch top opportunities with broad criteria for dashboard display
-	opps, err := m.FetchOpportunities(5, 500000, "LEND,BORROW,HOLD")
+	m.mu.RLock()
+	f := m.filters
+	m.mu.RUnlock()
+	opps, err := m.FetchOpportunities(f.MinAPR, f.MinTVL, f.Actions)
 	if err != nil {
 		return nil, err
 	}
@@ -213,6 +242,11 @@ func (m *Merkl) FetchSnapshot() (*monitor.Snapshot, error) {
 }
 
 func (m *Merkl) FetchDailyReport() (string, error) {
+	return m.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (m *Merkl) FetchDailyReportLang(lang string) (string, error) {
 	m.mu.RLock()
 	opps := m.opps
 	m.mu.RUnlock()
@@ -221,37 +255,24 @@ func (m *Merkl) FetchDailyReport() (string, error) {
 		return "", fmt.Errorf("no merkl data available")
 	}
 
-	var b strings.Builder
-	b.WriteString("📊 Merkl Yield Opportunities Report\n\n")
 	count := 5
 	if len(opps) < count {
 		count = len(opps)
 	}
-	for i := 0; i < count; i++ {
-		o := opps[i]
-		stable := ""
-		if o.IsStablecoin() {
-			stable = " 🟢"
-		}
-		b.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, o.Name, stable))
-		b.WriteString(fmt.Sprintf("   APR: %.1f%% | TVL: $%s | %s | %s\n",
-			o.APR, fmtTVL(o.TVL), o.Chain.Name, o.Action))
-		if o.DepositURL != "" {
-			b.WriteString(fmt.Sprintf("   🔗 %s\n", o.DepositURL))
+	items := make([]map[string]any, count)
+	for i, o := range opps[:count] {
+		items[i] = map[string]any{
+			"Name":       o.Name,
+			"Stablecoin": o.IsStablecoin(),
+			"APR":        o.APR,
+			"TVL":        o.TVL,
+			"Chain":      o.Chain.Name,
+			"Action":     o.Action,
+			"DepositURL": o.DepositURL,
 		}
-		b.WriteString("\n")
-	}
-	b.WriteString(fmt.Sprintf("Total: %d opportunities\n", len(opps)))
-	b.WriteString("🔗 https://app.merkl.xyz/")
-	return b.String(), nil
-}
-
-func fmtTVL(v float64) string {
-	if v >= 1_000_000 {
-		return fmt.Sprintf("%.1fM", v/1_000_000)
-	}
-	if v >= 1_000 {
-		return fmt.Sprintf("%.0fK", v/1_000)
 	}
-	return fmt.Sprintf("%.0f", v)
+	return messages.Render(lang, "merkl_report", map[string]any{
+		"Opps":  items,
+		"Total": len(opps),
+	})
 }
@@ -156,23 +156,3 @@ func TestMerklURL(t *testing.T) {
 		t.Errorf("MerklURL() = %q, want %q", got, want)
 	}
 }
-
-func TestFmtTVL(t *testing.T) {
-	tests := []struct {
-		input float64
-		want  string
-	}{
-		{500, "500"},
-		{1500, "2K"},
-		{50000, "50K"},
-		{1000000, "1.0M"},
-		{2500000, "2.5M"},
-		{123456789, "123.5M"},
-	}
-	for _, tt := range tests {
-		got := fmtTVL(tt.input)
-		if got != tt.want {
-			t.Errorf("fmtTVL(%v) = %q, want %q", tt.input, got, tt.want)
-		}
-	}
-}
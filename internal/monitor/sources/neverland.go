@@ -7,23 +7,30 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 )
 
 const (
-	neverlandTVLAPI  = "https://api.llama.fi/tvl/neverland"
-	neverlandDataAPI = "https://api.llama.fi/protocol/neverland"
-	neverlandFeesAPI = "https://api.llama.fi/summary/fees/neverland"
-	dustPriceAPI     = "https://api.dexscreener.com/latest/dex/pairs/monad/0xD15965968fe8BF2BAbbe39b2FC5de1Ab6749141F"
+	neverlandAPI      = "https://api.llama.fi"
+	neverlandDataPath = "/protocol/neverland"
+	neverlandFeesPath = "/summary/fees/neverland"
+	dexscreenerAPI    = "https://api.dexscreener.com"
+	dustPricePath     = "/latest/dex/pairs/monad/0xD15965968fe8BF2BAbbe39b2FC5de1Ab6749141F"
 )
 
 type Neverland struct {
-	client *http.Client
+	client      *http.Client
+	baseURL     string // DefiLlama
+	dexscreener string
 }
 
-func NewNeverland() *Neverland {
+func NewNeverland(opts ...Option) *Neverland {
+	o := newOptions("neverland", neverlandAPI, 15*time.Second, opts)
 	return &Neverland{
-		client: &http.Client{Timeout: 15 * time.Second},
+		client:      o.httpClient(),
+		baseURL:     o.baseURL,
+		dexscreener: o.upstream("dexscreener", dexscreenerAPI),
 	}
 }
 
@@ -76,27 +83,21 @@ func (n *Neverland) FetchSnapshot() (*monitor.Snapshot, error) {
 }
 
 func (n *Neverland) FetchDailyReport() (string, error) {
+	return n.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (n *Neverland) FetchDailyReportLang(lang string) (string, error) {
 	snap, err := n.FetchSnapshot()
 	if err != nil {
 		return "", err
 	}
 
-	now := time.Now().Format("2006-01-02")
-	msg := fmt.Sprintf("📊 NEVERLAND DAILY REPORT — %s\n\n", now)
-	msg += fmt.Sprintf("TVL: $%s\n", formatNumber(snap.Metrics["tvl"]))
-	msg += fmt.Sprintf("veDUST TVL: $%s\n", formatNumber(snap.Metrics["vedust_tvl"]))
-	if p, ok := snap.Metrics["price"]; ok && p > 0 {
-		msg += fmt.Sprintf("DUST Price: $%.4f\n", p)
-	}
-	msg += fmt.Sprintf("\nFees (24h): $%s\n", formatNumber(snap.Metrics["fees_24h"]))
-	msg += fmt.Sprintf("Fees (7d): $%s\n", formatNumber(snap.Metrics["fees_7d"]))
-	msg += fmt.Sprintf("Fees (30d): $%s\n", formatNumber(snap.Metrics["fees_30d"]))
-
 	// Historical TVL from DefiLlama
+	var history []map[string]any
 	hist, err := n.fetchTVLHistory()
 	if err == nil && len(hist) > 0 {
 		currentTVL := snap.Metrics["tvl"]
-		msg += "\nTVL History:\n"
 		for _, p := range []struct {
 			label string
 			days  int
@@ -104,19 +105,26 @@ func (n *Neverland) FetchDailyReport() (string, error) {
 			if p.days < len(hist) {
 				pastTVL := hist[len(hist)-1-p.days].TVL
 				if pastTVL > 0 {
-					pctChange := (currentTVL - pastTVL) / pastTVL * 100
-					sign := "+"
-					if pctChange < 0 {
-						sign = ""
-					}
-					msg += fmt.Sprintf("  %s: $%s (%s%.1f%%)\n", p.label, formatNumber(pastTVL), sign, pctChange)
+					history = append(history, map[string]any{
+						"Label":  p.label,
+						"TVL":    pastTVL,
+						"Change": (currentTVL - pastTVL) / pastTVL * 100,
+					})
 				}
 			}
 		}
 	}
 
-	msg += "\n🔗 https://app.neverland.money"
-	return msg, nil
+	return messages.Render(lang, "neverland_report", map[string]any{
+		"Date":    time.Now(),
+		"TVL":     snap.Metrics["tvl"],
+		"VeDUST":  snap.Metrics["vedust_tvl"],
+		"Price":   snap.Metrics["price"],
+		"Fees24h": snap.Metrics["fees_24h"],
+		"Fees7d":  snap.Metrics["fees_7d"],
+		"Fees30d": snap.Metrics["fees_30d"],
+		"History": history,
+	})
 }
 
 // --- API helpers ---
@@ -129,7 +137,7 @@ type neverlandProtocol struct {
 }
 
 func (n *Neverland) fetchProtocol() (*neverlandProtocol, error) {
-	body, err := n.httpGet(neverlandDataAPI)
+	body, err := n.httpGet(n.baseURL + neverlandDataPath)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +155,7 @@ type neverlandFees struct {
 }
 
 func (n *Neverland) fetchFees() (*neverlandFees, error) {
-	body, err := n.httpGet(neverlandFeesAPI)
+	body, err := n.httpGet(n.baseURL + neverlandFeesPath)
 	if err != nil {
 		return nil, err
 	}
@@ -164,7 +172,7 @@ type tvlPoint struct {
 }
 
 func (n *Neverland) fetchTVLHistory() ([]tvlPoint, error) {
-	body, err := n.httpGet(neverlandDataAPI)
+	body, err := n.httpGet(n.baseURL + neverlandDataPath)
 	if err != nil {
 		return nil, err
 	}
@@ -178,7 +186,7 @@ func (n *Neverland) fetchTVLHistory() ([]tvlPoint, error) {
 }
 
 func (n *Neverland) fetchDustPrice() (float64, error) {
-	body, err := n.httpGet(dustPriceAPI)
+	body, err := n.httpGet(n.dexscreener + dustPricePath)
 	if err != nil {
 		return 0, err
 	}
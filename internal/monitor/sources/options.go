@@ -0,0 +1,108 @@
+package sources
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/upstream"
+)
+
+// Option configures how a source reaches its upstream APIs. Every
+// constructor that calls an HTTP API accepts them.
+type Option func(*options)
+
+type options struct {
+	source         string
+	baseURL        string
+	upstreams      map[string]string
+	client         *http.Client
+	timeout        time.Duration
+	defaultTimeout time.Duration
+	pools          *PoolsCache
+}
+
+// WithBaseURL replaces the root of the source's primary upstream API, e.g.
+// "https://api.binance.com" with a caching proxy or a local stand-in. The
+// source appends its own paths.
+func WithBaseURL(u string) Option {
+	return func(o *options) { o.baseURL = strings.TrimSuffix(u, "/") }
+}
+
+// WithUpstream replaces the root of a secondary upstream API by name (see
+// Upstreams), such as neverland's "dexscreener".
+func WithUpstream(name, u string) Option {
+	return func(o *options) {
+		if o.upstreams == nil {
+			o.upstreams = make(map[string]string)
+		}
+		o.upstreams[name] = strings.TrimSuffix(u, "/")
+	}
+}
+
+// WithHTTPClient makes the source send its requests through c instead of
+// upstream.Default, so without its retries, breakers and limits.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) { o.client = c }
+}
+
+// WithTimeout bounds each upstream request, overriding the source's
+// default and any timeout of a WithHTTPClient client.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithPoolsCache makes a pool-based source (defillama, defillama_lp) read
+// the DeFi Llama pools list through c, shared with the other sources given
+// c. Without it the source keeps a cache of its own.
+func WithPoolsCache(c *PoolsCache) Option {
+	return func(o *options) { o.pools = c }
+}
+
+// Upstreams lists the secondary upstreams each source accepts in
+// WithUpstream, with their default roots.
+var Upstreams = map[string]map[string]string{
+	"neverland": {"dexscreener": dexscreenerAPI},
+}
+
+// newOptions applies opts over a source's defaults.
+func newOptions(source, baseURL string, timeout time.Duration, opts []Option) options {
+	o := options{source: source, baseURL: baseURL, defaultTimeout: timeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// poolsCache returns the WithPoolsCache cache, or a new one.
+func (o options) poolsCache() *PoolsCache {
+	if o.pools != nil {
+		return o.pools
+	}
+	return NewPoolsCache(defaultPoolsTTL)
+}
+
+// upstream returns the root of a secondary upstream, or def.
+func (o options) upstream(name, def string) string {
+	if u, ok := o.upstreams[name]; ok {
+		return u
+	}
+	return def
+}
+
+// httpClient returns the client the source sends requests through: one of
+// upstream.Default, or a WithHTTPClient client, which keeps its own
+// timeout unless WithTimeout is given.
+func (o options) httpClient() *http.Client {
+	if o.client == nil {
+		if o.timeout > 0 {
+			return upstream.Default.Client(o.source, o.timeout)
+		}
+		return upstream.Default.Client(o.source, o.defaultTimeout)
+	}
+	c := *o.client
+	if o.timeout > 0 {
+		c.Timeout = o.timeout
+	}
+	return &c
+}
@@ -0,0 +1,125 @@
+package sources
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/upstream"
+)
+
+// TestSourcesUseOptions points every HTTP source at a local stand-in and
+// checks that its requests arrive there, through the given client.
+func TestSourcesUseOptions(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		http.Error(w, "stand-in", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	logger := slog.New(slog.DiscardHandler)
+	var viaClient int
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		viaClient++
+		mu.Unlock()
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	opts := []Option{WithBaseURL(srv.URL + "/proxy/"), WithHTTPClient(client), WithTimeout(5 * time.Second)}
+
+	for _, tc := range []struct {
+		fetch func() error
+		path  string
+	}{
+		{func() error { _, err := NewAltura(opts...).FetchSnapshot(); return err }, "/proxy" + alturaVaultPath},
+		{func() error { _, err := NewFearGreed(opts...).FetchSnapshot(); return err }, "/proxy" + fngPath},
+		{func() error { _, err := NewMerkl(logger, opts...).FetchSnapshot(); return err }, "/proxy" + merklOpportunitiesPath},
+		{func() error { _, err := NewTurtle(logger, opts...).FetchSnapshot(); return err }, "/proxy" + turtlePath},
+		{func() error { _, err := NewBinance(opts...).FetchSnapshot(); return err }, "/proxy" + binanceTickerPath},
+		{func() error { _, err := NewAlpha(opts...).FetchSnapshot(); return err }, "/proxy/api/data"},
+		{func() error { _, err := NewDefiLlama(logger, opts...).FetchSnapshot(); return err }, "/proxy/pools"},
+		{func() error { _, err := NewDefiLlamaLP(logger, opts...).FetchSnapshot(); return err }, "/proxy/pools"},
+		{func() error { _, err := NewDefiLlamaTVL(logger, opts...).FetchSnapshot(); return err }, "/proxy" + defillamaTVLProtocolsPath},
+		{func() error { _, err := NewNeverland(opts...).FetchSnapshot(); return err }, "/proxy" + neverlandDataPath},
+	} {
+		mu.Lock()
+		paths = nil
+		mu.Unlock()
+		if err := tc.fetch(); err == nil {
+			t.Errorf("%s: fetch from a failing stand-in succeeded", tc.path)
+		}
+		mu.Lock()
+		if !slices.Contains(paths, tc.path) {
+			t.Errorf("stand-in got %v, want a request to %s", paths, tc.path)
+		}
+		mu.Unlock()
+	}
+	if viaClient == 0 {
+		t.Error("no request went through the WithHTTPClient client")
+	}
+}
+
+func TestNeverlandUpstream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/dex/latest/") {
+			_, _ = w.Write([]byte(`{"pair":{"priceNative":"0.25"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"currentChainTvls":{"Monad":1000000,"staking":5000}}`))
+	}))
+	defer srv.Close()
+
+	n := NewNeverland(WithBaseURL(srv.URL+"/llama"), WithUpstream("dexscreener", srv.URL+"/dex"))
+	snap, err := n.FetchSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Metrics["tvl"] != 1000000 || snap.Metrics["price"] != 0.25 {
+		t.Errorf("metrics = %v, want tvl 1000000 and price 0.25", snap.Metrics)
+	}
+}
+
+func TestDefaultClientUsesUpstreamPool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"value":"40","value_classification":"Fear","timestamp":"1700000000"}]}`))
+	}))
+	defer srv.Close()
+
+	if _, err := NewFearGreed(WithBaseURL(srv.URL)).FetchSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(srv.URL, "http://")
+	hosts := upstream.Default.Hosts("general")
+	if !slices.ContainsFunc(hosts, func(h upstream.HostState) bool { return h.Host == host && h.State == "closed" }) {
+		t.Errorf("upstream.Default hosts for general = %+v, want %s closed", hosts, host)
+	}
+}
+
+func TestHTTPClientTimeout(t *testing.T) {
+	if c := newOptions("alpha", alphaAPI, 15*time.Second, nil).httpClient(); c.Timeout != 15*time.Second {
+		t.Errorf("default timeout = %v, want 15s", c.Timeout)
+	}
+	custom := &http.Client{Timeout: time.Minute}
+	if c := newOptions("alpha", alphaAPI, 15*time.Second, []Option{WithHTTPClient(custom)}).httpClient(); c.Timeout != time.Minute {
+		t.Errorf("WithHTTPClient timeout = %v, want the client's 1m", c.Timeout)
+	}
+	c := newOptions("alpha", alphaAPI, 15*time.Second, []Option{WithHTTPClient(custom), WithTimeout(time.Second)}).httpClient()
+	if c.Timeout != time.Second || custom.Timeout != time.Minute {
+		t.Errorf("WithTimeout = %v (client left at %v), want 1s without touching the client", c.Timeout, custom.Timeout)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
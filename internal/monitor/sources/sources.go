@@ -0,0 +1,131 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/config"
+	"github.com/web3-frozen/onchain-monitor/internal/monitor"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// All returns every built-in source, in the order the server registers
+// them. opts holds extra constructor options by source name (see
+// OptionsFrom); MaxPain reads its liquidations from liq and takes none.
+// The pool-based sources share one PoolsCache.
+func All(logger *slog.Logger, liq store.LiquidationStore, opts map[string][]Option) []monitor.Source {
+	pools := WithPoolsCache(NewPoolsCache(defaultPoolsTTL))
+	return []monitor.Source{
+		NewAltura(opts["altura"]...),
+		NewNeverland(opts["neverland"]...),
+		NewFearGreed(opts["general"]...),
+		NewMaxPain(logger, liq),
+		NewMerkl(logger, opts["merkl"]...),
+		NewTurtle(logger, opts["turtle"]...),
+		NewBinance(opts["binance"]...),
+		NewAlpha(opts["alpha"]...),
+		NewDefiLlama(logger, slices.Concat([]Option{pools}, opts["defillama"])...),
+		NewDefiLlamaLP(logger, slices.Concat([]Option{pools}, opts["defillama_lp"])...),
+		NewDefiLlamaTVL(logger, opts["defillama_tvl"]...),
+	}
+}
+
+// OptionsFrom turns the config file's upstream settings and timeouts into
+// constructor options for All.
+func OptionsFrom(settings map[string]config.SourceSettings) map[string][]Option {
+	opts := make(map[string][]Option, len(settings))
+	for name, s := range settings {
+		if s.BaseURL != "" {
+			opts[name] = append(opts[name], WithBaseURL(s.BaseURL))
+		}
+		for upstream, u := range s.Upstreams {
+			opts[name] = append(opts[name], WithUpstream(upstream, u))
+		}
+		if s.Timeout > 0 {
+			opts[name] = append(opts[name], WithTimeout(time.Duration(s.Timeout)))
+		}
+	}
+	return opts
+}
+
+// Validate checks the config file's per-source settings against srcs:
+// every section names a source, and upstreams and filters are only set
+// where the source supports them.
+func Validate(srcs []monitor.Source, settings map[string]config.SourceSettings) error {
+	byName := make(map[string]monitor.Source, len(srcs))
+	for _, src := range srcs {
+		byName[src.Name()] = src
+	}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		s := settings[name]
+		src, ok := byName[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("sources.%s: unknown source", name))
+			continue
+		}
+		if _, ok := src.(*MaxPain); ok && s.BaseURL != "" {
+			errs = append(errs, fmt.Errorf("sources.%s.base_url: not supported by this source", name))
+		}
+		for _, upstream := range slices.Sorted(maps.Keys(s.Upstreams)) {
+			if _, ok := Upstreams[name][upstream]; !ok {
+				errs = append(errs, fmt.Errorf("sources.%s.upstreams: unknown upstream %q", name, upstream))
+			}
+		}
+		f := s.Filters
+		switch src.(type) {
+		case *Merkl:
+			if len(f.BinSize) > 0 {
+				errs = append(errs, fmt.Errorf("sources.%s.filters.bin_size: not supported by this source", name))
+			}
+			for _, a := range f.Actions {
+				if a == "" || strings.Contains(a, ",") {
+					errs = append(errs, fmt.Errorf("sources.%s.filters.actions: invalid action %q", name, a))
+				}
+			}
+		case *MaxPain:
+			if f.MinAPR != nil || f.MinTVL != nil || len(f.Actions) > 0 {
+				errs = append(errs, fmt.Errorf("sources.%s.filters: only bin_size is supported", name))
+			}
+			for sym := range f.BinSize {
+				if !slices.Contains(trackedCoins, sym) {
+					errs = append(errs, fmt.Errorf("sources.%s.filters.bin_size: unknown symbol %q (want one of %v)", name, sym, trackedCoins))
+				}
+			}
+		default:
+			if !f.IsZero() {
+				errs = append(errs, fmt.Errorf("sources.%s.filters: not supported by this source", name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyFilters sets the config file's filters on srcs, restoring the
+// defaults of filters it leaves out. Safe while polling.
+func ApplyFilters(srcs []monitor.Source, settings map[string]config.SourceSettings) {
+	for _, src := range srcs {
+		f := settings[src.Name()].Filters
+		switch s := src.(type) {
+		case *Merkl:
+			mf := DefaultMerklFilters
+			if f.MinAPR != nil {
+				mf.MinAPR = *f.MinAPR
+			}
+			if f.MinTVL != nil {
+				mf.MinTVL = *f.MinTVL
+			}
+			if len(f.Actions) > 0 {
+				mf.Actions = strings.ToUpper(strings.Join(f.Actions, ","))
+			}
+			s.SetFilters(mf)
+		case *MaxPain:
+			s.SetBinSizes(f.BinSize)
+		}
+	}
+}
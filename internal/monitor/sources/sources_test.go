@@ -0,0 +1,111 @@
+package sources
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/config"
+)
+
+func TestValidateSettings(t *testing.T) {
+	srcs := All(slog.New(slog.DiscardHandler), nil, nil)
+	apr := 10.0
+
+	ok := map[string]config.SourceSettings{
+		"binance":   {BaseURL: "https://mirror.example.com/binance/"},
+		"merkl":     {Filters: config.SourceFilters{MinAPR: &apr, Actions: []string{"LEND"}}},
+		"maxpain":   {Filters: config.SourceFilters{BinSize: map[string]float64{"BTC": 250}}},
+		"altura":    {Interval: config.Duration(5 * time.Minute), BaseURL: "http://localhost:9000/subgraphs"},
+		"neverland": {Upstreams: map[string]string{"dexscreener": "http://localhost:9001"}},
+	}
+	if err := Validate(srcs, ok); err != nil {
+		t.Errorf("Validate = %v, want nil", err)
+	}
+
+	bad := map[string]config.SourceSettings{
+		"nope":    {},
+		"altura":  {Upstreams: map[string]string{"dexscreener": "https://example.com"}},
+		"binance": {Filters: config.SourceFilters{MinAPR: &apr}},
+		"maxpain": {BaseURL: "https://example.com", Filters: config.SourceFilters{BinSize: map[string]float64{"DOGE": 1}}},
+		"merkl":   {Filters: config.SourceFilters{Actions: []string{"LEND,HOLD"}}},
+		"general": {},
+	}
+	err := Validate(srcs, bad)
+	if err == nil {
+		t.Fatal("Validate = nil, want errors")
+	}
+	for _, want := range []string{"sources.nope", `sources.altura.upstreams: unknown upstream "dexscreener"`, "sources.maxpain.base_url", "sources.binance.filters", "DOGE", "LEND,HOLD"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error %q does not mention %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "general") {
+		t.Errorf("Validate error %q mentions a valid section", err)
+	}
+}
+
+func TestApplySettings(t *testing.T) {
+	apr := 12.0
+	settings := map[string]config.SourceSettings{
+		"merkl": {
+			BaseURL: "https://mirror.example.com/merkl/",
+			Filters: config.SourceFilters{MinAPR: &apr, Actions: []string{"lend", "hold"}},
+		},
+		"maxpain":   {Filters: config.SourceFilters{BinSize: map[string]float64{"BTC": 250}}},
+		"neverland": {Upstreams: map[string]string{"dexscreener": "http://localhost:9001"}},
+		"binance":   {Timeout: config.Duration(7 * time.Second)},
+	}
+	srcs := All(slog.New(slog.DiscardHandler), nil, OptionsFrom(settings))
+	var merkl *Merkl
+	var maxpain *MaxPain
+	var neverland *Neverland
+	var binance *Binance
+	for _, src := range srcs {
+		switch s := src.(type) {
+		case *Merkl:
+			merkl = s
+		case *MaxPain:
+			maxpain = s
+		case *Neverland:
+			neverland = s
+		case *Binance:
+			binance = s
+		}
+	}
+
+	ApplyFilters(srcs, settings)
+	if merkl.baseURL != "https://mirror.example.com/merkl" {
+		t.Errorf("merkl base URL = %q", merkl.baseURL)
+	}
+	if neverland.baseURL != neverlandAPI || neverland.dexscreener != "http://localhost:9001" {
+		t.Errorf("neverland upstreams = %q, %q", neverland.baseURL, neverland.dexscreener)
+	}
+	if binance.client.Timeout != 7*time.Second || merkl.client.Timeout != 30*time.Second {
+		t.Errorf("client timeouts = %v (binance), %v (merkl); want 7s and 30s", binance.client.Timeout, merkl.client.Timeout)
+	}
+	want := MerklFilters{MinAPR: 12, MinTVL: DefaultMerklFilters.MinTVL, Actions: "LEND,HOLD"}
+	if merkl.filters != want {
+		t.Errorf("merkl filters = %+v, want %+v", merkl.filters, want)
+	}
+	if maxpain.binWidth("BTC") != 250 || maxpain.binWidth("ETH") != DefaultBinSizes["ETH"] {
+		t.Errorf("bin widths = %v/%v, want 250/%v", maxpain.binWidth("BTC"), maxpain.binWidth("ETH"), DefaultBinSizes["ETH"])
+	}
+
+	// Filters left out of a reloaded file go back to their defaults
+	ApplyFilters(srcs, nil)
+	if merkl.filters != DefaultMerklFilters || maxpain.binWidth("BTC") != DefaultBinSizes["BTC"] {
+		t.Errorf("filters after reset = %+v, BTC bin %v; want defaults", merkl.filters, maxpain.binWidth("BTC"))
+	}
+}
+
+func TestExampleConfigIsValid(t *testing.T) {
+	s, err := config.LoadSettings("../../../config.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(All(slog.New(slog.DiscardHandler), nil, nil), s.Sources); err != nil {
+		t.Error(err)
+	}
+}
@@ -9,10 +9,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/monitor"
 )
 
-const turtleAPI = "https://api.turtle.xyz/turtle/opportunities"
+const (
+	turtleAPI  = "https://api.turtle.xyz"
+	turtlePath = "/turtle/opportunities"
+)
 
 // TurtleOpportunity represents a single yield opportunity from Turtle.
 type TurtleOpportunity struct {
@@ -186,10 +190,11 @@ type Turtle struct {
 	opps    []TurtleOpportunity
 }
 
-func NewTurtle(logger *slog.Logger) *Turtle {
+func NewTurtle(logger *slog.Logger, opts ...Option) *Turtle {
+	o := newOptions("turtle", turtleAPI, 30*time.Second, opts)
 	return &Turtle{
-		baseURL: turtleAPI,
-		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: o.baseURL,
+		client:  o.httpClient(),
 		logger:  logger,
 	}
 }
@@ -200,7 +205,7 @@ func (t *Turtle) URL() string   { return "https://app.turtle.xyz/earn/opportunit
 
 // FetchAllOpportunities fetches all opportunities from Turtle API.
 func (t *Turtle) FetchAllOpportunities() ([]TurtleOpportunity, error) {
-	resp, err := t.client.Get(t.baseURL)
+	resp, err := t.client.Get(t.baseURL + turtlePath)
 	if err != nil {
 		return nil, fmt.Errorf("turtle API: %w", err)
 	}
@@ -350,6 +355,11 @@ func (t *Turtle) FetchSnapshot() (*monitor.Snapshot, error) {
 }
 
 func (t *Turtle) FetchDailyReport() (string, error) {
+	return t.FetchDailyReportLang(messages.English)
+}
+
+// FetchDailyReportLang renders the daily report in lang.
+func (t *Turtle) FetchDailyReportLang(lang string) (string, error) {
 	t.mu.RLock()
 	opps := t.opps
 	t.mu.RUnlock()
@@ -369,33 +379,26 @@ func (t *Turtle) FetchDailyReport() (string, error) {
 		}
 	}
 
-	var b strings.Builder
-	b.WriteString("🐢 Turtle Yield Opportunities Report\n\n")
 	count := 5
 	if len(sorted) < count {
 		count = len(sorted)
 	}
-	for i := 0; i < count; i++ {
-		o := sorted[i]
-		stable := ""
-		if o.IsStablecoin() {
-			stable = " 🟢"
-		}
-		b.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, o.Name, stable))
-		b.WriteString(fmt.Sprintf("   Yield: %.1f%%", o.TotalYield()))
-		if len(o.Incentives) > 1 {
-			parts := make([]string, len(o.Incentives))
-			for j, inc := range o.Incentives {
-				parts[j] = fmt.Sprintf("%s: %.1f%%", inc.Name, inc.Yield)
-			}
-			b.WriteString(fmt.Sprintf(" (%s)", strings.Join(parts, " + ")))
+	items := make([]map[string]any, count)
+	for i, o := range sorted[:count] {
+		items[i] = map[string]any{
+			"Name":       o.Name,
+			"Stablecoin": o.IsStablecoin(),
+			"Yield":      o.TotalYield(),
+			"Incentives": o.Incentives,
+			"TVL":        o.TVL,
+			"Chain":      o.ChainName(),
+			"Type":       o.Type,
+			"Protocol":   o.OrganizationName(),
+			"Token":      o.TokenSymbol(),
 		}
-		b.WriteString(fmt.Sprintf(" | TVL: $%s | %s | %s\n",
-			fmtTVL(o.TVL), o.ChainName(), o.Type))
-		b.WriteString(fmt.Sprintf("   Protocol: %s | Token: %s\n", o.OrganizationName(), o.TokenSymbol()))
-		b.WriteString("\n")
 	}
-	b.WriteString(fmt.Sprintf("Total: %d opportunities\n", len(opps)))
-	b.WriteString("🔗 https://app.turtle.xyz/earn/opportunities")
-	return b.String(), nil
+	return messages.Render(lang, "turtle_report", map[string]any{
+		"Opps":  items,
+		"Total": len(opps),
+	})
 }
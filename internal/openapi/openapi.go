@@ -0,0 +1,240 @@
+// Package openapi embeds the OpenAPI 3 document of the HTTP API and
+// validates requests against it, so the contract the frontend reads and the
+// input the handlers accept cannot drift apart.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// JSON returns the raw OpenAPI document.
+func JSON() []byte {
+	return document
+}
+
+// Spec is the subset of an OpenAPI 3 document needed for validation.
+type Spec struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// Operation is one method on a path.
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []Parameter  `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes a JSON request body.
+type RequestBody struct {
+	Required bool `json:"required"`
+	Content  map[string]struct {
+		Schema *Schema `json:"schema"`
+	} `json:"content"`
+}
+
+// Schema is the subset of JSON Schema the validator understands: types,
+// formats, enums, numeric and length bounds, required properties and
+// array items. References are resolved by Load.
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Nullable   bool               `json:"nullable"`
+	Enum       []any              `json:"enum"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	MinLength  *int               `json:"minLength"`
+	MaxLength  *int               `json:"maxLength"`
+	MinItems   *int               `json:"minItems"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+	Items      *Schema            `json:"items"`
+}
+
+// Load parses the embedded document and resolves component references.
+func Load() (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(document, &spec); err != nil {
+		return nil, fmt.Errorf("parse openapi document: %w", err)
+	}
+	for path, ops := range spec.Paths {
+		for method, op := range ops {
+			if err := spec.resolveOperation(op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+		}
+	}
+	return &spec, nil
+}
+
+// Route is a method and path template of the API.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Routes lists every operation in the document, sorted.
+func (s *Spec) Routes() []Route {
+	var routes []Route
+	for path, ops := range s.Paths {
+		for method := range ops {
+			routes = append(routes, Route{Method: strings.ToUpper(method), Path: path})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+func (s *Spec) resolveOperation(op *Operation) error {
+	for i := range op.Parameters {
+		schema, err := s.resolve(op.Parameters[i].Schema, 0)
+		if err != nil {
+			return err
+		}
+		op.Parameters[i].Schema = schema
+	}
+	if op.RequestBody == nil {
+		return nil
+	}
+	for ct, media := range op.RequestBody.Content {
+		schema, err := s.resolve(media.Schema, 0)
+		if err != nil {
+			return err
+		}
+		media.Schema = schema
+		op.RequestBody.Content[ct] = media
+	}
+	return nil
+}
+
+// resolve replaces $ref schemas with their component, recursively.
+func (s *Spec) resolve(schema *Schema, depth int) (*Schema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	if depth > 32 {
+		return nil, fmt.Errorf("schema references nest too deeply")
+	}
+	if schema.Ref != "" {
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		target := s.Components.Schemas[name]
+		if !ok || target == nil {
+			return nil, fmt.Errorf("unresolved reference %q", schema.Ref)
+		}
+		return s.resolve(target, depth+1)
+	}
+	for name, prop := range schema.Properties {
+		resolved, err := s.resolve(prop, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		schema.Properties[name] = resolved
+	}
+	items, err := s.resolve(schema.Items, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	schema.Items = items
+	return schema, nil
+}
+
+// find returns the operation for a request path, with its path parameters.
+// Literal segments win over parameters ("/keys/new" over "/keys/{id}").
+func (s *Spec) find(method, path string) (*Operation, map[string]string) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	var (
+		best       *Operation
+		bestParams map[string]string
+		bestScore  = -1
+	)
+	for tmpl, ops := range s.Paths {
+		op, ok := ops[strings.ToLower(method)]
+		if !ok {
+			continue
+		}
+		tsegs := strings.Split(strings.Trim(tmpl, "/"), "/")
+		if len(tsegs) != len(segs) {
+			continue
+		}
+		params := map[string]string{}
+		score := 0
+		for i, t := range tsegs {
+			if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+				params[t[1:len(t)-1]] = segs[i]
+				continue
+			}
+			if t != segs[i] {
+				score = -1
+				break
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestParams, bestScore = op, params, score
+		}
+	}
+	return best, bestParams
+}
+
+// Validate checks requests against the document: path and query
+// parameters, then the JSON body. Invalid requests get a 400 listing every
+// offending field; requests for paths the document does not describe pass
+// through to the router. The body is buffered so handlers can decode it
+// again.
+func Validate(spec *Spec) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, pathParams := spec.find(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			errs := validateParams(op, pathParams, r)
+			bodyErrs, err := validateBody(op, r)
+			if err != nil {
+				http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+				return
+			}
+			errs = append(errs, bodyErrs...)
+			if len(errs) > 0 {
+				writeErrors(w, errs)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeErrors(w http.ResponseWriter, errs []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error  string       `json:"error"`
+		Fields []FieldError `json:"fields"`
+	}{"invalid request", errs})
+}
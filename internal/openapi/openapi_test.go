@@ -0,0 +1,109 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	spec, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(spec.Routes()) == 0 {
+		t.Fatal("no routes in document")
+	}
+	for _, rt := range spec.Routes() {
+		op, _ := spec.find(rt.Method, rt.Path)
+		if op == nil || op.OperationID == "" {
+			t.Errorf("%s %s: missing operationId", rt.Method, rt.Path)
+		}
+	}
+}
+
+func TestFindPrefersLiteralSegments(t *testing.T) {
+	spec := &Spec{Paths: map[string]map[string]*Operation{
+		"/keys/{id}": {"get": {OperationID: "byID"}},
+		"/keys/new":  {"get": {OperationID: "new"}},
+	}}
+	if op, _ := spec.find("GET", "/keys/new"); op.OperationID != "new" {
+		t.Errorf("/keys/new matched %s", op.OperationID)
+	}
+	op, params := spec.find("GET", "/keys/42")
+	if op.OperationID != "byID" || params["id"] != "42" {
+		t.Errorf("/keys/42 matched %s with %v", op.OperationID, params)
+	}
+	if op, _ := spec.find("POST", "/keys/42"); op != nil {
+		t.Errorf("POST matched %s", op.OperationID)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	minZero, one, ten := 0.0, 1, 10.0
+	schema := &Schema{
+		Type:     "object",
+		Required: []string{"name"},
+		Properties: map[string]*Schema{
+			"name":  {Type: "string", MinLength: &one},
+			"count": {Type: "integer", Minimum: &minZero, Maximum: &ten},
+			"mode":  {Type: "string", Enum: []any{"fast", "slow"}},
+			"when":  {Type: "string", Format: "date-time", Nullable: true},
+			"tags":  {Type: "array", Items: &Schema{Type: "string"}},
+		},
+	}
+
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{`{"name": "a", "count": 3, "mode": "fast", "when": null, "tags": ["x"]}`, nil},
+		{`{"count": 3}`, []string{"name: is required"}},
+		{`{"name": "", "count": 11}`, []string{"count: must be at most 10", "name: must be at least 1 characters"}},
+		{`{"name": "a", "count": 1.5}`, []string{"count: must be an integer"}},
+		{`{"name": "a", "count": "3"}`, []string{"count: must be an integer"}},
+		{`{"name": "a", "mode": "medium"}`, []string{"mode: must be one of fast, slow"}},
+		{`{"name": "a", "when": "tomorrow"}`, []string{"when: must be an RFC 3339 date-time"}},
+		{`{"name": "a", "tags": ["x", 2]}`, []string{"tags[1]: must be a string"}},
+		{`{"name": "a", "extra": true}`, nil},
+		{`[1]`, []string{": must be an object"}},
+	}
+
+	for _, tt := range tests {
+		dec := json.NewDecoder(strings.NewReader(tt.body))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("decode %s: %v", tt.body, err)
+		}
+		var got []string
+		for _, v := range check(v, schema, "") {
+			got = append(got, v.field+": "+v.message)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("check(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRestoresBody(t *testing.T) {
+	spec, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var seen string
+	handler := Validate(spec)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		seen = string(b)
+	}))
+
+	body := `{"code": "ABCDE-FGHJK"}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/link", strings.NewReader(body)))
+	if rec.Code != http.StatusOK || seen != body {
+		t.Errorf("status %d, handler saw %q; want 200 and %q", rec.Code, seen, body)
+	}
+}
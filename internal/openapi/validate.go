@@ -0,0 +1,281 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBodyBytes bounds request bodies read for validation.
+const maxBodyBytes = 1 << 20
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	In      string `json:"in"`
+	Message string `json:"message"`
+}
+
+func validateParams(op *Operation, pathParams map[string]string, r *http.Request) []FieldError {
+	var errs []FieldError
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var (
+			raw     string
+			present bool
+		)
+		switch p.In {
+		case "path":
+			raw, present = pathParams[p.Name]
+		case "query":
+			present = query.Has(p.Name)
+			raw = query.Get(p.Name)
+		default:
+			continue
+		}
+		if !present || raw == "" {
+			if p.Required {
+				errs = append(errs, FieldError{p.Name, p.In, "is required"})
+			}
+			continue
+		}
+		if p.Schema == nil {
+			continue
+		}
+		v, ok := parseParam(raw, p.Schema.Type)
+		if !ok {
+			errs = append(errs, FieldError{p.Name, p.In, "must be " + article(p.Schema.Type)})
+			continue
+		}
+		for _, msg := range check(v, p.Schema, p.Name) {
+			errs = append(errs, FieldError{msg.field, p.In, msg.message})
+		}
+	}
+	return errs
+}
+
+// parseParam converts a string parameter to the JSON value it stands for.
+func parseParam(raw, typ string) (any, bool) {
+	switch typ {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(raw), true
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		return b, err == nil
+	default:
+		return raw, true
+	}
+}
+
+// validateBody checks a JSON body and restores r.Body for the handler. A
+// body that is not JSON at all is returned as an error.
+func validateBody(op *Operation, r *http.Request) ([]FieldError, error) {
+	if op.RequestBody == nil {
+		return nil, nil
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil, nil
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > maxBodyBytes {
+			return nil, errors.New("request body too large")
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return []FieldError{{"", "body", "is required"}}, nil
+		}
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var errs []FieldError
+	for _, msg := range check(v, media.Schema, "") {
+		errs = append(errs, FieldError{msg.field, "body", msg.message})
+	}
+	return errs, nil
+}
+
+type violation struct {
+	field   string
+	message string
+}
+
+// check validates a decoded JSON value (numbers as json.Number) against
+// schema, naming fields by their path ("scopes[1]").
+func check(v any, schema *Schema, field string) []violation {
+	if v == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return []violation{{field, "must not be null"}}
+	}
+
+	fail := func(format string, args ...any) []violation {
+		return []violation{{field, fmt.Sprintf(format, args...)}}
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fail("must be an object")
+		}
+		var out []violation
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				out = append(out, violation{join(field, name), "is required"})
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := schema.Properties[name]; ok {
+				out = append(out, check(obj[name], prop, join(field, name))...)
+			}
+		}
+		return out
+
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return fail("must be an array")
+		}
+		if schema.MinItems != nil && len(arr) < *schema.MinItems {
+			return fail("must have at least %d items", *schema.MinItems)
+		}
+		var out []violation
+		if schema.Items != nil {
+			for i, item := range arr {
+				out = append(out, check(item, schema.Items, fmt.Sprintf("%s[%d]", field, i))...)
+			}
+		}
+		return out
+
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		n := len([]rune(s))
+		if schema.MinLength != nil && n < *schema.MinLength {
+			return fail("must be at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && n > *schema.MaxLength {
+			return fail("must be at most %d characters", *schema.MaxLength)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				return fail("must be an RFC 3339 date-time")
+			}
+		}
+		if len(schema.Enum) > 0 && !inEnum(s, schema.Enum) {
+			return fail("must be one of %s", enumList(schema.Enum))
+		}
+		return nil
+
+	case "integer", "number":
+		num, ok := v.(json.Number)
+		if !ok {
+			return fail("must be %s", article(schema.Type))
+		}
+		f, err := num.Float64()
+		if err != nil {
+			return fail("must be %s", article(schema.Type))
+		}
+		if schema.Type == "integer" {
+			if _, err := num.Int64(); err != nil || f != math.Trunc(f) {
+				return fail("must be an integer")
+			}
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			return fail("must be at least %s", formatNumber(*schema.Minimum))
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			return fail("must be at most %s", formatNumber(*schema.Maximum))
+		}
+		if len(schema.Enum) > 0 && !inEnum(f, schema.Enum) {
+			return fail("must be one of %s", enumList(schema.Enum))
+		}
+		return nil
+
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fail("must be a boolean")
+		}
+		return nil
+	}
+	return nil
+}
+
+func inEnum(v any, enum []any) bool {
+	for _, e := range enum {
+		if f, ok := v.(float64); ok {
+			if ef, ok := e.(float64); ok && ef == f {
+				return true
+			}
+			continue
+		}
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []any) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		parts[i] = fmt.Sprint(e)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func article(typ string) string {
+	switch typ {
+	case "integer", "array", "object":
+		return "an " + typ
+	case "":
+		return "a value"
+	}
+	return "a " + typ
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,477 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runConformance checks the behaviour every Store backend must share.
+// newStore returns an empty, migrated store.
+func runConformance(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("Events", func(t *testing.T) { testEvents(t, newStore(t)) })
+	t.Run("Users", func(t *testing.T) { testUsers(t, newStore(t)) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, newStore(t)) })
+	t.Run("APIKeys", func(t *testing.T) { testAPIKeys(t, newStore(t)) })
+	t.Run("Subscriptions", func(t *testing.T) { testSubscriptions(t, newStore(t)) })
+	t.Run("Liquidations", func(t *testing.T) { testLiquidations(t, newStore(t)) })
+	t.Run("Notifications", func(t *testing.T) { testNotifications(t, newStore(t)) })
+	t.Run("Sources", func(t *testing.T) { testSources(t, newStore(t)) })
+	t.Run("Audit", func(t *testing.T) { testAudit(t, newStore(t)) })
+}
+
+func TestMemoryConformance(t *testing.T) {
+	runConformance(t, func(*testing.T) Store { return NewMemory() })
+}
+
+func eventID(t *testing.T, s Store, name string) int {
+	t.Helper()
+	events, err := s.ListEvents(context.Background())
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	for _, ev := range events {
+		if ev.Name == name {
+			return ev.ID
+		}
+	}
+	t.Fatalf("event %q not seeded", name)
+	return 0
+}
+
+func testEvents(t *testing.T, s Store) {
+	events, err := s.ListEvents(context.Background())
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(events) != len(seedEvents) {
+		t.Fatalf("len(events) = %d, want %d", len(events), len(seedEvents))
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].ID <= events[i-1].ID {
+			t.Errorf("events not ordered by id: %d after %d", events[i].ID, events[i-1].ID)
+		}
+	}
+	if !events[0].Enabled || events[0].Description == "" {
+		t.Errorf("events[0] = %+v", events[0])
+	}
+
+	// Disabled events are hidden from users but not from admins.
+	ctx := context.Background()
+	ev, err := s.SetEventEnabled(ctx, events[0].ID, false)
+	if err != nil || ev.Enabled || ev.Name != events[0].Name {
+		t.Fatalf("SetEventEnabled(false) = %+v, %v", ev, err)
+	}
+	if enabled, _ := s.ListEvents(ctx); len(enabled) != len(seedEvents)-1 {
+		t.Errorf("ListEvents after disabling one = %d events, want %d", len(enabled), len(seedEvents)-1)
+	}
+	all, err := s.ListAllEvents(ctx)
+	if err != nil || len(all) != len(seedEvents) || all[0].Enabled {
+		t.Errorf("ListAllEvents = %d events (first %+v), %v", len(all), all[0], err)
+	}
+	if ev, err := s.SetEventEnabled(ctx, events[0].ID, true); err != nil || !ev.Enabled {
+		t.Errorf("SetEventEnabled(true) = %+v, %v", ev, err)
+	}
+	if _, err := s.SetEventEnabled(ctx, 99999, true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetEventEnabled(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testUsers(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	if lang, err := s.GetUserLanguage(ctx, 1); err != nil || lang != "en" {
+		t.Errorf("GetUserLanguage(unknown) = %q, %v; want en", lang, err)
+	}
+	if _, err := s.GetTelegramUser(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTelegramUser(unknown) error = %v, want ErrNotFound", err)
+	}
+
+	// Link code flow: expired codes fail, a valid one links once.
+	if err := s.UpsertTelegramUser(ctx, 1, "ada", "EXPIRED", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("UpsertTelegramUser: %v", err)
+	}
+	if _, err := s.LinkByCode(ctx, "EXPIRED"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LinkByCode(expired) error = %v, want ErrNotFound", err)
+	}
+	if err := s.UpsertTelegramUser(ctx, 1, "ada", "CODE", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("UpsertTelegramUser: %v", err)
+	}
+	u, err := s.LinkByCode(ctx, "CODE")
+	if err != nil || u.TgChatID != 1 || !u.Linked || u.TgUsername != "ada" {
+		t.Fatalf("LinkByCode = %+v, %v", u, err)
+	}
+	if _, err := s.LinkByCode(ctx, "CODE"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LinkByCode(reused) error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.SetUserLanguage(ctx, 1, "ada", "zh"); err != nil {
+		t.Fatalf("SetUserLanguage: %v", err)
+	}
+	if lang, _ := s.GetUserLanguage(ctx, 1); lang != "zh" {
+		t.Errorf("language = %q, want zh", lang)
+	}
+
+	// Login widget: registers new chats linked, keeps the old username
+	// when none is sent.
+	if u, err := s.UpsertLoginUser(ctx, 2, "bob"); err != nil || !u.Linked {
+		t.Fatalf("UpsertLoginUser(new) = %+v, %v", u, err)
+	}
+	if n, _ := s.CountLinkedUsers(ctx); n != 2 {
+		t.Errorf("CountLinkedUsers = %d, want 2", n)
+	}
+	if err := s.UnlinkTelegram(ctx, 2); err != nil {
+		t.Fatalf("UnlinkTelegram: %v", err)
+	}
+	if u, _ := s.GetTelegramUser(ctx, 2); u == nil || u.Linked {
+		t.Errorf("after unlink user = %+v", u)
+	}
+	if u, err := s.UpsertLoginUser(ctx, 2, ""); err != nil || !u.Linked || u.TgUsername != "bob" {
+		t.Errorf("UpsertLoginUser(relink) = %+v, %v", u, err)
+	}
+
+	users, err := s.ListTelegramUsers(ctx)
+	if err != nil || len(users) != 2 || users[0].TgChatID != 1 || users[1].TgUsername != "bob" || users[0].Language != "zh" {
+		t.Errorf("ListTelegramUsers = %+v, %v", users, err)
+	}
+	for _, tc := range []struct {
+		query string
+		want  int
+	}{{"", 2}, {"BO", 1}, {"2", 1}, {"%", 0}, {"nobody", 0}} {
+		if found, err := s.SearchTelegramUsers(ctx, tc.query, 10); err != nil || len(found) != tc.want {
+			t.Errorf("SearchTelegramUsers(%q) = %+v, %v; want %d", tc.query, found, err, tc.want)
+		}
+	}
+	if found, _ := s.SearchTelegramUsers(ctx, "", 1); len(found) != 1 || found[0].TgChatID != 1 {
+		t.Errorf("SearchTelegramUsers(limit 1) = %+v, want chat 1", found)
+	}
+
+	if err := s.LogLinkAttempt(ctx, "192.0.2.1", "invalid_code", 0); err != nil {
+		t.Errorf("LogLinkAttempt: %v", err)
+	}
+}
+
+func testSessions(t *testing.T, s Store) {
+	ctx := context.Background()
+	if _, err := s.UpsertLoginUser(ctx, 1, "ada"); err != nil {
+		t.Fatalf("UpsertLoginUser: %v", err)
+	}
+
+	token, expiresAt, err := s.CreateSession(ctx, 1, time.Hour)
+	if err != nil || len(token) != 64 || time.Until(expiresAt) < 59*time.Minute {
+		t.Fatalf("CreateSession = %q, %v, %v", token, expiresAt, err)
+	}
+	if chatID, err := s.SessionChatID(ctx, token); err != nil || chatID != 1 {
+		t.Errorf("SessionChatID = %d, %v; want 1", chatID, err)
+	}
+	if _, err := s.SessionChatID(ctx, "bogus"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SessionChatID(bogus) error = %v, want ErrNotFound", err)
+	}
+
+	expired, _, _ := s.CreateSession(ctx, 1, -time.Minute)
+	if _, err := s.SessionChatID(ctx, expired); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SessionChatID(expired) error = %v, want ErrNotFound", err)
+	}
+
+	// Unlinking invalidates sessions without deleting them.
+	_ = s.UnlinkTelegram(ctx, 1)
+	if _, err := s.SessionChatID(ctx, token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SessionChatID(unlinked) error = %v, want ErrNotFound", err)
+	}
+	_, _ = s.UpsertLoginUser(ctx, 1, "")
+
+	if err := s.DeleteSession(ctx, token); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if _, err := s.SessionChatID(ctx, token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SessionChatID(deleted) error = %v, want ErrNotFound", err)
+	}
+
+	other, _, _ := s.CreateSession(ctx, 1, time.Hour)
+	if err := s.DeleteSessions(ctx, 1); err != nil {
+		t.Fatalf("DeleteSessions: %v", err)
+	}
+	if _, err := s.SessionChatID(ctx, other); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SessionChatID after DeleteSessions error = %v, want ErrNotFound", err)
+	}
+}
+
+func testAPIKeys(t *testing.T, s Store) {
+	ctx := context.Background()
+	_, _ = s.UpsertLoginUser(ctx, 1, "ada")
+	_, _ = s.UpsertLoginUser(ctx, 2, "bob")
+
+	key, k, err := s.CreateAPIKey(ctx, 1, "ci", []string{ScopeReadStats}, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if k.Prefix != key[:len(APIKeyPrefix)+8] || k.ID == 0 || k.LastUsedAt != nil {
+		t.Errorf("key = %+v", k)
+	}
+	past := time.Now().Add(-time.Hour)
+	expiredKey, _, _ := s.CreateAPIKey(ctx, 1, "old", []string{ScopeReadStats}, &past)
+
+	chatID, scopes, err := s.APIKeyChatID(ctx, key)
+	if err != nil || chatID != 1 || len(scopes) != 1 || scopes[0] != ScopeReadStats {
+		t.Errorf("APIKeyChatID = %d, %v, %v", chatID, scopes, err)
+	}
+	if _, _, err := s.APIKeyChatID(ctx, expiredKey); !errors.Is(err, ErrNotFound) {
+		t.Errorf("APIKeyChatID(expired) error = %v, want ErrNotFound", err)
+	}
+
+	keys, err := s.ListAPIKeys(ctx, 1)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("ListAPIKeys = %+v, %v", keys, err)
+	}
+	if keys[0].Name != "old" || keys[1].LastUsedAt == nil {
+		t.Errorf("keys = %+v, want newest first and last_used_at recorded", keys)
+	}
+
+	if err := s.DeleteAPIKey(ctx, 2, k.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteAPIKey(other chat) error = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteAPIKey(ctx, 1, k.ID); err != nil {
+		t.Errorf("DeleteAPIKey: %v", err)
+	}
+	if _, _, err := s.APIKeyChatID(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("APIKeyChatID(revoked) error = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteAPIKeys(ctx, 1); err != nil {
+		t.Fatalf("DeleteAPIKeys: %v", err)
+	}
+	if keys, _ := s.ListAPIKeys(ctx, 1); len(keys) != 0 {
+		t.Errorf("keys after DeleteAPIKeys = %+v", keys)
+	}
+}
+
+func testSubscriptions(t *testing.T, s Store) {
+	ctx := context.Background()
+	alert := eventID(t, s, "altura_metric_alert")
+	report := eventID(t, s, "altura_daily_report")
+
+	if _, err := s.Subscribe(ctx, 1, alert, 5, 1, "drop", 8, 0, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Subscribe(unknown chat) error = %v, want ErrNotFound", err)
+	}
+
+	_, _ = s.UpsertLoginUser(ctx, 1, "ada")
+	_, _ = s.UpsertLoginUser(ctx, 2, "bob")
+	sub, err := s.Subscribe(ctx, 1, alert, 5, 10, "drop", 8, 0, "")
+	if err != nil || sub.EventID != alert || sub.WindowMinutes != 10 {
+		t.Fatalf("Subscribe = %+v, %v", sub, err)
+	}
+	if _, err := s.Subscribe(ctx, 2, alert, 0, 1, "higher", 8, 100, "BTC"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	reportSub, err := s.Subscribe(ctx, 1, report, 0, 1, "drop", 9, 0, "")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if subs, _ := s.ListSubscriptions(ctx, 1); len(subs) != 2 || subs[0].ID != sub.ID {
+		t.Errorf("ListSubscriptions = %+v", subs)
+	}
+	if owner, err := s.GetSubscriptionChatID(ctx, sub.ID); err != nil || owner != 1 {
+		t.Errorf("GetSubscriptionChatID = %d, %v", owner, err)
+	}
+	if _, err := s.GetSubscriptionChatID(ctx, -1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSubscriptionChatID(unknown) error = %v, want ErrNotFound", err)
+	}
+
+	cfgs, err := s.GetSubscribersWithThresholds(ctx, "altura_metric_alert")
+	if err != nil || len(cfgs) != 2 {
+		t.Fatalf("GetSubscribersWithThresholds = %+v, %v", cfgs, err)
+	}
+	if cfgs[0].SubscriptionID != sub.ID || cfgs[0].ChatID != 1 {
+		t.Errorf("GetSubscribersWithThresholds[0] = %+v, want subscription %d of chat 1", cfgs[0], sub.ID)
+	}
+	if subs, _ := s.GetDailyReportSubscribers(ctx, "altura_daily_report", 9); len(subs) != 1 || subs[0].ChatID != 1 || subs[0].SubscriptionID != reportSub.ID || subs[0].ReportHour != 9 {
+		t.Errorf("GetDailyReportSubscribers(9) = %+v, want subscription %d of chat 1", subs, reportSub.ID)
+	}
+	if subs, _ := s.GetDailyReportSubscribers(ctx, "altura_daily_report", 8); len(subs) != 0 {
+		t.Errorf("GetDailyReportSubscribers(8) = %+v, want none", subs)
+	}
+
+	// Unlinked chats keep their subscriptions but get no alerts.
+	_ = s.UnlinkTelegram(ctx, 2)
+	if ids, _ := s.GetSubscriberChatIDs(ctx, "altura_metric_alert"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("GetSubscriberChatIDs = %v, want [1]", ids)
+	}
+	if n, _ := s.CountSubscriptions(ctx, "altura_metric_alert"); n != 2 {
+		t.Errorf("CountSubscriptions = %d, want 2", n)
+	}
+	all, err := s.ListEventSubscriptions(ctx)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("ListEventSubscriptions = %+v, %v", all, err)
+	}
+	if all[0].SubscriptionID != sub.ID || all[0].EventName != "altura_metric_alert" || !all[0].Linked {
+		t.Errorf("ListEventSubscriptions[0] = %+v, want linked subscription %d", all[0], sub.ID)
+	}
+	if all[1].ChatID != 2 || all[1].Linked || all[1].Coin != "BTC" {
+		t.Errorf("ListEventSubscriptions[1] = %+v, want unlinked chat 2 on BTC", all[1])
+	}
+	if all[2].SubscriptionID != reportSub.ID || all[2].EventName != "altura_daily_report" || all[2].ReportHour != 9 {
+		t.Errorf("ListEventSubscriptions[2] = %+v, want report subscription %d at 9", all[2], reportSub.ID)
+	}
+
+	updated, err := s.UpdateSubscription(ctx, sub.ID, 15, 30, "increase", 8, 0, "")
+	if err != nil || updated.ThresholdPct != 15 || updated.Direction != "increase" || updated.EventID != alert {
+		t.Errorf("UpdateSubscription = %+v, %v", updated, err)
+	}
+	if _, err := s.UpdateSubscription(ctx, -1, 1, 1, "drop", 8, 0, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateSubscription(unknown) error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Unsubscribe(ctx, sub.ID); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if n, _ := s.CountSubscriptions(ctx, "altura_metric_alert"); n != 1 {
+		t.Errorf("CountSubscriptions after unsubscribe = %d, want 1", n)
+	}
+}
+
+func testLiquidations(t *testing.T, s Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, err := s.GetCurrentPrice(ctx, "BTCUSDT"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCurrentPrice(empty) error = %v, want ErrNotFound", err)
+	}
+	long, short, err := s.QueryMaxPain(ctx, "BTCUSDT", time.Hour, 100)
+	if err != nil || *long != (MaxPainResult{}) || *short != (MaxPainResult{}) {
+		t.Errorf("QueryMaxPain(empty) = %+v, %+v, %v", long, short, err)
+	}
+
+	events := []LiquidationEvent{
+		{Symbol: "BTCUSDT", Side: "LONG", Price: 60_010, USDValue: 100, EventTime: now.Add(-3 * time.Minute)},
+		{Symbol: "BTCUSDT", Side: "LONG", Price: 59_990, USDValue: 50, EventTime: now.Add(-2 * time.Minute)},
+		{Symbol: "BTCUSDT", Side: "LONG", Price: 59_000, USDValue: 120, EventTime: now.Add(-2 * time.Minute)},
+		{Symbol: "BTCUSDT", Side: "SHORT", Price: 61_040, USDValue: 70, EventTime: now.Add(-time.Minute)},
+		{Symbol: "BTCUSDT", Side: "LONG", Price: 10_000, USDValue: 1e9, EventTime: now.Add(-48 * time.Hour)},
+		{Symbol: "ETHUSDT", Side: "LONG", Price: 3_000, USDValue: 1e9, EventTime: now.Add(-time.Minute)},
+	}
+	if err := s.InsertLiquidationEvents(ctx, events[:5]); err != nil {
+		t.Fatalf("InsertLiquidationEvents: %v", err)
+	}
+	if err := s.InsertLiquidationEvent(ctx, &events[5]); err != nil {
+		t.Fatalf("InsertLiquidationEvent: %v", err)
+	}
+
+	// 60,010 and 59,990 share the 60,000 bin (150) and beat 59,000 (120);
+	// the 48h-old event is outside the window.
+	long, short, err = s.QueryMaxPain(ctx, "BTCUSDT", time.Hour, 100)
+	if err != nil {
+		t.Fatalf("QueryMaxPain: %v", err)
+	}
+	if *long != (MaxPainResult{PriceBin: 60_000, USDTotal: 150}) {
+		t.Errorf("long max pain = %+v, want 60000/150", *long)
+	}
+	if *short != (MaxPainResult{PriceBin: 61_000, USDTotal: 70}) {
+		t.Errorf("short max pain = %+v, want 61000/70", *short)
+	}
+
+	bins, err := s.QueryLiquidationBins(ctx, "BTCUSDT", time.Hour, 100)
+	want := []LiquidationBin{{59_000, 120, 0}, {60_000, 150, 0}, {61_000, 0, 70}}
+	if err != nil || len(bins) != len(want) {
+		t.Fatalf("QueryLiquidationBins = %+v, %v", bins, err)
+	}
+	for i := range want {
+		if bins[i] != want[i] {
+			t.Errorf("bins[%d] = %+v, want %+v", i, bins[i], want[i])
+		}
+	}
+
+	if price, err := s.GetCurrentPrice(ctx, "BTCUSDT"); err != nil || price != 61_040 {
+		t.Errorf("GetCurrentPrice = %v, %v; want 61040", price, err)
+	}
+	if n, _ := s.CountLiquidationEvents(ctx, "BTCUSDT", time.Hour); n != 4 {
+		t.Errorf("CountLiquidationEvents = %d, want 4", n)
+	}
+	if n, err := s.CleanupOldLiquidationEvents(ctx, 24*time.Hour); err != nil || n != 1 {
+		t.Errorf("CleanupOldLiquidationEvents = %d, %v; want 1", n, err)
+	}
+	if n, _ := s.CountLiquidationEvents(ctx, "BTCUSDT", 72*time.Hour); n != 4 {
+		t.Errorf("CountLiquidationEvents after cleanup = %d, want 4", n)
+	}
+}
+
+func testNotifications(t *testing.T, s Store) {
+	ctx := context.Background()
+	for _, summary := range []string{"first", "second", "third"} {
+		if err := s.LogNotification(ctx, 1, "metric", "altura_metric_alert", summary); err != nil {
+			t.Fatalf("LogNotification: %v", err)
+		}
+	}
+	_ = s.LogNotification(ctx, 2, "metric", "altura_metric_alert", "other chat")
+
+	logs, err := s.ListNotifications(ctx, 1, 2)
+	if err != nil || len(logs) != 2 {
+		t.Fatalf("ListNotifications = %+v, %v", logs, err)
+	}
+	if logs[0].Summary != "third" || logs[1].Summary != "second" {
+		t.Errorf("summaries = %q, %q; want newest first", logs[0].Summary, logs[1].Summary)
+	}
+	if logs, _ := s.ListNotifications(ctx, 1, 0); len(logs) != 3 {
+		t.Errorf("ListNotifications(limit 0) = %d entries, want 3", len(logs))
+	}
+
+	for _, chat := range []int64{1, 2} {
+		if err := s.LogDeliveryFailure(ctx, chat, strings.Repeat("x", 2*maxFailureMessage), "Forbidden: bot was blocked by the user"); err != nil {
+			t.Fatalf("LogDeliveryFailure: %v", err)
+		}
+	}
+	failures, err := s.ListDeliveryFailures(ctx, 0)
+	if err != nil || len(failures) != 2 || failures[0].TgChatID != 2 || failures[1].TgChatID != 1 {
+		t.Fatalf("ListDeliveryFailures = %+v, %v; want both chats, newest first", failures, err)
+	}
+	if n := len([]rune(failures[0].Message)); n != maxFailureMessage+1 || failures[0].Error == "" {
+		t.Errorf("failure message = %d runes, error %q; want it cut to %d plus an ellipsis", n, failures[0].Error, maxFailureMessage)
+	}
+}
+
+func testSources(t *testing.T, s Store) {
+	ctx := context.Background()
+	if paused, err := s.ListPausedSources(ctx); err != nil || len(paused) != 0 {
+		t.Fatalf("ListPausedSources = %+v, %v; want none", paused, err)
+	}
+	for _, name := range []string{"merkl", "altura", "merkl"} {
+		if err := s.SetSourcePaused(ctx, name, true); err != nil {
+			t.Fatalf("SetSourcePaused(%s, true): %v", name, err)
+		}
+	}
+	paused, err := s.ListPausedSources(ctx)
+	if err != nil || len(paused) != 2 || paused[0].Name != "altura" || paused[1].Name != "merkl" || paused[0].PausedAt.IsZero() {
+		t.Fatalf("ListPausedSources = %+v, %v; want altura and merkl", paused, err)
+	}
+	if err := s.SetSourcePaused(ctx, "altura", false); err != nil {
+		t.Fatalf("SetSourcePaused(altura, false): %v", err)
+	}
+	if err := s.SetSourcePaused(ctx, "turtle", false); err != nil {
+		t.Errorf("resuming a running source: %v", err)
+	}
+	if paused, _ := s.ListPausedSources(ctx); len(paused) != 1 || paused[0].Name != "merkl" {
+		t.Errorf("after resuming altura: %+v, want merkl", paused)
+	}
+}
+
+func testAudit(t *testing.T, s Store) {
+	ctx := context.Background()
+	if err := s.LogAdminAction(ctx, "chat:1", "set_event_enabled", "altura_metric_alert", "enabled=false"); err != nil {
+		t.Fatalf("LogAdminAction: %v", err)
+	}
+	if err := s.LogAdminAction(ctx, "key:ab12cd34", "set_source_paused", "merkl", "paused=true"); err != nil {
+		t.Fatalf("LogAdminAction: %v", err)
+	}
+
+	actions, err := s.ListAdminActions(ctx, 10)
+	if err != nil || len(actions) != 2 {
+		t.Fatalf("ListAdminActions = %+v, %v; want 2", actions, err)
+	}
+	if a := actions[0]; a.Actor != "key:ab12cd34" || a.Action != "set_source_paused" || a.Target != "merkl" || a.Detail != "paused=true" || a.CreatedAt.IsZero() {
+		t.Errorf("newest action = %+v", a)
+	}
+	if actions, _ := s.ListAdminActions(ctx, 1); len(actions) != 1 || actions[0].Target != "merkl" {
+		t.Errorf("ListAdminActions(limit 1) = %+v, want the newest", actions)
+	}
+}
@@ -0,0 +1,827 @@
+package store
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is a Store kept entirely in process memory, for dev mode and
+// tests. It mirrors the Postgres semantics (link state, expiry, ownership)
+// but loses everything on restart.
+type Memory struct {
+	mu sync.Mutex
+
+	events        []Event
+	users         map[int64]*TelegramUser // by chat ID
+	subscriptions []Subscription          // ordered by ID
+	sessions      map[string]memorySession
+	apiKeys       []memoryAPIKey // ordered by ID
+	linkAttempts  []memoryLinkAttempt
+	liquidations  []LiquidationEvent
+	notifications []NotificationLog // ordered by ID
+	failures      []DeliveryFailure // ordered by ID
+	paused        map[string]time.Time
+	audit         []AdminAction // ordered by ID
+
+	lastID int64
+}
+
+type memorySession struct {
+	chatID    int64
+	expiresAt time.Time
+}
+
+type memoryAPIKey struct {
+	APIKey
+	chatID int64
+	hash   string
+}
+
+type memoryLinkAttempt struct {
+	ip, outcome string
+	chatID      int64
+	createdAt   time.Time
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory returns an empty Memory store with the events already seeded.
+func NewMemory() *Memory {
+	m := &Memory{
+		users:    make(map[int64]*TelegramUser),
+		sessions: make(map[string]memorySession),
+		paused:   make(map[string]time.Time),
+	}
+	now := time.Now()
+	for i, ev := range seedEvents {
+		ev.ID = i + 1
+		ev.Enabled = true
+		ev.CreatedAt = now
+		m.events = append(m.events, ev)
+	}
+	return m
+}
+
+func (m *Memory) Close() {}
+
+func (m *Memory) Ping(context.Context) error { return nil }
+
+// Migrate is a no-op: NewMemory seeds everything.
+func (m *Memory) Migrate(context.Context) error { return nil }
+
+// nextID returns a fresh ID; IDs are unique across all tables, which
+// callers cannot tell apart from per-table sequences.
+func (m *Memory) nextID() int64 {
+	m.lastID++
+	return m.lastID
+}
+
+// --- Events ---
+
+func (m *Memory) ListEvents(context.Context) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var events []Event
+	for _, ev := range m.events {
+		if ev.Enabled {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+func (m *Memory) ListAllEvents(context.Context) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.events), nil
+}
+
+func (m *Memory) SetEventEnabled(_ context.Context, id int, enabled bool) (*Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.events {
+		if m.events[i].ID == id {
+			m.events[i].Enabled = enabled
+			ev := m.events[i]
+			return &ev, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) eventByName(name string) (Event, bool) {
+	for _, ev := range m.events {
+		if ev.Name == name {
+			return ev, true
+		}
+	}
+	return Event{}, false
+}
+
+// --- Telegram Users ---
+
+func (m *Memory) UpsertTelegramUser(_ context.Context, chatID int64, username, linkCode string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u := m.user(chatID, username)
+	u.TgUsername = username
+	u.LinkCode = linkCode
+	u.LinkCodeExpiresAt = expiresAt
+	return nil
+}
+
+func (m *Memory) LinkByCode(_ context.Context, code string) (*TelegramUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, u := range m.users {
+		if u.LinkCode == code && code != "" && u.LinkCodeExpiresAt.After(now) {
+			u.Linked = true
+			u.LinkCode = ""
+			u.LinkCodeExpiresAt = time.Time{}
+			c := *u
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) UpsertLoginUser(_ context.Context, chatID int64, username string) (*TelegramUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u := m.user(chatID, username)
+	u.Linked = true
+	u.LinkCode = ""
+	u.LinkCodeExpiresAt = time.Time{}
+	if username != "" {
+		u.TgUsername = username
+	}
+	c := *u
+	return &c, nil
+}
+
+func (m *Memory) UnlinkTelegram(_ context.Context, chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Only mark as unlinked — preserve subscriptions so they restore on re-link
+	if u, ok := m.users[chatID]; ok {
+		u.Linked = false
+	}
+	return nil
+}
+
+func (m *Memory) GetTelegramUser(_ context.Context, chatID int64) (*TelegramUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[chatID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *u
+	c.LinkCode, c.LinkCodeExpiresAt = "", time.Time{}
+	return &c, nil
+}
+
+func (m *Memory) ListTelegramUsers(_ context.Context) ([]TelegramUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var users []TelegramUser
+	for _, u := range m.users {
+		c := *u
+		c.LinkCode, c.LinkCodeExpiresAt = "", time.Time{}
+		users = append(users, c)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (m *Memory) SearchTelegramUsers(ctx context.Context, query string, limit int) ([]TelegramUser, error) {
+	users, _ := m.ListTelegramUsers(ctx)
+	q := strings.ToLower(query)
+	var found []TelegramUser
+	for _, u := range users {
+		if len(found) == userSearchLimit(limit) {
+			break
+		}
+		if q == "" || strings.Contains(strings.ToLower(u.TgUsername), q) || strconv.FormatInt(u.TgChatID, 10) == query {
+			found = append(found, u)
+		}
+	}
+	return found, nil
+}
+
+func (m *Memory) GetUserLanguage(_ context.Context, chatID int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if u, ok := m.users[chatID]; ok {
+		return u.Language, nil
+	}
+	return "en", nil
+}
+
+func (m *Memory) SetUserLanguage(_ context.Context, chatID int64, username, lang string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.user(chatID, username).Language = lang
+	return nil
+}
+
+func (m *Memory) LogLinkAttempt(_ context.Context, ip, outcome string, chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.linkAttempts = append(m.linkAttempts, memoryLinkAttempt{ip: ip, outcome: outcome, chatID: chatID, createdAt: time.Now()})
+	return nil
+}
+
+func (m *Memory) CountLinkedUsers(context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, u := range m.users {
+		if u.Linked {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// user returns the chat's user, registering it unlinked if it is new.
+// Callers hold m.mu.
+func (m *Memory) user(chatID int64, username string) *TelegramUser {
+	u, ok := m.users[chatID]
+	if !ok {
+		u = &TelegramUser{ID: m.nextID(), TgChatID: chatID, TgUsername: username, Language: "en", CreatedAt: time.Now()}
+		m.users[chatID] = u
+	}
+	return u
+}
+
+// linkedUser returns the chat's user if it exists and is linked. Callers
+// hold m.mu.
+func (m *Memory) linkedUser(chatID int64) (*TelegramUser, bool) {
+	u, ok := m.users[chatID]
+	return u, ok && u.Linked
+}
+
+func (m *Memory) userByID(id int64) (*TelegramUser, bool) {
+	for _, u := range m.users {
+		if u.ID == id {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+// --- Sessions ---
+
+func (m *Memory) CreateSession(_ context.Context, chatID int64, ttl time.Duration) (string, time.Time, error) {
+	token, err := newSecret()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for hash, sess := range m.sessions {
+		if sess.expiresAt.Before(now) {
+			delete(m.sessions, hash)
+		}
+	}
+	m.sessions[hashToken(token)] = memorySession{chatID: chatID, expiresAt: expiresAt}
+	return token, expiresAt, nil
+}
+
+func (m *Memory) SessionChatID(_ context.Context, token string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sess, ok := m.sessions[hashToken(token)]
+	if !ok || !sess.expiresAt.After(time.Now()) {
+		return 0, ErrNotFound
+	}
+	if _, linked := m.linkedUser(sess.chatID); !linked {
+		return 0, ErrNotFound
+	}
+	return sess.chatID, nil
+}
+
+func (m *Memory) DeleteSession(_ context.Context, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, hashToken(token))
+	return nil
+}
+
+func (m *Memory) DeleteSessions(_ context.Context, chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for hash, sess := range m.sessions {
+		if sess.chatID == chatID {
+			delete(m.sessions, hash)
+		}
+	}
+	return nil
+}
+
+// --- API keys ---
+
+func (m *Memory) CreateAPIKey(_ context.Context, chatID int64, name string, scopes []string, expiresAt *time.Time) (string, *APIKey, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	key := APIKeyPrefix + secret
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memoryAPIKey{
+		APIKey: APIKey{
+			ID:        m.nextID(),
+			Name:      name,
+			Prefix:    key[:len(APIKeyPrefix)+8],
+			Scopes:    slices.Clone(scopes),
+			CreatedAt: time.Now(),
+			ExpiresAt: expiresAt,
+		},
+		chatID: chatID,
+		hash:   hashToken(key),
+	}
+	m.apiKeys = append(m.apiKeys, k)
+	out := k.APIKey
+	out.Scopes = slices.Clone(scopes)
+	return key, &out, nil
+}
+
+func (m *Memory) ListAPIKeys(_ context.Context, chatID int64) ([]APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []APIKey
+	for i := len(m.apiKeys) - 1; i >= 0; i-- { // newest first
+		if k := m.apiKeys[i]; k.chatID == chatID {
+			c := k.APIKey
+			c.Scopes = slices.Clone(k.Scopes)
+			keys = append(keys, c)
+		}
+	}
+	return keys, nil
+}
+
+func (m *Memory) APIKeyChatID(_ context.Context, key string) (int64, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash := hashToken(key)
+	now := time.Now()
+	for i := range m.apiKeys {
+		k := &m.apiKeys[i]
+		if k.hash != hash {
+			continue
+		}
+		if k.ExpiresAt != nil && !k.ExpiresAt.After(now) {
+			break
+		}
+		if _, linked := m.linkedUser(k.chatID); !linked {
+			break
+		}
+		k.LastUsedAt = &now
+		return k.chatID, slices.Clone(k.Scopes), nil
+	}
+	return 0, nil, ErrNotFound
+}
+
+func (m *Memory) DeleteAPIKey(_ context.Context, chatID, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, k := range m.apiKeys {
+		if k.ID == id && k.chatID == chatID {
+			m.apiKeys = slices.Delete(m.apiKeys, i, i+1)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *Memory) DeleteAPIKeys(_ context.Context, chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiKeys = slices.DeleteFunc(m.apiKeys, func(k memoryAPIKey) bool { return k.chatID == chatID })
+	return nil
+}
+
+// --- Subscriptions ---
+
+func (m *Memory) ListSubscriptions(_ context.Context, tgChatID int64) ([]Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[tgChatID]
+	if !ok {
+		return nil, nil
+	}
+	var subs []Subscription
+	for _, sub := range m.subscriptions {
+		if sub.TgUserID == u.ID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (m *Memory) Subscribe(_ context.Context, tgChatID int64, eventID int, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[tgChatID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !slices.ContainsFunc(m.events, func(ev Event) bool { return ev.ID == eventID }) {
+		return nil, ErrNotFound
+	}
+	sub := Subscription{
+		ID:             m.nextID(),
+		TgUserID:       u.ID,
+		EventID:        eventID,
+		ThresholdPct:   thresholdPct,
+		WindowMinutes:  windowMinutes,
+		Direction:      direction,
+		ReportHour:     reportHour,
+		ThresholdValue: thresholdValue,
+		Coin:           coin,
+		CreatedAt:      time.Now(),
+	}
+	m.subscriptions = append(m.subscriptions, sub)
+	return &sub, nil
+}
+
+func (m *Memory) UpdateSubscription(_ context.Context, id int64, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.subscriptions {
+		sub := &m.subscriptions[i]
+		if sub.ID != id {
+			continue
+		}
+		sub.ThresholdPct = thresholdPct
+		sub.WindowMinutes = windowMinutes
+		sub.Direction = direction
+		sub.ReportHour = reportHour
+		sub.ThresholdValue = thresholdValue
+		sub.Coin = coin
+		c := *sub
+		return &c, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) Unsubscribe(_ context.Context, subID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscriptions = slices.DeleteFunc(m.subscriptions, func(s Subscription) bool { return s.ID == subID })
+	return nil
+}
+
+func (m *Memory) GetSubscriptionChatID(_ context.Context, subID int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sub := range m.subscriptions {
+		if sub.ID == subID {
+			if u, ok := m.userByID(sub.TgUserID); ok {
+				return u.TgChatID, nil
+			}
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (m *Memory) GetSubscriberChatIDs(_ context.Context, eventName string) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ids []int64
+	m.eachLinkedSubscription(eventName, func(u *TelegramUser, _ Subscription) {
+		ids = append(ids, u.TgChatID)
+	})
+	return ids, nil
+}
+
+func (m *Memory) GetSubscribersWithThresholds(_ context.Context, eventName string) ([]SubscriberConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var configs []SubscriberConfig
+	m.eachLinkedSubscription(eventName, func(u *TelegramUser, sub Subscription) {
+		configs = append(configs, SubscriberConfig{
+			SubscriptionID: sub.ID,
+			ChatID:         u.TgChatID,
+			ThresholdPct:   sub.ThresholdPct,
+			WindowMinutes:  sub.WindowMinutes,
+			Direction:      sub.Direction,
+			ThresholdValue: sub.ThresholdValue,
+			Coin:           sub.Coin,
+		})
+	})
+	return configs, nil
+}
+
+func (m *Memory) GetDailyReportSubscribers(_ context.Context, eventName string, hour int) ([]DailyReportSubscriber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var subs []DailyReportSubscriber
+	m.eachLinkedSubscription(eventName, func(u *TelegramUser, sub Subscription) {
+		if sub.ReportHour == hour {
+			subs = append(subs, DailyReportSubscriber{SubscriptionID: sub.ID, ChatID: u.TgChatID, ReportHour: sub.ReportHour})
+		}
+	})
+	return subs, nil
+}
+
+func (m *Memory) CountSubscriptions(_ context.Context, eventName string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ev, ok := m.eventByName(eventName)
+	if !ok {
+		return 0, nil
+	}
+	n := 0
+	for _, sub := range m.subscriptions {
+		if sub.EventID == ev.ID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *Memory) ListEventSubscriptions(_ context.Context) ([]EventSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var subs []EventSubscription
+	for _, sub := range m.subscriptions {
+		u, ok := m.userByID(sub.TgUserID)
+		if !ok {
+			continue
+		}
+		var eventName string
+		for _, ev := range m.events {
+			if ev.ID == sub.EventID {
+				eventName = ev.Name
+			}
+		}
+		subs = append(subs, EventSubscription{
+			SubscriberConfig: SubscriberConfig{
+				SubscriptionID: sub.ID,
+				ChatID:         u.TgChatID,
+				ThresholdPct:   sub.ThresholdPct,
+				WindowMinutes:  sub.WindowMinutes,
+				Direction:      sub.Direction,
+				ThresholdValue: sub.ThresholdValue,
+				Coin:           sub.Coin,
+			},
+			EventName:  eventName,
+			ReportHour: sub.ReportHour,
+			Linked:     u.Linked,
+		})
+	}
+	return subs, nil
+}
+
+// eachLinkedSubscription calls fn for every subscription to eventName
+// whose chat is linked. Callers hold m.mu.
+func (m *Memory) eachLinkedSubscription(eventName string, fn func(*TelegramUser, Subscription)) {
+	ev, ok := m.eventByName(eventName)
+	if !ok {
+		return
+	}
+	for _, sub := range m.subscriptions {
+		if sub.EventID != ev.ID {
+			continue
+		}
+		if u, ok := m.userByID(sub.TgUserID); ok && u.Linked {
+			fn(u, sub)
+		}
+	}
+}
+
+// --- Liquidation Events ---
+
+func (m *Memory) InsertLiquidationEvent(_ context.Context, e *LiquidationEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.liquidations = append(m.liquidations, *e)
+	return nil
+}
+
+func (m *Memory) InsertLiquidationEvents(_ context.Context, events []LiquidationEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.liquidations = append(m.liquidations, events...)
+	return nil
+}
+
+func (m *Memory) QueryMaxPain(_ context.Context, symbol string, window time.Duration, binSize float64) (*MaxPainResult, *MaxPainResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	long, short := make(map[float64]float64), make(map[float64]float64)
+	m.eachLiquidation(symbol, window, func(e LiquidationEvent) {
+		switch e.Side {
+		case "LONG":
+			long[priceBin(e.Price, binSize)] += e.USDValue
+		case "SHORT":
+			short[priceBin(e.Price, binSize)] += e.USDValue
+		}
+	})
+	return maxBin(long), maxBin(short), nil
+}
+
+func (m *Memory) QueryLiquidationBins(_ context.Context, symbol string, window time.Duration, binSize float64) ([]LiquidationBin, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byBin := make(map[float64]*LiquidationBin)
+	m.eachLiquidation(symbol, window, func(e LiquidationEvent) {
+		p := priceBin(e.Price, binSize)
+		b, ok := byBin[p]
+		if !ok {
+			b = &LiquidationBin{PriceBin: p}
+			byBin[p] = b
+		}
+		switch e.Side {
+		case "LONG":
+			b.LongUSD += e.USDValue
+		case "SHORT":
+			b.ShortUSD += e.USDValue
+		}
+	})
+	var bins []LiquidationBin
+	for _, b := range byBin {
+		bins = append(bins, *b)
+	}
+	sort.Slice(bins, func(i, j int) bool { return bins[i].PriceBin < bins[j].PriceBin })
+	return bins, nil
+}
+
+func (m *Memory) GetCurrentPrice(_ context.Context, symbol string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *LiquidationEvent
+	for i, e := range m.liquidations {
+		if e.Symbol == symbol && (latest == nil || e.EventTime.After(latest.EventTime)) {
+			latest = &m.liquidations[i]
+		}
+	}
+	if latest == nil {
+		return 0, ErrNotFound
+	}
+	return latest.Price, nil
+}
+
+func (m *Memory) CountLiquidationEvents(_ context.Context, symbol string, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	m.eachLiquidation(symbol, window, func(LiquidationEvent) { n++ })
+	return n, nil
+}
+
+func (m *Memory) CleanupOldLiquidationEvents(_ context.Context, maxAge time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cutoff := time.Now().Add(-maxAge)
+	before := len(m.liquidations)
+	m.liquidations = slices.DeleteFunc(m.liquidations, func(e LiquidationEvent) bool { return e.EventTime.Before(cutoff) })
+	return int64(before - len(m.liquidations)), nil
+}
+
+// eachLiquidation calls fn for the symbol's events within window. Callers
+// hold m.mu.
+func (m *Memory) eachLiquidation(symbol string, window time.Duration, fn func(LiquidationEvent)) {
+	since := time.Now().Add(-window)
+	for _, e := range m.liquidations {
+		if e.Symbol == symbol && e.EventTime.After(since) {
+			fn(e)
+		}
+	}
+}
+
+// priceBin rounds price to a multiple of binSize like Postgres'
+// ROUND(price / bin) * bin on double precision (ties to even).
+func priceBin(price, binSize float64) float64 {
+	return math.RoundToEven(price/binSize) * binSize
+}
+
+// maxBin returns the bin with the largest total, or a zero result when
+// there is none.
+func maxBin(totals map[float64]float64) *MaxPainResult {
+	var best MaxPainResult
+	for bin, total := range totals {
+		if total > best.USDTotal {
+			best = MaxPainResult{PriceBin: bin, USDTotal: total}
+		}
+	}
+	return &best
+}
+
+// --- Notification Log ---
+
+func (m *Memory) LogNotification(_ context.Context, chatID int64, alertType, eventName, summary string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notifications = append(m.notifications, NotificationLog{
+		ID:        m.nextID(),
+		TgChatID:  chatID,
+		AlertType: alertType,
+		EventName: eventName,
+		Summary:   summary,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (m *Memory) ListNotifications(_ context.Context, chatID int64, limit int) ([]NotificationLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	limit = notificationLimit(limit)
+	var logs []NotificationLog
+	for i := len(m.notifications) - 1; i >= 0 && len(logs) < limit; i-- { // newest first
+		if n := m.notifications[i]; n.TgChatID == chatID {
+			logs = append(logs, n)
+		}
+	}
+	return logs, nil
+}
+
+func (m *Memory) LogDeliveryFailure(_ context.Context, chatID int64, message, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = append(m.failures, DeliveryFailure{
+		ID:        m.nextID(),
+		TgChatID:  chatID,
+		Message:   truncateMessage(message),
+		Error:     errMsg,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (m *Memory) ListDeliveryFailures(_ context.Context, limit int) ([]DeliveryFailure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	limit = notificationLimit(limit)
+	var failures []DeliveryFailure
+	for i := len(m.failures) - 1; i >= 0 && len(failures) < limit; i-- { // newest first
+		failures = append(failures, m.failures[i])
+	}
+	return failures, nil
+}
+
+// --- Sources ---
+
+func (m *Memory) ListPausedSources(context.Context) ([]PausedSource, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var paused []PausedSource
+	for name, at := range m.paused {
+		paused = append(paused, PausedSource{Name: name, PausedAt: at})
+	}
+	sort.Slice(paused, func(i, j int) bool { return paused[i].Name < paused[j].Name })
+	return paused, nil
+}
+
+func (m *Memory) SetSourcePaused(_ context.Context, name string, paused bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !paused {
+		delete(m.paused, name)
+	} else if _, ok := m.paused[name]; !ok {
+		m.paused[name] = time.Now()
+	}
+	return nil
+}
+
+// --- Audit log ---
+
+func (m *Memory) LogAdminAction(_ context.Context, actor, action, target, detail string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audit = append(m.audit, AdminAction{
+		ID:        m.nextID(),
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		Detail:    detail,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (m *Memory) ListAdminActions(_ context.Context, limit int) ([]AdminAction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	limit = notificationLimit(limit)
+	var actions []AdminAction
+	for i := len(m.audit) - 1; i >= 0 && len(actions) < limit; i-- { // newest first
+		actions = append(actions, m.audit[i])
+	}
+	return actions, nil
+}
@@ -1,117 +1,272 @@
 package store
 
-import "context"
-
-const migrationSQL = `
-CREATE TABLE IF NOT EXISTS events (
-    id SERIAL PRIMARY KEY,
-    name TEXT NOT NULL UNIQUE,
-    description TEXT NOT NULL DEFAULT '',
-    category TEXT NOT NULL DEFAULT 'general',
-    enabled BOOLEAN NOT NULL DEFAULT true,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
-CREATE TABLE IF NOT EXISTS telegram_users (
-    id BIGSERIAL PRIMARY KEY,
-    tg_chat_id BIGINT NOT NULL UNIQUE,
-    tg_username TEXT NOT NULL DEFAULT '',
-    link_code TEXT UNIQUE,
-    link_code_expires_at TIMESTAMPTZ,
-    linked BOOLEAN NOT NULL DEFAULT false,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
-CREATE TABLE IF NOT EXISTS subscriptions (
-    id BIGSERIAL PRIMARY KEY,
-    tg_user_id BIGINT NOT NULL REFERENCES telegram_users(id) ON DELETE CASCADE,
-    event_id INT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
-    threshold_pct DOUBLE PRECISION NOT NULL DEFAULT 10,
-    window_minutes INT NOT NULL DEFAULT 1,
-    direction TEXT NOT NULL DEFAULT 'drop',
-    report_hour INT NOT NULL DEFAULT 8,
-    threshold_value DOUBLE PRECISION NOT NULL DEFAULT 0,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
--- Add columns if upgrading from older schema
-ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS threshold_pct DOUBLE PRECISION NOT NULL DEFAULT 10;
-ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS window_minutes INT NOT NULL DEFAULT 1;
-ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS direction TEXT NOT NULL DEFAULT 'drop';
-ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS report_hour INT NOT NULL DEFAULT 8;
-ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS threshold_value DOUBLE PRECISION NOT NULL DEFAULT 0;
-ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS coin TEXT NOT NULL DEFAULT '';
-
--- Drop unique constraint to allow multiple subscriptions per event with different configs
-ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_tg_user_id_event_id_key;
-
--- Rename old event names (idempotent)
-UPDATE events SET name = 'altura_metric_alert', description = 'Alert when Altura metrics'
-    WHERE name IN ('altura_tvl_drop', 'altura_drop');
-UPDATE events SET name = 'neverland_metric_alert', description = 'Alert when Neverland metrics'
-    WHERE name IN ('neverland_drop');
-
--- Liquidation events for self-built max pain calculation
-CREATE TABLE IF NOT EXISTS liquidation_events (
-    id BIGSERIAL PRIMARY KEY,
-    symbol TEXT NOT NULL,
-    side TEXT NOT NULL,
-    price DOUBLE PRECISION NOT NULL,
-    quantity DOUBLE PRECISION NOT NULL,
-    usd_value DOUBLE PRECISION NOT NULL,
-    exchange TEXT NOT NULL DEFAULT 'binance',
-    event_time TIMESTAMPTZ NOT NULL,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-CREATE INDEX IF NOT EXISTS idx_liq_events_symbol_time ON liquidation_events(symbol, event_time);
-
--- Seed events (idempotent)
-INSERT INTO events (name, description, category) VALUES
-    ('altura_metric_alert', 'Alert when Altura metrics', 'altura'),
-    ('altura_daily_report', 'Daily UTC+8 report — Altura TVL, AVLT price, APR', 'altura'),
-    ('neverland_metric_alert', 'Alert when Neverland metrics', 'neverland'),
-    ('neverland_daily_report', 'Daily UTC+8 report — Neverland TVL, veDUST, DUST price, fees', 'neverland'),
-    ('general_metric_alert', 'Alert when Fear & Greed Index', 'general'),
-    ('general_daily_report', 'Daily UTC+8 report — Crypto Fear & Greed Index', 'general'),
-    ('general_maxpain_alert', 'Alert when price hits liquidation max pain', 'general'),
-    ('general_merkl_alert', 'Alert on new Merkl yield opportunities', 'general'),
-    ('general_binance_price_alert', 'Alert when Binance price reaches target', 'general'),
-    ('general_turtle_alert', 'Alert on new Turtle yield opportunities', 'general'),
-    ('general_alpha_alert', 'Alert on Binance Alpha airdrops', 'general'),
-    ('general_defillama_alert', 'Alert on DeFi Llama USDC/USDT yield opportunities', 'general'),
-    ('general_defillama_lp_alert', 'Alert on DeFi Llama LP/DEX reward opportunities by chain', 'general'),
-    ('general_defillama_tvl_alert', 'Alert on DeFi Llama protocol TVL changes (1d/7d/30d)', 'general')
-ON CONFLICT (name) DO NOTHING;
-
--- Notification log for debugging and audit trail
-CREATE TABLE IF NOT EXISTS notification_log (
-    id BIGSERIAL PRIMARY KEY,
-    tg_chat_id BIGINT NOT NULL,
-    alert_type TEXT NOT NULL,
-    event_name TEXT NOT NULL DEFAULT '',
-    summary TEXT NOT NULL DEFAULT '',
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
-CREATE INDEX IF NOT EXISTS idx_notif_log_chat_time ON notification_log(tg_chat_id, created_at DESC);
-
--- Update existing descriptions
-UPDATE events SET description = 'Alert when Altura metrics' WHERE name = 'altura_metric_alert';
-UPDATE events SET description = 'Daily UTC+8 report — Altura TVL, AVLT price, APR' WHERE name = 'altura_daily_report';
-UPDATE events SET description = 'Alert when Neverland metrics' WHERE name = 'neverland_metric_alert';
-UPDATE events SET description = 'Daily UTC+8 report — Neverland TVL, veDUST, DUST price, fees' WHERE name = 'neverland_daily_report';
-UPDATE events SET description = 'Alert when Fear & Greed Index' WHERE name = 'general_metric_alert';
-UPDATE events SET description = 'Daily UTC+8 report — Crypto Fear & Greed Index' WHERE name = 'general_daily_report';
-UPDATE events SET description = 'Alert when price hits liquidation max pain' WHERE name = 'general_maxpain_alert';
-UPDATE events SET description = 'Alert on new Merkl yield opportunities' WHERE name = 'general_merkl_alert';
-UPDATE events SET description = 'Alert when Binance price reaches target' WHERE name = 'general_binance_price_alert';
-UPDATE events SET description = 'Alert on new Turtle yield opportunities' WHERE name = 'general_turtle_alert';
-UPDATE events SET description = 'Alert on Binance Alpha airdrops' WHERE name = 'general_alpha_alert';
-UPDATE events SET description = 'Alert on DeFi Llama USDC/USDT yield opportunities' WHERE name = 'general_defillama_alert';
-UPDATE events SET description = 'Alert on DeFi Llama LP/DEX reward opportunities by chain' WHERE name = 'general_defillama_lp_alert';
-UPDATE events SET description = 'Alert on DeFi Llama protocol TVL changes (1d/7d/30d)' WHERE name = 'general_defillama_tvl_alert';
-`
-
-func (s *Store) Migrate(ctx context.Context) error {
-	_, err := s.pool.Exec(ctx, migrationSQL)
-	return err
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// postgresMigrations holds the numbered schema migrations:
+// NNNN_name.up.sql, and optionally NNNN_name.down.sql to undo it. Never
+// edit a migration once released; add a new one.
+//
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// migrationLockID is the advisory lock serialising migrations across
+// replicas booting at once (leader election uses a different key).
+const migrationLockID int64 = 0x6f6e6d6d6967 // "onmmig"
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string // empty if the migration cannot be undone
+}
+
+// MigrationStatus describes a known migration and whether it is applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator is implemented by backends with versioned migrations (Postgres).
+// SQLite and Memory create their whole schema in Migrate.
+type Migrator interface {
+	MigrationStatus(ctx context.Context) ([]MigrationStatus, error)
+	// MigrateUp applies every pending migration, each in its own
+	// transaction, and returns the ones it applied.
+	MigrateUp(ctx context.Context) ([]Migration, error)
+	// MigrateDown undoes the latest applied migration and returns it, or
+	// nil if none is applied.
+	MigrateDown(ctx context.Context) (*Migration, error)
+}
+
+var migrationFile = regexp.MustCompile(`^(\d{4})_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// loadMigrations reads the migrations in dir of fsys, ordered by version.
+// Versions must run 1, 2, 3... and each needs an up file.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.up.sql or NNNN_name.down.sql", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, mig := range migrations {
+		if mig.Version != i+1 {
+			return nil, fmt.Errorf("migration %04d missing (found %04d_%s)", i+1, mig.Version, mig.Name)
+		}
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", mig.Version, mig.Name)
+		}
+	}
+	return migrations, nil
+}
+
+var _ Migrator = (*Postgres)(nil)
+
+// Migrate applies pending migrations and seeds the events, refreshing
+// descriptions that changed.
+func (s *Postgres) Migrate(ctx context.Context) error {
+	if _, err := s.MigrateUp(ctx); err != nil {
+		return err
+	}
+	names := make([]string, len(seedEvents))
+	descriptions := make([]string, len(seedEvents))
+	categories := make([]string, len(seedEvents))
+	for i, ev := range seedEvents {
+		names[i], descriptions[i], categories[i] = ev.Name, ev.Description, ev.Category
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO events (name, description, category)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::text[])
+		ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description
+		WHERE events.description IS DISTINCT FROM EXCLUDED.description`,
+		names, descriptions, categories)
+	if err != nil {
+		return fmt.Errorf("seed events: %w", err)
+	}
+	return nil
+}
+
+// MigrationStatus only reads: it takes no lock and creates nothing, so a
+// database that was never migrated shows every migration pending.
+func (s *Postgres) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		return nil, err
+	}
+	var exists bool
+	if err := s.pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]time.Time)
+	if exists {
+		if applied, err = appliedMigrations(ctx, s.pool); err != nil {
+			return nil, err
+		}
+	}
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, mig := range migrations {
+		st := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			st.AppliedAt = &at
+		}
+		status = append(status, st)
+	}
+	return status, nil
+}
+
+func (s *Postgres) MigrateUp(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := s.withMigrationLock(ctx, func(conn *pgx.Conn, migrations []Migration, applied map[int]time.Time) error {
+		for _, mig := range migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, mig.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (s *Postgres) MigrateDown(ctx context.Context) (*Migration, error) {
+	var undone *Migration
+	err := s.withMigrationLock(ctx, func(conn *pgx.Conn, migrations []Migration, applied map[int]time.Time) error {
+		for i := len(migrations) - 1; i >= 0; i-- {
+			mig := migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %04d_%s cannot be undone (no down file)", mig.Version, mig.Name)
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, mig.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("undo migration %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			undone = &mig
+			return nil
+		}
+		return nil
+	})
+	return undone, err
+}
+
+// withMigrationLock runs fn on one connection holding the migration
+// advisory lock, with the known migrations and the applied versions. A
+// replica booting while another migrates waits for it to finish.
+func (s *Postgres) withMigrationLock(ctx context.Context, fn func(conn *pgx.Conn, migrations []Migration, applied map[int]time.Time) error) error {
+	migrations, err := loadMigrations(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		return err
+	}
+
+	c, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Release()
+	conn := c.Conn()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("take migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID) //nolint:errcheck // the lock also ends with the session
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, migrations, applied)
+}
+
+// querier is a pool or a single connection.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// appliedMigrations reads schema_migrations: applied version -> when.
+func appliedMigrations(ctx context.Context, q querier) (map[int]time.Time, error) {
+	rows, err := q.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
 }
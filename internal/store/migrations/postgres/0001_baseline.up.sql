@@ -0,0 +1,112 @@
+-- Baseline: the schema as it stood before versioned migrations. Every
+-- statement is idempotent so it also applies cleanly to databases created
+-- by the old boot-time migration.
+
+CREATE TABLE IF NOT EXISTS events (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT 'general',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS telegram_users (
+    id BIGSERIAL PRIMARY KEY,
+    tg_chat_id BIGINT NOT NULL UNIQUE,
+    tg_username TEXT NOT NULL DEFAULT '',
+    link_code TEXT UNIQUE,
+    link_code_expires_at TIMESTAMPTZ,
+    linked BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    tg_user_id BIGINT NOT NULL REFERENCES telegram_users(id) ON DELETE CASCADE,
+    event_id INT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    threshold_pct DOUBLE PRECISION NOT NULL DEFAULT 10,
+    window_minutes INT NOT NULL DEFAULT 1,
+    direction TEXT NOT NULL DEFAULT 'drop',
+    report_hour INT NOT NULL DEFAULT 8,
+    threshold_value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Add columns if upgrading from older schema
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS threshold_pct DOUBLE PRECISION NOT NULL DEFAULT 10;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS window_minutes INT NOT NULL DEFAULT 1;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS direction TEXT NOT NULL DEFAULT 'drop';
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS report_hour INT NOT NULL DEFAULT 8;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS threshold_value DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS coin TEXT NOT NULL DEFAULT '';
+
+-- Per-user language for alerts, reports and bot replies
+ALTER TABLE telegram_users ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'en';
+
+-- Drop unique constraint to allow multiple subscriptions per event with different configs
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_tg_user_id_event_id_key;
+
+-- Rename old event names (idempotent)
+UPDATE events SET name = 'altura_metric_alert', description = 'Alert when Altura metrics'
+    WHERE name IN ('altura_tvl_drop', 'altura_drop');
+UPDATE events SET name = 'neverland_metric_alert', description = 'Alert when Neverland metrics'
+    WHERE name IN ('neverland_drop');
+
+-- Liquidation events for self-built max pain calculation
+CREATE TABLE IF NOT EXISTS liquidation_events (
+    id BIGSERIAL PRIMARY KEY,
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL,
+    price DOUBLE PRECISION NOT NULL,
+    quantity DOUBLE PRECISION NOT NULL,
+    usd_value DOUBLE PRECISION NOT NULL,
+    exchange TEXT NOT NULL DEFAULT 'binance',
+    event_time TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_liq_events_symbol_time ON liquidation_events(symbol, event_time);
+
+-- API sessions issued on link; only the SHA-256 of each token is stored
+CREATE TABLE IF NOT EXISTS sessions (
+    token_hash TEXT PRIMARY KEY,
+    tg_chat_id BIGINT NOT NULL REFERENCES telegram_users(tg_chat_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_chat ON sessions(tg_chat_id);
+
+-- Scoped API keys for scripts; like sessions only the SHA-256 is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    tg_chat_id BIGINT NOT NULL REFERENCES telegram_users(tg_chat_id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    key_prefix TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_chat ON api_keys(tg_chat_id);
+
+-- Audit log of link code attempts (brute-force forensics)
+CREATE TABLE IF NOT EXISTS link_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    ip TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    tg_chat_id BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_link_attempts_ip_time ON link_attempts(ip, created_at DESC);
+
+-- Notification log for debugging and audit trail
+CREATE TABLE IF NOT EXISTS notification_log (
+    id BIGSERIAL PRIMARY KEY,
+    tg_chat_id BIGINT NOT NULL,
+    alert_type TEXT NOT NULL,
+    event_name TEXT NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_notif_log_chat_time ON notification_log(tg_chat_id, created_at DESC);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS events_changed ON events;
+DROP TRIGGER IF EXISTS telegram_users_changed ON telegram_users;
+DROP TRIGGER IF EXISTS subscriptions_changed ON subscriptions;
+DROP FUNCTION IF EXISTS notify_subscriptions_changed();
@@ -0,0 +1,17 @@
+-- Tell every replica's subscription cache to reload (see
+-- ListenSubscriptionChanges). Replaces the triggers if they already exist.
+CREATE OR REPLACE FUNCTION notify_subscriptions_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('subscriptions_changed', TG_TABLE_NAME);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS subscriptions_changed ON subscriptions;
+CREATE TRIGGER subscriptions_changed AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON subscriptions
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_subscriptions_changed();
+DROP TRIGGER IF EXISTS telegram_users_changed ON telegram_users;
+CREATE TRIGGER telegram_users_changed AFTER INSERT OR UPDATE OF tg_chat_id, linked OR DELETE OR TRUNCATE ON telegram_users
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_subscriptions_changed();
+DROP TRIGGER IF EXISTS events_changed ON events;
+CREATE TRIGGER events_changed AFTER INSERT OR UPDATE OF name OR DELETE ON events
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_subscriptions_changed();
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS paused_sources;
+DROP TABLE IF EXISTS delivery_failures;
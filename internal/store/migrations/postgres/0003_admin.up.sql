@@ -0,0 +1,15 @@
+-- Messages the alert transport failed to deliver, for the admin API
+CREATE TABLE delivery_failures (
+    id BIGSERIAL PRIMARY KEY,
+    tg_chat_id BIGINT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_delivery_failures_time ON delivery_failures(created_at DESC);
+
+-- Sources an admin paused; the engine skips them on every replica
+CREATE TABLE paused_sources (
+    name TEXT PRIMARY KEY,
+    paused_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,10 @@
+-- Changes admins made through /api/admin
+CREATE TABLE admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target TEXT NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_admin_audit_log_time ON admin_audit_log(created_at DESC);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dedup_keys;
@@ -0,0 +1,5 @@
+-- Alert dedup keys for DEDUP_BACKEND=postgres
+CREATE TABLE dedup_keys (
+    key TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ
+);
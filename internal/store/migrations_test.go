@@ -0,0 +1,52 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		t.Fatalf("embedded migrations: %v", err)
+	}
+	if len(migrations) < 2 || migrations[0].Name != "baseline" || migrations[0].Down != "" {
+		t.Errorf("embedded migrations = %d, first %q; want a baseline without a down file first", len(migrations), migrations[0].Name)
+	}
+
+	for name, tc := range map[string]struct {
+		files fstest.MapFS
+		want  string
+	}{
+		"gap": {fstest.MapFS{
+			"m/0001_a.up.sql": {Data: []byte("SELECT 1")},
+			"m/0003_c.up.sql": {Data: []byte("SELECT 1")},
+		}, "migration 0002 missing"},
+		"down only": {fstest.MapFS{
+			"m/0001_a.down.sql": {Data: []byte("SELECT 1")},
+		}, "has no up file"},
+		"bad name": {fstest.MapFS{
+			"m/1_a.sql": {Data: []byte("SELECT 1")},
+		}, "name must be"},
+		"two names": {fstest.MapFS{
+			"m/0001_a.up.sql":   {Data: []byte("SELECT 1")},
+			"m/0001_b.down.sql": {Data: []byte("SELECT 1")},
+		}, "two names"},
+	} {
+		_, err := loadMigrations(tc.files, "m")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want it to mention %q", name, err, tc.want)
+		}
+	}
+
+	ok := fstest.MapFS{
+		"m/0002_b.up.sql":   {Data: []byte("up b")},
+		"m/0002_b.down.sql": {Data: []byte("down b")},
+		"m/0001_a.up.sql":   {Data: []byte("up a")},
+	}
+	migrations, err = loadMigrations(ok, "m")
+	if err != nil || len(migrations) != 2 || migrations[1].Version != 2 || migrations[1].Down != "down b" {
+		t.Errorf("loadMigrations = %+v, %v", migrations, err)
+	}
+}
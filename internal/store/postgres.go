@@ -2,17 +2,23 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Store struct {
+// Postgres is the production Store, backed by a pgx connection pool.
+type Postgres struct {
 	pool *pgxpool.Pool
 }
 
-func New(ctx context.Context, databaseURL string) (*Store, error) {
+var _ Store = (*Postgres)(nil)
+
+// NewPostgres connects to databaseURL and verifies the connection.
+func NewPostgres(ctx context.Context, databaseURL string) (*Postgres, error) {
 	cfg, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("parse database url: %w", err)
@@ -30,27 +36,35 @@ func New(ctx context.Context, databaseURL string) (*Store, error) {
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
-	return &Store{pool: pool}, nil
+	return &Postgres{pool: pool}, nil
 }
 
-func (s *Store) Close() { s.pool.Close() }
+// notFound maps pgx's no-rows error to ErrNotFound, so callers need not
+// know the backend.
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
 
-func (s *Store) Ping(ctx context.Context) error { return s.pool.Ping(ctx) }
+func (s *Postgres) Close() { s.pool.Close() }
+
+func (s *Postgres) Ping(ctx context.Context) error { return s.pool.Ping(ctx) }
 
 // --- Events ---
 
-type Event struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Category    string    `json:"category"`
-	Enabled     bool      `json:"enabled"`
-	CreatedAt   time.Time `json:"created_at"`
+func (s *Postgres) ListEvents(ctx context.Context) ([]Event, error) {
+	return s.listEvents(ctx, `WHERE enabled = true`)
+}
+
+func (s *Postgres) ListAllEvents(ctx context.Context) ([]Event, error) {
+	return s.listEvents(ctx, ``)
 }
 
-func (s *Store) ListEvents(ctx context.Context) ([]Event, error) {
+func (s *Postgres) listEvents(ctx context.Context, where string) ([]Event, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, name, description, category, enabled, created_at FROM events WHERE enabled = true ORDER BY id`)
+		`SELECT id, name, description, category, enabled, created_at FROM events `+where+` ORDER BY id`)
 	if err != nil {
 		return nil, err
 	}
@@ -67,19 +81,21 @@ func (s *Store) ListEvents(ctx context.Context) ([]Event, error) {
 	return events, rows.Err()
 }
 
-// --- Telegram Users ---
-
-type TelegramUser struct {
-	ID                int64     `json:"id"`
-	TgChatID          int64     `json:"tg_chat_id"`
-	TgUsername        string    `json:"tg_username"`
-	LinkCode          string    `json:"link_code,omitempty"`
-	LinkCodeExpiresAt time.Time `json:"link_code_expires_at,omitempty"`
-	Linked            bool      `json:"linked"`
-	CreatedAt         time.Time `json:"created_at"`
+func (s *Postgres) SetEventEnabled(ctx context.Context, id int, enabled bool) (*Event, error) {
+	var e Event
+	err := s.pool.QueryRow(ctx, `
+		UPDATE events SET enabled = $2 WHERE id = $1
+		RETURNING id, name, description, category, enabled, created_at`, id, enabled).
+		Scan(&e.ID, &e.Name, &e.Description, &e.Category, &e.Enabled, &e.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &e, nil
 }
 
-func (s *Store) UpsertTelegramUser(ctx context.Context, chatID int64, username, linkCode string, expiresAt time.Time) error {
+// --- Telegram Users ---
+
+func (s *Postgres) UpsertTelegramUser(ctx context.Context, chatID int64, username, linkCode string, expiresAt time.Time) error {
 	_, err := s.pool.Exec(ctx, `
 		INSERT INTO telegram_users (tg_chat_id, tg_username, link_code, link_code_expires_at, linked)
 		VALUES ($1, $2, $3, $4, false)
@@ -89,54 +105,253 @@ func (s *Store) UpsertTelegramUser(ctx context.Context, chatID int64, username,
 	return err
 }
 
-func (s *Store) LinkByCode(ctx context.Context, code string) (*TelegramUser, error) {
+func (s *Postgres) LinkByCode(ctx context.Context, code string) (*TelegramUser, error) {
 	var u TelegramUser
 	err := s.pool.QueryRow(ctx, `
 		UPDATE telegram_users SET linked = true, link_code = NULL, link_code_expires_at = NULL
 		WHERE link_code = $1 AND link_code_expires_at > now()
-		RETURNING id, tg_chat_id, tg_username, linked, created_at`, code).
-		Scan(&u.ID, &u.TgChatID, &u.TgUsername, &u.Linked, &u.CreatedAt)
+		RETURNING id, tg_chat_id, tg_username, linked, language, created_at`, code).
+		Scan(&u.ID, &u.TgChatID, &u.TgUsername, &u.Linked, &u.Language, &u.CreatedAt)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &u, nil
 }
 
-func (s *Store) UnlinkTelegram(ctx context.Context, chatID int64) error {
+// UpsertLoginUser registers or re-links a chat that signed in with the
+// Telegram Login Widget. It needs no link code: the widget signature already
+// proves the user owns the account.
+func (s *Postgres) UpsertLoginUser(ctx context.Context, chatID int64, username string) (*TelegramUser, error) {
+	var u TelegramUser
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO telegram_users (tg_chat_id, tg_username, linked)
+		VALUES ($1, $2, true)
+		ON CONFLICT (tg_chat_id) DO UPDATE
+			SET linked = true, link_code = NULL, link_code_expires_at = NULL,
+				tg_username = COALESCE(NULLIF($2, ''), telegram_users.tg_username)
+		RETURNING id, tg_chat_id, tg_username, linked, language, created_at`, chatID, username).
+		Scan(&u.ID, &u.TgChatID, &u.TgUsername, &u.Linked, &u.Language, &u.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &u, nil
+}
+
+func (s *Postgres) UnlinkTelegram(ctx context.Context, chatID int64) error {
 	// Only mark as unlinked — preserve subscriptions so they restore on re-link
 	_, err := s.pool.Exec(ctx, `
 		UPDATE telegram_users SET linked = false WHERE tg_chat_id = $1`, chatID)
 	return err
 }
 
-func (s *Store) GetTelegramUser(ctx context.Context, chatID int64) (*TelegramUser, error) {
+func (s *Postgres) GetTelegramUser(ctx context.Context, chatID int64) (*TelegramUser, error) {
 	var u TelegramUser
 	err := s.pool.QueryRow(ctx, `
-		SELECT id, tg_chat_id, tg_username, linked, created_at
+		SELECT id, tg_chat_id, tg_username, linked, language, created_at
 		FROM telegram_users WHERE tg_chat_id = $1`, chatID).
-		Scan(&u.ID, &u.TgChatID, &u.TgUsername, &u.Linked, &u.CreatedAt)
+		Scan(&u.ID, &u.TgChatID, &u.TgUsername, &u.Linked, &u.Language, &u.CreatedAt)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &u, nil
 }
 
-// --- Subscriptions ---
+// ListTelegramUsers returns every chat that has talked to the bot, linked
+// or not, in ID order.
+func (s *Postgres) ListTelegramUsers(ctx context.Context) ([]TelegramUser, error) {
+	return s.queryUsers(ctx, `
+		SELECT id, tg_chat_id, tg_username, linked, language, created_at
+		FROM telegram_users ORDER BY id`)
+}
+
+// SearchTelegramUsers returns up to limit chats whose username contains
+// query (case-insensitively) or whose chat ID is query, in ID order. An
+// empty query matches every chat.
+func (s *Postgres) SearchTelegramUsers(ctx context.Context, query string, limit int) ([]TelegramUser, error) {
+	return s.queryUsers(ctx, `
+		SELECT id, tg_chat_id, tg_username, linked, language, created_at
+		FROM telegram_users
+		WHERE $1 = '' OR tg_username ILIKE $2 ESCAPE '\' OR tg_chat_id::text = $1
+		ORDER BY id LIMIT $3`, query, likePattern(query), userSearchLimit(limit))
+}
+
+func (s *Postgres) queryUsers(ctx context.Context, query string, args ...any) ([]TelegramUser, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []TelegramUser
+	for rows.Next() {
+		var u TelegramUser
+		if err := rows.Scan(&u.ID, &u.TgChatID, &u.TgUsername, &u.Linked, &u.Language, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// GetUserLanguage returns the message language of a chat. Chats that have
+// never talked to the bot get the default, "en".
+func (s *Postgres) GetUserLanguage(ctx context.Context, chatID int64) (string, error) {
+	var lang string
+	err := s.pool.QueryRow(ctx, `
+		SELECT language FROM telegram_users WHERE tg_chat_id = $1`, chatID).Scan(&lang)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "en", nil
+	}
+	return lang, err
+}
+
+// SetUserLanguage stores the message language of a chat, registering the
+// chat (unlinked) if it is not known yet.
+func (s *Postgres) SetUserLanguage(ctx context.Context, chatID int64, username, lang string) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO telegram_users (tg_chat_id, tg_username, language)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tg_chat_id) DO UPDATE SET language = $3`,
+		chatID, username, lang)
+	return err
+}
+
+// LogLinkAttempt records a link code attempt for auditing. chatID is 0
+// unless the attempt linked a chat.
+func (s *Postgres) LogLinkAttempt(ctx context.Context, ip, outcome string, chatID int64) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO link_attempts (ip, outcome, tg_chat_id) VALUES ($1, $2, NULLIF($3, 0))`,
+		ip, outcome, chatID)
+	return err
+}
+
+// --- Sessions ---
+
+// CreateSession issues an API session token for a chat, valid for ttl.
+// Only a hash of the token is stored, so a database leak does not leak
+// usable tokens. Expired sessions are purged on the way.
+func (s *Postgres) CreateSession(ctx context.Context, chatID int64, ttl time.Duration) (string, time.Time, error) {
+	token, err := newSecret()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM sessions WHERE expires_at < now()`); err != nil {
+		return "", time.Time{}, err
+	}
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO sessions (token_hash, tg_chat_id, expires_at) VALUES ($1, $2, $3)`,
+		hashToken(token), chatID, expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// SessionChatID resolves a session token to its chat ID. Expired tokens and
+// tokens of chats that have since been unlinked are rejected.
+func (s *Postgres) SessionChatID(ctx context.Context, token string) (int64, error) {
+	var chatID int64
+	err := s.pool.QueryRow(ctx, `
+		SELECT s.tg_chat_id FROM sessions s
+		JOIN telegram_users u ON u.tg_chat_id = s.tg_chat_id
+		WHERE s.token_hash = $1 AND s.expires_at > now() AND u.linked`, hashToken(token)).Scan(&chatID)
+	return chatID, notFound(err)
+}
+
+// DeleteSession revokes a single session token.
+func (s *Postgres) DeleteSession(ctx context.Context, token string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM sessions WHERE token_hash = $1`, hashToken(token))
+	return err
+}
+
+// DeleteSessions revokes every session of a chat.
+func (s *Postgres) DeleteSessions(ctx context.Context, chatID int64) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM sessions WHERE tg_chat_id = $1`, chatID)
+	return err
+}
+
+// --- API keys ---
+
+// CreateAPIKey mints a key for a chat. The key itself is returned only
+// here; the database keeps its hash. A nil expiresAt never expires.
+func (s *Postgres) CreateAPIKey(ctx context.Context, chatID int64, name string, scopes []string, expiresAt *time.Time) (string, *APIKey, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	key := APIKeyPrefix + secret
+
+	k := APIKey{Name: name, Prefix: key[:len(APIKeyPrefix)+8], Scopes: scopes, ExpiresAt: expiresAt}
+	err = s.pool.QueryRow(ctx, `
+		INSERT INTO api_keys (tg_chat_id, name, key_hash, key_prefix, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`, chatID, name, hashToken(key), k.Prefix, scopes, expiresAt).
+		Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, &k, nil
+}
+
+// ListAPIKeys returns a chat's keys, newest first, expired ones included.
+func (s *Postgres) ListAPIKeys(ctx context.Context, chatID int64) ([]APIKey, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, name, key_prefix, scopes, created_at, expires_at, last_used_at
+		FROM api_keys WHERE tg_chat_id = $1 ORDER BY created_at DESC`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-type Subscription struct {
-	ID             int64     `json:"id"`
-	TgUserID       int64     `json:"tg_user_id"`
-	EventID        int       `json:"event_id"`
-	ThresholdPct   float64   `json:"threshold_pct"`
-	WindowMinutes  int       `json:"window_minutes"`
-	Direction      string    `json:"direction"`
-	ReportHour     int       `json:"report_hour"`
-	ThresholdValue float64   `json:"threshold_value"`
-	Coin           string    `json:"coin"`
-	CreatedAt      time.Time `json:"created_at"`
+	var keys []APIKey
+	for rows.Next() {
+		var k APIKey
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &k.Scopes, &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
 }
 
-func (s *Store) ListSubscriptions(ctx context.Context, tgChatID int64) ([]Subscription, error) {
+// APIKeyChatID resolves an API key to its chat ID and scopes, recording
+// the use. Expired keys and keys of unlinked chats are rejected.
+func (s *Postgres) APIKeyChatID(ctx context.Context, key string) (int64, []string, error) {
+	var chatID int64
+	var scopes []string
+	err := s.pool.QueryRow(ctx, `
+		UPDATE api_keys k SET last_used_at = now()
+		FROM telegram_users u
+		WHERE u.tg_chat_id = k.tg_chat_id AND u.linked
+			AND k.key_hash = $1 AND (k.expires_at IS NULL OR k.expires_at > now())
+		RETURNING k.tg_chat_id, k.scopes`, hashToken(key)).Scan(&chatID, &scopes)
+	return chatID, scopes, notFound(err)
+}
+
+// DeleteAPIKey revokes one of a chat's keys. It returns ErrNotFound if the
+// chat has no key with that ID.
+func (s *Postgres) DeleteAPIKey(ctx context.Context, chatID, id int64) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM api_keys WHERE id = $1 AND tg_chat_id = $2`, id, chatID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteAPIKeys revokes every key of a chat.
+func (s *Postgres) DeleteAPIKeys(ctx context.Context, chatID int64) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM api_keys WHERE tg_chat_id = $1`, chatID)
+	return err
+}
+
+// --- Subscriptions ---
+
+func (s *Postgres) ListSubscriptions(ctx context.Context, tgChatID int64) ([]Subscription, error) {
 	rows, err := s.pool.Query(ctx, `
 		SELECT s.id, s.tg_user_id, s.event_id, s.threshold_pct, s.window_minutes, s.direction, s.report_hour, s.threshold_value, s.coin, s.created_at
 		FROM subscriptions s
@@ -159,7 +374,7 @@ func (s *Store) ListSubscriptions(ctx context.Context, tgChatID int64) ([]Subscr
 	return subs, rows.Err()
 }
 
-func (s *Store) Subscribe(ctx context.Context, tgChatID int64, eventID int, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error) {
+func (s *Postgres) Subscribe(ctx context.Context, tgChatID int64, eventID int, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error) {
 	var sub Subscription
 	err := s.pool.QueryRow(ctx, `
 		INSERT INTO subscriptions (tg_user_id, event_id, threshold_pct, window_minutes, direction, report_hour, threshold_value, coin)
@@ -168,12 +383,12 @@ func (s *Store) Subscribe(ctx context.Context, tgChatID int64, eventID int, thre
 		tgChatID, eventID, thresholdPct, windowMinutes, direction, reportHour, thresholdValue, coin).
 		Scan(&sub.ID, &sub.TgUserID, &sub.EventID, &sub.ThresholdPct, &sub.WindowMinutes, &sub.Direction, &sub.ReportHour, &sub.ThresholdValue, &sub.Coin, &sub.CreatedAt)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &sub, nil
 }
 
-func (s *Store) UpdateSubscription(ctx context.Context, id int64, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error) {
+func (s *Postgres) UpdateSubscription(ctx context.Context, id int64, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error) {
 	var sub Subscription
 	err := s.pool.QueryRow(ctx, `
 		UPDATE subscriptions SET threshold_pct = $2, window_minutes = $3, direction = $4, report_hour = $5, threshold_value = $6, coin = $7
@@ -182,27 +397,27 @@ func (s *Store) UpdateSubscription(ctx context.Context, id int64, thresholdPct f
 		id, thresholdPct, windowMinutes, direction, reportHour, thresholdValue, coin).
 		Scan(&sub.ID, &sub.TgUserID, &sub.EventID, &sub.ThresholdPct, &sub.WindowMinutes, &sub.Direction, &sub.ReportHour, &sub.ThresholdValue, &sub.Coin, &sub.CreatedAt)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &sub, nil
 }
 
-func (s *Store) Unsubscribe(ctx context.Context, subID int64) error {
+func (s *Postgres) Unsubscribe(ctx context.Context, subID int64) error {
 	_, err := s.pool.Exec(ctx, `DELETE FROM subscriptions WHERE id = $1`, subID)
 	return err
 }
 
 // GetSubscriptionChatID returns the chat_id for a subscription (for dedup cleanup).
-func (s *Store) GetSubscriptionChatID(ctx context.Context, subID int64) (int64, error) {
+func (s *Postgres) GetSubscriptionChatID(ctx context.Context, subID int64) (int64, error) {
 	var chatID int64
 	err := s.pool.QueryRow(ctx, `
 		SELECT u.tg_chat_id FROM subscriptions s
 		JOIN telegram_users u ON u.id = s.tg_user_id
 		WHERE s.id = $1`, subID).Scan(&chatID)
-	return chatID, err
+	return chatID, notFound(err)
 }
 
-func (s *Store) GetSubscriberChatIDs(ctx context.Context, eventName string) ([]int64, error) {
+func (s *Postgres) GetSubscriberChatIDs(ctx context.Context, eventName string) ([]int64, error) {
 	rows, err := s.pool.Query(ctx, `
 		SELECT u.tg_chat_id
 		FROM subscriptions s
@@ -225,23 +440,14 @@ func (s *Store) GetSubscriberChatIDs(ctx context.Context, eventName string) ([]i
 	return ids, rows.Err()
 }
 
-// SubscriberConfig holds per-subscriber alert configuration.
-type SubscriberConfig struct {
-	ChatID         int64
-	ThresholdPct   float64
-	WindowMinutes  int
-	Direction      string
-	ThresholdValue float64
-	Coin           string
-}
-
-func (s *Store) GetSubscribersWithThresholds(ctx context.Context, eventName string) ([]SubscriberConfig, error) {
+func (s *Postgres) GetSubscribersWithThresholds(ctx context.Context, eventName string) ([]SubscriberConfig, error) {
 	rows, err := s.pool.Query(ctx, `
-		SELECT u.tg_chat_id, s.threshold_pct, s.window_minutes, s.direction, s.threshold_value, s.coin
+		SELECT s.id, u.tg_chat_id, s.threshold_pct, s.window_minutes, s.direction, s.threshold_value, s.coin
 		FROM subscriptions s
 		JOIN telegram_users u ON u.id = s.tg_user_id
 		JOIN events e ON e.id = s.event_id
-		WHERE e.name = $1 AND u.linked = true`, eventName)
+		WHERE e.name = $1 AND u.linked = true
+		ORDER BY s.id`, eventName)
 	if err != nil {
 		return nil, err
 	}
@@ -250,7 +456,7 @@ func (s *Store) GetSubscribersWithThresholds(ctx context.Context, eventName stri
 	var configs []SubscriberConfig
 	for rows.Next() {
 		var c SubscriberConfig
-		if err := rows.Scan(&c.ChatID, &c.ThresholdPct, &c.WindowMinutes, &c.Direction, &c.ThresholdValue, &c.Coin); err != nil {
+		if err := rows.Scan(&c.SubscriptionID, &c.ChatID, &c.ThresholdPct, &c.WindowMinutes, &c.Direction, &c.ThresholdValue, &c.Coin); err != nil {
 			return nil, err
 		}
 		configs = append(configs, c)
@@ -258,37 +464,32 @@ func (s *Store) GetSubscribersWithThresholds(ctx context.Context, eventName stri
 	return configs, rows.Err()
 }
 
-// DailyReportSubscriber holds per-subscriber daily report config.
-type DailyReportSubscriber struct {
-	ChatID     int64
-	ReportHour int
-}
-
-func (s *Store) GetDailyReportSubscribers(ctx context.Context, eventName string, hour int) ([]int64, error) {
+func (s *Postgres) GetDailyReportSubscribers(ctx context.Context, eventName string, hour int) ([]DailyReportSubscriber, error) {
 	rows, err := s.pool.Query(ctx, `
-		SELECT u.tg_chat_id
+		SELECT s.id, u.tg_chat_id, s.report_hour
 		FROM subscriptions s
 		JOIN telegram_users u ON u.id = s.tg_user_id
 		JOIN events e ON e.id = s.event_id
-		WHERE e.name = $1 AND u.linked = true AND s.report_hour = $2`, eventName, hour)
+		WHERE e.name = $1 AND u.linked = true AND s.report_hour = $2
+		ORDER BY s.id`, eventName, hour)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var ids []int64
+	var subs []DailyReportSubscriber
 	for rows.Next() {
-		var id int64
-		if err := rows.Scan(&id); err != nil {
+		var d DailyReportSubscriber
+		if err := rows.Scan(&d.SubscriptionID, &d.ChatID, &d.ReportHour); err != nil {
 			return nil, err
 		}
-		ids = append(ids, id)
+		subs = append(subs, d)
 	}
-	return ids, rows.Err()
+	return subs, rows.Err()
 }
 
 // CountSubscriptions returns the number of active subscriptions for an event.
-func (s *Store) CountSubscriptions(ctx context.Context, eventName string) (int, error) {
+func (s *Postgres) CountSubscriptions(ctx context.Context, eventName string) (int, error) {
 	var count int
 	err := s.pool.QueryRow(ctx, `
 		SELECT COUNT(*)
@@ -298,8 +499,63 @@ func (s *Store) CountSubscriptions(ctx context.Context, eventName string) (int,
 	return count, err
 }
 
+// ListEventSubscriptions returns every subscription, linked or not, in ID
+// order.
+func (s *Postgres) ListEventSubscriptions(ctx context.Context) ([]EventSubscription, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT s.id, u.tg_chat_id, s.threshold_pct, s.window_minutes, s.direction, s.threshold_value, s.coin,
+		       e.name, s.report_hour, u.linked
+		FROM subscriptions s
+		JOIN telegram_users u ON u.id = s.tg_user_id
+		JOIN events e ON e.id = s.event_id
+		ORDER BY s.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []EventSubscription
+	for rows.Next() {
+		var es EventSubscription
+		if err := rows.Scan(&es.SubscriptionID, &es.ChatID, &es.ThresholdPct, &es.WindowMinutes, &es.Direction,
+			&es.ThresholdValue, &es.Coin, &es.EventName, &es.ReportHour, &es.Linked); err != nil {
+			return nil, err
+		}
+		subs = append(subs, es)
+	}
+	return subs, rows.Err()
+}
+
+// subscriptionsChannel is notified by triggers on subscriptions,
+// telegram_users and events.
+const subscriptionsChannel = "subscriptions_changed"
+
+var _ SubscriptionNotifier = (*Postgres)(nil)
+
+// ListenSubscriptionChanges LISTENs on a connection of its own, taken out
+// of the pool because LISTEN belongs to the session.
+func (s *Postgres) ListenSubscriptionChanges(ctx context.Context, changed func()) error {
+	c, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := c.Hijack()
+	defer conn.Close(context.Background()) //nolint:errcheck // nothing to do on a failed close
+
+	if _, err := conn.Exec(ctx, "LISTEN "+subscriptionsChannel); err != nil {
+		return err
+	}
+	changed()
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		changed()
+	}
+}
+
 // CountLinkedUsers returns the number of linked Telegram users.
-func (s *Store) CountLinkedUsers(ctx context.Context) (int, error) {
+func (s *Postgres) CountLinkedUsers(ctx context.Context) (int, error) {
 	var count int
 	err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM telegram_users WHERE linked = true`).Scan(&count)
 	return count, err
@@ -307,19 +563,8 @@ func (s *Store) CountLinkedUsers(ctx context.Context) (int, error) {
 
 // --- Liquidation Events ---
 
-// LiquidationEvent represents a single forced liquidation from an exchange.
-type LiquidationEvent struct {
-	Symbol    string
-	Side      string // "LONG" or "SHORT" (which side got liquidated)
-	Price     float64
-	Quantity  float64
-	USDValue  float64
-	Exchange  string
-	EventTime time.Time
-}
-
 // InsertLiquidationEvent stores a liquidation event.
-func (s *Store) InsertLiquidationEvent(ctx context.Context, e *LiquidationEvent) error {
+func (s *Postgres) InsertLiquidationEvent(ctx context.Context, e *LiquidationEvent) error {
 	_, err := s.pool.Exec(ctx, `
 		INSERT INTO liquidation_events (symbol, side, price, quantity, usd_value, exchange, event_time)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
@@ -328,7 +573,7 @@ func (s *Store) InsertLiquidationEvent(ctx context.Context, e *LiquidationEvent)
 }
 
 // InsertLiquidationEvents batch-inserts liquidation events.
-func (s *Store) InsertLiquidationEvents(ctx context.Context, events []LiquidationEvent) error {
+func (s *Postgres) InsertLiquidationEvents(ctx context.Context, events []LiquidationEvent) error {
 	if len(events) == 0 {
 		return nil
 	}
@@ -350,16 +595,10 @@ func (s *Store) InsertLiquidationEvents(ctx context.Context, events []Liquidatio
 	return tx.Commit(ctx)
 }
 
-// MaxPainResult holds the calculated max pain price for one side.
-type MaxPainResult struct {
-	PriceBin float64
-	USDTotal float64
-}
-
 // QueryMaxPain calculates the liquidation max pain for a symbol and time window.
 // binSize: price bin width (e.g., 100 for BTC, 10 for ETH).
 // Returns (longMaxPain, shortMaxPain, error).
-func (s *Store) QueryMaxPain(ctx context.Context, symbol string, window time.Duration, binSize float64) (*MaxPainResult, *MaxPainResult, error) {
+func (s *Postgres) QueryMaxPain(ctx context.Context, symbol string, window time.Duration, binSize float64) (*MaxPainResult, *MaxPainResult, error) {
 	since := time.Now().Add(-window)
 
 	var longMP MaxPainResult
@@ -389,19 +628,46 @@ func (s *Store) QueryMaxPain(ctx context.Context, symbol string, window time.Dur
 	return &longMP, &shortMP, nil
 }
 
+// QueryLiquidationBins returns liquidation volume per price bin for a symbol
+// and time window, ordered by price — the distribution behind QueryMaxPain.
+func (s *Postgres) QueryLiquidationBins(ctx context.Context, symbol string, window time.Duration, binSize float64) ([]LiquidationBin, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT ROUND(price / $3) * $3 AS price_bin,
+			COALESCE(SUM(usd_value) FILTER (WHERE side = 'LONG'), 0),
+			COALESCE(SUM(usd_value) FILTER (WHERE side = 'SHORT'), 0)
+		FROM liquidation_events
+		WHERE symbol = $1 AND event_time > $2
+		GROUP BY price_bin
+		ORDER BY price_bin`, symbol, time.Now().Add(-window), binSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bins []LiquidationBin
+	for rows.Next() {
+		var b LiquidationBin
+		if err := rows.Scan(&b.PriceBin, &b.LongUSD, &b.ShortUSD); err != nil {
+			return nil, err
+		}
+		bins = append(bins, b)
+	}
+	return bins, rows.Err()
+}
+
 // GetCurrentPrice returns the latest liquidation event price for a symbol (rough proxy for current price).
-func (s *Store) GetCurrentPrice(ctx context.Context, symbol string) (float64, error) {
+func (s *Postgres) GetCurrentPrice(ctx context.Context, symbol string) (float64, error) {
 	var price float64
 	err := s.pool.QueryRow(ctx, `
 		SELECT price FROM liquidation_events
 		WHERE symbol = $1
 		ORDER BY event_time DESC
 		LIMIT 1`, symbol).Scan(&price)
-	return price, err
+	return price, notFound(err)
 }
 
 // CountLiquidationEvents returns event count for a symbol within a window.
-func (s *Store) CountLiquidationEvents(ctx context.Context, symbol string, window time.Duration) (int, error) {
+func (s *Postgres) CountLiquidationEvents(ctx context.Context, symbol string, window time.Duration) (int, error) {
 	var count int
 	err := s.pool.QueryRow(ctx, `
 		SELECT COUNT(*) FROM liquidation_events
@@ -410,7 +676,7 @@ func (s *Store) CountLiquidationEvents(ctx context.Context, symbol string, windo
 }
 
 // CleanupOldLiquidationEvents deletes events older than the given duration.
-func (s *Store) CleanupOldLiquidationEvents(ctx context.Context, maxAge time.Duration) (int64, error) {
+func (s *Postgres) CleanupOldLiquidationEvents(ctx context.Context, maxAge time.Duration) (int64, error) {
 	tag, err := s.pool.Exec(ctx, `
 		DELETE FROM liquidation_events WHERE event_time < $1`, time.Now().Add(-maxAge))
 	if err != nil {
@@ -421,30 +687,18 @@ func (s *Store) CleanupOldLiquidationEvents(ctx context.Context, maxAge time.Dur
 
 // --- Notification Log ---
 
-type NotificationLog struct {
-	ID        int64     `json:"id"`
-	TgChatID  int64     `json:"tg_chat_id"`
-	AlertType string    `json:"alert_type"`
-	EventName string    `json:"event_name"`
-	Summary   string    `json:"summary"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-func (s *Store) LogNotification(ctx context.Context, chatID int64, alertType, eventName, summary string) error {
+func (s *Postgres) LogNotification(ctx context.Context, chatID int64, alertType, eventName, summary string) error {
 	_, err := s.pool.Exec(ctx,
 		`INSERT INTO notification_log (tg_chat_id, alert_type, event_name, summary) VALUES ($1, $2, $3, $4)`,
 		chatID, alertType, eventName, summary)
 	return err
 }
 
-func (s *Store) ListNotifications(ctx context.Context, chatID int64, limit int) ([]NotificationLog, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
-	}
+func (s *Postgres) ListNotifications(ctx context.Context, chatID int64, limit int) ([]NotificationLog, error) {
 	rows, err := s.pool.Query(ctx,
 		`SELECT id, tg_chat_id, alert_type, event_name, summary, created_at
 		 FROM notification_log WHERE tg_chat_id = $1 ORDER BY created_at DESC LIMIT $2`,
-		chatID, limit)
+		chatID, notificationLimit(limit))
 	if err != nil {
 		return nil, err
 	}
@@ -461,7 +715,100 @@ func (s *Store) ListNotifications(ctx context.Context, chatID int64, limit int)
 	return logs, nil
 }
 
+func (s *Postgres) LogDeliveryFailure(ctx context.Context, chatID int64, message, errMsg string) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO delivery_failures (tg_chat_id, message, error) VALUES ($1, $2, $3)`,
+		chatID, truncateMessage(message), errMsg)
+	return err
+}
+
+// ListDeliveryFailures returns the latest failed deliveries of every chat,
+// newest first.
+func (s *Postgres) ListDeliveryFailures(ctx context.Context, limit int) ([]DeliveryFailure, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, tg_chat_id, message, error, created_at
+		 FROM delivery_failures ORDER BY created_at DESC, id DESC LIMIT $1`,
+		notificationLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []DeliveryFailure
+	for rows.Next() {
+		var f DeliveryFailure
+		if err := rows.Scan(&f.ID, &f.TgChatID, &f.Message, &f.Error, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+// --- Sources ---
+
+func (s *Postgres) ListPausedSources(ctx context.Context) ([]PausedSource, error) {
+	rows, err := s.pool.Query(ctx, `SELECT name, paused_at FROM paused_sources ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paused []PausedSource
+	for rows.Next() {
+		var p PausedSource
+		if err := rows.Scan(&p.Name, &p.PausedAt); err != nil {
+			return nil, err
+		}
+		paused = append(paused, p)
+	}
+	return paused, rows.Err()
+}
+
+// SetSourcePaused pauses or resumes a source. Pausing a paused source
+// keeps its original paused_at.
+func (s *Postgres) SetSourcePaused(ctx context.Context, name string, paused bool) error {
+	var err error
+	if paused {
+		_, err = s.pool.Exec(ctx, `INSERT INTO paused_sources (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, name)
+	} else {
+		_, err = s.pool.Exec(ctx, `DELETE FROM paused_sources WHERE name = $1`, name)
+	}
+	return err
+}
+
+// --- Audit log ---
+
+func (s *Postgres) LogAdminAction(ctx context.Context, actor, action, target, detail string) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO admin_audit_log (actor, action, target, detail) VALUES ($1, $2, $3, $4)`,
+		actor, action, target, detail)
+	return err
+}
+
+// ListAdminActions returns the latest audit log entries, newest first.
+func (s *Postgres) ListAdminActions(ctx context.Context, limit int) ([]AdminAction, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, actor, action, target, detail, created_at
+		 FROM admin_audit_log ORDER BY created_at DESC, id DESC LIMIT $1`,
+		notificationLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []AdminAction
+	for rows.Next() {
+		var a AdminAction
+		if err := rows.Scan(&a.ID, &a.Actor, &a.Action, &a.Target, &a.Detail, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
 // Pool exposes the underlying connection pool for use by other packages.
-func (s *Store) Pool() *pgxpool.Pool {
+func (s *Postgres) Pool() *pgxpool.Pool {
 	return s.pool
 }
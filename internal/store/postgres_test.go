@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestPostgresConformance runs the shared suite against a real database.
+// It truncates every table, so point TEST_DATABASE_URL at a scratch
+// database.
+func TestPostgresConformance(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pg, err := NewPostgres(ctx, url)
+	if err != nil {
+		t.Fatalf("NewPostgres: %v", err)
+	}
+	t.Cleanup(pg.Close)
+	if err := pg.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	runConformance(t, func(t *testing.T) Store {
+		_, err := pg.pool.Exec(ctx, `TRUNCATE telegram_users, subscriptions, sessions, api_keys,
+			link_attempts, liquidation_events, notification_log, delivery_failures,
+			paused_sources, admin_audit_log RESTART IDENTITY CASCADE`)
+		if err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return pg
+	})
+}
+
+func TestPostgresNotifiesSubscriptionChanges(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pg, err := NewPostgres(ctx, url)
+	if err != nil {
+		t.Fatalf("NewPostgres: %v", err)
+	}
+	t.Cleanup(pg.Close)
+	if err := pg.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	changes := make(chan struct{}, 10)
+	go pg.ListenSubscriptionChanges(ctx, func() { changes <- struct{}{} }) //nolint:errcheck // ends with ctx
+	wait := func(what string) {
+		t.Helper()
+		select {
+		case <-changes:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no notification %s", what)
+		}
+	}
+	wait("on listen")
+
+	if _, err := pg.UpsertLoginUser(ctx, 424242, "notify"); err != nil {
+		t.Fatalf("UpsertLoginUser: %v", err)
+	}
+	wait("after linking a user")
+	if err := pg.UnlinkTelegram(ctx, 424242); err != nil {
+		t.Fatalf("UnlinkTelegram: %v", err)
+	}
+	wait("after unlinking a user")
+}
+
+// TestPostgresMigrations runs the migrations twice concurrently (the lock
+// must serialise them), then steps the latest one down and back up.
+func TestPostgresMigrations(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pg, err := NewPostgres(ctx, url)
+	if err != nil {
+		t.Fatalf("NewPostgres: %v", err)
+	}
+	t.Cleanup(pg.Close)
+
+	// Status only reads: on a fresh database it must not create the
+	// bookkeeping table
+	var existed bool
+	if err := pg.Pool().QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&existed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pg.MigrationStatus(ctx); err != nil {
+		t.Fatalf("MigrationStatus before Migrate: %v", err)
+	}
+	var exists bool
+	if err := pg.Pool().QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+	if exists != existed {
+		t.Error("MigrationStatus created schema_migrations")
+	}
+
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() { errs <- pg.Migrate(ctx) }()
+	}
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatalf("concurrent Migrate: %v", err)
+		}
+	}
+
+	status, err := pg.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	for _, st := range status {
+		if st.AppliedAt == nil {
+			t.Errorf("migration %04d_%s pending after Migrate", st.Version, st.Name)
+		}
+	}
+
+	latest := status[len(status)-1]
+	undone, err := pg.MigrateDown(ctx)
+	if err != nil || undone == nil || undone.Version != latest.Version {
+		t.Fatalf("MigrateDown = %+v, %v, want %04d", undone, err, latest.Version)
+	}
+	done, err := pg.MigrateUp(ctx)
+	if err != nil || len(done) != 1 || done[0].Version != latest.Version {
+		t.Fatalf("MigrateUp = %+v, %v, want only %04d", done, err, latest.Version)
+	}
+}
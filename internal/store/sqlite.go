@@ -0,0 +1,909 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go driver, registers "sqlite"
+)
+
+// SQLite is a single-file Store for small self-hosted deployments.
+// Timestamps are stored as unix microseconds so that expiry checks compare
+// numbers, and API key scopes as comma-separated text.
+type SQLite struct {
+	db *sql.DB
+}
+
+var _ Store = (*SQLite)(nil)
+
+// NewSQLite opens (creating if needed) the database file at path.
+func NewSQLite(ctx context.Context, path string) (*SQLite, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	dsn := path + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// One writer at a time is all SQLite allows; a single connection
+	// avoids SQLITE_BUSY between our own goroutines.
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping sqlite: %w", err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT 'general',
+    enabled INTEGER NOT NULL DEFAULT 1,
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+
+CREATE TABLE IF NOT EXISTS telegram_users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tg_chat_id INTEGER NOT NULL UNIQUE,
+    tg_username TEXT NOT NULL DEFAULT '',
+    link_code TEXT UNIQUE,
+    link_code_expires_at INTEGER,
+    linked INTEGER NOT NULL DEFAULT 0,
+    language TEXT NOT NULL DEFAULT 'en',
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tg_user_id INTEGER NOT NULL REFERENCES telegram_users(id) ON DELETE CASCADE,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    threshold_pct REAL NOT NULL DEFAULT 10,
+    window_minutes INTEGER NOT NULL DEFAULT 1,
+    direction TEXT NOT NULL DEFAULT 'drop',
+    report_hour INTEGER NOT NULL DEFAULT 8,
+    threshold_value REAL NOT NULL DEFAULT 0,
+    coin TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+
+CREATE TABLE IF NOT EXISTS liquidation_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL,
+    price REAL NOT NULL,
+    quantity REAL NOT NULL,
+    usd_value REAL NOT NULL,
+    exchange TEXT NOT NULL DEFAULT 'binance',
+    event_time INTEGER NOT NULL,
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+CREATE INDEX IF NOT EXISTS idx_liq_events_symbol_time ON liquidation_events(symbol, event_time);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    token_hash TEXT PRIMARY KEY,
+    tg_chat_id INTEGER NOT NULL REFERENCES telegram_users(tg_chat_id) ON DELETE CASCADE,
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER)),
+    expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_chat ON sessions(tg_chat_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tg_chat_id INTEGER NOT NULL REFERENCES telegram_users(tg_chat_id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    key_prefix TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER,
+    last_used_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_chat ON api_keys(tg_chat_id);
+
+CREATE TABLE IF NOT EXISTS link_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ip TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    tg_chat_id INTEGER,
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+CREATE INDEX IF NOT EXISTS idx_link_attempts_ip_time ON link_attempts(ip, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS notification_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tg_chat_id INTEGER NOT NULL,
+    alert_type TEXT NOT NULL,
+    event_name TEXT NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+CREATE INDEX IF NOT EXISTS idx_notif_log_chat_time ON notification_log(tg_chat_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS delivery_failures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tg_chat_id INTEGER NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+CREATE INDEX IF NOT EXISTS idx_delivery_failures_time ON delivery_failures(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS paused_sources (
+    name TEXT PRIMARY KEY,
+    paused_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target TEXT NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL DEFAULT (CAST((julianday('now') - 2440587.5) * 86400000000 AS INTEGER))
+);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_time ON admin_audit_log(created_at DESC);
+`
+
+// Migrate creates the schema and seeds the events, refreshing their
+// descriptions.
+func (s *SQLite) Migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, sqliteSchema); err != nil {
+		return err
+	}
+	for _, ev := range seedEvents {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO events (name, description, category) VALUES (?, ?, ?)
+			ON CONFLICT (name) DO UPDATE SET description = excluded.description`,
+			ev.Name, ev.Description, ev.Category)
+		if err != nil {
+			return fmt.Errorf("seed event %s: %w", ev.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLite) Close() { s.db.Close() }
+
+func (s *SQLite) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+// micros and fromMicros convert between time.Time and the stored unix
+// microseconds.
+func micros(t time.Time) int64 { return t.UnixMicro() }
+
+func fromMicros(us int64) time.Time { return time.UnixMicro(us) }
+
+func nullMicros(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: micros(*t), Valid: true}
+}
+
+func fromNullMicros(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := fromMicros(v.Int64)
+	return &t
+}
+
+// sqlNotFound maps database/sql's no-rows error to ErrNotFound.
+func sqlNotFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// --- Events ---
+
+const sqliteEventColumns = `id, name, description, category, enabled, created_at`
+
+func scanSQLiteEvent(row rowScanner) (*Event, error) {
+	var e Event
+	var created int64
+	if err := row.Scan(&e.ID, &e.Name, &e.Description, &e.Category, &e.Enabled, &created); err != nil {
+		return nil, sqlNotFound(err)
+	}
+	e.CreatedAt = fromMicros(created)
+	return &e, nil
+}
+
+func (s *SQLite) ListEvents(ctx context.Context) ([]Event, error) {
+	return s.listEvents(ctx, `WHERE enabled = 1`)
+}
+
+func (s *SQLite) ListAllEvents(ctx context.Context) ([]Event, error) {
+	return s.listEvents(ctx, ``)
+}
+
+func (s *SQLite) listEvents(ctx context.Context, where string) ([]Event, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteEventColumns+` FROM events `+where+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		e, err := scanSQLiteEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLite) SetEventEnabled(ctx context.Context, id int, enabled bool) (*Event, error) {
+	return scanSQLiteEvent(s.db.QueryRowContext(ctx,
+		`UPDATE events SET enabled = ? WHERE id = ? RETURNING `+sqliteEventColumns, enabled, id))
+}
+
+// --- Telegram Users ---
+
+const sqliteUserColumns = `id, tg_chat_id, tg_username, linked, language, created_at`
+
+func scanSQLiteUser(row rowScanner) (*TelegramUser, error) {
+	var u TelegramUser
+	var created int64
+	if err := row.Scan(&u.ID, &u.TgChatID, &u.TgUsername, &u.Linked, &u.Language, &created); err != nil {
+		return nil, sqlNotFound(err)
+	}
+	u.CreatedAt = fromMicros(created)
+	return &u, nil
+}
+
+func (s *SQLite) UpsertTelegramUser(ctx context.Context, chatID int64, username, linkCode string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO telegram_users (tg_chat_id, tg_username, link_code, link_code_expires_at, linked)
+		VALUES (?1, ?2, ?3, ?4, 0)
+		ON CONFLICT (tg_chat_id) DO UPDATE
+			SET link_code = ?3, link_code_expires_at = ?4, tg_username = ?2`,
+		chatID, username, linkCode, micros(expiresAt))
+	return err
+}
+
+func (s *SQLite) LinkByCode(ctx context.Context, code string) (*TelegramUser, error) {
+	return scanSQLiteUser(s.db.QueryRowContext(ctx, `
+		UPDATE telegram_users SET linked = 1, link_code = NULL, link_code_expires_at = NULL
+		WHERE link_code = ? AND link_code_expires_at > ?
+		RETURNING `+sqliteUserColumns, code, micros(time.Now())))
+}
+
+func (s *SQLite) UpsertLoginUser(ctx context.Context, chatID int64, username string) (*TelegramUser, error) {
+	return scanSQLiteUser(s.db.QueryRowContext(ctx, `
+		INSERT INTO telegram_users (tg_chat_id, tg_username, linked)
+		VALUES (?1, ?2, 1)
+		ON CONFLICT (tg_chat_id) DO UPDATE
+			SET linked = 1, link_code = NULL, link_code_expires_at = NULL,
+				tg_username = COALESCE(NULLIF(?2, ''), telegram_users.tg_username)
+		RETURNING `+sqliteUserColumns, chatID, username))
+}
+
+func (s *SQLite) UnlinkTelegram(ctx context.Context, chatID int64) error {
+	// Only mark as unlinked — preserve subscriptions so they restore on re-link
+	_, err := s.db.ExecContext(ctx, `UPDATE telegram_users SET linked = 0 WHERE tg_chat_id = ?`, chatID)
+	return err
+}
+
+func (s *SQLite) GetTelegramUser(ctx context.Context, chatID int64) (*TelegramUser, error) {
+	return scanSQLiteUser(s.db.QueryRowContext(ctx,
+		`SELECT `+sqliteUserColumns+` FROM telegram_users WHERE tg_chat_id = ?`, chatID))
+}
+
+func (s *SQLite) ListTelegramUsers(ctx context.Context) ([]TelegramUser, error) {
+	return s.queryUsers(ctx, `SELECT `+sqliteUserColumns+` FROM telegram_users ORDER BY id`)
+}
+
+func (s *SQLite) SearchTelegramUsers(ctx context.Context, query string, limit int) ([]TelegramUser, error) {
+	// LIKE is case-insensitive for ASCII in SQLite
+	return s.queryUsers(ctx, `SELECT `+sqliteUserColumns+` FROM telegram_users
+		WHERE ?1 = '' OR tg_username LIKE ?2 ESCAPE '\' OR CAST(tg_chat_id AS TEXT) = ?1
+		ORDER BY id LIMIT ?3`, query, likePattern(query), userSearchLimit(limit))
+}
+
+func (s *SQLite) queryUsers(ctx context.Context, query string, args ...any) ([]TelegramUser, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []TelegramUser
+	for rows.Next() {
+		u, err := scanSQLiteUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+func (s *SQLite) GetUserLanguage(ctx context.Context, chatID int64) (string, error) {
+	var lang string
+	err := s.db.QueryRowContext(ctx, `SELECT language FROM telegram_users WHERE tg_chat_id = ?`, chatID).Scan(&lang)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "en", nil
+	}
+	return lang, err
+}
+
+func (s *SQLite) SetUserLanguage(ctx context.Context, chatID int64, username, lang string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO telegram_users (tg_chat_id, tg_username, language)
+		VALUES (?1, ?2, ?3)
+		ON CONFLICT (tg_chat_id) DO UPDATE SET language = ?3`,
+		chatID, username, lang)
+	return err
+}
+
+func (s *SQLite) LogLinkAttempt(ctx context.Context, ip, outcome string, chatID int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO link_attempts (ip, outcome, tg_chat_id) VALUES (?, ?, NULLIF(?, 0))`,
+		ip, outcome, chatID)
+	return err
+}
+
+func (s *SQLite) CountLinkedUsers(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM telegram_users WHERE linked = 1`).Scan(&count)
+	return count, err
+}
+
+// --- Sessions ---
+
+func (s *SQLite) CreateSession(ctx context.Context, chatID int64, ttl time.Duration) (string, time.Time, error) {
+	token, err := newSecret()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < ?`, micros(now)); err != nil {
+		return "", time.Time{}, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO sessions (token_hash, tg_chat_id, expires_at) VALUES (?, ?, ?)`,
+		hashToken(token), chatID, micros(expiresAt))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+func (s *SQLite) SessionChatID(ctx context.Context, token string) (int64, error) {
+	var chatID int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT s.tg_chat_id FROM sessions s
+		JOIN telegram_users u ON u.tg_chat_id = s.tg_chat_id
+		WHERE s.token_hash = ? AND s.expires_at > ? AND u.linked`, hashToken(token), micros(time.Now())).Scan(&chatID)
+	return chatID, sqlNotFound(err)
+}
+
+func (s *SQLite) DeleteSession(ctx context.Context, token string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE token_hash = ?`, hashToken(token))
+	return err
+}
+
+func (s *SQLite) DeleteSessions(ctx context.Context, chatID int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE tg_chat_id = ?`, chatID)
+	return err
+}
+
+// --- API keys ---
+
+func splitScopes(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+func (s *SQLite) CreateAPIKey(ctx context.Context, chatID int64, name string, scopes []string, expiresAt *time.Time) (string, *APIKey, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	key := APIKeyPrefix + secret
+
+	k := APIKey{Name: name, Prefix: key[:len(APIKeyPrefix)+8], Scopes: scopes, ExpiresAt: expiresAt, CreatedAt: time.Now()}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (tg_chat_id, name, key_hash, key_prefix, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`, chatID, name, hashToken(key), k.Prefix, strings.Join(scopes, ","), micros(k.CreatedAt), nullMicros(expiresAt)).
+		Scan(&k.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, &k, nil
+}
+
+func (s *SQLite) ListAPIKeys(ctx context.Context, chatID int64) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, key_prefix, scopes, created_at, expires_at, last_used_at
+		FROM api_keys WHERE tg_chat_id = ? ORDER BY created_at DESC, id DESC`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		var k APIKey
+		var scopes string
+		var created int64
+		var expires, lastUsed sql.NullInt64
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &scopes, &created, &expires, &lastUsed); err != nil {
+			return nil, err
+		}
+		k.Scopes = splitScopes(scopes)
+		k.CreatedAt = fromMicros(created)
+		k.ExpiresAt = fromNullMicros(expires)
+		k.LastUsedAt = fromNullMicros(lastUsed)
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *SQLite) APIKeyChatID(ctx context.Context, key string) (int64, []string, error) {
+	now := micros(time.Now())
+	var chatID int64
+	var scopes string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE api_keys SET last_used_at = ?1
+		FROM telegram_users u
+		WHERE u.tg_chat_id = api_keys.tg_chat_id AND u.linked
+			AND api_keys.key_hash = ?2 AND (api_keys.expires_at IS NULL OR api_keys.expires_at > ?1)
+		RETURNING api_keys.tg_chat_id, api_keys.scopes`, now, hashToken(key)).Scan(&chatID, &scopes)
+	if err != nil {
+		return 0, nil, sqlNotFound(err)
+	}
+	return chatID, splitScopes(scopes), nil
+}
+
+func (s *SQLite) DeleteAPIKey(ctx context.Context, chatID, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = ? AND tg_chat_id = ?`, id, chatID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLite) DeleteAPIKeys(ctx context.Context, chatID int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM api_keys WHERE tg_chat_id = ?`, chatID)
+	return err
+}
+
+// --- Subscriptions ---
+
+const sqliteSubscriptionColumns = `id, tg_user_id, event_id, threshold_pct, window_minutes, direction, report_hour, threshold_value, coin, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSQLiteSubscription(row rowScanner) (*Subscription, error) {
+	var sub Subscription
+	var created int64
+	err := row.Scan(&sub.ID, &sub.TgUserID, &sub.EventID, &sub.ThresholdPct, &sub.WindowMinutes, &sub.Direction, &sub.ReportHour, &sub.ThresholdValue, &sub.Coin, &created)
+	if err != nil {
+		return nil, sqlNotFound(err)
+	}
+	sub.CreatedAt = fromMicros(created)
+	return &sub, nil
+}
+
+func (s *SQLite) ListSubscriptions(ctx context.Context, tgChatID int64) ([]Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.tg_user_id, s.event_id, s.threshold_pct, s.window_minutes, s.direction, s.report_hour, s.threshold_value, s.coin, s.created_at
+		FROM subscriptions s
+		JOIN telegram_users u ON u.id = s.tg_user_id
+		WHERE u.tg_chat_id = ?
+		ORDER BY s.id`, tgChatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		sub, err := scanSQLiteSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+func (s *SQLite) Subscribe(ctx context.Context, tgChatID int64, eventID int, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error) {
+	return scanSQLiteSubscription(s.db.QueryRowContext(ctx, `
+		INSERT INTO subscriptions (tg_user_id, event_id, threshold_pct, window_minutes, direction, report_hour, threshold_value, coin)
+		SELECT u.id, ?2, ?3, ?4, ?5, ?6, ?7, ?8 FROM telegram_users u WHERE u.tg_chat_id = ?1
+		RETURNING `+sqliteSubscriptionColumns,
+		tgChatID, eventID, thresholdPct, windowMinutes, direction, reportHour, thresholdValue, coin))
+}
+
+func (s *SQLite) UpdateSubscription(ctx context.Context, id int64, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error) {
+	return scanSQLiteSubscription(s.db.QueryRowContext(ctx, `
+		UPDATE subscriptions SET threshold_pct = ?2, window_minutes = ?3, direction = ?4, report_hour = ?5, threshold_value = ?6, coin = ?7
+		WHERE id = ?1
+		RETURNING `+sqliteSubscriptionColumns,
+		id, thresholdPct, windowMinutes, direction, reportHour, thresholdValue, coin))
+}
+
+func (s *SQLite) Unsubscribe(ctx context.Context, subID int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM subscriptions WHERE id = ?`, subID)
+	return err
+}
+
+func (s *SQLite) GetSubscriptionChatID(ctx context.Context, subID int64) (int64, error) {
+	var chatID int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT u.tg_chat_id FROM subscriptions s
+		JOIN telegram_users u ON u.id = s.tg_user_id
+		WHERE s.id = ?`, subID).Scan(&chatID)
+	return chatID, sqlNotFound(err)
+}
+
+func (s *SQLite) GetSubscriberChatIDs(ctx context.Context, eventName string) ([]int64, error) {
+	return s.chatIDs(ctx, `
+		SELECT u.tg_chat_id
+		FROM subscriptions s
+		JOIN telegram_users u ON u.id = s.tg_user_id
+		JOIN events e ON e.id = s.event_id
+		WHERE e.name = ? AND u.linked = 1`, eventName)
+}
+
+func (s *SQLite) GetSubscribersWithThresholds(ctx context.Context, eventName string) ([]SubscriberConfig, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, u.tg_chat_id, s.threshold_pct, s.window_minutes, s.direction, s.threshold_value, s.coin
+		FROM subscriptions s
+		JOIN telegram_users u ON u.id = s.tg_user_id
+		JOIN events e ON e.id = s.event_id
+		WHERE e.name = ? AND u.linked = 1
+		ORDER BY s.id`, eventName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []SubscriberConfig
+	for rows.Next() {
+		var c SubscriberConfig
+		if err := rows.Scan(&c.SubscriptionID, &c.ChatID, &c.ThresholdPct, &c.WindowMinutes, &c.Direction, &c.ThresholdValue, &c.Coin); err != nil {
+			return nil, err
+		}
+		configs = append(configs, c)
+	}
+	return configs, rows.Err()
+}
+
+func (s *SQLite) GetDailyReportSubscribers(ctx context.Context, eventName string, hour int) ([]DailyReportSubscriber, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, u.tg_chat_id, s.report_hour
+		FROM subscriptions s
+		JOIN telegram_users u ON u.id = s.tg_user_id
+		JOIN events e ON e.id = s.event_id
+		WHERE e.name = ? AND u.linked = 1 AND s.report_hour = ?
+		ORDER BY s.id`, eventName, hour)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []DailyReportSubscriber
+	for rows.Next() {
+		var d DailyReportSubscriber
+		if err := rows.Scan(&d.SubscriptionID, &d.ChatID, &d.ReportHour); err != nil {
+			return nil, err
+		}
+		subs = append(subs, d)
+	}
+	return subs, rows.Err()
+}
+
+func (s *SQLite) chatIDs(ctx context.Context, query string, args ...any) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *SQLite) CountSubscriptions(ctx context.Context, eventName string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM subscriptions s
+		JOIN events e ON e.id = s.event_id
+		WHERE e.name = ?`, eventName).Scan(&count)
+	return count, err
+}
+
+func (s *SQLite) ListEventSubscriptions(ctx context.Context) ([]EventSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, u.tg_chat_id, s.threshold_pct, s.window_minutes, s.direction, s.threshold_value, s.coin,
+		       e.name, s.report_hour, u.linked
+		FROM subscriptions s
+		JOIN telegram_users u ON u.id = s.tg_user_id
+		JOIN events e ON e.id = s.event_id
+		ORDER BY s.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []EventSubscription
+	for rows.Next() {
+		var es EventSubscription
+		if err := rows.Scan(&es.SubscriptionID, &es.ChatID, &es.ThresholdPct, &es.WindowMinutes, &es.Direction,
+			&es.ThresholdValue, &es.Coin, &es.EventName, &es.ReportHour, &es.Linked); err != nil {
+			return nil, err
+		}
+		subs = append(subs, es)
+	}
+	return subs, rows.Err()
+}
+
+// --- Liquidation Events ---
+
+const sqliteInsertLiquidation = `
+	INSERT INTO liquidation_events (symbol, side, price, quantity, usd_value, exchange, event_time)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+func (s *SQLite) InsertLiquidationEvent(ctx context.Context, e *LiquidationEvent) error {
+	_, err := s.db.ExecContext(ctx, sqliteInsertLiquidation,
+		e.Symbol, e.Side, e.Price, e.Quantity, e.USDValue, e.Exchange, micros(e.EventTime))
+	return err
+}
+
+func (s *SQLite) InsertLiquidationEvents(ctx context.Context, events []LiquidationEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+	stmt, err := tx.PrepareContext(ctx, sqliteInsertLiquidation)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range events {
+		if _, err := stmt.ExecContext(ctx, e.Symbol, e.Side, e.Price, e.Quantity, e.USDValue, e.Exchange, micros(e.EventTime)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// QueryMaxPain mirrors the Postgres binning query. SQLite's ROUND breaks
+// exact .5 ties away from zero where Postgres rounds to even; prices
+// landing exactly between two bins are rare enough not to matter.
+func (s *SQLite) QueryMaxPain(ctx context.Context, symbol string, window time.Duration, binSize float64) (*MaxPainResult, *MaxPainResult, error) {
+	since := micros(time.Now().Add(-window))
+	side := func(name string) *MaxPainResult {
+		var mp MaxPainResult
+		err := s.db.QueryRowContext(ctx, `
+			SELECT ROUND(price / ?3) * ?3 AS price_bin, SUM(usd_value) AS total
+			FROM liquidation_events
+			WHERE symbol = ?1 AND event_time > ?2 AND side = ?4
+			GROUP BY price_bin
+			ORDER BY total DESC
+			LIMIT 1`, symbol, since, binSize, name).Scan(&mp.PriceBin, &mp.USDTotal)
+		if err != nil {
+			return &MaxPainResult{} // no data is ok
+		}
+		return &mp
+	}
+	return side("LONG"), side("SHORT"), nil
+}
+
+func (s *SQLite) QueryLiquidationBins(ctx context.Context, symbol string, window time.Duration, binSize float64) ([]LiquidationBin, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ROUND(price / ?3) * ?3 AS price_bin,
+			COALESCE(SUM(usd_value) FILTER (WHERE side = 'LONG'), 0),
+			COALESCE(SUM(usd_value) FILTER (WHERE side = 'SHORT'), 0)
+		FROM liquidation_events
+		WHERE symbol = ?1 AND event_time > ?2
+		GROUP BY price_bin
+		ORDER BY price_bin`, symbol, micros(time.Now().Add(-window)), binSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bins []LiquidationBin
+	for rows.Next() {
+		var b LiquidationBin
+		if err := rows.Scan(&b.PriceBin, &b.LongUSD, &b.ShortUSD); err != nil {
+			return nil, err
+		}
+		bins = append(bins, b)
+	}
+	return bins, rows.Err()
+}
+
+func (s *SQLite) GetCurrentPrice(ctx context.Context, symbol string) (float64, error) {
+	var price float64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT price FROM liquidation_events
+		WHERE symbol = ?
+		ORDER BY event_time DESC
+		LIMIT 1`, symbol).Scan(&price)
+	return price, sqlNotFound(err)
+}
+
+func (s *SQLite) CountLiquidationEvents(ctx context.Context, symbol string, window time.Duration) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM liquidation_events
+		WHERE symbol = ? AND event_time > ?`, symbol, micros(time.Now().Add(-window))).Scan(&count)
+	return count, err
+}
+
+func (s *SQLite) CleanupOldLiquidationEvents(ctx context.Context, maxAge time.Duration) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM liquidation_events WHERE event_time < ?`, micros(time.Now().Add(-maxAge)))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// --- Notification Log ---
+
+func (s *SQLite) LogNotification(ctx context.Context, chatID int64, alertType, eventName, summary string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO notification_log (tg_chat_id, alert_type, event_name, summary) VALUES (?, ?, ?, ?)`,
+		chatID, alertType, eventName, summary)
+	return err
+}
+
+func (s *SQLite) ListNotifications(ctx context.Context, chatID int64, limit int) ([]NotificationLog, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, tg_chat_id, alert_type, event_name, summary, created_at
+		 FROM notification_log WHERE tg_chat_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		chatID, notificationLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []NotificationLog
+	for rows.Next() {
+		var l NotificationLog
+		var created int64
+		if err := rows.Scan(&l.ID, &l.TgChatID, &l.AlertType, &l.EventName, &l.Summary, &created); err != nil {
+			return nil, err
+		}
+		l.CreatedAt = fromMicros(created)
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+func (s *SQLite) LogDeliveryFailure(ctx context.Context, chatID int64, message, errMsg string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO delivery_failures (tg_chat_id, message, error) VALUES (?, ?, ?)`,
+		chatID, truncateMessage(message), errMsg)
+	return err
+}
+
+func (s *SQLite) ListDeliveryFailures(ctx context.Context, limit int) ([]DeliveryFailure, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, tg_chat_id, message, error, created_at
+		 FROM delivery_failures ORDER BY created_at DESC, id DESC LIMIT ?`,
+		notificationLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []DeliveryFailure
+	for rows.Next() {
+		var f DeliveryFailure
+		var created int64
+		if err := rows.Scan(&f.ID, &f.TgChatID, &f.Message, &f.Error, &created); err != nil {
+			return nil, err
+		}
+		f.CreatedAt = fromMicros(created)
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+// --- Sources ---
+
+func (s *SQLite) ListPausedSources(ctx context.Context) ([]PausedSource, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, paused_at FROM paused_sources ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paused []PausedSource
+	for rows.Next() {
+		var p PausedSource
+		var at int64
+		if err := rows.Scan(&p.Name, &at); err != nil {
+			return nil, err
+		}
+		p.PausedAt = fromMicros(at)
+		paused = append(paused, p)
+	}
+	return paused, rows.Err()
+}
+
+func (s *SQLite) SetSourcePaused(ctx context.Context, name string, paused bool) error {
+	var err error
+	if paused {
+		_, err = s.db.ExecContext(ctx, `INSERT INTO paused_sources (name) VALUES (?) ON CONFLICT (name) DO NOTHING`, name)
+	} else {
+		_, err = s.db.ExecContext(ctx, `DELETE FROM paused_sources WHERE name = ?`, name)
+	}
+	return err
+}
+
+// --- Audit log ---
+
+func (s *SQLite) LogAdminAction(ctx context.Context, actor, action, target, detail string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO admin_audit_log (actor, action, target, detail) VALUES (?, ?, ?, ?)`,
+		actor, action, target, detail)
+	return err
+}
+
+func (s *SQLite) ListAdminActions(ctx context.Context, limit int) ([]AdminAction, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, actor, action, target, detail, created_at
+		 FROM admin_audit_log ORDER BY created_at DESC, id DESC LIMIT ?`,
+		notificationLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []AdminAction
+	for rows.Next() {
+		var a AdminAction
+		var created int64
+		if err := rows.Scan(&a.ID, &a.Actor, &a.Action, &a.Target, &a.Detail, &created); err != nil {
+			return nil, err
+		}
+		a.CreatedAt = fromMicros(created)
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
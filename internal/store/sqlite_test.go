@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T) Store {
+		ctx := context.Background()
+		s, err := NewSQLite(ctx, filepath.Join(t.TempDir(), "monitor.db"))
+		if err != nil {
+			t.Fatalf("NewSQLite: %v", err)
+		}
+		t.Cleanup(s.Close)
+		if err := s.Migrate(ctx); err != nil {
+			t.Fatalf("Migrate: %v", err)
+		}
+		// Migrating again must be a no-op.
+		if err := s.Migrate(ctx); err != nil {
+			t.Fatalf("second Migrate: %v", err)
+		}
+		return s
+	})
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	for _, url := range []string{
+		"sqlite://" + filepath.Join(dir, "a.db"),
+		"sqlite:" + filepath.Join(dir, "b.db"),
+	} {
+		s, err := Open(ctx, url)
+		if err != nil {
+			t.Fatalf("Open(%q): %v", url, err)
+		}
+		if _, ok := s.(*SQLite); !ok {
+			t.Errorf("Open(%q) = %T, want *SQLite", url, s)
+		}
+		s.Close()
+	}
+
+	for _, url := range []string{"mysql://localhost/db", "sqlite:", "monitor.db"} {
+		if _, err := Open(ctx, url); err == nil {
+			t.Errorf("Open(%q) succeeded, want error", url)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Store is everything the service persists. Postgres is the production
+// backend, SQLite suits single-host deployments, and Memory backs dev mode
+// and tests. Components that only touch one
+// area take the narrower interface below.
+type Store interface {
+	EventStore
+	UserStore
+	SessionStore
+	APIKeyStore
+	SubscriptionStore
+	LiquidationStore
+	NotificationStore
+	SourceStore
+	AuditStore
+
+	Ping(ctx context.Context) error
+	Migrate(ctx context.Context) error
+	Close()
+}
+
+// Open connects to the backend named by databaseURL's scheme:
+// postgres:// (or postgresql://) for Postgres and sqlite: for a SQLite file,
+// e.g. sqlite:///var/lib/onchain-monitor/monitor.db or sqlite:monitor.db.
+func Open(ctx context.Context, databaseURL string) (Store, error) {
+	switch {
+	case strings.HasPrefix(databaseURL, "postgres://"), strings.HasPrefix(databaseURL, "postgresql://"):
+		return NewPostgres(ctx, databaseURL)
+	case strings.HasPrefix(databaseURL, "sqlite:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(databaseURL, "sqlite:"), "//")
+		if path == "" || strings.HasPrefix(path, "?") {
+			return nil, errors.New("sqlite database URL has no file path")
+		}
+		return NewSQLite(ctx, path)
+	default:
+		return nil, errors.New("unsupported database URL scheme (want postgres:// or sqlite:)")
+	}
+}
+
+// EventStore lists the events users can subscribe to. ListEvents returns
+// only enabled events; admins see and toggle all of them.
+type EventStore interface {
+	ListEvents(ctx context.Context) ([]Event, error)
+	ListAllEvents(ctx context.Context) ([]Event, error)
+	SetEventEnabled(ctx context.Context, id int, enabled bool) (*Event, error)
+}
+
+// UserStore manages Telegram chats and their link state.
+type UserStore interface {
+	UpsertTelegramUser(ctx context.Context, chatID int64, username, linkCode string, expiresAt time.Time) error
+	LinkByCode(ctx context.Context, code string) (*TelegramUser, error)
+	UpsertLoginUser(ctx context.Context, chatID int64, username string) (*TelegramUser, error)
+	UnlinkTelegram(ctx context.Context, chatID int64) error
+	GetTelegramUser(ctx context.Context, chatID int64) (*TelegramUser, error)
+	ListTelegramUsers(ctx context.Context) ([]TelegramUser, error)
+	SearchTelegramUsers(ctx context.Context, query string, limit int) ([]TelegramUser, error)
+	GetUserLanguage(ctx context.Context, chatID int64) (string, error)
+	SetUserLanguage(ctx context.Context, chatID int64, username, lang string) error
+	LogLinkAttempt(ctx context.Context, ip, outcome string, chatID int64) error
+	CountLinkedUsers(ctx context.Context) (int, error)
+}
+
+// SessionStore issues and resolves API session tokens.
+type SessionStore interface {
+	CreateSession(ctx context.Context, chatID int64, ttl time.Duration) (string, time.Time, error)
+	SessionChatID(ctx context.Context, token string) (int64, error)
+	DeleteSession(ctx context.Context, token string) error
+	DeleteSessions(ctx context.Context, chatID int64) error
+}
+
+// APIKeyStore issues and resolves scoped API keys.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, chatID int64, name string, scopes []string, expiresAt *time.Time) (string, *APIKey, error)
+	ListAPIKeys(ctx context.Context, chatID int64) ([]APIKey, error)
+	APIKeyChatID(ctx context.Context, key string) (int64, []string, error)
+	DeleteAPIKey(ctx context.Context, chatID, id int64) error
+	DeleteAPIKeys(ctx context.Context, chatID int64) error
+}
+
+// SubscriptionStore manages event subscriptions and answers the engine's
+// "who wants this alert" queries.
+type SubscriptionStore interface {
+	ListSubscriptions(ctx context.Context, tgChatID int64) ([]Subscription, error)
+	Subscribe(ctx context.Context, tgChatID int64, eventID int, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error)
+	UpdateSubscription(ctx context.Context, id int64, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*Subscription, error)
+	Unsubscribe(ctx context.Context, subID int64) error
+	GetSubscriptionChatID(ctx context.Context, subID int64) (int64, error)
+	GetSubscriberChatIDs(ctx context.Context, eventName string) ([]int64, error)
+	GetSubscribersWithThresholds(ctx context.Context, eventName string) ([]SubscriberConfig, error)
+	GetDailyReportSubscribers(ctx context.Context, eventName string, hour int) ([]DailyReportSubscriber, error)
+	CountSubscriptions(ctx context.Context, eventName string) (int, error)
+	ListEventSubscriptions(ctx context.Context) ([]EventSubscription, error)
+}
+
+// SubscriptionNotifier is implemented by backends that can report
+// subscription and user changes made by any replica.
+type SubscriptionNotifier interface {
+	// ListenSubscriptionChanges calls changed once listening has started
+	// (changes made before then were missed) and again after every change,
+	// until ctx is done or the connection fails.
+	ListenSubscriptionChanges(ctx context.Context, changed func()) error
+}
+
+// LiquidationStore keeps exchange liquidations for max pain calculation.
+type LiquidationStore interface {
+	InsertLiquidationEvent(ctx context.Context, e *LiquidationEvent) error
+	InsertLiquidationEvents(ctx context.Context, events []LiquidationEvent) error
+	QueryMaxPain(ctx context.Context, symbol string, window time.Duration, binSize float64) (*MaxPainResult, *MaxPainResult, error)
+	QueryLiquidationBins(ctx context.Context, symbol string, window time.Duration, binSize float64) ([]LiquidationBin, error)
+	GetCurrentPrice(ctx context.Context, symbol string) (float64, error)
+	CountLiquidationEvents(ctx context.Context, symbol string, window time.Duration) (int, error)
+	CleanupOldLiquidationEvents(ctx context.Context, maxAge time.Duration) (int64, error)
+}
+
+// NotificationStore records delivered alerts and failed deliveries.
+type NotificationStore interface {
+	LogNotification(ctx context.Context, chatID int64, alertType, eventName, summary string) error
+	ListNotifications(ctx context.Context, chatID int64, limit int) ([]NotificationLog, error)
+	LogDeliveryFailure(ctx context.Context, chatID int64, message, errMsg string) error
+	ListDeliveryFailures(ctx context.Context, limit int) ([]DeliveryFailure, error)
+}
+
+// SourceStore keeps which sources an admin paused, so every replica (and
+// the next leader) skips them.
+type SourceStore interface {
+	ListPausedSources(ctx context.Context) ([]PausedSource, error)
+	SetSourcePaused(ctx context.Context, name string, paused bool) error
+}
+
+// AuditStore records the changes admins make through /api/admin.
+type AuditStore interface {
+	LogAdminAction(ctx context.Context, actor, action, target, detail string) error
+	ListAdminActions(ctx context.Context, limit int) ([]AdminAction, error)
+}
+
+// ErrNotFound is returned when a row to look up or modify does not exist
+// (or belongs to another chat).
+var ErrNotFound = errors.New("not found")
+
+// --- Events ---
+
+type Event struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// seedEvents are created by every backend on migration. Descriptions are
+// refreshed on each boot; names are never removed.
+var seedEvents = []Event{
+	{Name: "altura_metric_alert", Description: "Alert when Altura metrics", Category: "altura"},
+	{Name: "altura_daily_report", Description: "Daily UTC+8 report — Altura TVL, AVLT price, APR", Category: "altura"},
+	{Name: "neverland_metric_alert", Description: "Alert when Neverland metrics", Category: "neverland"},
+	{Name: "neverland_daily_report", Description: "Daily UTC+8 report — Neverland TVL, veDUST, DUST price, fees", Category: "neverland"},
+	{Name: "general_metric_alert", Description: "Alert when Fear & Greed Index", Category: "general"},
+	{Name: "general_daily_report", Description: "Daily UTC+8 report — Crypto Fear & Greed Index", Category: "general"},
+	{Name: "general_maxpain_alert", Description: "Alert when price hits liquidation max pain", Category: "general"},
+	{Name: "general_merkl_alert", Description: "Alert on new Merkl yield opportunities", Category: "general"},
+	{Name: "general_binance_price_alert", Description: "Alert when Binance price reaches target", Category: "general"},
+	{Name: "general_turtle_alert", Description: "Alert on new Turtle yield opportunities", Category: "general"},
+	{Name: "general_alpha_alert", Description: "Alert on Binance Alpha airdrops", Category: "general"},
+	{Name: "general_defillama_alert", Description: "Alert on DeFi Llama USDC/USDT yield opportunities", Category: "general"},
+	{Name: "general_defillama_lp_alert", Description: "Alert on DeFi Llama LP/DEX reward opportunities by chain", Category: "general"},
+	{Name: "general_defillama_tvl_alert", Description: "Alert on DeFi Llama protocol TVL changes (1d/7d/30d)", Category: "general"},
+}
+
+// --- Telegram Users ---
+
+type TelegramUser struct {
+	ID                int64     `json:"id"`
+	TgChatID          int64     `json:"tg_chat_id"`
+	TgUsername        string    `json:"tg_username"`
+	LinkCode          string    `json:"link_code,omitempty"`
+	LinkCodeExpiresAt time.Time `json:"link_code_expires_at,omitempty"`
+	Linked            bool      `json:"linked"`
+	Language          string    `json:"language"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// userSearchLimit clamps a SearchTelegramUsers limit to 1-500 (100 when
+// out of range).
+func userSearchLimit(limit int) int {
+	if limit <= 0 || limit > 500 {
+		return 100
+	}
+	return limit
+}
+
+// likePattern turns a search query into a LIKE pattern matching it as a
+// substring, escaping LIKE's wildcards with a backslash.
+func likePattern(query string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(query) + "%"
+}
+
+// --- API keys ---
+
+// APIKeyPrefix starts every API key, telling keys apart from session
+// tokens (plain hex) without a database lookup.
+const APIKeyPrefix = "omk_"
+
+// API key scopes. Sessions implicitly hold all of them.
+const (
+	ScopeReadStats          = "read:stats"
+	ScopeWriteSubscriptions = "write:subscriptions"
+	ScopeReadNotifications  = "read:notifications"
+)
+
+// APIKeyScopes lists every scope a key can be granted.
+var APIKeyScopes = []string{ScopeReadStats, ScopeWriteSubscriptions, ScopeReadNotifications}
+
+// APIKey describes a key without its secret. Prefix is the start of the key
+// so users can tell their keys apart.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// newSecret returns a random 256-bit hex string for session tokens and API
+// keys.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// --- Subscriptions ---
+
+type Subscription struct {
+	ID             int64     `json:"id"`
+	TgUserID       int64     `json:"tg_user_id"`
+	EventID        int       `json:"event_id"`
+	ThresholdPct   float64   `json:"threshold_pct"`
+	WindowMinutes  int       `json:"window_minutes"`
+	Direction      string    `json:"direction"`
+	ReportHour     int       `json:"report_hour"`
+	ThresholdValue float64   `json:"threshold_value"`
+	Coin           string    `json:"coin"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// SubscriberConfig holds per-subscriber alert configuration.
+type SubscriberConfig struct {
+	SubscriptionID int64
+	ChatID         int64
+	ThresholdPct   float64
+	WindowMinutes  int
+	Direction      string
+	ThresholdValue float64
+	Coin           string
+}
+
+// DailyReportSubscriber holds per-subscriber daily report config.
+type DailyReportSubscriber struct {
+	SubscriptionID int64
+	ChatID         int64
+	ReportHour     int
+}
+
+// EventSubscription is one subscription with its event name and chat, the
+// row the subscription cache indexes.
+type EventSubscription struct {
+	SubscriberConfig
+	EventName  string
+	ReportHour int
+	Linked     bool
+}
+
+// --- Liquidation Events ---
+
+// LiquidationEvent represents a single forced liquidation from an exchange.
+type LiquidationEvent struct {
+	Symbol    string
+	Side      string // "LONG" or "SHORT" (which side got liquidated)
+	Price     float64
+	Quantity  float64
+	USDValue  float64
+	Exchange  string
+	EventTime time.Time
+}
+
+// MaxPainResult holds the calculated max pain price for one side.
+type MaxPainResult struct {
+	PriceBin float64
+	USDTotal float64
+}
+
+// LiquidationBin holds the liquidated USD volume per side at one price level.
+type LiquidationBin struct {
+	PriceBin float64
+	LongUSD  float64
+	ShortUSD float64
+}
+
+// --- Notification Log ---
+
+type NotificationLog struct {
+	ID        int64     `json:"id"`
+	TgChatID  int64     `json:"tg_chat_id"`
+	AlertType string    `json:"alert_type"`
+	EventName string    `json:"event_name"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeliveryFailure is a message the alert transport failed to deliver.
+// Message is cut to maxFailureMessage runes.
+type DeliveryFailure struct {
+	ID        int64     `json:"id"`
+	TgChatID  int64     `json:"tg_chat_id"`
+	Message   string    `json:"message"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const maxFailureMessage = 500
+
+// truncateMessage cuts msg to maxFailureMessage runes.
+func truncateMessage(msg string) string {
+	if r := []rune(msg); len(r) > maxFailureMessage {
+		return string(r[:maxFailureMessage]) + "…"
+	}
+	return msg
+}
+
+// --- Sources ---
+
+// PausedSource is a source an admin stopped polling.
+type PausedSource struct {
+	Name     string    `json:"name"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// --- Audit log ---
+
+// AdminAction is an audit log entry: who (a middleware.AdminActor) did
+// what to which target, e.g. "chat:123" set_source_paused "altura"
+// "paused=true".
+type AdminAction struct {
+	ID        int64     `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// notificationLimit clamps a ListNotifications limit to 1-100 (50 when
+// out of range).
+func notificationLimit(limit int) int {
+	if limit <= 0 || limit > 100 {
+		return 50
+	}
+	return limit
+}
@@ -0,0 +1,199 @@
+// Package subcache keeps active subscriptions in memory so the engine's
+// per-poll "who wants this alert" queries do not hit the database. The
+// index is reloaded after writes made through the cache, on LISTEN/NOTIFY
+// from Postgres when another replica writes, and at least every MaxAge.
+// If a reload fails the previous index keeps being served, so alerts keep
+// flowing through short database outages.
+package subcache
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+const (
+	// MaxAge bounds how long the index is trusted without a reload, in case
+	// a notification was missed.
+	MaxAge = 5 * time.Minute
+	// retryInterval spaces out reload attempts while the database is down.
+	retryInterval = 10 * time.Second
+	// listenRetry is the wait before re-subscribing to change notifications.
+	listenRetry = 5 * time.Second
+)
+
+// Cache is a store.Store whose subscription reads are answered from memory.
+// Everything else passes through to the wrapped store.
+type Cache struct {
+	store.Store
+	logger *slog.Logger
+
+	gen atomic.Uint64 // bumped on every invalidation
+
+	mu        sync.Mutex
+	index     map[string][]store.EventSubscription // by event name, in ID order
+	loadedGen uint64
+	loadedAt  time.Time
+	failedAt  time.Time
+}
+
+var _ store.Store = (*Cache)(nil)
+
+// New wraps s. The index is loaded on first use.
+func New(s store.Store, logger *slog.Logger) *Cache {
+	return &Cache{Store: s, logger: logger}
+}
+
+// Run listens for changes made by other replicas until ctx is done. It
+// returns at once for backends without notifications; those only see
+// writes made through this Cache (and MaxAge reloads).
+func (c *Cache) Run(ctx context.Context) {
+	n, ok := c.Store.(store.SubscriptionNotifier)
+	if !ok {
+		return
+	}
+	for {
+		err := n.ListenSubscriptionChanges(ctx, func() { c.invalidate("notify") })
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("subscription change listener failed, retrying", "error", err, "retry_in", listenRetry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetry):
+		}
+	}
+}
+
+func (c *Cache) invalidate(reason string) {
+	c.gen.Add(1)
+	metrics.SubscriptionCacheInvalidationsTotal.WithLabelValues(reason).Inc()
+}
+
+// load returns the current index, reloading it when it has been
+// invalidated or is older than MaxAge. A failed reload serves the previous
+// index; only the very first load can fail.
+func (c *Cache) load(ctx context.Context) (map[string][]store.EventSubscription, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	gen := c.gen.Load()
+	switch {
+	case c.index == nil:
+	case c.loadedGen == gen && time.Since(c.loadedAt) < MaxAge:
+		return c.index, nil
+	case time.Since(c.failedAt) < retryInterval:
+		return c.index, nil
+	}
+
+	subs, err := c.Store.ListEventSubscriptions(ctx)
+	if err != nil {
+		metrics.SubscriptionCacheReloadsTotal.WithLabelValues("error").Inc()
+		if c.index == nil {
+			return nil, err
+		}
+		c.failedAt = time.Now()
+		c.logger.Warn("subscription cache reload failed, serving stale subscriptions",
+			"error", err, "age", time.Since(c.loadedAt).Round(time.Second))
+		metrics.SubscriptionCacheAge.Set(time.Since(c.loadedAt).Seconds())
+		return c.index, nil
+	}
+	metrics.SubscriptionCacheReloadsTotal.WithLabelValues("success").Inc()
+
+	index := make(map[string][]store.EventSubscription)
+	for _, s := range subs {
+		index[s.EventName] = append(index[s.EventName], s)
+	}
+	c.index, c.loadedGen, c.loadedAt, c.failedAt = index, gen, time.Now(), time.Time{}
+	metrics.SubscriptionCacheAge.Set(0)
+	return index, nil
+}
+
+// linked calls fn for each subscription to eventName whose chat is linked.
+func (c *Cache) linked(ctx context.Context, eventName string, fn func(store.EventSubscription)) error {
+	index, err := c.load(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range index[eventName] {
+		if s.Linked {
+			fn(s)
+		}
+	}
+	return nil
+}
+
+// --- Cached reads ---
+
+func (c *Cache) GetSubscriberChatIDs(ctx context.Context, eventName string) ([]int64, error) {
+	var ids []int64
+	err := c.linked(ctx, eventName, func(s store.EventSubscription) { ids = append(ids, s.ChatID) })
+	return ids, err
+}
+
+func (c *Cache) GetSubscribersWithThresholds(ctx context.Context, eventName string) ([]store.SubscriberConfig, error) {
+	var configs []store.SubscriberConfig
+	err := c.linked(ctx, eventName, func(s store.EventSubscription) { configs = append(configs, s.SubscriberConfig) })
+	return configs, err
+}
+
+func (c *Cache) GetDailyReportSubscribers(ctx context.Context, eventName string, hour int) ([]store.DailyReportSubscriber, error) {
+	var subs []store.DailyReportSubscriber
+	err := c.linked(ctx, eventName, func(s store.EventSubscription) {
+		if s.ReportHour == hour {
+			subs = append(subs, store.DailyReportSubscriber{SubscriptionID: s.SubscriptionID, ChatID: s.ChatID, ReportHour: s.ReportHour})
+		}
+	})
+	return subs, err
+}
+
+func (c *Cache) CountSubscriptions(ctx context.Context, eventName string) (int, error) {
+	index, err := c.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(index[eventName]), nil
+}
+
+// --- Writes that change the index ---
+
+func (c *Cache) UpsertTelegramUser(ctx context.Context, chatID int64, username, linkCode string, expiresAt time.Time) error {
+	defer c.invalidate("write")
+	return c.Store.UpsertTelegramUser(ctx, chatID, username, linkCode, expiresAt)
+}
+
+func (c *Cache) LinkByCode(ctx context.Context, code string) (*store.TelegramUser, error) {
+	defer c.invalidate("write")
+	return c.Store.LinkByCode(ctx, code)
+}
+
+func (c *Cache) UpsertLoginUser(ctx context.Context, chatID int64, username string) (*store.TelegramUser, error) {
+	defer c.invalidate("write")
+	return c.Store.UpsertLoginUser(ctx, chatID, username)
+}
+
+func (c *Cache) UnlinkTelegram(ctx context.Context, chatID int64) error {
+	defer c.invalidate("write")
+	return c.Store.UnlinkTelegram(ctx, chatID)
+}
+
+func (c *Cache) Subscribe(ctx context.Context, tgChatID int64, eventID int, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*store.Subscription, error) {
+	defer c.invalidate("write")
+	return c.Store.Subscribe(ctx, tgChatID, eventID, thresholdPct, windowMinutes, direction, reportHour, thresholdValue, coin)
+}
+
+func (c *Cache) UpdateSubscription(ctx context.Context, id int64, thresholdPct float64, windowMinutes int, direction string, reportHour int, thresholdValue float64, coin string) (*store.Subscription, error) {
+	defer c.invalidate("write")
+	return c.Store.UpdateSubscription(ctx, id, thresholdPct, windowMinutes, direction, reportHour, thresholdValue, coin)
+}
+
+func (c *Cache) Unsubscribe(ctx context.Context, subID int64) error {
+	defer c.invalidate("write")
+	return c.Store.Unsubscribe(ctx, subID)
+}
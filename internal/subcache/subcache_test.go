@@ -0,0 +1,137 @@
+package subcache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/store"
+)
+
+// countingStore counts index loads and can simulate a database outage.
+type countingStore struct {
+	store.Store
+	loads int
+	down  bool
+}
+
+func (s *countingStore) ListEventSubscriptions(ctx context.Context) ([]store.EventSubscription, error) {
+	s.loads++
+	if s.down {
+		return nil, errors.New("connection refused")
+	}
+	return s.Store.ListEventSubscriptions(ctx)
+}
+
+func newTestCache(t *testing.T) (*Cache, *countingStore, int) {
+	t.Helper()
+	ctx := context.Background()
+	mem := store.NewMemory()
+	if err := mem.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if _, err := mem.UpsertLoginUser(ctx, 1, "alice"); err != nil {
+		t.Fatalf("UpsertLoginUser: %v", err)
+	}
+	events, _ := mem.ListEvents(ctx)
+	var alert int
+	for _, ev := range events {
+		if ev.Name == "altura_metric_alert" {
+			alert = ev.ID
+		}
+	}
+	if _, err := mem.Subscribe(ctx, 1, alert, 10, 5, "drop", 8, 0, ""); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	cs := &countingStore{Store: mem}
+	return New(cs, slog.New(slog.DiscardHandler)), cs, alert
+}
+
+func TestCacheServesReadsFromIndex(t *testing.T) {
+	ctx := context.Background()
+	c, cs, alert := newTestCache(t)
+
+	for range 3 {
+		cfgs, err := c.GetSubscribersWithThresholds(ctx, "altura_metric_alert")
+		if err != nil || len(cfgs) != 1 || cfgs[0].ChatID != 1 || cfgs[0].ThresholdPct != 10 {
+			t.Fatalf("GetSubscribersWithThresholds = %+v, %v", cfgs, err)
+		}
+		if n, _ := c.CountSubscriptions(ctx, "altura_metric_alert"); n != 1 {
+			t.Fatalf("CountSubscriptions = %d, want 1", n)
+		}
+	}
+	if cs.loads != 1 {
+		t.Errorf("loads = %d, want 1", cs.loads)
+	}
+
+	// Writes through the cache are visible on the next read.
+	if _, err := c.Subscribe(ctx, 1, alert, 20, 5, "drop", 9, 0, ""); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if n, _ := c.CountSubscriptions(ctx, "altura_metric_alert"); n != 2 || cs.loads != 2 {
+		t.Errorf("after Subscribe: count = %d, loads = %d, want 2 and 2", n, cs.loads)
+	}
+
+	// Unlinked chats keep their subscriptions but get no alerts.
+	if err := c.UnlinkTelegram(ctx, 1); err != nil {
+		t.Fatalf("UnlinkTelegram: %v", err)
+	}
+	if ids, _ := c.GetSubscriberChatIDs(ctx, "altura_metric_alert"); len(ids) != 0 {
+		t.Errorf("GetSubscriberChatIDs after unlink = %v, want none", ids)
+	}
+	if n, _ := c.CountSubscriptions(ctx, "altura_metric_alert"); n != 2 {
+		t.Errorf("CountSubscriptions after unlink = %d, want 2", n)
+	}
+}
+
+func TestCacheReloadsOnNotification(t *testing.T) {
+	ctx := context.Background()
+	c, cs, alert := newTestCache(t)
+
+	if _, err := c.GetDailyReportSubscribers(ctx, "altura_daily_report", 8); err != nil {
+		t.Fatalf("GetDailyReportSubscribers: %v", err)
+	}
+	// Another replica writes straight to the database...
+	if _, err := cs.Store.Subscribe(ctx, 1, alert, 20, 5, "drop", 8, 0, ""); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if n, _ := c.CountSubscriptions(ctx, "altura_metric_alert"); n != 1 {
+		t.Errorf("CountSubscriptions before notification = %d, want the cached 1", n)
+	}
+	// ...and the trigger's notification invalidates the index.
+	c.invalidate("notify")
+	if n, _ := c.CountSubscriptions(ctx, "altura_metric_alert"); n != 2 {
+		t.Errorf("CountSubscriptions after notification = %d, want 2", n)
+	}
+}
+
+func TestCacheServesStaleIndexDuringOutage(t *testing.T) {
+	ctx := context.Background()
+	c, cs, _ := newTestCache(t)
+
+	cs.down = true
+	if _, err := c.GetSubscribersWithThresholds(ctx, "altura_metric_alert"); err == nil {
+		t.Fatal("first load during an outage: want an error, there is nothing to serve")
+	}
+
+	cs.down = false
+	c.failedAt = time.Time{}
+	if cfgs, err := c.GetSubscribersWithThresholds(ctx, "altura_metric_alert"); err != nil || len(cfgs) != 1 {
+		t.Fatalf("GetSubscribersWithThresholds = %+v, %v", cfgs, err)
+	}
+
+	cs.down = true
+	c.invalidate("notify")
+	loads := cs.loads
+	for range 3 {
+		cfgs, err := c.GetSubscribersWithThresholds(ctx, "altura_metric_alert")
+		if err != nil || len(cfgs) != 1 {
+			t.Fatalf("during outage: GetSubscribersWithThresholds = %+v, %v, want the stale index", cfgs, err)
+		}
+	}
+	if cs.loads != loads+1 {
+		t.Errorf("reload attempts during outage = %d, want 1 per retry interval", cs.loads-loads)
+	}
+}
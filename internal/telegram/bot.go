@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/web3-frozen/onchain-monitor/internal/messages"
 	"github.com/web3-frozen/onchain-monitor/internal/store"
 )
 
@@ -19,13 +22,13 @@ const telegramAPI = "https://api.telegram.org/bot"
 
 type Bot struct {
 	token  string
-	store  *store.Store
+	store  store.Store
 	logger *slog.Logger
 	client *http.Client
 	offset int64
 }
 
-func NewBot(token string, s *store.Store, logger *slog.Logger) *Bot {
+func NewBot(token string, s store.Store, logger *slog.Logger) *Bot {
 	return &Bot{
 		token:  token,
 		store:  s,
@@ -36,6 +39,17 @@ func NewBot(token string, s *store.Store, logger *slog.Logger) *Bot {
 
 // SendMessage sends a text message to a Telegram chat.
 func (b *Bot) SendMessage(chatID int64, text string) error {
+	// Grouped alerts can exceed Telegram's per-message limit; send them as
+	// consecutive messages instead of having the whole alert rejected.
+	for _, chunk := range messages.Split(text, messages.MaxLength) {
+		if err := b.sendChunk(chatID, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bot) sendChunk(chatID int64, text string) error {
 	payload := map[string]interface{}{
 		"chat_id":    chatID,
 		"text":       text,
@@ -63,6 +77,50 @@ func (b *Bot) SendMessage(chatID int64, text string) error {
 	return nil
 }
 
+// SendPhoto sends a PNG image with an HTML caption to a Telegram chat.
+// Captions over Telegram's limit are sent as a follow-up text message
+// instead, so long reports are never truncated.
+func (b *Bot) SendPhoto(chatID int64, png []byte, caption string) error {
+	followUp := ""
+	if utf8.RuneCountInString(caption) > messages.MaxCaptionLength {
+		caption, followUp = "", caption
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("chat_id", strconv.FormatInt(chatID, 10))
+	if caption != "" {
+		_ = mw.WriteField("caption", caption)
+		_ = mw.WriteField("parse_mode", "HTML")
+	}
+	part, err := mw.CreateFormFile("photo", "chart.png")
+	if err != nil {
+		return fmt.Errorf("send photo: %w", err)
+	}
+	_, _ = part.Write(png)
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("send photo: %w", err)
+	}
+
+	resp, err := b.client.Post(telegramAPI+b.token+"/sendPhoto", mw.FormDataContentType(), &body)
+	if err != nil {
+		return fmt.Errorf("send photo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Description string `json:"description"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return fmt.Errorf("telegram API error %d: %s", resp.StatusCode, errResp.Description)
+	}
+	if followUp != "" {
+		return b.SendMessage(chatID, followUp)
+	}
+	return nil
+}
+
 // Run starts the long-polling loop for incoming Telegram messages.
 func (b *Bot) Run(ctx context.Context) {
 	b.logger.Info("telegram bot started")
@@ -130,62 +188,65 @@ func (b *Bot) poll(ctx context.Context) {
 		case text == "/start":
 			b.handleStart(ctx, chatID, username)
 		case text == "/help":
-			b.handleHelp(chatID)
+			b.handleHelp(ctx, chatID)
 		case text == "/status":
 			b.handleStatus(ctx, chatID)
+		case text == "/lang" || strings.HasPrefix(text, "/lang "):
+			b.handleLang(ctx, chatID, username, strings.TrimSpace(strings.TrimPrefix(text, "/lang")))
 		default:
-			_ = b.SendMessage(chatID, "Unknown command. Send /help for available commands.")
+			_ = b.SendMessage(chatID, b.locale(ctx, chatID).T(messages.MsgUnknownCommand))
 		}
 	}
 }
 
+// locale returns the chat's message locale, falling back to English.
+func (b *Bot) locale(ctx context.Context, chatID int64) *messages.Locale {
+	lang, err := b.store.GetUserLanguage(ctx, chatID)
+	if err != nil {
+		b.logger.Warn("get user language", "chat_id", chatID, "error", err)
+	}
+	return messages.LocaleFor(lang)
+}
+
 func (b *Bot) handleStart(ctx context.Context, chatID int64, username string) {
+	loc := b.locale(ctx, chatID)
 	code := generateLinkCode()
 	expiresAt := time.Now().Add(10 * time.Minute)
 
-	if err := b.store.UpsertTelegramUser(ctx, chatID, username, code, expiresAt); err != nil {
+	if err := b.store.UpsertTelegramUser(ctx, chatID, username, NormalizeLinkCode(code), expiresAt); err != nil {
 		b.logger.Error("upsert telegram user", "error", err)
-		_ = b.SendMessage(chatID, "❌ Error generating link code. Please try again.")
+		_ = b.SendMessage(chatID, loc.T(messages.MsgLinkCodeError))
 		return
 	}
 
-	msg := fmt.Sprintf("👋 Welcome to Onchain Monitor!\n\n"+
-		"Your link code: <code>%s</code>\n\n"+
-		"Enter this code on the monitoring dashboard to link your Telegram account and subscribe to alerts.\n\n"+
-		"⏰ This code expires in 10 minutes.", code)
-	_ = b.SendMessage(chatID, msg)
+	_ = b.SendMessage(chatID, loc.T(messages.MsgWelcome, code))
 }
 
-func (b *Bot) handleHelp(chatID int64) {
-	msg := "🤖 <b>Onchain Monitor Bot</b>\n\n" +
-		"Commands:\n" +
-		"/start — Get a link code to connect your Telegram\n" +
-		"/status — Check your subscription status\n" +
-		"/help — Show this message\n\n" +
-		"Manage subscriptions on the web dashboard."
-	_ = b.SendMessage(chatID, msg)
+func (b *Bot) handleHelp(ctx context.Context, chatID int64) {
+	_ = b.SendMessage(chatID, b.locale(ctx, chatID).T(messages.MsgHelp))
 }
 
 func (b *Bot) handleStatus(ctx context.Context, chatID int64) {
+	loc := b.locale(ctx, chatID)
 	user, err := b.store.GetTelegramUser(ctx, chatID)
 	if err != nil {
-		_ = b.SendMessage(chatID, "You haven't linked your account yet. Send /start to get a link code.")
+		_ = b.SendMessage(chatID, loc.T(messages.MsgNotLinked))
 		return
 	}
 
 	if !user.Linked {
-		_ = b.SendMessage(chatID, "Your account is registered but not linked yet. Send /start to get a new link code.")
+		_ = b.SendMessage(chatID, loc.T(messages.MsgNotLinkedYet))
 		return
 	}
 
 	subs, err := b.store.ListSubscriptions(ctx, chatID)
 	if err != nil {
-		_ = b.SendMessage(chatID, "Error fetching subscriptions.")
+		_ = b.SendMessage(chatID, loc.T(messages.MsgSubsError))
 		return
 	}
 
 	if len(subs) == 0 {
-		_ = b.SendMessage(chatID, "✅ Account linked!\n\nYou have no active subscriptions. Visit the dashboard to subscribe to alerts.")
+		_ = b.SendMessage(chatID, loc.T(messages.MsgNoSubscriptions))
 		return
 	}
 
@@ -195,19 +256,72 @@ func (b *Bot) handleStatus(ctx context.Context, chatID int64) {
 		eventMap[e.ID] = e.Description
 	}
 
-	msg := fmt.Sprintf("✅ Account linked! (@%s)\n\n📋 Active subscriptions:\n", user.TgUsername)
+	msg := loc.T(messages.MsgLinkedHeader, messages.Escape(user.TgUsername))
 	for _, sub := range subs {
 		desc := eventMap[sub.EventID]
 		if desc == "" {
-			desc = "Unknown event"
+			desc = loc.T(messages.MsgUnknownEvent)
 		}
-		msg += fmt.Sprintf("• %s\n", desc)
+		msg += fmt.Sprintf("• %s\n", messages.Escape(desc))
 	}
 	_ = b.SendMessage(chatID, msg)
 }
 
+// handleLang shows or changes the language used for this chat's alerts,
+// reports and bot replies.
+func (b *Bot) handleLang(ctx context.Context, chatID int64, username, arg string) {
+	if arg == "" || !messages.Supported(arg) {
+		loc := b.locale(ctx, chatID)
+		_ = b.SendMessage(chatID, loc.T(messages.MsgLangUsage, loc.Lang()))
+		return
+	}
+
+	lang := messages.Normalize(arg)
+	if err := b.store.SetUserLanguage(ctx, chatID, username, lang); err != nil {
+		b.logger.Error("set user language", "chat_id", chatID, "error", err)
+		_ = b.SendMessage(chatID, b.locale(ctx, chatID).T(messages.MsgLangError))
+		return
+	}
+	_ = b.SendMessage(chatID, messages.LocaleFor(lang).T(messages.MsgLangSet))
+}
+
+// linkCodeAlphabet is Crockford's base32: no I, L, O or U, so codes survive
+// being read aloud or retyped.
+const linkCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// linkCodeLen is 10 base32 characters, 50 bits: far beyond what can be
+// guessed within a code's 10-minute lifetime under the link attempt limits.
+const linkCodeLen = 10
+
+// generateLinkCode returns a random code formatted for reading, e.g.
+// "7K2QD-M9XWA". Codes are stored normalized (see NormalizeLinkCode).
 func generateLinkCode() string {
-	b := make([]byte, 3)
+	b := make([]byte, linkCodeLen)
 	_, _ = rand.Read(b)
-	return strings.ToUpper(hex.EncodeToString(b))
+	code := make([]byte, 0, linkCodeLen+1)
+	for i, v := range b {
+		if i == linkCodeLen/2 {
+			code = append(code, '-')
+		}
+		code = append(code, linkCodeAlphabet[int(v)%len(linkCodeAlphabet)])
+	}
+	return string(code)
+}
+
+// NormalizeLinkCode canonicalizes a typed link code: case, dashes and
+// spaces are ignored, and the look-alikes O, I and L read as 0, 1 and 1.
+func NormalizeLinkCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		switch r {
+		case '-', ' ':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGenerateLinkCode(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{5}-[0-9A-HJKMNP-TV-Z]{5}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code := generateLinkCode()
+		if !format.MatchString(code) {
+			t.Fatalf("code %q does not match %s", code, format)
+		}
+		if seen[code] {
+			t.Fatalf("duplicate code %q", code)
+		}
+		seen[code] = true
+		if n := NormalizeLinkCode(code); len(n) != linkCodeLen {
+			t.Errorf("NormalizeLinkCode(%q) = %q, want %d chars", code, n, linkCodeLen)
+		}
+	}
+}
+
+func TestNormalizeLinkCode(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"7K2QD-M9XWA", "7K2QDM9XWA"},
+		{"7k2qd m9xwa", "7K2QDM9XWA"},
+		{"O1IL0-abcde", "01110ABCDE"},
+		{"A1B2C3", "A1B2C3"}, // legacy 6-hex-digit codes still match
+	}
+	for _, tt := range tests {
+		if got := NormalizeLinkCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeLinkCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Login widget verification errors.
+var (
+	ErrLoginHash    = errors.New("invalid login hash")
+	ErrLoginExpired = errors.New("login data expired")
+)
+
+// LoginUser is the verified identity from a Telegram Login Widget callback.
+// For the private chat with the bot the chat ID equals the user ID.
+type LoginUser struct {
+	ID        int64
+	Username  string
+	FirstName string
+	AuthDate  time.Time
+}
+
+// VerifyLogin checks Telegram Login Widget data (id, first_name, username,
+// photo_url, auth_date, hash, ...) as described at
+// https://core.telegram.org/widgets/login#checking-authorization: the hash
+// must be the HMAC-SHA256 of the sorted "key=value" lines keyed with
+// SHA256(bot token), and auth_date must be no older than maxAge.
+func VerifyLogin(botToken string, fields map[string]string, maxAge time.Duration, now time.Time) (*LoginUser, error) {
+	hash := fields["hash"]
+	if hash == "" || botToken == "" { // without a token anyone could sign
+		return nil, ErrLoginHash
+	}
+
+	lines := make([]string, 0, len(fields))
+	for k, v := range fields {
+		if k != "hash" {
+			lines = append(lines, k+"="+v)
+		}
+	}
+	sort.Strings(lines)
+
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	want := mac.Sum(nil)
+	got, err := hex.DecodeString(hash)
+	if err != nil || !hmac.Equal(got, want) {
+		return nil, ErrLoginHash
+	}
+
+	id, err := strconv.ParseInt(fields["id"], 10, 64)
+	if err != nil || id == 0 {
+		return nil, ErrLoginHash
+	}
+	authUnix, err := strconv.ParseInt(fields["auth_date"], 10, 64)
+	if err != nil {
+		return nil, ErrLoginHash
+	}
+	authDate := time.Unix(authUnix, 0)
+	if now.Sub(authDate) > maxAge {
+		return nil, ErrLoginExpired
+	}
+
+	return &LoginUser{
+		ID:        id,
+		Username:  fields["username"],
+		FirstName: fields["first_name"],
+		AuthDate:  authDate,
+	}, nil
+}
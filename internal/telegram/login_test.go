@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "123456:TEST-TOKEN"
+
+// sign adds the hash Telegram would send for fields.
+func sign(fields map[string]string) map[string]string {
+	return signWith(testToken, fields)
+}
+
+func signWith(token string, fields map[string]string) map[string]string {
+	var lines []string
+	for k, v := range fields {
+		lines = append(lines, k+"="+v)
+	}
+	sort.Strings(lines)
+	secret := sha256.Sum256([]byte(token))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	fields["hash"] = hex.EncodeToString(mac.Sum(nil))
+	return fields
+}
+
+func TestVerifyLogin(t *testing.T) {
+	now := time.Unix(1_735_800_000, 0)
+	fresh := strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)
+
+	valid := func() map[string]string {
+		return sign(map[string]string{
+			"id":         "12345",
+			"first_name": "Ada",
+			"username":   "ada",
+			"photo_url":  "https://t.me/i/userpic/320/ada.jpg",
+			"auth_date":  fresh,
+		})
+	}
+
+	user, err := VerifyLogin(testToken, valid(), 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("VerifyLogin: %v", err)
+	}
+	if user.ID != 12345 || user.Username != "ada" || user.FirstName != "Ada" {
+		t.Errorf("user = %+v", user)
+	}
+
+	tampered := valid()
+	tampered["id"] = "99999"
+	expired := sign(map[string]string{"id": "12345", "auth_date": strconv.FormatInt(now.Add(-48*time.Hour).Unix(), 10)})
+	noHash := valid()
+	delete(noHash, "hash")
+
+	tests := []struct {
+		name   string
+		token  string
+		fields map[string]string
+		want   error
+	}{
+		{"tampered field", testToken, tampered, ErrLoginHash},
+		{"other bot's token", "999:OTHER", valid(), ErrLoginHash},
+		{"missing hash", testToken, noHash, ErrLoginHash},
+		{"no bot token", "", signWith("", map[string]string{"id": "1", "auth_date": fresh}), ErrLoginHash},
+		{"non-hex hash", testToken, map[string]string{"id": "1", "auth_date": fresh, "hash": "zz"}, ErrLoginHash},
+		{"expired", testToken, expired, ErrLoginExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyLogin(tt.token, tt.fields, 24*time.Hour, now); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
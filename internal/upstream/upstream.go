@@ -0,0 +1,367 @@
+// Package upstream is the HTTP layer sources call their APIs through. Per
+// host it keeps a circuit breaker, a concurrency limit and the cooldown of
+// a 429's Retry-After; idempotent requests that fail transiently are
+// retried with jittered exponential backoff.
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/web3-frozen/onchain-monitor/internal/metrics"
+)
+
+// Requests failed fast without reaching the host wrap one of these.
+var (
+	ErrCircuitOpen = errors.New("circuit open")
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Policy configures a Pool.
+type Policy struct {
+	Retries          int           // extra attempts of an idempotent request
+	RetryBase        time.Duration // first backoff; doubles per attempt, fully jittered
+	RetryMax         time.Duration // cap of a backoff, and the longest Retry-After waited out
+	FailureThreshold int           // consecutive failed requests that open a host's breaker
+	OpenFor          time.Duration // first open period; doubles on every failed probe
+	MaxOpenFor       time.Duration
+	MaxPerHost       int // concurrent requests per host, each until its body is closed
+}
+
+// DefaultPolicy retries twice, opens a breaker after 5 failed requests in a
+// row for 30s (up to 10m), and sends at most 4 requests at once per host.
+var DefaultPolicy = Policy{
+	Retries:          2,
+	RetryBase:        500 * time.Millisecond,
+	RetryMax:         10 * time.Second,
+	FailureThreshold: 5,
+	OpenFor:          30 * time.Second,
+	MaxOpenFor:       10 * time.Minute,
+	MaxPerHost:       4,
+}
+
+// Default is the pool sources use unless given their own client.
+var Default = New(DefaultPolicy, http.DefaultTransport)
+
+// Circuit breaker states, as exported in the circuit_state gauge.
+const (
+	Closed State = iota
+	HalfOpen
+	Open
+)
+
+type State int
+
+func (s State) String() string {
+	switch s {
+	case HalfOpen:
+		return "half_open"
+	case Open:
+		return "open"
+	}
+	return "closed"
+}
+
+// HostState is a host's breaker as reported by the source health API.
+type HostState struct {
+	Host       string     `json:"host"`
+	State      string     `json:"state"`
+	Failures   int        `json:"consecutive_failures"`
+	OpenUntil  *time.Time `json:"open_until,omitempty"`
+	RetryAfter *time.Time `json:"retry_after,omitempty"`
+}
+
+// Pool holds the per-host state shared by every client it hands out.
+type Pool struct {
+	policy Policy
+	base   http.RoundTripper
+	now    func() time.Time
+
+	mu      sync.Mutex
+	hosts   map[string]*host
+	callers map[string]map[string]bool // source → hosts it called
+}
+
+type host struct {
+	sem chan struct{}
+
+	// guarded by Pool.mu
+	state      State
+	failures   int
+	openFor    time.Duration
+	openUntil  time.Time
+	probing    bool // a half-open probe is in flight
+	retryAfter time.Time
+}
+
+// New creates a pool sending requests through base.
+func New(policy Policy, base http.RoundTripper) *Pool {
+	return &Pool{
+		policy:  policy,
+		base:    base,
+		now:     time.Now,
+		hosts:   make(map[string]*host),
+		callers: make(map[string]map[string]bool),
+	}
+}
+
+// Client returns a client for the named source whose requests go through
+// the pool. timeout bounds a request including its retries.
+func (p *Pool) Client(source string, timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &transport{pool: p, source: source}}
+}
+
+// Hosts reports the breakers of the hosts the source has called, sorted.
+func (p *Pool) Hosts(source string) []HostState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := slices.Sorted(maps.Keys(p.callers[source]))
+	states := make([]HostState, 0, len(names))
+	now := p.now()
+	for _, name := range names {
+		h := p.hosts[name]
+		st := HostState{Host: name, State: h.state.String(), Failures: h.failures}
+		if h.state == Open {
+			until := h.openUntil
+			st.OpenUntil = &until
+		}
+		if h.retryAfter.After(now) {
+			ra := h.retryAfter
+			st.RetryAfter = &ra
+		}
+		states = append(states, st)
+	}
+	return states
+}
+
+// host returns the state of a host, noting that source calls it.
+func (p *Pool) host(source, name string) *host {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.hosts[name]
+	if h == nil {
+		h = &host{sem: make(chan struct{}, max(p.policy.MaxPerHost, 1)), openFor: p.policy.OpenFor}
+		p.hosts[name] = h
+		p.setState(name, h, Closed)
+	}
+	if p.callers[source] == nil {
+		p.callers[source] = make(map[string]bool)
+	}
+	p.callers[source][name] = true
+	return h
+}
+
+// allow decides whether a request may go to the host now. After the open
+// period one request is let through as a probe.
+func (p *Pool) allow(name string, h *host) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if now.Before(h.retryAfter) {
+		metrics.UpstreamRejectedTotal.WithLabelValues(name, "rate_limited").Inc()
+		return fmt.Errorf("%w by %s until %s", ErrRateLimited, name, h.retryAfter.UTC().Format(time.TimeOnly))
+	}
+	switch h.state {
+	case Open:
+		if now.Before(h.openUntil) {
+			metrics.UpstreamRejectedTotal.WithLabelValues(name, "circuit_open").Inc()
+			return fmt.Errorf("%w for %s until %s", ErrCircuitOpen, name, h.openUntil.UTC().Format(time.TimeOnly))
+		}
+		p.setState(name, h, HalfOpen)
+	case HalfOpen:
+		if h.probing {
+			metrics.UpstreamRejectedTotal.WithLabelValues(name, "circuit_open").Inc()
+			return fmt.Errorf("%w for %s: probe in flight", ErrCircuitOpen, name)
+		}
+	}
+	if h.state == HalfOpen {
+		h.probing = true
+	}
+	return nil
+}
+
+// record updates the host's breaker with the outcome of a request.
+func (p *Pool) record(name string, h *host, failed bool, retryAfter time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	h.probing = false
+	if retryAfter > 0 {
+		h.retryAfter = now.Add(retryAfter)
+	}
+	if !failed {
+		h.failures = 0
+		h.openFor = p.policy.OpenFor
+		p.setState(name, h, Closed)
+		return
+	}
+	h.failures++
+	switch {
+	case h.state == HalfOpen:
+		h.openFor = min(h.openFor*2, max(p.policy.MaxOpenFor, p.policy.OpenFor))
+		h.openUntil = now.Add(h.openFor)
+		p.setState(name, h, Open)
+	case h.state == Closed && h.failures >= p.policy.FailureThreshold:
+		h.openUntil = now.Add(h.openFor)
+		p.setState(name, h, Open)
+	}
+}
+
+// abandon ends a request that never reached the host, so it neither
+// counts for nor against it.
+func (p *Pool) abandon(h *host) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h.probing = false
+}
+
+func (p *Pool) setState(name string, h *host, s State) {
+	h.state = s
+	metrics.UpstreamCircuitState.WithLabelValues(name).Set(float64(s))
+}
+
+// backoff returns the wait before retry attempt n (0-based): a random
+// duration up to RetryBase·2ⁿ, capped at RetryMax.
+func (p *Pool) backoff(n int) time.Duration {
+	d := min(p.policy.RetryBase<<n, p.policy.RetryMax)
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+type transport struct {
+	pool   *Pool
+	source string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	p, name := t.pool, req.URL.Host
+	h := p.host(t.source, name)
+	if err := p.allow(name, h); err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	ctx := req.Context()
+	select {
+	case h.sem <- struct{}{}:
+	case <-ctx.Done():
+		p.abandon(h)
+		closeBody(req)
+		return nil, ctx.Err()
+	}
+	metrics.UpstreamInFlight.WithLabelValues(name).Inc()
+	// The slot is held while the caller reads the body
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			<-h.sem
+			metrics.UpstreamInFlight.WithLabelValues(name).Dec()
+		})
+	}
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body = &slotBody{ReadCloser: resp.Body, release: release}
+			return
+		}
+		release()
+	}()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := p.base.RoundTrip(req)
+		failed, retryAfter := outcome(resp, err)
+		if !failed || attempt >= p.policy.Retries || !idempotent(req) || ctx.Err() != nil {
+			p.record(name, h, failed, retryAfter)
+			return resp, err
+		}
+		wait := p.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > p.policy.RetryMax {
+				p.record(name, h, failed, retryAfter)
+				return resp, err
+			}
+			wait = retryAfter
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		metrics.UpstreamRetriesTotal.WithLabelValues(name).Inc()
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			p.record(name, h, true, 0)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// slotBody gives the host's concurrency slot back when the body is closed.
+type slotBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *slotBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// outcome reports whether a response counts against the host and, for a
+// 429, how long it asked clients to wait. Transport errors, 429 and 5xx
+// fail; other statuses are the caller's to handle.
+func outcome(resp *http.Response, err error) (failed bool, retryAfter time.Duration) {
+	if err != nil {
+		return true, 0
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case resp.StatusCode >= 500:
+		return true, 0
+	}
+	return false, 0
+}
+
+// closeBody closes the body of a request that is not sent, as a
+// RoundTripper must.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// idempotent reports whether a request may be sent again.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header: delay seconds or an HTTP
+// date. Anything else is no delay.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(s, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,212 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPolicy retries without waiting noticeably.
+var testPolicy = Policy{
+	Retries:          2,
+	RetryBase:        time.Millisecond,
+	RetryMax:         10 * time.Millisecond,
+	FailureThreshold: 2,
+	OpenFor:          30 * time.Second,
+	MaxOpenFor:       time.Minute,
+	MaxPerHost:       4,
+}
+
+// statusServer answers with the given statuses in turn, then 200.
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			if statuses[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "120")
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetries(t *testing.T) {
+	srv, calls := statusServer(t, 503, 502)
+	c := New(testPolicy, http.DefaultTransport).Client("merkl", time.Second)
+
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("GET = %d after %d calls, want 200 after 3", resp.StatusCode, calls.Load())
+	}
+
+	// A request with a body is not idempotent
+	srv, calls = statusServer(t, 503)
+	resp, err = c.Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("POST = %d after %d calls, want 503 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	srv, calls := statusServer(t, 500, 500, 500)
+	policy := testPolicy
+	policy.Retries = 0
+	p := New(policy, http.DefaultTransport)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	c := p.Client("defillama", time.Second)
+	get := func() error {
+		resp, err := c.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	_ = get()
+	_ = get()
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || calls.Load() != 2 {
+		t.Fatalf("third request: err %v after %d calls, want circuit open after 2", err, calls.Load())
+	}
+	hosts := p.Hosts("defillama")
+	if len(hosts) != 1 || hosts[0].State != "open" || hosts[0].Failures != 2 || hosts[0].OpenUntil == nil {
+		t.Errorf("hosts = %+v, want one open after 2 failures", hosts)
+	}
+	if len(p.Hosts("merkl")) != 0 {
+		t.Error("merkl never called the host")
+	}
+
+	// The probe fails: open twice as long
+	now = now.Add(31 * time.Second)
+	_ = get()
+	if got := p.Hosts("defillama")[0]; got.State != "open" || !got.OpenUntil.Equal(now.Add(time.Minute)) {
+		t.Errorf("after failed probe: %+v, want open for 1m", got)
+	}
+	now = now.Add(61 * time.Second)
+	if err := get(); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Hosts("defillama")[0]; got.State != "closed" || got.Failures != 0 {
+		t.Errorf("after good probe: %+v, want closed", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv, calls := statusServer(t, 429)
+	p := New(testPolicy, http.DefaultTransport)
+	c := p.Client("binance", time.Second)
+
+	// 120s is longer than RetryMax: no retry, and the host waits it out
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Errorf("GET = %d after %d calls, want 429 after 1", resp.StatusCode, calls.Load())
+	}
+	if _, err := c.Get(srv.URL); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("during Retry-After: err = %v, want rate limited", err)
+	}
+	if h := p.Hosts("binance"); len(h) != 1 || h[0].RetryAfter == nil {
+		t.Errorf("hosts = %+v, want retry_after set", h)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for v, want := range map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"-3":                            0,
+		"soon":                          0,
+		"Thu, 01 Jan 2026 12:01:30 GMT": 90 * time.Second,
+	} {
+		if got := parseRetryAfter(v, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestMaxPerHost(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	policy := testPolicy
+	policy.MaxPerHost = 1
+	p := New(policy, http.DefaultTransport)
+	c := p.Client("turtle", 5*time.Second)
+	go func() {
+		if resp, err := c.Get(srv.URL); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := c.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second request: err = %v, want to wait for the first", err)
+	}
+	if h := p.Hosts("turtle")[0]; h.Failures != 0 {
+		t.Errorf("waiting for a slot counted as a failure: %+v", h)
+	}
+}
+
+func TestMaxPerHostHoldsUntilBodyClosed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	policy := testPolicy
+	policy.MaxPerHost = 1
+	p := New(policy, http.DefaultTransport)
+	c := p.Client("turtle", 5*time.Second)
+	first, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := c.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("request while a body is unread: err = %v, want to wait for it", err)
+	}
+
+	first.Body.Close()
+	first.Body.Close() // a second close must not free another slot
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request after the body was closed: %v", err)
+	}
+	resp.Body.Close()
+	if n := len(p.host("turtle", strings.TrimPrefix(srv.URL, "http://")).sem); n != 0 {
+		t.Errorf("%d slots still held", n)
+	}
+}
@@ -1,23 +1,34 @@
 #!/bin/bash
-# Clear all alert dedup keys for a specific Telegram chat ID.
-# Usage: ./clear-dedup.sh <chat_id> [--dry-run]
+# Clear alert dedup keys for one subscription, or the legacy chat-scoped
+# keys of a Telegram chat ID (keys are now namespaced by subscription,
+# "sub:<id>:...", and no longer contain the chat ID).
+# `monitorctl dedup clear -chat ID|-sub ID` does the same against any
+# shared DEDUP_BACKEND, including a chat's subscription keys.
+# Usage: ./clear-dedup.sh <chat_id>|sub:<subscription_id> [--dry-run]
 #
 # Examples:
-#   ./clear-dedup.sh 123456789              # delete all dedup keys
+#   ./clear-dedup.sh sub:42                 # delete subscription 42's keys
+#   ./clear-dedup.sh 123456789              # delete legacy keys of a chat
 #   ./clear-dedup.sh 123456789 --dry-run    # list keys without deleting
 
 set -euo pipefail
 
-CHAT_ID="${1:?Usage: $0 <chat_id> [--dry-run]}"
+TARGET="${1:?Usage: $0 <chat_id>|sub:<subscription_id> [--dry-run]}"
 DRY_RUN="${2:-}"
 
+if [[ "$TARGET" == sub:* ]]; then
+    PATTERN="${TARGET}:*"
+else
+    PATTERN="*${TARGET}*"
+fi
+
 REDIS_PW=$(kubectl get secret -n redis redis-password -o jsonpath='{.data.password}' | base64 -d)
 
-echo "Looking up dedup keys for chat ID: $CHAT_ID ..."
-KEYS=$(kubectl exec -n redis redis-0 -- redis-cli -a "$REDIS_PW" --no-auth-warning KEYS "*${CHAT_ID}*" 2>/dev/null)
+echo "Looking up dedup keys for $TARGET ..."
+KEYS=$(kubectl exec -n redis redis-0 -- redis-cli -a "$REDIS_PW" --no-auth-warning KEYS "$PATTERN" 2>/dev/null)
 
 if [ -z "$KEYS" ]; then
-    echo "No dedup keys found for chat ID $CHAT_ID"
+    echo "No dedup keys found for $TARGET"
     exit 0
 fi
 
@@ -37,4 +48,4 @@ echo "$KEYS" | while read -r key; do
     kubectl exec -n redis redis-0 -- redis-cli -a "$REDIS_PW" --no-auth-warning DEL "$key" >/dev/null 2>&1
 done
 
-echo "Done — cleared $COUNT dedup key(s) for chat ID $CHAT_ID"
+echo "Done — cleared $COUNT dedup key(s) for $TARGET"
@@ -4,6 +4,8 @@
 set -euo pipefail
 
 BASE_URL="${BASE_URL:-http://localhost:8080}"
+# engine.poll_interval of the server's CONFIG_FILE, if it sets one
+POLL_INTERVAL="${POLL_INTERVAL:-60s}"
 PASS=0
 FAIL=0
 TOTAL=0
@@ -68,9 +70,37 @@ assert_json_field "GET /healthz returns ok" \
 assert_json_field "GET /readyz returns ready" \
   "$BASE_URL/readyz" '.status' 'ready'
 
+assert_json_field "GET /readyz reports leadership (single replica leads)" \
+  "$BASE_URL/readyz" '.leader' 'true'
+
 assert_status "GET /metrics returns prometheus data" \
   GET "$BASE_URL/metrics" 200
 
+# ── OpenAPI ───────────────────────────────────
+echo ""
+echo "▸ OpenAPI"
+assert_json_field "GET /api/openapi.json is OpenAPI 3" \
+  "$BASE_URL/api/openapi.json" '.openapi' '3.0.3'
+
+assert_json_field "Invalid chart interval lists the field" \
+  "$BASE_URL/api/charts/liquidations/BTC?interval=5m" '.fields[0].field' 'interval'
+
+# ── Live stream ───────────────────────────────
+echo ""
+echo "▸ Live Stream"
+TOTAL=$((TOTAL + 1))
+STREAM_OUT=$(curl -s -N --max-time 3 "$BASE_URL/api/stream" 2>/dev/null || true)
+if echo "$STREAM_OUT" | grep -q '^event: snapshot'; then
+  green "  ✓ GET /api/stream sends snapshot events"
+  PASS=$((PASS + 1))
+else
+  red   "  ✗ GET /api/stream sent no snapshot event"
+  FAIL=$((FAIL + 1))
+fi
+
+assert_status "GET /api/stream with bogus token → 401" \
+  GET "$BASE_URL/api/stream?access_token=not-a-real-token" 401
+
 # ── Events ────────────────────────────────────
 echo ""
 echo "▸ Events API"
@@ -95,78 +125,220 @@ assert_status "GET /api/stats returns 200" \
 assert_status "GET /api/stats/meta returns 200" \
   GET "$BASE_URL/api/stats/meta" 200
 
-assert_json_field "Stats meta poll_interval is 60s" \
-  "$BASE_URL/api/stats/meta" '.poll_interval' '60s'
+assert_json_field "Stats meta poll_interval is $POLL_INTERVAL" \
+  "$BASE_URL/api/stats/meta" '.poll_interval' "$POLL_INTERVAL"
+
+assert_json_field "Stats meta lists disabled events" \
+  "$BASE_URL/api/stats/meta" '.disabled_events | type' 'array'
+
+# ── Source health ─────────────────────────────
+echo ""
+echo "▸ Source health"
+assert_status "GET /api/sources returns 200" \
+  GET "$BASE_URL/api/sources" 200
+
+assert_json_field "Source health lists merkl" \
+  "$BASE_URL/api/sources" '[.[] | select(.name=="merkl")] | length' '1'
+
+assert_json_field "Every source has a known status" \
+  "$BASE_URL/api/sources" '[.[].status | select(IN("ok","failing","paused","pending") | not)] | length' '0'
+
+assert_json_field "Source health reports upstream breakers" \
+  "$BASE_URL/api/sources" '[.[] | select(.upstreams | type != "array")] | length' '0'
+
+assert_status "GET /status renders the status page" \
+  GET "$BASE_URL/status" 200
+
+# ── Authentication ────────────────────────────
+echo ""
+echo "▸ Authentication"
+assert_status "GET /api/subscriptions without session → 401" \
+  GET "$BASE_URL/api/subscriptions" 401
+
+assert_status "GET /api/subscriptions with bogus token → 401" \
+  GET "$BASE_URL/api/subscriptions" 401 \
+  -H "Authorization: Bearer not-a-real-token"
+
+assert_status "GET /api/notifications without session → 401" \
+  GET "$BASE_URL/api/notifications" 401
+
+assert_status "GET /api/link/status without session → 401" \
+  GET "$BASE_URL/api/link/status" 401
+
+assert_status "POST /api/unlink without session → 401" \
+  POST "$BASE_URL/api/unlink" 401 \
+  -H "Content-Type: application/json" -d '{}'
+
+assert_status "PUT /api/language without session → 401" \
+  PUT "$BASE_URL/api/language" 401 \
+  -H "Content-Type: application/json" -d '{"language":"zh"}'
+
+assert_status "GET /api/keys without session → 401" \
+  GET "$BASE_URL/api/keys" 401
+
+assert_status "GET /api/stats with bogus API key → 401" \
+  GET "$BASE_URL/api/stats" 401 \
+  -H "Authorization: Bearer omk_not-a-real-key"
 
 # ── Subscriptions ─────────────────────────────
 echo ""
 echo "▸ Subscriptions CRUD"
 
-# Missing param → 400
-assert_status "GET /api/subscriptions without tg_chat_id → 400" \
-  GET "$BASE_URL/api/subscriptions" 400
+# Per-user endpoints need a session token, obtained by linking a Telegram
+# account (POST /api/link with a code from the bot's /start). Pass it as
+# SESSION_TOKEN to run this section.
+if [ -z "${SESSION_TOKEN:-}" ]; then
+  printf '\033[0;33m  ⊘ skipped (set SESSION_TOKEN to a linked session token)\033[0m\n'
+else
+  AUTH=(-H "Authorization: Bearer $SESSION_TOKEN")
 
-# Valid empty list
-assert_status "GET /api/subscriptions with unknown user → 200" \
-  GET "$BASE_URL/api/subscriptions?tg_chat_id=999999" 200
+  assert_status "GET /api/subscriptions with session → 200" \
+    GET "$BASE_URL/api/subscriptions" 200 "${AUTH[@]}"
 
-# Get first event ID for subscription test
-EVENT_ID=$(curl -s "$BASE_URL/api/events" | jq '.[0].id' 2>/dev/null || echo "1")
+  assert_status "GET /api/notifications with session → 200" \
+    GET "$BASE_URL/api/notifications" 200 "${AUTH[@]}"
 
-# Create subscription (requires a linked Telegram user in DB — may fail against
-# production or a fresh DB without seed data; treated as a soft test).
-TOTAL=$((TOTAL + 1))
-SUB_RESP=$(curl -s -X POST "$BASE_URL/api/subscriptions" \
-  -H "Content-Type: application/json" \
-  -d "{\"tg_chat_id\":12345,\"event_id\":$EVENT_ID,\"threshold_pct\":5,\"direction\":\"drop\"}" 2>/dev/null || echo "")
-SUB_ID=$(echo "$SUB_RESP" | jq -r '.id' 2>/dev/null || echo "")
-if [ -n "$SUB_ID" ] && [ "$SUB_ID" != "null" ] && [ "$SUB_ID" != "" ]; then
-  green "  ✓ POST /api/subscriptions creates subscription (id=$SUB_ID)"
-  PASS=$((PASS + 1))
-else
-  # Subscription requires a linked Telegram user — skip CRUD if no user in DB
-  SUB_SKIP=$(echo "$SUB_RESP" | jq -r '.error // empty' 2>/dev/null)
-  if [ "$SUB_SKIP" = "failed to subscribe" ]; then
-    printf '\033[0;33m  ⊘ POST /api/subscriptions skipped (no test user in DB)\033[0m\n'
+  assert_status "GET /api/link/status with session → 200" \
+    GET "$BASE_URL/api/link/status" 200 "${AUTH[@]}"
+
+  assert_status "PUT /api/language with unsupported language → 400" \
+    PUT "$BASE_URL/api/language" 400 "${AUTH[@]}" \
+    -H "Content-Type: application/json" -d '{"language":"fr"}'
+
+  assert_status "PUT /api/subscriptions/0 (not owned) → 404" \
+    PUT "$BASE_URL/api/subscriptions/0" 404 "${AUTH[@]}" \
+    -H "Content-Type: application/json" -d '{"threshold_pct":15}'
+
+  # API key: mint a read:stats key, check its scope, then revoke it
+  TOTAL=$((TOTAL + 1))
+  KEY_RESP=$(curl -s -X POST "$BASE_URL/api/keys" "${AUTH[@]}" \
+    -H "Content-Type: application/json" \
+    -d '{"name":"integration-test","scopes":["read:stats"]}' 2>/dev/null || echo "")
+  API_KEY=$(echo "$KEY_RESP" | jq -r '.key' 2>/dev/null || echo "")
+  KEY_ID=$(echo "$KEY_RESP" | jq -r '.id' 2>/dev/null || echo "")
+  if [ -n "$API_KEY" ] && [ "$API_KEY" != "null" ]; then
+    green "  ✓ POST /api/keys creates API key (id=$KEY_ID)"
     PASS=$((PASS + 1))
+    KEY_AUTH=(-H "Authorization: Bearer $API_KEY")
+
+    assert_status "GET /api/stats with read:stats key → 200" \
+      GET "$BASE_URL/api/stats" 200 "${KEY_AUTH[@]}"
+
+    assert_status "GET /api/subscriptions without write:subscriptions → 403" \
+      GET "$BASE_URL/api/subscriptions" 403 "${KEY_AUTH[@]}"
+
+    assert_status "GET /api/keys with API key → 403" \
+      GET "$BASE_URL/api/keys" 403 "${KEY_AUTH[@]}"
+
+    assert_status "DELETE /api/keys/$KEY_ID revokes" \
+      DELETE "$BASE_URL/api/keys/$KEY_ID" 204 "${AUTH[@]}"
+
+    assert_status "GET /api/stats with revoked key → 401" \
+      GET "$BASE_URL/api/stats" 401 "${KEY_AUTH[@]}"
   else
-    red   "  ✗ POST /api/subscriptions unexpected error: $SUB_RESP"
+    red   "  ✗ POST /api/keys unexpected response: $KEY_RESP"
     FAIL=$((FAIL + 1))
   fi
-fi
 
-# List subscription for that user
-if [ -n "$SUB_ID" ] && [ "$SUB_ID" != "null" ]; then
-  assert_json_gt "Subscription appears in user list" \
-    "$BASE_URL/api/subscriptions?tg_chat_id=12345" 'length' 0
+  # Get first event ID for subscription test
+  EVENT_ID=$(curl -s "$BASE_URL/api/events" | jq '.[0].id' 2>/dev/null || echo "1")
 
-  # Update subscription
-  assert_status "PUT /api/subscriptions/$SUB_ID updates" \
-    PUT "$BASE_URL/api/subscriptions/$SUB_ID" 200 \
+  TOTAL=$((TOTAL + 1))
+  SUB_RESP=$(curl -s -X POST "$BASE_URL/api/subscriptions" "${AUTH[@]}" \
     -H "Content-Type: application/json" \
-    -d '{"threshold_pct":15,"direction":"increase"}'
-
-  # Delete subscription
-  assert_status "DELETE /api/subscriptions/$SUB_ID removes" \
-    DELETE "$BASE_URL/api/subscriptions/$SUB_ID" 204
+    -d "{\"event_id\":$EVENT_ID,\"threshold_pct\":5,\"direction\":\"drop\"}" 2>/dev/null || echo "")
+  SUB_ID=$(echo "$SUB_RESP" | jq -r '.id' 2>/dev/null || echo "")
+  if [ -n "$SUB_ID" ] && [ "$SUB_ID" != "null" ]; then
+    green "  ✓ POST /api/subscriptions creates subscription (id=$SUB_ID)"
+    PASS=$((PASS + 1))
 
-  # Verify deleted
-  assert_json_field "Subscription list is empty after delete" \
-    "$BASE_URL/api/subscriptions?tg_chat_id=12345" 'length' '0'
+    assert_status "PUT /api/subscriptions/$SUB_ID updates" \
+      PUT "$BASE_URL/api/subscriptions/$SUB_ID" 200 "${AUTH[@]}" \
+      -H "Content-Type: application/json" \
+      -d '{"threshold_pct":15,"direction":"increase"}'
+
+    assert_status "DELETE /api/subscriptions/$SUB_ID removes" \
+      DELETE "$BASE_URL/api/subscriptions/$SUB_ID" 204 "${AUTH[@]}"
+
+    TOTAL=$((TOTAL + 1))
+    # Read the whole body first: grep -q exits early and pipefail would
+    # count curl's SIGPIPE as a failure
+    if grep -q 'onchain_monitor_subscription_cache_invalidations_total{reason="write"}' <<<"$(curl -s "$BASE_URL/metrics")"; then
+      green "  ✓ subscription writes invalidate the subscription cache"
+      PASS=$((PASS + 1))
+    else
+      red   "  ✗ subscription_cache_invalidations_total{reason=\"write\"} missing from /metrics"
+      FAIL=$((FAIL + 1))
+    fi
+  else
+    red   "  ✗ POST /api/subscriptions unexpected response: $SUB_RESP"
+    FAIL=$((FAIL + 1))
+  fi
 fi
 
-# ── Notifications ─────────────────────────────
+# ── Admin ─────────────────────────────────────
 echo ""
-echo "▸ Notifications API"
-assert_status "GET /api/notifications without tg_chat_id → 400" \
-  GET "$BASE_URL/api/notifications" 400
+echo "▸ Admin API"
+assert_status "GET /api/admin/users without token → 401" \
+  GET "$BASE_URL/api/admin/users" 401
+
+# Needs an admin chat's session or an ADMIN_API_KEYS key (with --dev the
+# demo session is an admin). Nothing is broadcast; a source is paused and
+# resumed straight away.
+if [ -z "${ADMIN_TOKEN:-}" ]; then
+  printf '\033[0;33m  ⊘ skipped (set ADMIN_TOKEN to an admin session or admin API key)\033[0m\n'
+else
+  ADMIN_AUTH=(-H "Authorization: Bearer $ADMIN_TOKEN")
+
+  assert_status "GET /api/admin/users → 200" \
+    GET "$BASE_URL/api/admin/users?q=a" 200 "${ADMIN_AUTH[@]}"
+
+  assert_status "GET /api/admin/subscriptions → 200" \
+    GET "$BASE_URL/api/admin/subscriptions" 200 "${ADMIN_AUTH[@]}"
+
+  assert_status "GET /api/admin/events → 200" \
+    GET "$BASE_URL/api/admin/events" 200 "${ADMIN_AUTH[@]}"
+
+  assert_status "PUT /api/admin/events/1 without enabled → 400" \
+    PUT "$BASE_URL/api/admin/events/1" 400 "${ADMIN_AUTH[@]}" \
+    -H "Content-Type: application/json" -d '{}'
+
+  assert_status "POST /api/admin/broadcast without message → 400" \
+    POST "$BASE_URL/api/admin/broadcast" 400 "${ADMIN_AUTH[@]}" \
+    -H "Content-Type: application/json" -d '{}'
+
+  assert_status "GET /api/admin/delivery-failures → 200" \
+    GET "$BASE_URL/api/admin/delivery-failures" 200 "${ADMIN_AUTH[@]}"
 
-assert_status "GET /api/notifications with valid user → 200" \
-  GET "$BASE_URL/api/notifications?tg_chat_id=12345" 200
+  assert_status "POST /api/admin/sources/nope/pause → 404" \
+    POST "$BASE_URL/api/admin/sources/nope/pause" 404 "${ADMIN_AUTH[@]}"
 
-# ── Link / Unlink ─────────────────────────────
+  SOURCE=$(curl -s "$BASE_URL/api/admin/sources" "${ADMIN_AUTH[@]}" | jq -r '.[0].name' 2>/dev/null || echo "")
+  if [ -n "$SOURCE" ] && [ "$SOURCE" != "null" ]; then
+    assert_status "POST /api/admin/sources/$SOURCE/pause → 200" \
+      POST "$BASE_URL/api/admin/sources/$SOURCE/pause" 200 "${ADMIN_AUTH[@]}"
+
+    assert_json_field "Stats meta shows $SOURCE paused" \
+      "$BASE_URL/api/stats/meta" "[.paused_sources[].name] | index(\"$SOURCE\") != null" 'true'
+
+    assert_status "POST /api/admin/sources/$SOURCE/resume → 200" \
+      POST "$BASE_URL/api/admin/sources/$SOURCE/resume" 200 "${ADMIN_AUTH[@]}"
+
+    TOTAL=$((TOTAL + 1))
+    LAST_ACTION=$(curl -s "$BASE_URL/api/admin/audit-log?limit=1" "${ADMIN_AUTH[@]}" | jq -r '.[0] | "\(.action) \(.target) \(.detail)"' 2>/dev/null || echo "")
+    if [ "$LAST_ACTION" = "set_source_paused $SOURCE paused=false" ]; then
+      green "  ✓ GET /api/admin/audit-log records the resume"
+      PASS=$((PASS + 1))
+    else
+      red   "  ✗ GET /api/admin/audit-log latest entry: '$LAST_ACTION'"
+      FAIL=$((FAIL + 1))
+    fi
+  fi
+fi
+
+# ── Link ──────────────────────────────────────
 echo ""
-echo "▸ Link/Unlink API"
+echo "▸ Link API"
 assert_status "POST /api/link without code → 400" \
   POST "$BASE_URL/api/link" 400 \
   -H "Content-Type: application/json" -d '{}'
@@ -175,9 +347,10 @@ assert_status "POST /api/link with bad code → 404" \
   POST "$BASE_URL/api/link" 404 \
   -H "Content-Type: application/json" -d '{"code":"invalid-code"}'
 
-assert_status "POST /api/unlink without tg_chat_id → 400" \
-  POST "$BASE_URL/api/unlink" 400 \
-  -H "Content-Type: application/json" -d '{}'
+assert_status "POST /api/login/telegram with bad hash → 401" \
+  POST "$BASE_URL/api/login/telegram" 401 \
+  -H "Content-Type: application/json" \
+  -d "{\"id\":12345,\"auth_date\":$(date +%s),\"hash\":\"00ff\"}"
 
 # ── CORS ──────────────────────────────────────
 echo ""